@@ -4,12 +4,14 @@ import (
 	"bufio"
 	"bytes"
 	"crypto/md5"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -21,10 +23,17 @@ import (
 
 	"github.com/zimmski/tavor"
 	tavorFuzzFilter "github.com/zimmski/tavor/fuzz/filter"
+	tavorFuzzProfile "github.com/zimmski/tavor/fuzz/profile"
 	tavorFuzzStrategy "github.com/zimmski/tavor/fuzz/strategy"
+	tavorFuzzTrace "github.com/zimmski/tavor/fuzz/trace"
 	"github.com/zimmski/tavor/graph"
+	tavorImporter "github.com/zimmski/tavor/importer"
+	"github.com/zimmski/tavor/lint"
 	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/lsp"
 	"github.com/zimmski/tavor/parser"
+	"github.com/zimmski/tavor/printer"
+	tavorRand "github.com/zimmski/tavor/rand"
 	tavorReduceStrategy "github.com/zimmski/tavor/reduce/strategy"
 	"github.com/zimmski/tavor/token"
 )
@@ -54,7 +63,8 @@ type options struct {
 
 	Format struct {
 		Check         bool           `long:"check" description:"Just check the syntax of the format file and exit"`
-		FormatFile    flags.Filename `long:"format-file" description:"Input tavor format file" required:"true"`
+		FormatFile    flags.Filename `long:"format-file" description:"Input tavor format file"`
+		JSON          bool           `long:"json" description:"Prints the errors and lint problems of the format file as JSON"`
 		Print         bool           `long:"print" description:"Prints the AST of the parsed format file"`
 		PrintInternal bool           `long:"print-internal" description:"Prints the internal AST of the parsed format file"`
 	} `group:"Format file options"`
@@ -79,18 +89,50 @@ type options struct {
 
 		Filter optsFuzzingFilters
 
+		ProfileFile     flags.Filename `long:"profile-file" description:"Weight alternatives and repetitions with the profile learned by the learn command"`
+		ProfileInverted bool           `long:"profile-inverted" description:"Favour rarely taken alternatives and repetitions of the profile"`
+
 		Strategy       fuzzStrategy `long:"strategy" description:"The fuzzing strategy" default:"random"`
 		ListStrategies bool         `long:"list-strategies" description:"List all available fuzzing strategies"`
 
 		ResultFolder     flags.Filename `long:"result-folder" description:"Save every fuzzing result with the MD5 checksum as filename in this folder"`
 		ResultExtensions string         `long:"result-extension" description:"If result-folder is used this will be the extension of every filename"`
 		ResultSeparator  string         `long:"result-separator" description:"Separates result outputs of each fuzzing step" default:"\n"`
+
+		Record flags.Filename `long:"record" description:"Record the random decisions of the run to this trace file"`
 	} `command:"fuzz" description:"Fuzz the given format file"`
 
+	FormatCommand struct {
+		EmitTavor bool `long:"emit-tavor" description:"Prints the parsed format file in the Tavor format which is the default"`
+
+		Filter optsFuzzingFilters
+	} `command:"format" description:"Print the given format file"`
+
 	Graph struct {
 		Filter optsFuzzingFilters
 	} `command:"graph" description:"Generate a DOT file out of the internal AST"`
 
+	Import struct {
+		From        importFormat   `long:"from" description:"The format of the input file"`
+		ListFormats bool           `long:"list-formats" description:"List all available import formats"`
+		InputFile   flags.Filename `long:"input-file" description:"Input file which gets converted into the Tavor format"`
+		Start       string         `long:"start" description:"The start rule of the input file, defaults to its first rule"`
+	} `command:"import" description:"Convert the given input file into the Tavor format"`
+
+	Learn struct {
+		InputFiles  []flags.Filename `long:"input-file" description:"Input file or folder of input files which get parsed via the format file to learn the profile" required:"true"`
+		ProfileFile flags.Filename   `long:"profile-file" description:"Save the learned profile to this file" required:"true"`
+	} `command:"learn" description:"Learn the taken alternatives and repetitions of the given input files"`
+
+	Lint struct {
+		Rules     []lintRule   `long:"rule" description:"Lint rule to apply, all rules are applied if none is given"`
+		ListRules bool         `long:"list-rules" description:"List all available lint rules"`
+		Severity  lintSeverity `long:"severity" description:"Only report problems with at least this severity" default:"info"`
+	} `command:"lint" description:"Check the given format file for problems"`
+
+	Lsp struct {
+	} `command:"lsp" description:"Serve format files to editors via the Language Server Protocol over STDIN and STDOUT"`
+
 	Reduce struct {
 		Exec struct {
 			Exec                    string           `long:"exec" description:"Execute this binary with possible arguments to test a generation"`
@@ -114,6 +156,15 @@ type options struct {
 		ResultSeparator string `long:"result-separator" description:"Separates result outputs of each reducing step" default:"\n"`
 	} `command:"reduce" description:"Reduce the given input file"`
 
+	Replay struct {
+		TraceFile flags.Filename `long:"trace-file" description:"Trace file which was recorded by the fuzz command" required:"true"`
+
+		ProfileFile     flags.Filename `long:"profile-file" description:"Weight alternatives and repetitions with the profile learned by the learn command"`
+		ProfileInverted bool           `long:"profile-inverted" description:"Favour rarely taken alternatives and repetitions of the profile"`
+
+		ResultSeparator string `long:"result-separator" description:"Separates result outputs of each replayed fuzzing step" default:"\n"`
+	} `command:"replay" description:"Replay the recorded random decisions of the given trace file"`
+
 	Validate struct {
 		InputFile flags.Filename `long:"input-file" description:"Input file which gets parsed and validated via the format file" required:"true"`
 	} `command:"validate" description:"Validate the given input file"`
@@ -179,6 +230,54 @@ func (s *fuzzStrategy) Complete(match string) []flags.Completion {
 	return items
 }
 
+type importFormat string
+
+func (s *importFormat) Complete(match string) []flags.Completion {
+	var items []flags.Completion
+
+	for _, name := range tavorImporter.List() {
+		if strings.HasPrefix(name, match) {
+			items = append(items, flags.Completion{
+				Item: name,
+			})
+		}
+	}
+
+	return items
+}
+
+type lintRule string
+
+func (s lintRule) Complete(match string) []flags.Completion {
+	var items []flags.Completion
+
+	for _, name := range lint.List() {
+		if strings.HasPrefix(name, match) {
+			items = append(items, flags.Completion{
+				Item: name,
+			})
+		}
+	}
+
+	return items
+}
+
+type lintSeverity string
+
+func (s lintSeverity) Complete(match string) []flags.Completion {
+	var items []flags.Completion
+
+	for _, name := range lint.ListSeverities() {
+		if strings.HasPrefix(name, match) {
+			items = append(items, flags.Completion{
+				Item: name,
+			})
+		}
+	}
+
+	return items
+}
+
 type reduceStrategy string
 
 func (s *reduceStrategy) Complete(match string) []flags.Completion {
@@ -215,7 +314,7 @@ func checkArguments(args []string, opts *options) (string, exitCodeType) {
 		fmt.Printf("Tavor v%s\n", tavor.Version)
 
 		return "", exitCodeOk
-	} else if opts.Fuzz.Filter.ListFilters || opts.Graph.Filter.ListFilters {
+	} else if opts.Fuzz.Filter.ListFilters || opts.FormatCommand.Filter.ListFilters || opts.Graph.Filter.ListFilters {
 		for _, name := range tavorFuzzFilter.List() {
 			fmt.Println(name)
 		}
@@ -232,6 +331,18 @@ func checkArguments(args []string, opts *options) (string, exitCodeType) {
 			fmt.Println(name)
 		}
 
+		return "", exitCodeOk
+	} else if opts.Import.ListFormats {
+		for _, name := range tavorImporter.List() {
+			fmt.Println(name)
+		}
+
+		return "", exitCodeOk
+	} else if opts.Lint.ListRules {
+		for _, name := range lint.List() {
+			fmt.Println(name)
+		}
+
 		return "", exitCodeOk
 	} else if opts.Reduce.ListStrategies {
 		for _, name := range tavorReduceStrategy.List() {
@@ -249,6 +360,16 @@ func checkArguments(args []string, opts *options) (string, exitCodeType) {
 		return "", exitCodeBashCompletion
 	}
 
+	if p.Active.Name == "import" {
+		if opts.Import.From == "" {
+			return "", exitError("the required flag `--from' was not specified")
+		} else if opts.Import.InputFile == "" {
+			return "", exitError("the required flag `--input-file' was not specified")
+		}
+	} else if p.Active.Name != "lsp" && opts.Format.FormatFile == "" {
+		return "", exitError("the required flag `--format-file' was not specified")
+	}
+
 	if opts.General.Debug {
 		log.LevelDebug()
 	} else if opts.General.Verbose {
@@ -275,6 +396,11 @@ func checkArguments(args []string, opts *options) (string, exitCodeType) {
 			opts.Fuzz.ResultSeparator = t
 		}
 	}
+	if opts.Replay.ResultSeparator != "" {
+		if t, err := strconv.Unquote(`"` + opts.Replay.ResultSeparator + `"`); err == nil {
+			opts.Replay.ResultSeparator = t
+		}
+	}
 
 	if opts.Reduce.Exec.ExecArgumentType != "" {
 		found := false
@@ -314,6 +440,53 @@ func exitError(format string, args ...interface{}) exitCodeType {
 	return exitCodeError
 }
 
+type jsonParserError struct {
+	Message  string `json:"message"`
+	Type     string `json:"type,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// exitParserErrors prints all errors of parsing a format file to STDERR or as a JSON array to STDOUT
+func exitParserErrors(err error, asJSON bool) exitCodeType {
+	errs := []error{err}
+	if perrs, ok := err.(token.ParserErrors); ok {
+		errs = perrs
+	}
+
+	if !asJSON {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "cannot parse tavor file: %v\n", err)
+		}
+
+		return exitCodeError
+	}
+
+	jerrs := make([]jsonParserError, len(errs))
+	for i, err := range errs {
+		if perr, ok := err.(*token.ParserError); ok {
+			jerrs[i] = jsonParserError{
+				Message:  perr.Message,
+				Type:     perr.Type.String(),
+				Filename: perr.Position.Filename,
+				Line:     perr.Position.Line,
+				Column:   perr.Position.Column,
+			}
+		} else {
+			jerrs[i] = jsonParserError{
+				Message: err.Error(),
+			}
+		}
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(jerrs); err != nil {
+		return exitError("cannot encode errors: %v", err)
+	}
+
+	return exitCodeError
+}
+
 func applyFilters(opts *options, filterNames []fuzzFilter, doc token.Token) (token.Token, error) {
 	if len(filterNames) > 0 {
 		var err error
@@ -351,31 +524,313 @@ func applyFilters(opts *options, filterNames []fuzzFilter, doc token.Token) (tok
 	return doc, nil
 }
 
-func mainCmd(args []string) exitCodeType {
-	var opts = new(options)
+func applyProfile(profileFile string, inverted bool, doc token.Token) error {
+	f, err := os.Open(profileFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			panic(err)
+		}
+	}()
 
-	command, exitCode := checkArguments(args, opts)
-	if command == "" {
-		return exitCode
+	prof, err := tavorFuzzProfile.Read(f)
+	if err != nil {
+		return err
 	}
 
-	tavor.MaxRepeat = opts.Global.MaxRepeat
+	log.Infof("using profile %s learned of %d input files", profileFile, prof.Inputs)
+
+	return prof.Apply(doc, inverted)
+}
+
+func readTrace(traceFile string) (*tavorFuzzTrace.Trace, error) {
+	f, err := os.Open(traceFile)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
+	return tavorFuzzTrace.Read(f)
+}
+
+func writeTrace(traceFile string, t *tavorFuzzTrace.Trace) error {
+	out, err := os.Create(traceFile)
+	if err != nil {
+		return fmt.Errorf("cannot create trace file %s: %v", traceFile, err)
+	}
+	defer func() {
+		if err := out.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
+	if err := t.Write(out); err != nil {
+		return fmt.Errorf("cannot write trace file %s: %v", traceFile, err)
+	}
+
+	return nil
+}
+
+func learnProfile(inputFiles []flags.Filename, formatFile string) (*tavorFuzzProfile.Profile, error) {
+	var files []string
+
+	for _, inputFile := range inputFiles {
+		fi, err := os.Stat(string(inputFile))
+		if err != nil {
+			return nil, fmt.Errorf("cannot open input file %s: %v", inputFile, err)
+		}
+
+		if !fi.IsDir() {
+			files = append(files, string(inputFile))
+
+			continue
+		}
+
+		fis, err := ioutil.ReadDir(string(inputFile))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read input folder %s: %v", inputFile, err)
+		}
+
+		for _, fi := range fis {
+			if !fi.IsDir() {
+				files = append(files, filepath.Join(string(inputFile), fi.Name()))
+			}
+		}
+	}
+
+	prof := tavorFuzzProfile.New()
+
+	for _, file := range files {
+		// every input file needs a fresh token graph since parsing changes the state of the graph
+		doc, err := parser.ParseTavorFile(formatFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse tavor file: %v", err)
+		}
+
+		input, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open input file %s: %v", file, err)
+		}
+
+		if errs := parser.ParseInternal(doc, bytes.NewReader(input)); len(errs) != 0 {
+			log.Warnf("skip invalid input file %s: %v", file, errs[0])
+
+			continue
+		}
+
+		log.Infof("learn from input file %s", file)
+
+		prof.Count(doc)
+	}
+
+	if prof.Inputs == 0 {
+		return nil, fmt.Errorf("no valid input files to learn from")
+	}
+
+	return prof, nil
+}
+
+type jsonProblem struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func lintCmd(opts *options) exitCodeType {
+	severity, err := lint.ParseSeverity(string(opts.Lint.Severity))
+	if err != nil {
+		return exitError(err.Error())
+	}
+
+	rules := make([]string, len(opts.Lint.Rules))
+	for i, rule := range opts.Lint.Rules {
+		rules[i] = string(rule)
+	}
 
 	log.Infof("open file %s", opts.Format.FormatFile)
 
-	file, err := os.Open(string(opts.Format.FormatFile))
+	input, err := os.Open(string(opts.Format.FormatFile))
 	if err != nil {
 		return exitError("cannot open tavor file %s: %v", opts.Format.FormatFile, err)
 	}
 	defer func() {
-		if err := file.Close(); err != nil {
+		if err := input.Close(); err != nil {
 			panic(err)
 		}
 	}()
 
-	doc, err := parser.ParseTavor(file)
+	all, err := lint.Lint(input, string(opts.Format.FormatFile), rules)
 	if err != nil {
-		return exitError("cannot parse tavor file: %v", err)
+		return exitError("cannot lint tavor file: %v", err)
+	}
+
+	var problems []lint.Problem
+	for _, problem := range all {
+		if problem.Severity >= severity {
+			problems = append(problems, problem)
+		}
+	}
+
+	if opts.Format.JSON {
+		jproblems := make([]jsonProblem, len(problems))
+		for i, problem := range problems {
+			jproblems[i] = jsonProblem{
+				Rule:     problem.Rule,
+				Severity: problem.Severity.String(),
+				Message:  problem.Message,
+				Filename: problem.Position.Filename,
+				Line:     problem.Position.Line,
+				Column:   problem.Position.Column,
+			}
+		}
+
+		if err := json.NewEncoder(os.Stdout).Encode(jproblems); err != nil {
+			return exitError("cannot encode problems: %v", err)
+		}
+	} else {
+		for _, problem := range problems {
+			fmt.Println(problem.String())
+		}
+	}
+
+	if len(problems) != 0 {
+		return exitCodeError
+	}
+
+	return exitCodeOk
+}
+
+func lspCmd() exitCodeType {
+	log.Info("serve the language server protocol over STDIN and STDOUT")
+
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		return exitError("language server stopped: %v", err)
+	}
+
+	return exitCodeOk
+}
+
+func formatCmd(opts *options) exitCodeType {
+	log.Infof("open file %s", opts.Format.FormatFile)
+
+	input, err := os.Open(string(opts.Format.FormatFile))
+	if err != nil {
+		return exitError("cannot open tavor file %s: %v", opts.Format.FormatFile, err)
+	}
+	defer func() {
+		if err := input.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
+	// the definitions keep the names of the token definitions
+	doc, definitions, err := parser.ParseTavorDefinitions(input, string(opts.Format.FormatFile))
+	if err != nil {
+		return exitParserErrors(err, opts.Format.JSON)
+	}
+
+	log.Info("format file is valid")
+
+	// only the filtered format is printed if filters are applied
+	doc, err = applyFilters(opts, opts.FormatCommand.Filter.Filters, doc)
+	if err != nil {
+		return exitError("cannot apply filters: %v", err)
+	}
+
+	if err := printer.WriteTavorDefinitions(doc, definitions, os.Stdout); err != nil {
+		return exitError("cannot emit tavor format: %v", err)
+	}
+
+	return exitCodeOk
+}
+
+func importCmd(opts *options) exitCodeType {
+	imp, err := tavorImporter.New(string(opts.Import.From))
+	if err != nil {
+		return exitError(err.Error())
+	}
+
+	log.Infof("import file %s", opts.Import.InputFile)
+
+	format, warnings, err := tavorImporter.ImportFile(imp, string(opts.Import.InputFile), opts.Import.Start)
+	if err != nil {
+		return exitError("cannot import input file: %v", err)
+	}
+
+	for _, w := range warnings {
+		log.Warn(w)
+	}
+
+	// the imported format must be a valid Tavor format
+	doc, err := parser.ParseTavor(strings.NewReader(format))
+	if err != nil {
+		return exitError("cannot parse imported tavor format: %v", err)
+	}
+
+	if opts.Format.PrintInternal {
+		log.Info("Internal AST:")
+
+		token.PrettyPrintInternalTree(os.Stdout, doc)
+	}
+
+	if opts.Format.Print {
+		log.Info("AST:")
+
+		token.PrettyPrintTree(os.Stdout, doc)
+	}
+
+	fmt.Print(format)
+
+	return exitCodeOk
+}
+
+func mainCmd(args []string) exitCodeType {
+	var opts = new(options)
+
+	command, exitCode := checkArguments(args, opts)
+	if command == "" {
+		return exitCode
+	}
+
+	tavor.MaxRepeat = opts.Global.MaxRepeat
+
+	if command == "format" {
+		return formatCmd(opts)
+	} else if command == "import" {
+		return importCmd(opts)
+	} else if command == "lint" {
+		return lintCmd(opts)
+	} else if command == "lsp" {
+		return lspCmd()
+	}
+
+	log.Infof("open file %s", opts.Format.FormatFile)
+
+	var doc token.Token
+	var definitions map[string]token.Token
+	var err error
+
+	// traces identify tokens relative to their token definitions
+	if command == "replay" || (command == "fuzz" && opts.Fuzz.Record != "") {
+		doc, definitions, err = parser.ParseTavorFileDefinitions(string(opts.Format.FormatFile))
+	} else {
+		doc, err = parser.ParseTavorFile(string(opts.Format.FormatFile))
+	}
+	if err != nil {
+		if _, ok := err.(*os.PathError); ok {
+			return exitError("cannot open tavor file %s: %v", opts.Format.FormatFile, err)
+		}
+
+		return exitParserErrors(err, opts.Format.JSON)
 	}
 
 	log.Info("format file is valid")
@@ -400,6 +855,16 @@ func mainCmd(args []string) exitCodeType {
 
 	switch command {
 	case "fuzz":
+		if opts.Fuzz.ProfileFile != "" {
+			if err := applyProfile(string(opts.Fuzz.ProfileFile), opts.Fuzz.ProfileInverted, doc); err != nil {
+				return exitError("cannot apply profile: %v", err)
+			}
+
+			if opts.Fuzz.Strategy != "random" {
+				log.Warnf("the profile does only influence the random fuzzing strategy")
+			}
+		}
+
 		doc, err = applyFilters(opts, opts.Fuzz.Filter.Filters, doc)
 		if err != nil {
 			return exitError("cannot apply filters: %v", err)
@@ -412,6 +877,10 @@ func mainCmd(args []string) exitCodeType {
 			return exitError(err.Error())
 		}
 
+		if s, ok := strat.(tavorFuzzStrategy.DefinitionStrategy); ok {
+			s.SetDefinitions(definitions)
+		}
+
 		log.Infof("using %s fuzzing strategy", opts.Fuzz.Strategy)
 
 		folder := opts.Fuzz.ResultFolder
@@ -419,7 +888,34 @@ func mainCmd(args []string) exitCodeType {
 			folder += "/"
 		}
 
-		ch, err := strat.Fuzz(r)
+		var fuzzRand tavorRand.Rand = r
+
+		if opts.Fuzz.Record != "" {
+			recorder := tavorRand.NewRecordingRand(r)
+			fuzzRand = recorder
+
+			// the trace is also written if the run is aborted by an error
+			defer func() {
+				t := &tavorFuzzTrace.Trace{
+					Strategy:  string(opts.Fuzz.Strategy),
+					Seed:      opts.Global.Seed,
+					Decisions: recorder.Decisions(),
+				}
+				for _, name := range opts.Fuzz.Filter.Filters {
+					t.Filters = append(t.Filters, string(name))
+				}
+
+				if err := writeTrace(string(opts.Fuzz.Record), t); err != nil {
+					log.Error(err)
+
+					return
+				}
+
+				log.Infof("recorded %d random decisions to %s", len(t.Decisions), opts.Fuzz.Record)
+			}()
+		}
+
+		ch, err := strat.Fuzz(fuzzRand)
 		if err != nil {
 			return exitError(err.Error())
 		}
@@ -767,6 +1263,37 @@ func mainCmd(args []string) exitCodeType {
 				ch <- i
 			}
 		}
+	case "replay":
+		if opts.Replay.ProfileFile != "" {
+			if err := applyProfile(string(opts.Replay.ProfileFile), opts.Replay.ProfileInverted, doc); err != nil {
+				return exitError("cannot apply profile: %v", err)
+			}
+		}
+
+		t, err := readTrace(string(opts.Replay.TraceFile))
+		if err != nil {
+			return exitError(err.Error())
+		}
+
+		log.Infof("replay %d random decisions of the %s fuzzing strategy", len(t.Decisions), t.Strategy)
+
+		divergences, err := t.Replay(doc, definitions, func(root token.Token) error {
+			fmt.Print(root.String())
+			fmt.Print(opts.Replay.ResultSeparator)
+
+			return nil
+		})
+		if err != nil {
+			return exitError("cannot replay trace: %v", err)
+		}
+
+		if len(divergences) != 0 {
+			for _, d := range divergences {
+				fmt.Fprintf(os.Stderr, "replay diverged at %s\n", d.String())
+			}
+
+			return exitCodeError
+		}
 	case "graph":
 		doc, err = applyFilters(opts, opts.Graph.Filter.Filters, doc)
 		if err != nil {
@@ -774,6 +1301,27 @@ func mainCmd(args []string) exitCodeType {
 		}
 
 		graph.WriteDot(doc, os.Stdout)
+	case "learn":
+		prof, err := learnProfile(opts.Learn.InputFiles, string(opts.Format.FormatFile))
+		if err != nil {
+			return exitError(err.Error())
+		}
+
+		out, err := os.Create(string(opts.Learn.ProfileFile))
+		if err != nil {
+			return exitError("cannot create profile file %s: %v", opts.Learn.ProfileFile, err)
+		}
+		defer func() {
+			if err := out.Close(); err != nil {
+				panic(err)
+			}
+		}()
+
+		if err := prof.Write(out); err != nil {
+			return exitError("cannot write profile file %s: %v", opts.Learn.ProfileFile, err)
+		}
+
+		log.Infof("learned profile of %d input files", prof.Inputs)
 	case "reduce", "validate":
 		inputFile := opts.Validate.InputFile
 
@@ -2,9 +2,12 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -32,6 +35,267 @@ func TestMain(t *testing.T) {
 	assert.Contains(t, out, "1\n2\n3\n")
 }
 
+func TestMainLearn(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+
+	formatFile := filepath.Join(dir, "format.tavor")
+	profileFile := filepath.Join(dir, "profile.json")
+	corpus := filepath.Join(dir, "corpus")
+
+	assert.Nil(t, ioutil.WriteFile(formatFile, []byte("START = +1,3(1 | 2 | 3)\n"), 0644))
+	assert.Nil(t, os.Mkdir(corpus, 0755))
+	for name, data := range map[string]string{
+		"a": "11",
+		"b": "1",
+		"c": "4",
+	} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(corpus, name), []byte(data), 0644))
+	}
+
+	exitCode, out := execMain(t, []string{"--format-file", formatFile, "learn", "--input-file", corpus, "--profile-file", profileFile})
+	assert.Equal(t, exitCodeOk, exitCode, out)
+
+	data, err := ioutil.ReadFile(profileFile)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"inputs": 2`)
+
+	exitCode, out = execMain(t, []string{"--format-file", formatFile, "--seed", "1", "fuzz", "--profile-file", profileFile, "--profile-inverted"})
+	assert.Equal(t, exitCodeOk, exitCode, out)
+
+	// no valid input files
+	exitCode, _ = execMain(t, []string{"--format-file", formatFile, "learn", "--input-file", filepath.Join(corpus, "c"), "--profile-file", profileFile})
+	assert.Equal(t, exitCodeError, exitCode)
+
+	// the profile must match the format file
+	assert.Nil(t, ioutil.WriteFile(formatFile, []byte("START = +1,3(1 | 2)\n"), 0644))
+
+	exitCode, _ = execMain(t, []string{"--format-file", formatFile, "fuzz", "--profile-file", profileFile})
+	assert.Equal(t, exitCodeError, exitCode)
+}
+
+func TestMainEmitTavor(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("$Number Int = from: 1,\n              to: 10\n\nSTART = Number\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"--format-file", f.Name(), "format", "--emit-tavor"})
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, "$Number Int = from: 1,\n              to: 10,\n              step: 1\n\nSTART = Number\n", out)
+
+	// only the filtered format is printed
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "format", "--emit-tavor", "--filter", "PositiveBoundaryValueAnalysis"})
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, "Number = 1 | 5 | 10\n\nSTART = Number\n", out)
+
+	// the Tavor format is the default
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "format"})
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, "$Number Int = from: 1,\n              to: 10,\n              step: 1\n\nSTART = Number\n", out)
+}
+
+func TestMainParserErrors(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("A = B\nSTART = 1 {endif}\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"--format-file", f.Name(), "fuzz"})
+	assert.Equal(t, exitCodeError, exitCode)
+	assert.Equal(t, 3, strings.Count(out, "cannot parse tavor file: "))
+	assert.Contains(t, out, "L:2, C:12 - endif without if\n")
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "--json", "fuzz"})
+	assert.Equal(t, exitCodeError, exitCode)
+
+	var errs []jsonParserError
+	assert.Nil(t, json.Unmarshal([]byte(out), &errs))
+	assert.Equal(t, []jsonParserError{
+		{Message: `token "A" declared but not used`, Type: "ParseErrorUnusedToken", Filename: f.Name(), Line: 1, Column: 1},
+		{Message: `token "B" is not defined`, Type: "ParseErrorTokenNotDefined", Filename: f.Name(), Line: 1, Column: 5},
+		{Message: "endif without if", Type: "ParseErrorConditionWithoutIf", Filename: f.Name(), Line: 2, Column: 12},
+	}, errs)
+}
+
+func TestMainImport(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("number = 1*DIGIT\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"import", "--from", "abnf", "--input-file", f.Name()})
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, "START = number\n\nnumber = +(DIGIT)\nDIGIT = [0-9]\n", out)
+
+	exitCode, _ = execMain(t, []string{"import", "--from", "abnf", "--input-file", f.Name(), "--start", "unknown"})
+	assert.Equal(t, exitCodeError, exitCode)
+
+	exitCode, _ = execMain(t, []string{"import", "--from", "unknown", "--input-file", f.Name()})
+	assert.Equal(t, exitCodeError, exitCode)
+
+	exitCode, _ = execMain(t, []string{"import", "--input-file", f.Name()})
+	assert.Equal(t, exitCodeError, exitCode)
+
+	// every other command needs a format file
+	exitCode, _ = execMain(t, []string{"fuzz"})
+	assert.Equal(t, exitCodeError, exitCode)
+
+	exitCode, out = execMain(t, []string{"import", "--list-formats"})
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Contains(t, out, "abnf\nebnf\njsonschema\n")
+}
+
+func TestMainReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	defer func() {
+		assert.Nil(t, os.RemoveAll(dir))
+	}()
+
+	formatFile := filepath.Join(dir, "format.tavor")
+	traceFile := filepath.Join(dir, "trace.json")
+
+	assert.Nil(t, ioutil.WriteFile(formatFile, []byte("A = \"a\" | \"b\" | \"c\"\nSTART = +2,4(A) [0-9]\n"), 0644))
+
+	exitCode, generation := execMain(t, []string{"--seed", "7", "--format-file", formatFile, "fuzz", "--record", traceFile})
+	assert.Equal(t, exitCodeOk, exitCode)
+
+	// the same format reproduces the generation
+	exitCode, out := execMain(t, []string{"--format-file", formatFile, "replay", "--trace-file", traceFile})
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, generation, out)
+
+	// changes of the format are reported
+	assert.Nil(t, ioutil.WriteFile(formatFile, []byte("A = \"a\" | \"b\" | \"c\" | \"d\"\nSTART = +2,4(A) [0-9]\n"), 0644))
+
+	exitCode, out = execMain(t, []string{"--format-file", formatFile, "replay", "--trace-file", traceFile})
+	assert.Equal(t, exitCodeError, exitCode)
+	assert.Contains(t, out, generation)
+	assert.Contains(t, out, "replay diverged at decision ")
+	assert.Contains(t, out, "bound of Int63n changed from 3 to 4")
+
+	exitCode, _ = execMain(t, []string{"--format-file", formatFile, "replay", "--trace-file", filepath.Join(dir, "unknown.json")})
+	assert.Equal(t, exitCodeError, exitCode)
+
+	// aborted runs are recorded too
+	assert.Nil(t, os.Remove(traceFile))
+
+	exitCode, _ = execMain(t, []string{"--format-file", formatFile, "fuzz", "--record", traceFile, "--exec", filepath.Join(dir, "unknown")})
+	assert.Equal(t, exitCodeError, exitCode)
+
+	_, err = os.Stat(traceFile)
+	assert.Nil(t, err)
+}
+
+func TestMainLint(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("A = B\nB = A | 1\nSTART = 1 | [0-9]\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"--format-file", f.Name(), "lint"})
+	assert.Equal(t, exitCodeError, exitCode)
+	assert.Contains(t, out, "L:1, C:1 - warning: token \"A\" is not reachable from START (UnreachableDefinition)\n")
+	assert.Contains(t, out, "L:3, C:1 - info: ")
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "--json", "lint", "--severity", "warning"})
+	assert.Equal(t, exitCodeError, exitCode)
+
+	var problems []jsonProblem
+	assert.Nil(t, json.Unmarshal([]byte(out), &problems))
+	assert.Equal(t, []jsonProblem{
+		{Rule: "UnreachableDefinition", Severity: "warning", Message: `token "A" is not reachable from START`, Filename: f.Name(), Line: 1, Column: 1},
+		{Rule: "UnreachableDefinition", Severity: "warning", Message: `token "B" is not reachable from START`, Filename: f.Name(), Line: 2, Column: 1},
+	}, problems)
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "lint", "--rule", "IdenticalAlternatives"})
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, "", out)
+
+	exitCode, _ = execMain(t, []string{"--format-file", f.Name(), "lint", "--rule", "unknown"})
+	assert.Equal(t, exitCodeError, exitCode)
+
+	exitCode, _ = execMain(t, []string{"--format-file", f.Name(), "lint", "--severity", "unknown"})
+	assert.Equal(t, exitCodeError, exitCode)
+
+	exitCode, out = execMain(t, []string{"lint", "--list-rules"})
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Contains(t, out, "DeadBranch\n")
+}
+
+func TestMainLsp(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	for _, m := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		_, err = fmt.Fprintf(f, "Content-Length: %d\r\n\r\n%s", len(m), m)
+		assert.Nil(t, err)
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+	assert.Nil(t, err)
+
+	saveStdin := os.Stdin
+	os.Stdin = f
+
+	defer func() {
+		os.Stdin = saveStdin
+
+		assert.Nil(t, f.Close())
+		assert.Nil(t, os.Remove(f.Name()))
+	}()
+
+	exitCode, out := execMain(t, []string{"lsp"})
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Contains(t, out, `{"jsonrpc":"2.0","id":1,"result":null}`)
+}
+
 func TestMainCommandListingOptions(t *testing.T) {
 
 	exitCode, out := execMain(t, []string{"fuzz", "--list-exec-argument-types"})
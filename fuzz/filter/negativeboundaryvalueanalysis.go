@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"math"
 	"strconv"
 
 	"github.com/zimmski/tavor/token"
@@ -9,7 +10,7 @@ import (
 )
 
 // NegativeBoundaryValueAnalysisFilter implements a fuzzing filter for negative boundary-value analysis.
-// This filter searches the token graph for integer range tokens which will be transformed to exactly two integers: The lower and higher negative boundary. Using this filter reduces for example the integer range 1-100 to the integers 0 and 101. Which reduces the range away from the model definition and therefore to an invalid data generation, which can be used for example for negative tests.
+// This filter searches the token graph for integer and floating point range tokens which will be transformed to exactly two values: The lower and higher negative boundary. Using this filter reduces for example the integer range 1-100 to the integers 0 and 101 and the floating point range 1.00-2.00 to 0.99 and 2.01. Which reduces the range away from the model definition and therefore to an invalid data generation, which can be used for example for negative tests.
 type NegativeBoundaryValueAnalysisFilter struct{}
 
 // NewNegativeBoundaryValueAnalysisFilter returns a new instance of the negative boundary-value analysis fuzzing filter
@@ -26,32 +27,44 @@ func init() {
 // Apply applies the fuzzing filter onto the token and returns a replacement token, or nil if there is no replacement.
 // If a fatal error is encountered the error return argument is not nil.
 func (f *NegativeBoundaryValueAnalysisFilter) Apply(tok token.Token) (token.Token, error) {
-	t, ok := tok.(*primitives.RangeInt)
-	if !ok {
-		return nil, nil
-	}
+	var replacements []token.Token
 
-	l := t.Permutations()
+	switch t := tok.(type) {
+	case *primitives.RangeInt:
+		l := t.Permutations()
 
-	var replacements []token.Token
+		// lower boundary
+		if err := t.Permutation(1); err != nil {
+			panic(err)
+		}
 
-	// lower boundary
-	if err := t.Permutation(1); err != nil {
-		panic(err)
-	}
+		i, _ := strconv.Atoi(t.String())
 
-	i, _ := strconv.Atoi(t.String())
+		replacements = append(replacements, primitives.NewConstantInt(i-1))
 
-	replacements = append(replacements, primitives.NewConstantInt(i-1))
+		// upper boundary
+		if err := t.Permutation(l); err != nil {
+			panic(err)
+		}
 
-	// upper boundary
-	if err := t.Permutation(l); err != nil {
-		panic(err)
-	}
+		i, _ = strconv.Atoi(t.String())
 
-	i, _ = strconv.Atoi(t.String())
+		replacements = append(replacements, primitives.NewConstantInt(i+1))
+	case *primitives.RangeFloat:
+		if t.Format() == primitives.FloatFormatDecimal && t.Precision() >= 0 {
+			// the closest values which can be represented with the precision
+			step := math.Pow10(-t.Precision())
 
-	replacements = append(replacements, primitives.NewConstantInt(i+1))
+			replacements = append(replacements, primitives.NewConstantFloat(t.From()-step, t.Format(), t.Precision()))
+			replacements = append(replacements, primitives.NewConstantFloat(t.To()+step, t.Format(), t.Precision()))
+		} else {
+			// the closest values which can be represented at all
+			replacements = append(replacements, primitives.NewConstantFloat(math.Nextafter(t.From(), math.Inf(-1)), t.Format(), -1))
+			replacements = append(replacements, primitives.NewConstantFloat(math.Nextafter(t.To(), math.Inf(1)), t.Format(), -1))
+		}
+	default:
+		return nil, nil
+	}
 
 	return lists.NewOne(replacements...), nil
 }
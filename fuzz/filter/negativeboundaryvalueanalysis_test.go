@@ -69,3 +69,22 @@ func TestNewNegativeBoundaryValueAnalysisFilter(t *testing.T) {
 		))
 	}
 }
+
+func TestNewNegativeBoundaryValueAnalysisFilterFloat(t *testing.T) {
+	f := NewNegativeBoundaryValueAnalysisFilter()
+
+	// range with precision
+	{
+		root := primitives.NewRangeFloat(1, 2, primitives.FloatFormatDecimal, 2)
+		replacements, err := f.Apply(root)
+		Nil(t, err)
+		Equal(t, []string{"0.99", "2.01"}, replacementStrings(replacements))
+	}
+	// range without precision
+	{
+		root := primitives.NewRangeFloat(0, 1, primitives.FloatFormatScientific, -1)
+		replacements, err := f.Apply(root)
+		Nil(t, err)
+		Equal(t, []string{"-5e-324", "1.0000000000000002e+00"}, replacementStrings(replacements))
+	}
+}
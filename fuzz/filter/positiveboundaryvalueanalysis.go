@@ -10,7 +10,7 @@ import (
 )
 
 // PositiveBoundaryValueAnalysisFilter implements a fuzzing filter for positive boundary-value analysis.
-// This filter searches the token graph for range tokens which will be transformed to at most three new values: The lower and higher boundary as well as the value exactly at the middle of the range. Using this filter reduces for example integer ranges of 1-100 to the integers 1, 50 and 100. Which reduces permutations dramatically. A range of 1-2 will be reduces to the integers 1 and 2. A range of 1 will be reduced to the integer 1. Resulting integers of this filter therefore do not overlap.
+// This filter searches the token graph for range tokens which will be transformed to at most three new values: The lower and higher boundary as well as the value exactly at the middle of the range. Special values of floating point ranges like NaN or infinity are kept as additional values. Using this filter reduces for example integer ranges of 1-100 to the integers 1, 50 and 100. Which reduces permutations dramatically. A range of 1-2 will be reduces to the integers 1 and 2. A range of 1 will be reduced to the integer 1. Resulting integers of this filter therefore do not overlap.
 type PositiveBoundaryValueAnalysisFilter struct{}
 
 // NewPositiveBoundaryValueAnalysisFilter returns a new instance of the positive boundary-value analysis fuzzing filter
@@ -90,6 +90,34 @@ func (f *PositiveBoundaryValueAnalysisFilter) Apply(tok token.Token) (token.Toke
 
 			replacements = append(replacements, primitives.NewConstantInt(i))
 		}
+	case *primitives.RangeFloat:
+		l := tok.RangePermutations()
+
+		// lower boundary
+		replacements = append(replacements, primitives.NewConstantFloat(tok.From(), tok.Format(), tok.Precision()))
+
+		// middle
+		if l > 2 {
+			if err := tok.Permutation(uint(math.Ceil(float64(l) / 2.0))); err != nil {
+				panic(err)
+			}
+
+			replacements = append(replacements, primitives.NewConstantFloat(tok.Value(), tok.Format(), tok.Precision()))
+		}
+
+		// upper boundary
+		if l > 1 {
+			if err := tok.Permutation(l); err != nil {
+				panic(err)
+			}
+
+			replacements = append(replacements, primitives.NewConstantFloat(tok.Value(), tok.Format(), tok.Precision()))
+		}
+
+		// special values are boundaries on their own
+		for _, special := range tok.Specials() {
+			replacements = append(replacements, primitives.NewConstantFloat(special, tok.Format(), -1))
+		}
 	default:
 		return nil, nil
 	}
@@ -5,6 +5,7 @@ import (
 
 	. "github.com/zimmski/tavor/test/assert"
 
+	"github.com/zimmski/tavor/token"
 	"github.com/zimmski/tavor/token/lists"
 	"github.com/zimmski/tavor/token/primitives"
 )
@@ -80,3 +81,52 @@ func TestNewPositiveBoundaryValueAnalysisFilter(t *testing.T) {
 		))
 	}
 }
+
+func replacementStrings(tok token.Token) []string {
+	var strs []string
+
+	l := tok.(token.ListToken)
+	for i := 0; i < l.InternalLen(); i++ {
+		c, _ := l.InternalGet(i)
+
+		strs = append(strs, c.String())
+	}
+
+	return strs
+}
+
+func TestNewPositiveBoundaryValueAnalysisFilterFloat(t *testing.T) {
+	f := NewPositiveBoundaryValueAnalysisFilter()
+
+	// single value range
+	{
+		root := primitives.NewRangeFloat(1.5, 1.5, primitives.FloatFormatDecimal, 2)
+		replacements, err := f.Apply(root)
+		Nil(t, err)
+		Equal(t, replacements, primitives.NewConstantFloat(1.5, primitives.FloatFormatDecimal, 2))
+	}
+	// range with precision
+	{
+		root := primitives.NewRangeFloat(1, 2, primitives.FloatFormatDecimal, 1)
+		replacements, err := f.Apply(root)
+		Nil(t, err)
+		Equal(t, []string{"1.0", "1.5", "2.0"}, replacementStrings(replacements))
+	}
+	// range without precision
+	{
+		root := primitives.NewRangeFloat(-1, 1, primitives.FloatFormatScientific, -1)
+		replacements, err := f.Apply(root)
+		Nil(t, err)
+		Equal(t, []string{"-1e+00", "0e+00", "1e+00"}, replacementStrings(replacements))
+	}
+	// special values
+	{
+		root := primitives.NewRangeFloatWithSpecials(0, 1, primitives.FloatFormatDecimal, 1, []float64{
+			primitives.FloatSpecials["NaN"],
+			primitives.FloatSpecials["-0"],
+		})
+		replacements, err := f.Apply(root)
+		Nil(t, err)
+		Equal(t, []string{"0.0", "0.5", "1.0", "NaN", "-0"}, replacementStrings(replacements))
+	}
+}
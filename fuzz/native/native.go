@@ -0,0 +1,95 @@
+// Package native provides helpers to drive Tavor formats with coverage-guided fuzzing engines like Go's native fuzzing
+// The fuzzing engine mutates a byte slice which is turned into a generation of a format by drawing all random decisions of the generation from the bytes. Mutations of the bytes therefore map to structural mutations of the generation.
+package native
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zimmski/tavor/fuzz/strategy"
+	"github.com/zimmski/tavor/parser"
+	"github.com/zimmski/tavor/rand"
+	"github.com/zimmski/tavor/token"
+)
+
+// Generate returns the generation of the given token graph for the given bytes
+// The random fuzzing strategy is applied with a random generator which draws its values from the bytes, which means that the same bytes always lead to the same generation. Missing bytes are 0, hence an empty byte slice leads to a generation which mostly consists of the first alternatives and the fewest repetitions. The token graph is permutated by the generation. The error return argument is not nil if the token graph cannot be fuzzed, e.g. because of an endless loop.
+func Generate(root token.Token, data []byte) (string, error) {
+	strat := strategy.NewRandomStrategy(root)
+
+	ch, err := strat.Fuzz(rand.NewBytesRand(data))
+	if err != nil {
+		return "", err
+	}
+
+	var generation string
+
+	for i := range ch {
+		generation = root.String()
+
+		ch <- i
+	}
+
+	return generation, nil
+}
+
+// Generator generates inputs of a format out of bytes
+// A generator can be used concurrently.
+type Generator struct {
+	mutex sync.Mutex
+	root  token.Token
+}
+
+// NewGenerator returns a new generator for the given token graph
+func NewGenerator(root token.Token) *Generator {
+	return &Generator{
+		root: root,
+	}
+}
+
+// Parse returns a new generator for the given Tavor format
+func Parse(format string) (*Generator, error) {
+	root, err := parser.ParseTavor(strings.NewReader(format))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGenerator(root), nil
+}
+
+// ParseFile returns a new generator for the given Tavor format file
+func ParseFile(filename string) (*Generator, error) {
+	root, err := parser.ParseTavorFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGenerator(root), nil
+}
+
+// Generate returns the generation of the format for the given bytes
+// The error return argument is not nil if the format cannot be fuzzed, e.g. because of an endless loop.
+func (g *Generator) Generate(data []byte) (string, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return Generate(g.root, data)
+}
+
+// Fuzz runs the given fuzz target with generations of the format and is meant to be called instead of f.Fuzz
+// Every byte slice of the fuzzing engine is turned into a generation which is handed to the fuzz target. An empty byte slice is added to the seed corpus, additional seeds can be added with f.Add before calling Fuzz.
+func (g *Generator) Fuzz(f *testing.F, target func(t *testing.T, input string)) {
+	f.Helper()
+
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		input, err := g.Generate(data)
+		if err != nil {
+			t.Fatalf("cannot generate input: %v", err)
+		}
+
+		target(t, input)
+	})
+}
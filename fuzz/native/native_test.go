@@ -0,0 +1,73 @@
+package native
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+const testFormat = "Digit = \"0\" | \"1\" | \"2\"\nSTART = +1,3(Digit) \".\"\n"
+
+func TestGenerate(t *testing.T) {
+	g, err := Parse(testFormat)
+	Nil(t, err)
+
+	// empty bytes lead to the smallest generation
+	input, err := g.Generate(nil)
+	Nil(t, err)
+	Equal(t, "0.", input)
+
+	// the same bytes lead to the same generation
+	data := []byte{2, 1, 2, 0}
+
+	first, err := g.Generate(data)
+	Nil(t, err)
+	Equal(t, "120.", first)
+
+	for i := 0; i < 10; i++ {
+		input, err = g.Generate(data)
+		Nil(t, err)
+		Equal(t, first, input)
+	}
+
+	// every generation can be reached
+	inputs := make(map[string]struct{})
+
+	for i := 0; i < 3*3*3*3; i++ {
+		input, err = g.Generate([]byte{byte(i % 3), byte(i / 3 % 3), byte(i / 9 % 3), byte(i / 27)})
+		Nil(t, err)
+		True(t, strings.HasSuffix(input, "."))
+
+		inputs[input] = struct{}{}
+	}
+	Equal(t, 3+3*3+3*3*3, len(inputs))
+
+	// invalid formats
+	_, err = Parse("START = A\n")
+	NotNil(t, err)
+	_, err = ParseFile("unknown.tavor")
+	NotNil(t, err)
+
+	// token graphs can be used directly
+	input, err = NewGenerator(primitives.NewConstantString("a")).Generate([]byte{1})
+	Nil(t, err)
+	Equal(t, "a", input)
+}
+
+func FuzzGenerator(f *testing.F) {
+	g, err := Parse(testFormat)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add([]byte{2, 1, 2, 0})
+
+	g.Fuzz(f, func(t *testing.T, input string) {
+		if !strings.HasSuffix(input, ".") || len(input) < 2 || len(input) > 4 {
+			t.Errorf("unexpected input %q", input)
+		}
+	})
+}
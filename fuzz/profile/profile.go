@@ -0,0 +1,280 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+)
+
+// Profile holds how often alternatives and repetition counts of a token graph were taken by a corpus of inputs
+// Tokens are identified by their path in the internal token graph which is why a profile can only be applied to token graphs of the same format.
+type Profile struct {
+	// Inputs is the number of counted inputs
+	Inputs uint `json:"inputs"`
+	// Alternatives holds for every alternation how often each of its alternatives was taken
+	Alternatives map[string][]uint `json:"alternatives"`
+	// Repeats holds for every repetition how often each repetition count was taken
+	Repeats map[string]map[int64]uint `json:"repeats"`
+}
+
+// New returns a new empty profile
+func New() *Profile {
+	return &Profile{
+		Alternatives: make(map[string][]uint),
+		Repeats:      make(map[string]map[int64]uint),
+	}
+}
+
+// Read reads a profile in the JSON format
+func Read(r io.Reader) (*Profile, error) {
+	p := New()
+
+	if err := json.NewDecoder(r).Decode(p); err != nil {
+		return nil, fmt.Errorf("cannot read profile: %v", err)
+	}
+
+	if p.Alternatives == nil {
+		p.Alternatives = make(map[string][]uint)
+	}
+	if p.Repeats == nil {
+		p.Repeats = make(map[string]map[int64]uint)
+	}
+
+	return p, nil
+}
+
+// Write writes the profile in the JSON format
+func (p *Profile) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(p, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+
+	return err
+}
+
+// Count counts the taken alternatives and repetition counts of the given token graph
+// The token graph must hold a parsed input, e.g. after a successful call to parser.ParseInternal.
+func (p *Profile) Count(root token.Token) {
+	paths := make(map[token.Token]string)
+
+	walk(root, func(tok token.Token, path string) error {
+		paths[tok] = path
+
+		return nil
+	})
+
+	walked := make(map[token.Token]struct{})
+
+	var count func(tok token.Token)
+	count = func(tok token.Token) {
+		if _, ok := walked[tok]; ok {
+			return
+		}
+		walked[tok] = struct{}{}
+
+		if t, ok := tok.(token.Follow); ok && !t.Follow() {
+			return
+		}
+
+		switch t := tok.(type) {
+		case *lists.One:
+			c, _ := t.Get(0)
+
+			for i := 0; i < t.InternalLen(); i++ {
+				if a, _ := t.InternalGet(i); a == c {
+					alternatives, ok := p.Alternatives[paths[t]]
+					if !ok {
+						alternatives = make([]uint, t.InternalLen())
+						p.Alternatives[paths[t]] = alternatives
+					}
+					if len(alternatives) == t.InternalLen() {
+						alternatives[i]++
+					}
+
+					break
+				}
+			}
+
+			count(c)
+		case *lists.Repeat:
+			repeats, ok := p.Repeats[paths[t]]
+			if !ok {
+				repeats = make(map[int64]uint)
+				p.Repeats[paths[t]] = repeats
+			}
+			repeats[int64(t.Len())]++
+
+			for i := 0; i < t.Len(); i++ {
+				c, _ := t.Get(i)
+
+				count(c)
+			}
+		case token.ForwardToken:
+			if c := t.Get(); c != nil {
+				count(c)
+			}
+		case token.ListToken:
+			for i := 0; i < t.InternalLen(); i++ {
+				c, _ := t.InternalGet(i)
+
+				count(c)
+			}
+		}
+	}
+
+	count(root)
+
+	p.Inputs++
+}
+
+// Apply weights the alternatives and repetitions of the given token graph with the counts of the profile
+// Every alternative and repetition count is weighted with its count plus one so that never taken alternatives and counts can still be chosen. If inverted is true every weight is inverted which favours rarely taken alternatives and counts. Tokens which are not in the profile keep their weights. The error return argument is not nil if the profile does not match the token graph.
+func (p *Profile) Apply(root token.Token, inverted bool) error {
+	weight := func(count uint) float64 {
+		if inverted {
+			return 1 / float64(count+1)
+		}
+
+		return float64(count + 1)
+	}
+
+	return walk(root, func(tok token.Token, path string) error {
+		switch t := tok.(type) {
+		case *lists.One:
+			alternatives, ok := p.Alternatives[path]
+			if !ok {
+				return nil
+			}
+
+			if len(alternatives) != t.InternalLen() {
+				return fmt.Errorf("profile has %d alternatives for %q but the token has %d", len(alternatives), path, t.InternalLen())
+			}
+
+			weights := make([]float64, len(alternatives))
+			for i, c := range alternatives {
+				weights[i] = weight(c)
+			}
+
+			t.SetWeights(weights)
+		case *lists.Repeat:
+			repeats, ok := p.Repeats[path]
+			if !ok {
+				return nil
+			}
+
+			// taken counts get their own buckets while the counts in between share one bucket, so the number of buckets does not depend on the range of the repetition
+			var counts []int64
+			for c := range repeats {
+				if c >= t.From() && c <= t.To() {
+					counts = append(counts, c)
+				}
+			}
+			sort.Slice(counts, func(i, j int) bool {
+				return counts[i] < counts[j]
+			})
+
+			var buckets []lists.HistogramBucket
+			next, rest := t.From(), t.From() <= t.To()
+			for _, c := range counts {
+				if c > next {
+					buckets = append(buckets, lists.HistogramBucket{
+						From:   next,
+						To:     c - 1,
+						Weight: weight(0),
+					})
+				}
+
+				buckets = append(buckets, lists.HistogramBucket{
+					From:   c,
+					To:     c,
+					Weight: weight(repeats[c]),
+				})
+
+				next, rest = c+1, c < t.To()
+			}
+			if rest {
+				buckets = append(buckets, lists.HistogramBucket{
+					From:   next,
+					To:     t.To(),
+					Weight: weight(0),
+				})
+			}
+
+			if len(buckets) != 0 {
+				t.SetDistribution(lists.NewHistogramDistribution(buckets...))
+			}
+		default:
+			if _, ok := p.Alternatives[path]; ok {
+				return fmt.Errorf("profile has alternatives for %q but the token is no alternation", path)
+			}
+			if _, ok := p.Repeats[path]; ok {
+				return fmt.Errorf("profile has repetitions for %q but the token is no repetition", path)
+			}
+		}
+
+		return nil
+	})
+}
+
+// walk traverses the internal token graph and calls the given function for every token with its path in the graph
+// Repeated tokens have the same path as their original token.
+func walk(root token.Token, walkFunc func(tok token.Token, path string) error) error {
+	walked := make(map[token.Token]struct{})
+
+	var w func(tok token.Token, path string) error
+	w = func(tok token.Token, path string) error {
+		if _, ok := walked[tok]; ok {
+			return nil
+		}
+		walked[tok] = struct{}{}
+
+		if err := walkFunc(tok, path); err != nil {
+			return err
+		}
+
+		if t, ok := tok.(token.Follow); ok && !t.Follow() {
+			return nil
+		}
+
+		switch t := tok.(type) {
+		case *lists.Repeat:
+			c, _ := t.InternalGet(0)
+
+			if err := w(c, path+"/*"); err != nil {
+				return err
+			}
+
+			for i := 0; i < t.Len(); i++ {
+				c, _ := t.Get(i)
+
+				if err := w(c, path+"/*"); err != nil {
+					return err
+				}
+			}
+		case token.ForwardToken:
+			if c := t.InternalGet(); c != nil {
+				return w(c, path+"/0")
+			}
+		case token.ListToken:
+			for i := 0; i < t.InternalLen(); i++ {
+				c, _ := t.InternalGet(i)
+
+				if err := w(c, path+"/"+strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	return w(root, "")
+}
@@ -0,0 +1,179 @@
+package profile
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/fuzz/strategy"
+	"github.com/zimmski/tavor/parser"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+)
+
+const format = `
+Op = "+" | "-" | "*"
+
+START = +1,3(Op) "\n"
+`
+
+func parseFormat(t *testing.T) token.Token {
+	doc, err := parser.ParseTavor(strings.NewReader(format))
+	Nil(t, err)
+
+	return doc
+}
+
+func findTokens(root token.Token) (*lists.One, *lists.Repeat) {
+	var one *lists.One
+	var repeat *lists.Repeat
+
+	_ = walk(root, func(tok token.Token, path string) error {
+		switch t := tok.(type) {
+		case *lists.One:
+			if one == nil {
+				one = t
+			}
+		case *lists.Repeat:
+			if repeat == nil {
+				repeat = t
+			}
+		}
+
+		return nil
+	})
+
+	return one, repeat
+}
+
+func TestProfileCount(t *testing.T) {
+	p := New()
+
+	for _, input := range []string{"+\n", "++\n", "+-\n", "*\n"} {
+		doc := parseFormat(t)
+
+		Nil(t, parser.ParseInternal(doc, strings.NewReader(input)))
+
+		p.Count(doc)
+	}
+
+	Equal(t, uint(4), p.Inputs)
+
+	Equal(t, 1, len(p.Alternatives))
+	for _, alternatives := range p.Alternatives {
+		Equal(t, []uint{4, 1, 1}, alternatives)
+	}
+
+	Equal(t, 1, len(p.Repeats))
+	for _, repeats := range p.Repeats {
+		Equal(t, map[int64]uint{1: 2, 2: 2}, repeats)
+	}
+
+	// profiles can be saved and loaded
+	var buf bytes.Buffer
+	Nil(t, p.Write(&buf))
+
+	p2, err := Read(&buf)
+	Nil(t, err)
+	Equal(t, p, p2)
+
+	_, err = Read(strings.NewReader("{"))
+	NotNil(t, err)
+}
+
+func TestProfileApply(t *testing.T) {
+	p := New()
+
+	for _, input := range []string{"+\n", "++\n", "+-\n", "*\n"} {
+		doc := parseFormat(t)
+
+		Nil(t, parser.ParseInternal(doc, strings.NewReader(input)))
+
+		p.Count(doc)
+	}
+
+	doc := parseFormat(t)
+	Nil(t, p.Apply(doc, false))
+
+	one, repeat := findTokens(doc)
+	Equal(t, []float64{5, 2, 2}, one.Weights())
+	Equal(t, []float64{3, 3, 1}, repeat.Weights())
+
+	// the random strategy generates with the learned weights
+	strat, err := strategy.New("random", doc)
+	Nil(t, err)
+
+	r := rand.New(rand.NewSource(1))
+	plus := 0
+	all := 0
+
+	for i := 0; i < 100; i++ {
+		ch, err := strat.Fuzz(r)
+		Nil(t, err)
+
+		for range ch {
+			out := doc.String()
+
+			plus += strings.Count(out, "+")
+			all += len(out) - 1
+
+			ch <- struct{}{}
+		}
+	}
+
+	True(t, plus*2 > all)
+
+	// inverted weights favour rarely taken alternatives and counts
+	doc = parseFormat(t)
+	Nil(t, p.Apply(doc, true))
+
+	one, repeat = findTokens(doc)
+	Equal(t, []float64{0.2, 0.5, 0.5}, one.Weights())
+	Equal(t, []float64{1.0 / 3.0, 1.0 / 3.0, 1}, repeat.Weights())
+
+	// profiles of other formats do not match
+	doc, err = parser.ParseTavor(strings.NewReader("START = +1,3(\"+\" | \"-\") \"\\n\"\n"))
+	Nil(t, err)
+	NotNil(t, p.Apply(doc, false))
+
+	doc, err = parser.ParseTavor(strings.NewReader("START = \"+\"\n"))
+	Nil(t, err)
+	Nil(t, p.Apply(doc, false))
+}
+
+func TestProfileApplyLargeRepeat(t *testing.T) {
+	doc, err := parser.ParseTavor(strings.NewReader("START = +0,1000000(\"a\")\n"))
+	Nil(t, err)
+
+	_, repeat := findTokens(doc)
+
+	var path string
+	_ = walk(doc, func(tok token.Token, p string) error {
+		if tok == repeat {
+			path = p
+		}
+
+		return nil
+	})
+
+	p := New()
+	p.Repeats[path] = map[int64]uint{2: 5, 3: 1, 1000000: 2, 2000000: 7}
+
+	Nil(t, p.Apply(doc, false))
+
+	// counts which were not taken are grouped together
+	Equal(t, &lists.HistogramDistribution{
+		Buckets: []lists.HistogramBucket{
+			{From: 0, To: 1, Weight: 1},
+			{From: 2, To: 2, Weight: 6},
+			{From: 3, To: 3, Weight: 2},
+			{From: 4, To: 999999, Weight: 1},
+			{From: 1000000, To: 1000000, Weight: 3},
+		},
+	}, repeat.Distribution())
+	Equal(t, 6.0, repeat.Distribution().Weight(2, 0, 1000000))
+	Equal(t, 1.0, repeat.Distribution().Weight(500000, 0, 1000000))
+}
@@ -565,6 +565,25 @@ func TestAllPermutationsStrategy(t *testing.T) {
 			},
 		)
 	}
+	{
+		// parameterized token definitions
+		validateTavorAllPermutations(
+			t,
+			`
+				Opt(X) = ?(X)
+
+				START = Opt("a") Opt(1 | 2)
+			`,
+			[]string{
+				"",
+				"a",
+				"1",
+				"a1",
+				"2",
+				"a2",
+			},
+		)
+	}
 }
 
 func validateTavorAllPermutations(t *testing.T, format string, expect []string) {
@@ -1,16 +1,22 @@
 package strategy
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/zimmski/tavor/log"
 	"github.com/zimmski/tavor/rand"
 	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/dictionaries"
 	"github.com/zimmski/tavor/token/sequences"
 )
 
 // RandomStrategy implements a fuzzing strategy that generates a random permutation of a token graph.
-// The strategy does exactly one iteration which permutates at random all reachable tokens in the graph. The determinism is dependent on the random generator and is therefore for example deterministic if a seed for the random generator produces always the same outputs.
+// The strategy does exactly one iteration which permutates at random all reachable tokens in the graph. The determinism is dependent on the random generator and is therefore for example deterministic if a seed for the random generator produces always the same outputs. If the random generator implements the rand.TokenRand interface, it is told for every token which is permutated the identifier of the token, which consists of the name of the token definition the token belongs to, the path of the token relative to this definition and its type. The token definitions are set with SetDefinitions, without them the path of a token is relative to the root of the graph. Tokens which are created during the fuzzing, like the items of a repetition, are identified relative to their nearest token definition in the graph.
 type RandomStrategy struct {
-	root token.Token
+	root        token.Token
+	definitions map[string]token.Token
+	names       map[token.Token]string
 }
 
 // NewRandomStrategy returns a new instance of the random fuzzing strategy
@@ -26,6 +32,11 @@ func init() {
 	})
 }
 
+// SetDefinitions sets the token definitions of the token graph by their names
+func (s *RandomStrategy) SetDefinitions(definitions map[string]token.Token) {
+	s.definitions = definitions
+}
+
 // Fuzz starts the first iteration of the fuzzing strategy returning a channel which controls the iteration flow.
 // The channel returns a value if the iteration is complete and waits with calculating the next iteration until a value is put in. The channel is automatically closed when there are no more iterations. The error return argument is not nil if an error occurs during the setup of the fuzzing strategy.
 func (s *RandomStrategy) Fuzz(r rand.Rand) (chan struct{}, error) {
@@ -36,12 +47,16 @@ func (s *RandomStrategy) Fuzz(r rand.Rand) (chan struct{}, error) {
 		}
 	}
 
+	if _, ok := r.(rand.TokenRand); ok {
+		s.names = token.DefinitionNames(s.root, s.definitions)
+	}
+
 	continueFuzzing := make(chan struct{})
 
 	go func() {
 		log.Debug("start random fuzzing routine")
 
-		s.fuzz(s.root, r, token.NewVariableScope())
+		s.fuzz(s.root, r, token.NewVariableScope(), "")
 
 		s.fuzzYADDA(s.root, r)
 
@@ -62,14 +77,20 @@ func (s *RandomStrategy) Fuzz(r rand.Rand) (chan struct{}, error) {
 	return continueFuzzing, nil
 }
 
-func (s *RandomStrategy) fuzz(tok token.Token, r rand.Rand, variableScope *token.VariableScope) {
+func (s *RandomStrategy) fuzz(tok token.Token, r rand.Rand, variableScope *token.VariableScope, path string) {
 	log.Debugf("Fuzz (%p)%#v with maxPermutations %d", tok, tok, tok.Permutations())
 
 	if t, ok := tok.(token.Scoping); ok && t.Scoping() {
 		variableScope = variableScope.Push()
 	}
 
-	err := tok.Permutation(uint(r.Int63n(int64(tok.Permutations())) + 1))
+	path = s.definitionPath(tok, path)
+
+	if t, ok := r.(rand.TokenRand); ok {
+		t.SetToken(tokenID(tok, path))
+	}
+
+	err := tok.Permutation(randomPermutation(tok, r))
 	if err != nil {
 		log.Panic(err)
 	}
@@ -78,14 +99,14 @@ func (s *RandomStrategy) fuzz(tok token.Token, r rand.Rand, variableScope *token
 		switch t := tok.(type) {
 		case token.ForwardToken:
 			if v := t.Get(); v != nil {
-				s.fuzz(v, r, variableScope)
+				s.fuzz(v, r, variableScope, path+"/0")
 			}
 		case token.ListToken:
 			l := t.Len()
 
 			for i := 0; i < l; i++ {
 				c, _ := t.Get(i)
-				s.fuzz(c, r, variableScope)
+				s.fuzz(c, r, variableScope, path+"/"+strconv.Itoa(i))
 			}
 		}
 	}
@@ -95,6 +116,41 @@ func (s *RandomStrategy) fuzz(tok token.Token, r rand.Rand, variableScope *token
 	}
 }
 
+// randomPermutation returns a random permutation of the token which honours the weights of weighted tokens
+func randomPermutation(tok token.Token, r rand.Rand) uint {
+	if t, ok := tok.(token.WeightedToken); ok {
+		if weights := t.Weights(); weights != nil {
+			var total float64
+			for _, w := range weights {
+				total += w
+			}
+
+			if total > 0 {
+				x := float64(r.Int63n(1<<53)) / (1 << 53) * total
+
+				last := 0
+				for i, w := range weights {
+					if w <= 0 {
+						continue
+					}
+
+					if x < w {
+						return uint(i + 1)
+					}
+
+					x -= w
+					last = i
+				}
+
+				// rounding errors can leave a rest
+				return uint(last + 1)
+			}
+		}
+	}
+
+	return uint(r.Int63n(int64(tok.Permutations())) + 1)
+}
+
 func (s *RandomStrategy) fuzzYADDA(root token.Token, r rand.Rand) {
 	// TODO FIXME AND FIXME FIXME FIXME this should be done automatically somehow
 	// since this doesn't work in other heuristics...
@@ -104,11 +160,22 @@ func (s *RandomStrategy) fuzzYADDA(root token.Token, r rand.Rand) {
 	token.ResetResetTokens(root)
 	token.ResetCombinedScope(root)
 
+	tr, ok := r.(rand.TokenRand)
+
+	var paths map[token.Token]string
+	if ok {
+		paths = s.tokenPaths(root)
+	}
+
 	err := token.Walk(root, func(tok token.Token) error {
 		switch tok.(type) {
-		case *sequences.SequenceExistingItem:
+		case *sequences.SequenceExistingItem, *dictionaries.DictionaryExistingItem:
 			log.Debugf("Fuzz again %p(%#v)", tok, tok)
 
+			if tr != nil {
+				tr.SetToken(tokenID(tok, paths[tok]))
+			}
+
 			err := tok.Permutation(uint(r.Int63n(int64(tok.Permutations())) + 1))
 			if err != nil {
 				log.Panic(err)
@@ -121,3 +188,53 @@ func (s *RandomStrategy) fuzzYADDA(root token.Token, r rand.Rand) {
 		panic(err)
 	}
 }
+
+// definitionPath returns the name of the token definition if the token is a token definition and otherwise the given path
+// Paths of tokens begin therefore with the name of the nearest token definition, which means that changes of one token definition do not change the paths of the tokens of other token definitions.
+func (s *RandomStrategy) definitionPath(tok token.Token, path string) string {
+	if name, ok := s.names[tok]; ok {
+		return name
+	}
+
+	return path
+}
+
+// tokenID returns the identifier of a token which consists of its path in the token graph and its type
+func tokenID(tok token.Token, path string) string {
+	if path == "" {
+		path = "/"
+	}
+
+	return fmt.Sprintf("%s %T", path, tok)
+}
+
+// tokenPaths returns the paths of all tokens in the token graph relative to their token definitions
+func (s *RandomStrategy) tokenPaths(root token.Token) map[token.Token]string {
+	paths := make(map[token.Token]string)
+
+	var walk func(tok token.Token, path string)
+	walk = func(tok token.Token, path string) {
+		if _, ok := paths[tok]; ok {
+			return
+		}
+		path = s.definitionPath(tok, path)
+		paths[tok] = path
+
+		switch t := tok.(type) {
+		case token.ForwardToken:
+			if v := t.Get(); v != nil {
+				walk(v, path+"/0")
+			}
+		case token.ListToken:
+			for i := 0; i < t.Len(); i++ {
+				c, _ := t.Get(i)
+
+				walk(c, path+"/"+strconv.Itoa(i))
+			}
+		}
+	}
+
+	walk(root, "")
+
+	return paths
+}
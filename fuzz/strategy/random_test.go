@@ -1,6 +1,7 @@
 package strategy
 
 import (
+	"math/rand"
 	"strings"
 	"testing"
 
@@ -148,6 +149,61 @@ func TestRandomStrategyCases(t *testing.T) {
 	}
 }
 
+func TestRandomStrategyWeights(t *testing.T) {
+	count := func(tok token.Token, n int) map[string]int {
+		got := make(map[string]int)
+
+		for i := 0; i < n; i++ {
+			o := NewRandomStrategy(tok)
+
+			ch, err := o.Fuzz(rand.New(rand.NewSource(int64(i))))
+			Nil(t, err)
+
+			for j := range ch {
+				got[tok.String()]++
+
+				ch <- j
+			}
+		}
+
+		return got
+	}
+
+	// weighted alternation terms
+	{
+		tok := lists.NewOneWithWeights(
+			[]float64{9, 1, 0.000001},
+			primitives.NewConstantString("a"),
+			primitives.NewConstantString("b"),
+			primitives.NewConstantString("c"),
+		)
+
+		got := count(tok, 1000)
+		True(t, got["a"] > 800, "a was generated %d times", got["a"])
+		True(t, got["b"] > 50 && got["b"] < 200, "b was generated %d times", got["b"])
+		Equal(t, 0, got["c"])
+	}
+	// distributed repeats
+	{
+		tok := lists.NewRepeat(primitives.NewConstantString("a"), 0, 5)
+		tok.SetDistribution(lists.NewHistogramDistribution(
+			lists.HistogramBucket{From: 1, To: 1, Weight: 1},
+			lists.HistogramBucket{From: 4, To: 4, Weight: 1},
+		))
+
+		got := count(tok, 100)
+		Equal(t, 2, len(got))
+		True(t, got["a"] > 0)
+		True(t, got["aaaa"] > 0)
+		Equal(t, 100, got["a"]+got["aaaa"])
+
+		tok.SetDistribution(lists.NewGeometricDistribution(0.99))
+
+		got = count(tok, 100)
+		True(t, got[""] > 90, "empty string was generated %d times", got[""])
+	}
+}
+
 func validateTavorRandom(t *testing.T, seed int, format string, expect []string) {
 	m := leak.MarkGoRoutines()
 
@@ -33,6 +33,14 @@ type Strategy interface {
 	Fuzz(r rand.Rand) (chan struct{}, error)
 }
 
+// DefinitionStrategy defines a fuzzing strategy which can make use of the token definitions of the token graph
+type DefinitionStrategy interface {
+	Strategy
+
+	// SetDefinitions sets the token definitions of the token graph by their names.
+	SetDefinitions(definitions map[string]token.Token)
+}
+
 var strategyLookup = make(map[string]func(tok token.Token) Strategy)
 
 // New returns a new fuzzing strategy instance given the registered name of the strategy.
@@ -0,0 +1,101 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	mathRand "math/rand"
+
+	"github.com/zimmski/tavor/fuzz/filter"
+	"github.com/zimmski/tavor/fuzz/strategy"
+	"github.com/zimmski/tavor/rand"
+	"github.com/zimmski/tavor/token"
+)
+
+// Trace holds the random decisions of a fuzzing run
+// Since every decision is recorded along with the token it was made for, a trace can be replayed onto the token graph of a changed format. Tokens are identified by the name of their token definition, their path relative to this definition and their type, which means that changes of a token definition which move its tokens lead to divergences of this definition only.
+type Trace struct {
+	// Strategy is the name of the fuzzing strategy of the run
+	Strategy string `json:"strategy"`
+	// Filters holds the names of the fuzzing filters of the run in the order they were applied
+	Filters []string `json:"filters,omitempty"`
+	// Seed is the seed of the random generator of the run
+	Seed int64 `json:"seed"`
+	// Decisions holds the random decisions of the run in the order they were made
+	Decisions []rand.Decision `json:"decisions"`
+}
+
+// Read reads a trace in the JSON format
+func Read(r io.Reader) (*Trace, error) {
+	t := &Trace{}
+
+	if err := json.NewDecoder(r).Decode(t); err != nil {
+		return nil, fmt.Errorf("cannot read trace: %v", err)
+	}
+
+	if t.Strategy == "" {
+		return nil, fmt.Errorf("cannot read trace: no fuzzing strategy defined")
+	}
+
+	return t, nil
+}
+
+// Write writes the trace in the JSON format
+func (t *Trace) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(t, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+
+	return err
+}
+
+// Replay replays the trace onto the given token graph
+// The fuzzing filters of the trace are applied to the token graph and its fuzzing strategy is started with a random generator which replays the recorded decisions. The token definitions of the token graph are handed to the fuzzing strategy if it supports them, since tokens of a trace are identified relative to their token definitions. The given function is called with the filtered token graph for every generation. Values which cannot be replayed are drawn from a random generator which is seeded with the seed of the trace. The divergences between the recorded and the replayed decisions are returned, they are empty if the run was exactly reproduced. The error return argument is not nil if a filter or the strategy cannot be applied or if the given function returns an error.
+func (t *Trace) Replay(root token.Token, definitions map[string]token.Token, generation func(root token.Token) error) ([]rand.Divergence, error) {
+	if len(t.Filters) != 0 {
+		filters := make([]filter.Filter, len(t.Filters))
+		for i, name := range t.Filters {
+			filt, err := filter.New(name)
+			if err != nil {
+				return nil, err
+			}
+
+			filters[i] = filt
+		}
+
+		var err error
+		root, err = filter.ApplyFilters(filters, root)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	strat, err := strategy.New(t.Strategy, root)
+	if err != nil {
+		return nil, err
+	}
+
+	if s, ok := strat.(strategy.DefinitionStrategy); ok {
+		s.SetDefinitions(definitions)
+	}
+
+	r := rand.NewReplayRand(t.Decisions, mathRand.New(mathRand.NewSource(t.Seed)))
+
+	ch, err := strat.Fuzz(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ch {
+		if err := generation(root); err != nil {
+			return nil, err
+		}
+
+		ch <- i
+	}
+
+	return r.Divergences(), nil
+}
@@ -0,0 +1,116 @@
+package trace
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/fuzz/strategy"
+	"github.com/zimmski/tavor/parser"
+	"github.com/zimmski/tavor/rand"
+	"github.com/zimmski/tavor/token"
+)
+
+func record(t *testing.T, format string, seed int64) (*Trace, string) {
+	doc, definitions, err := parser.ParseTavorDefinitions(strings.NewReader(format), "")
+	Nil(t, err)
+
+	strat, err := strategy.New("random", doc)
+	Nil(t, err)
+	strat.(strategy.DefinitionStrategy).SetDefinitions(definitions)
+
+	r := rand.NewRecordingRand(rand.NewIncrementRand(seed))
+
+	ch, err := strat.Fuzz(r)
+	Nil(t, err)
+
+	var out string
+	for i := range ch {
+		out += doc.String()
+
+		ch <- i
+	}
+
+	return &Trace{
+		Strategy:  "random",
+		Seed:      seed,
+		Decisions: r.Decisions(),
+	}, out
+}
+
+func replay(t *testing.T, tr *Trace, format string) (string, []rand.Divergence) {
+	doc, definitions, err := parser.ParseTavorDefinitions(strings.NewReader(format), "")
+	Nil(t, err)
+
+	var out string
+	divergences, err := tr.Replay(doc, definitions, func(root token.Token) error {
+		out += root.String()
+
+		return nil
+	})
+	Nil(t, err)
+
+	return out, divergences
+}
+
+func TestTrace(t *testing.T) {
+	format := "A = \"a\" | \"b\" | \"c\"\nSTART = +2,4(A) [0-9]\n"
+
+	tr, out := record(t, format, 1)
+	NotEqual(t, 0, len(tr.Decisions))
+	Equal(t, "START *primitives.Scope", tr.Decisions[0].Token)
+
+	// write and read
+	var buf bytes.Buffer
+	Nil(t, tr.Write(&buf))
+
+	read, err := Read(&buf)
+	Nil(t, err)
+	Equal(t, tr, read)
+
+	_, err = Read(strings.NewReader("{}"))
+	NotNil(t, err)
+	_, err = Read(strings.NewReader("{"))
+	NotNil(t, err)
+
+	// the same format reproduces the generation
+	got, divergences := replay(t, tr, format)
+	Equal(t, out, got)
+	Equal(t, 0, len(divergences))
+
+	// additional alternatives are reported but the recorded alternatives are still taken
+	got, divergences = replay(t, tr, "A = \"a\" | \"b\" | \"c\" | \"d\"\nSTART = +2,4(A) [0-9]\n")
+	Equal(t, out, got)
+	NotEqual(t, 0, len(divergences))
+	True(t, strings.Contains(divergences[0].Message, "bound of Int63n changed from 3 to 4"))
+
+	// tokens are identified relative to their token definitions, so inserting tokens in front of a definition does not change the replay of the definition
+	format = "A = \"a\" | \"b\" | \"c\"\nAs = +2,4(A)\nDigit = [0-9]\nSTART = As Digit\n"
+
+	tr, out = record(t, format, 1)
+
+	got, divergences = replay(t, tr, "A = \"a\" | \"b\" | \"c\"\nAs = +2,4(A)\nDigit = [0-9]\nSTART = \"x\" As Digit\n")
+	Equal(t, "x"+out, got)
+	NotEqual(t, 0, len(divergences))
+	for _, d := range divergences {
+		True(t, strings.HasPrefix(d.Token, "START"), d.String())
+	}
+
+	// errors of the generation are returned
+	doc, definitions, err := parser.ParseTavorDefinitions(strings.NewReader(format), "")
+	Nil(t, err)
+
+	_, err = tr.Replay(doc, definitions, func(root token.Token) error {
+		return errors.New("generation failed")
+	})
+	Equal(t, "generation failed", err.Error())
+
+	// unknown strategies and filters
+	_, err = (&Trace{Strategy: "unknown"}).Replay(nil, nil, nil)
+	NotNil(t, err)
+	_, err = (&Trace{Strategy: "random", Filters: []string{"unknown"}}).Replay(nil, nil, nil)
+	NotNil(t, err)
+}
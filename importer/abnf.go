@@ -0,0 +1,494 @@
+package importer
+
+import (
+	"io"
+	"strings"
+	"unicode"
+)
+
+// coreRules holds the core rules of ABNF which can be used without defining them, see RFC 5234 Appendix B.1
+const coreRules = `
+ALPHA  = %x41-5A / %x61-7A
+BIT    = "0" / "1"
+CHAR   = %x01-7F
+CR     = %x0D
+CRLF   = CR LF
+CTL    = %x00-1F / %x7F
+DIGIT  = %x30-39
+DQUOTE = %x22
+HEXDIG = DIGIT / %x41-46 / %x61-66
+HTAB   = %x09
+LF     = %x0A
+LWSP   = *(WSP / CRLF WSP)
+OCTET  = %x00-FF
+SP     = %x20
+VCHAR  = %x21-7E
+WSP    = SP / HTAB
+`
+
+// ABNFImporter implements an importer for grammars in the Augmented Backus-Naur Form as defined by RFC 5234 and RFC 7405
+// Rule names are case-insensitive, the core rules like ALPHA and DIGIT are defined if they are used, incremental alternatives are added to their rule and quoted strings are case-insensitive unless they are prefixed by %s. Prose values cannot be converted and lead to an error.
+type ABNFImporter struct{}
+
+// NewABNFImporter returns a new instance of the ABNF importer
+func NewABNFImporter() *ABNFImporter {
+	return &ABNFImporter{}
+}
+
+func init() {
+	Register("abnf", func() Importer {
+		return NewABNFImporter()
+	})
+}
+
+// Import reads the given ABNF grammar and returns its equivalent in the Tavor format.
+// The start argument names the start rule of the grammar, if it is empty the first rule is used. Rules which are not reachable from the start rule are returned as warnings. The error return argument is not nil if the grammar cannot be read or converted.
+func (imp *ABNFImporter) Import(src io.Reader, start string) (string, []*Error, error) {
+	g, err := parseABNF(src)
+	if err != nil {
+		return "", nil, err
+	}
+
+	core, err := parseABNF(strings.NewReader(coreRules))
+	if err != nil {
+		panic(err)
+	}
+
+	g.fallback = func(key string) *rule {
+		return core.rules[key]
+	}
+
+	return g.tavor(start)
+}
+
+type abnfParser struct {
+	*reader
+
+	g *grammar
+}
+
+func parseABNF(src io.Reader) (*grammar, error) {
+	r, err := newReader(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &abnfParser{
+		reader: r,
+
+		g: newGrammar(strings.ToLower),
+	}
+
+	for {
+		p.skipLines()
+
+		if p.peek(0) == -1 {
+			break
+		}
+
+		if err := p.parseRule(); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.g, nil
+}
+
+// skipLines skips white spaces, comments and new lines between rules
+func (p *abnfParser) skipLines() {
+	for {
+		switch c := p.peek(0); {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			p.next()
+		case c == ';':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *abnfParser) skipComment() {
+	for c := p.peek(0); c != -1 && c != '\n'; c = p.peek(0) {
+		p.next()
+	}
+}
+
+// skip skips white spaces and comments within a rule. A rule continues on the next line only if the line begins with a white space.
+func (p *abnfParser) skip() {
+	for {
+		switch c := p.peek(0); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.next()
+		case c == ';':
+			p.skipComment()
+		case c == '\n':
+			i := 1
+			for p.peek(i) == '\r' || p.peek(i) == '\n' {
+				i++
+			}
+
+			if n := p.peek(i); n != ' ' && n != '\t' {
+				return
+			}
+
+			p.next()
+		default:
+			return
+		}
+	}
+}
+
+// atRuleEnd checks if the current rule has no more elements
+func (p *abnfParser) atRuleEnd() bool {
+	c := p.peek(0)
+
+	return c == -1 || c == '\n'
+}
+
+func isABNFRuleNameStart(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isABNFRuleNameChar(c rune) bool {
+	return isABNFRuleNameStart(c) || (c >= '0' && c <= '9') || c == '-'
+}
+
+func (p *abnfParser) parseRuleName() (string, bool) {
+	if !isABNFRuleNameStart(p.peek(0)) {
+		return "", false
+	}
+
+	var name []rune
+
+	for isABNFRuleNameChar(p.peek(0)) {
+		name = append(name, p.next())
+	}
+
+	return string(name), true
+}
+
+func (p *abnfParser) parseRule() error {
+	pos := p.position()
+
+	name, ok := p.parseRuleName()
+	if !ok {
+		return p.unexpected("rule name")
+	}
+
+	p.skip()
+
+	if p.peek(0) != '=' {
+		return p.unexpected(`"=" or "=/"`)
+	}
+	p.next()
+
+	incremental := false
+	if p.peek(0) == '/' {
+		p.next()
+
+		incremental = true
+	}
+
+	p.skip()
+
+	e, err := p.parseAlternation()
+	if err != nil {
+		return err
+	}
+
+	if !p.atRuleEnd() {
+		return p.unexpected("end of rule")
+	}
+
+	if incremental {
+		r, ok := p.g.rules[p.g.key(name)]
+		if !ok {
+			return &Error{
+				Message:  "incremental alternatives for undefined rule " + name,
+				Type:     ErrorUndefinedRule,
+				Position: pos,
+			}
+		}
+
+		alternatives, ok := r.expression.(alternation)
+		if !ok {
+			alternatives = alternation{r.expression}
+		}
+
+		if a, ok := e.(alternation); ok {
+			alternatives = append(alternatives, a...)
+		} else {
+			alternatives = append(alternatives, e)
+		}
+
+		r.expression = alternatives
+
+		return nil
+	}
+
+	return p.g.add(&rule{
+		name:       name,
+		position:   pos,
+		expression: e,
+	})
+}
+
+func (p *abnfParser) parseAlternation() (expression, error) {
+	var alternatives alternation
+
+	for {
+		e, err := p.parseConcatenation()
+		if err != nil {
+			return nil, err
+		}
+
+		alternatives = append(alternatives, e)
+
+		if p.peek(0) != '/' {
+			break
+		}
+
+		p.next()
+		p.skip()
+	}
+
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+
+	return alternatives, nil
+}
+
+func (p *abnfParser) parseConcatenation() (expression, error) {
+	var elements concatenation
+
+	for {
+		c := p.peek(0)
+		if !(isABNFRuleNameStart(c) || unicode.IsDigit(c) || c == '*' || c == '(' || c == '[' || c == '"' || c == '%' || c == '<') {
+			break
+		}
+
+		e, err := p.parseRepetition()
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, e)
+
+		p.skip()
+	}
+
+	if len(elements) == 0 {
+		return nil, p.unexpected("element")
+	}
+	if len(elements) == 1 {
+		return elements[0], nil
+	}
+
+	return elements, nil
+}
+
+func (p *abnfParser) parseRepetition() (expression, error) {
+	from, hasFrom := p.readInt()
+	to := from
+
+	if p.peek(0) == '*' {
+		p.next()
+
+		if !hasFrom {
+			from = 0
+		}
+
+		var ok bool
+		if to, ok = p.readInt(); !ok {
+			to = -1
+		}
+	} else if !hasFrom {
+		return p.parseElement()
+	}
+
+	if to != -1 && from > to {
+		return nil, p.errorf(ErrorSyntax, "repetition minimum %d is bigger than its maximum %d", from, to)
+	}
+
+	e, err := p.parseElement()
+	if err != nil {
+		return nil, err
+	}
+
+	return repetition{from, to, e}, nil
+}
+
+func (p *abnfParser) parseElement() (expression, error) {
+	pos := p.position()
+
+	switch c := p.peek(0); {
+	case isABNFRuleNameStart(c):
+		name, _ := p.parseRuleName()
+
+		return reference{
+			name:     name,
+			position: pos,
+		}, nil
+	case c == '(' || c == '[':
+		p.next()
+		p.skip()
+
+		e, err := p.parseAlternation()
+		if err != nil {
+			return nil, err
+		}
+
+		closing := ')'
+		if c == '[' {
+			closing = ']'
+		}
+
+		if p.peek(0) != closing {
+			return nil, p.unexpected(`"` + string(closing) + `"`)
+		}
+		p.next()
+
+		if c == '[' {
+			return optional{e}, nil
+		}
+
+		return e, nil
+	case c == '"':
+		return p.parseCharValue(true)
+	case c == '%':
+		p.next()
+
+		switch b := p.peek(0); b {
+		case 's', 'S', 'i', 'I':
+			p.next()
+
+			if p.peek(0) != '"' {
+				return nil, p.unexpected(`"\""`)
+			}
+
+			return p.parseCharValue(b == 'i' || b == 'I')
+		case 'b', 'B':
+			p.next()
+
+			return p.parseNumValue(2)
+		case 'd', 'D':
+			p.next()
+
+			return p.parseNumValue(10)
+		case 'x', 'X':
+			p.next()
+
+			return p.parseNumValue(16)
+		}
+
+		return nil, p.unexpected("one of the value bases b, d or x")
+	case c == '<':
+		return nil, p.errorf(ErrorUnsupported, "prose values are not supported")
+	}
+
+	return nil, p.unexpected("element")
+}
+
+func (p *abnfParser) parseCharValue(caseInsensitive bool) (expression, error) {
+	p.next()
+
+	var value []rune
+
+	for {
+		c := p.peek(0)
+
+		if c == '"' {
+			p.next()
+
+			break
+		} else if c == -1 || c == '\n' {
+			return nil, p.unexpected(`"\""`)
+		}
+
+		value = append(value, p.next())
+	}
+
+	return terminal{
+		value:           string(value),
+		caseInsensitive: caseInsensitive,
+	}, nil
+}
+
+func (p *abnfParser) readNum(base int) (rune, bool) {
+	var n rune
+	digits := 0
+
+	for {
+		d := digitValue(p.peek(0))
+		if d < 0 || d >= base {
+			break
+		}
+
+		n = n*rune(base) + rune(d)
+		if n > unicode.MaxRune {
+			return 0, false
+		}
+
+		digits++
+		p.next()
+	}
+
+	return n, digits != 0
+}
+
+func digitValue(c rune) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+
+	return -1
+}
+
+func (p *abnfParser) parseNumValue(base int) (expression, error) {
+	from, ok := p.readNum(base)
+	if !ok {
+		return nil, p.unexpected("value")
+	}
+
+	switch p.peek(0) {
+	case '-':
+		p.next()
+
+		to, ok := p.readNum(base)
+		if !ok {
+			return nil, p.unexpected("value")
+		}
+
+		if from > to {
+			return nil, p.errorf(ErrorSyntax, "value range begins with %d which is bigger than its end %d", from, to)
+		}
+
+		return characterRange{from, to}, nil
+	case '.':
+		value := []rune{from}
+
+		for p.peek(0) == '.' {
+			p.next()
+
+			c, ok := p.readNum(base)
+			if !ok {
+				return nil, p.unexpected("value")
+			}
+
+			value = append(value, c)
+		}
+
+		return terminal{
+			value: string(value),
+		}, nil
+	}
+
+	return characterRange{from, from}, nil
+}
@@ -0,0 +1,152 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/parser"
+)
+
+func TestABNFImporterToBeImporter(t *testing.T) {
+	var imp *Importer
+
+	Implements(t, imp, &ABNFImporter{})
+}
+
+func TestABNFImporter(t *testing.T) {
+	imp := NewABNFImporter()
+
+	for _, tc := range []struct {
+		grammar string
+		start   string
+		format  string
+	}{
+		// rule names are case-insensitive and converted to token names
+		{
+			"date-time = Full-Date \"T\" 2DIGIT\nfull-date = 4DIGIT \"-\" 2DIGIT\n",
+			"",
+			"START = date_time\n\ndate_time = full_date [tT] +2(DIGIT)\nfull_date = +4(DIGIT) \"-\" +2(DIGIT)\nDIGIT = [0-9]\n",
+		},
+		// comments, continuation lines and incremental alternatives
+		{
+			"; a comment\nSTART = a ; the start\n      / %s\"B\"\n\nSTART =/ \"c\" / \"12\"\na = %x41\n",
+			"",
+			"START = a | \"B\" | [cC] | \"12\"\na = \"A\"\n",
+		},
+		// repetitions and optionals
+		{
+			"r = *a 1*a 2*a *3a 2*3a 3a [a] 0a\na = %d97-99\n",
+			"",
+			"START = r\n\nr = *(a) +(a) +2,(a) +0,3(a) +2,3(a) +3(a) ?(a)\na = [a-c]\n",
+		},
+		// values
+		{
+			"v = %x41.42.43 %b1000001 %d10 %x0-1F %i\"x\" (\"a\" / \"b\")\n",
+			"",
+			"START = v\n\nv = \"ABC\" \"A\" \"\\n\" [\\x{0}-\\x{1F}] [xX] ([aA] | [bB])\n",
+		},
+		// core rules
+		{
+			"line = *(VCHAR / WSP) CRLF\n",
+			"",
+			"START = line\n\nline = *(VCHAR | WSP) CRLF\nVCHAR = [\\x{21}-\\x{7E}]\nWSP = SP | HTAB\nCRLF = CR LF\nSP = \" \"\nHTAB = \"\\t\"\nCR = \"\\r\"\nLF = \"\\n\"\n",
+		},
+		// start rule and unreachable rules
+		{
+			"a = b\nb = \"b\"\nc = \"c\"\n",
+			"C",
+			"START = c\n\nc = [cC]\n",
+		},
+		// empty elements
+		{
+			"a = \"\" / \"a\" b\nb = 0\"b\" / \"\"\n",
+			"",
+			"START = a\n\na = ?([aA])\n",
+		},
+	} {
+		format, _, err := imp.Import(strings.NewReader(tc.grammar), tc.start)
+		Nil(t, err, tc.grammar)
+		Equal(t, tc.format, format, tc.grammar)
+	}
+
+	// rules which are not reachable from the start rule are reported
+	_, warnings, err := imp.Import(strings.NewReader("a = b\nb = \"b\"\nc = \"c\"\nd = c\n"), "b")
+	Nil(t, err)
+	if Equal(t, 3, len(warnings)) {
+		Equal(t, ErrorUnusedRule, warnings[0].Type)
+		Equal(t, 1, warnings[0].Position.Line)
+		Equal(t, ErrorUnusedRule, warnings[1].Type)
+		Equal(t, 3, warnings[1].Position.Line)
+		Equal(t, ErrorUnusedRule, warnings[2].Type)
+		Equal(t, 4, warnings[2].Position.Line)
+		Equal(t, "L:1, C:1 - rule \"a\" is not reachable from the start rule \"b\"", warnings[0].Error())
+	}
+}
+
+func TestABNFImporterParse(t *testing.T) {
+	grammar := `
+date-time = full-date "T" partial-time
+full-date = date-fullyear "-" date-month "-" date-mday
+date-fullyear = 4DIGIT
+date-month = 2DIGIT
+date-mday = 2DIGIT
+partial-time = time-hour ":" time-minute [time-fraction]
+time-hour = 2DIGIT
+time-minute = 2DIGIT
+time-fraction = "." 1*DIGIT
+`
+
+	doc, err := Parse(NewABNFImporter(), strings.NewReader(grammar), "")
+	Nil(t, err)
+
+	for data, valid := range map[string]bool{
+		"2024-01-02T12:30":    true,
+		"2024-01-02t12:30.05": true,
+		"2024-01-02 12:30":    false,
+		"24-01-02T12:30":      false,
+	} {
+		errs := parser.ParseInternal(doc, strings.NewReader(data))
+		Equal(t, valid, len(errs) == 0, data)
+	}
+}
+
+func TestABNFImporterErrors(t *testing.T) {
+	imp := NewABNFImporter()
+
+	for _, tc := range []struct {
+		grammar string
+		typ     ErrorType
+		line    int
+		column  int
+	}{
+		{"", ErrorSyntax, 0, 0},
+		{"a \"a\"\n", ErrorSyntax, 1, 3},
+		{"a = \n", ErrorSyntax, 1, 5},
+		{"a = \"a\n", ErrorSyntax, 1, 7},
+		{"a = (\"a\"\n", ErrorSyntax, 1, 9},
+		{"a = %q1\n", ErrorSyntax, 1, 6},
+		{"a = %x5-3\n", ErrorSyntax, 1, 10},
+		{"a = 3*2\"a\"\n", ErrorSyntax, 1, 8},
+		{"a = \"a\" )\n", ErrorSyntax, 1, 9},
+		{"a = <prose value>\n", ErrorUnsupported, 1, 5},
+		{"a = b\n", ErrorUndefinedRule, 1, 5},
+		{"a = \"a\"\nb =/ \"b\"\n", ErrorUndefinedRule, 2, 1},
+		{"a = \"a\"\nA = \"b\"\n", ErrorRuleAlreadyDefined, 2, 1},
+		{"a = \"\"\n", ErrorUnsupported, 1, 1},
+	} {
+		_, _, err := imp.Import(strings.NewReader(tc.grammar), "")
+		if !NotNil(t, err, tc.grammar) {
+			continue
+		}
+
+		e := err.(*Error)
+		Equal(t, tc.typ, e.Type, tc.grammar)
+		Equal(t, tc.line, e.Position.Line, tc.grammar)
+		Equal(t, tc.column, e.Position.Column, tc.grammar)
+	}
+
+	_, _, err := imp.Import(strings.NewReader("a = \"a\"\n"), "b")
+	Equal(t, ErrorUndefinedRule, err.(*Error).Type)
+}
@@ -0,0 +1,436 @@
+package importer
+
+import (
+	"io"
+	"strings"
+	"unicode"
+)
+
+// EBNFImporter implements an importer for grammars in the Extended Backus-Naur Form as defined by ISO/IEC 14977
+// Concatenations are separated by commas and rules are terminated by semicolons or periods. The alternative representations of the standard for brackets and definition separators are supported too. Special sequences and exceptions cannot be converted and lead to an error, except for the empty exception of a repetition which repeats at least once.
+type EBNFImporter struct{}
+
+// NewEBNFImporter returns a new instance of the EBNF importer
+func NewEBNFImporter() *EBNFImporter {
+	return &EBNFImporter{}
+}
+
+func init() {
+	Register("ebnf", func() Importer {
+		return NewEBNFImporter()
+	})
+}
+
+// Import reads the given EBNF grammar and returns its equivalent in the Tavor format.
+// The start argument names the start rule of the grammar, if it is empty the first rule is used. Rules which are not reachable from the start rule are returned as warnings. The error return argument is not nil if the grammar cannot be read or converted.
+func (imp *EBNFImporter) Import(src io.Reader, start string) (string, []*Error, error) {
+	g, err := parseEBNF(src)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return g.tavor(start)
+}
+
+type ebnfParser struct {
+	*reader
+
+	g *grammar
+}
+
+func parseEBNF(src io.Reader) (*grammar, error) {
+	r, err := newReader(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ebnfParser{
+		reader: r,
+
+		g: newGrammar(func(name string) string {
+			return name
+		}),
+	}
+
+	for {
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+
+		if p.peek(0) == -1 {
+			break
+		}
+
+		if err := p.parseRule(); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.g, nil
+}
+
+// skip skips white spaces and comments
+func (p *ebnfParser) skip() error {
+	for {
+		switch c := p.peek(0); {
+		case unicode.IsSpace(c):
+			p.next()
+		case c == '(' && p.peek(1) == '*':
+			if err := p.skipComment(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// skipComment skips a comment which can be nested
+func (p *ebnfParser) skipComment() error {
+	pos := p.position()
+	depth := 0
+
+	for {
+		switch c := p.peek(0); {
+		case c == -1:
+			return &Error{
+				Message:  "comment is not terminated",
+				Type:     ErrorSyntax,
+				Position: pos,
+			}
+		case c == '(' && p.peek(1) == '*':
+			p.next()
+			p.next()
+
+			depth++
+		case c == '*' && p.peek(1) == ')':
+			p.next()
+			p.next()
+
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		default:
+			p.next()
+		}
+	}
+}
+
+func isEBNFLetter(c rune) bool {
+	return unicode.IsLetter(c)
+}
+
+func isEBNFIdentifierChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
+}
+
+// parseMetaIdentifier parses an identifier which can contain white spaces between its words, e.g. "digit excluding zero".
+func (p *ebnfParser) parseMetaIdentifier() (string, bool, error) {
+	if !isEBNFLetter(p.peek(0)) {
+		return "", false, nil
+	}
+
+	var words []string
+
+	for {
+		var word []rune
+
+		for isEBNFIdentifierChar(p.peek(0)) {
+			word = append(word, p.next())
+		}
+
+		words = append(words, string(word))
+
+		if err := p.skip(); err != nil {
+			return "", false, err
+		}
+
+		if !isEBNFIdentifierChar(p.peek(0)) {
+			break
+		}
+	}
+
+	return strings.Join(words, " "), true, nil
+}
+
+func (p *ebnfParser) parseRule() error {
+	pos := p.position()
+
+	name, ok, err := p.parseMetaIdentifier()
+	if err != nil {
+		return err
+	} else if !ok {
+		return p.unexpected("rule name")
+	}
+
+	if p.peek(0) != '=' {
+		return p.unexpected(`"="`)
+	}
+	p.next()
+
+	e, err := p.parseDefinitionsList()
+	if err != nil {
+		return err
+	}
+
+	if c := p.peek(0); c != ';' && c != '.' {
+		return p.unexpected(`",", "|" or ";"`)
+	}
+	p.next()
+
+	return p.g.add(&rule{
+		name:       name,
+		position:   pos,
+		expression: e,
+	})
+}
+
+// isDefinitionSeparator checks if the current rune separates definitions, which is either "|", "/" or "!"
+func (p *ebnfParser) isDefinitionSeparator() bool {
+	switch p.peek(0) {
+	case '|', '!':
+		return true
+	case '/':
+		return p.peek(1) != ')'
+	}
+
+	return false
+}
+
+func (p *ebnfParser) parseDefinitionsList() (expression, error) {
+	var alternatives alternation
+
+	for {
+		e, err := p.parseSingleDefinition()
+		if err != nil {
+			return nil, err
+		}
+
+		alternatives = append(alternatives, e)
+
+		if !p.isDefinitionSeparator() {
+			break
+		}
+
+		p.next()
+	}
+
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+
+	return alternatives, nil
+}
+
+func (p *ebnfParser) parseSingleDefinition() (expression, error) {
+	var elements concatenation
+
+	for {
+		e, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+
+		if e != nil {
+			elements = append(elements, e)
+		}
+
+		if p.peek(0) != ',' {
+			break
+		}
+
+		p.next()
+	}
+
+	if len(elements) == 1 {
+		return elements[0], nil
+	}
+
+	return elements, nil
+}
+
+// atTermEnd checks if the current term has no more factors
+func (p *ebnfParser) atTermEnd() bool {
+	switch c := p.peek(0); c {
+	case -1, ',', ';', '.', '|', '!', ')', ']', '}':
+		return true
+	case '/', ':':
+		return p.peek(1) == ')' || c == '/'
+	}
+
+	return false
+}
+
+func (p *ebnfParser) parseTerm() (expression, error) {
+	e, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek(0) != '-' {
+		return e, nil
+	}
+
+	pos := p.position()
+	p.next()
+
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+
+	// the empty exception of a repetition means that the repetition has to be repeated at least once
+	if rep, ok := e.(repetition); ok && rep.from == 0 && p.atTermEnd() {
+		rep.from = 1
+
+		return rep, nil
+	}
+
+	return nil, &Error{
+		Message:  "exceptions are not supported",
+		Type:     ErrorUnsupported,
+		Position: pos,
+	}
+}
+
+func (p *ebnfParser) parseFactor() (expression, error) {
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+
+	if !unicode.IsDigit(p.peek(0)) {
+		return p.parsePrimary()
+	}
+
+	n, _ := p.readInt()
+
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+
+	if p.peek(0) != '*' {
+		return nil, p.unexpected(`"*"`)
+	}
+	p.next()
+
+	e, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, nil
+	}
+
+	return repetition{n, n, e}, nil
+}
+
+func (p *ebnfParser) parsePrimary() (expression, error) {
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+
+	pos := p.position()
+
+	var e expression
+	var err error
+
+	switch c := p.peek(0); {
+	case isEBNFLetter(c):
+		name, _, err := p.parseMetaIdentifier()
+		if err != nil {
+			return nil, err
+		}
+
+		return reference{
+			name:     name,
+			position: pos,
+		}, nil
+	case c == '\'' || c == '"':
+		p.next()
+
+		var value []rune
+
+		for {
+			n := p.peek(0)
+
+			if n == c {
+				p.next()
+
+				break
+			} else if n == -1 || n == '\n' {
+				return nil, p.unexpected(string(c))
+			}
+
+			value = append(value, p.next())
+		}
+
+		if len(value) == 0 {
+			return nil, &Error{
+				Message:  "terminal strings must not be empty",
+				Type:     ErrorSyntax,
+				Position: pos,
+			}
+		}
+
+		e = terminal{
+			value: string(value),
+		}
+	case c == '[' || (c == '(' && p.peek(1) == '/'):
+		if e, err = p.parseBracket(c == '(', ']', "/)"); err != nil {
+			return nil, err
+		}
+
+		e = optional{e}
+	case c == '{' || (c == '(' && p.peek(1) == ':'):
+		if e, err = p.parseBracket(c == '(', '}', ":)"); err != nil {
+			return nil, err
+		}
+
+		e = repetition{0, -1, e}
+	case c == '(':
+		if e, err = p.parseBracket(false, ')', ""); err != nil {
+			return nil, err
+		}
+	case c == '?':
+		return nil, p.errorf(ErrorUnsupported, "special sequences are not supported")
+	default:
+		// empty sequence
+		return nil, nil
+	}
+
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// parseBracket parses a definitions list between brackets which have either the given closing rune or, if the alternative representation was used, the given closing text
+func (p *ebnfParser) parseBracket(alternative bool, closing rune, alternativeClosing string) (expression, error) {
+	p.next()
+	if alternative {
+		p.next()
+	}
+
+	e, err := p.parseDefinitionsList()
+	if err != nil {
+		return nil, err
+	}
+
+	if alternative {
+		if p.peek(0) != rune(alternativeClosing[0]) || p.peek(1) != rune(alternativeClosing[1]) {
+			return nil, p.unexpected(`"` + alternativeClosing + `"`)
+		}
+
+		p.next()
+		p.next()
+	} else {
+		if p.peek(0) != closing {
+			return nil, p.unexpected(`"` + string(closing) + `"`)
+		}
+
+		p.next()
+	}
+
+	return e, nil
+}
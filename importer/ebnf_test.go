@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/parser"
+)
+
+func TestEBNFImporterToBeImporter(t *testing.T) {
+	var imp *Importer
+
+	Implements(t, imp, &EBNFImporter{})
+}
+
+func TestEBNFImporter(t *testing.T) {
+	imp := NewEBNFImporter()
+
+	for _, tc := range []struct {
+		grammar string
+		start   string
+		format  string
+	}{
+		// identifiers with white spaces and nested comments
+		{
+			"(* a (* nested *) comment *)\nnumber = digit excluding zero, { digit } ;\ndigit excluding zero = \"1\" | \"2\" ;\ndigit = \"0\" | digit excluding zero ;\n",
+			"",
+			"START = number\n\nnumber = digit_excluding_zero *(digit)\ndigit_excluding_zero = \"1\" | \"2\"\ndigit = \"0\" | digit_excluding_zero\n",
+		},
+		// brackets, repetitions and empty sequences
+		{
+			"a = [ 'x' ], { 'y' }-, 3 * 'z', ( 'u' | 'v' | ) ;",
+			"",
+			"START = a\n\na = ?(\"x\") +(\"y\") +3(\"z\") ?(\"u\" | \"v\")\n",
+		},
+		// alternative representations
+		{
+			"a = (/ 'x' /), (: 'y' :) / 'z' ! 'w' .",
+			"",
+			"START = a\n\na = ?(\"x\") *(\"y\") | \"z\" | \"w\"\n",
+		},
+		// start rule and unreachable rules
+		{
+			"a = b ; b = 'b' ; c = 'c' ;",
+			"b",
+			"START = b\n\nb = \"b\"\n",
+		},
+		// the start rule keeps its name
+		{
+			"START = 'a', START_ ; START_ = 'b' ;",
+			"",
+			"START = \"a\" START_\nSTART_ = \"b\"\n",
+		},
+	} {
+		format, _, err := imp.Import(strings.NewReader(tc.grammar), tc.start)
+		Nil(t, err, tc.grammar)
+		Equal(t, tc.format, format, tc.grammar)
+	}
+
+	// rules which are not reachable from the start rule are reported
+	_, warnings, err := imp.Import(strings.NewReader("a = b ;\nb = 'b' ;\nc = 'c' ;\nd = c ;"), "b")
+	Nil(t, err)
+	if Equal(t, 3, len(warnings)) {
+		Equal(t, ErrorUnusedRule, warnings[0].Type)
+		Equal(t, 1, warnings[0].Position.Line)
+		Equal(t, ErrorUnusedRule, warnings[1].Type)
+		Equal(t, 3, warnings[1].Position.Line)
+		Equal(t, ErrorUnusedRule, warnings[2].Type)
+		Equal(t, 4, warnings[2].Position.Line)
+		Equal(t, "L:1, C:1 - rule \"a\" is not reachable from the start rule \"b\"", warnings[0].Error())
+	}
+}
+
+func TestEBNFImporterParse(t *testing.T) {
+	grammar := `
+list = "[", [ item, { ",", item } ], "]" ;
+item = digit, { digit } | list ;
+digit = "0" | "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9" ;
+`
+
+	doc, err := Parse(NewEBNFImporter(), strings.NewReader(grammar), "")
+	Nil(t, err)
+
+	for data, valid := range map[string]bool{
+		"[]":          true,
+		"[1,23]":      true,
+		"[1,[2,[]]]":  true,
+		"[1,]":        false,
+		"[1,[2,[]]":   false,
+		"[1,[2,[a]]]": false,
+	} {
+		errs := parser.ParseInternal(doc, strings.NewReader(data))
+		Equal(t, valid, len(errs) == 0, data)
+	}
+}
+
+func TestEBNFImporterErrors(t *testing.T) {
+	imp := NewEBNFImporter()
+
+	for _, tc := range []struct {
+		grammar string
+		typ     ErrorType
+		line    int
+		column  int
+	}{
+		{"", ErrorSyntax, 0, 0},
+		{"a 'a' ;", ErrorSyntax, 1, 3},
+		{"a = 'a' 'b' ;", ErrorSyntax, 1, 9},
+		{"a = 'a'", ErrorSyntax, 1, 8},
+		{"a = '' ;", ErrorSyntax, 1, 5},
+		{"a = 'a ;", ErrorSyntax, 1, 9},
+		{"a = ( 'a' ;", ErrorSyntax, 1, 11},
+		{"a = 3 'a' ;", ErrorSyntax, 1, 7},
+		{"(* a", ErrorSyntax, 1, 1},
+		{"a = ? special ? ;", ErrorUnsupported, 1, 5},
+		{"a = b - 'c' ;\nb = 'b' ;", ErrorUnsupported, 1, 7},
+		{"a = b ;", ErrorUndefinedRule, 1, 5},
+		{"a = 'a' ;\na = 'b' ;", ErrorRuleAlreadyDefined, 2, 1},
+		{"a = ;", ErrorUnsupported, 1, 1},
+	} {
+		_, _, err := imp.Import(strings.NewReader(tc.grammar), "")
+		if !NotNil(t, err, tc.grammar) {
+			continue
+		}
+
+		e := err.(*Error)
+		Equal(t, tc.typ, e.Type, tc.grammar)
+		Equal(t, tc.line, e.Position.Line, tc.grammar)
+		Equal(t, tc.column, e.Position.Column, tc.grammar)
+	}
+}
@@ -0,0 +1,585 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"text/scanner"
+	"unicode"
+)
+
+// expression is an element of an imported grammar which is either an alternation, a concatenation, a repetition, an optional, a reference, a terminal or a character range
+type expression interface{}
+
+type alternation []expression
+
+type concatenation []expression
+
+type repetition struct {
+	from int
+	to   int // -1 if the repetition is unbounded
+	expression
+}
+
+type optional struct {
+	expression
+}
+
+type reference struct {
+	name     string
+	position scanner.Position
+}
+
+type terminal struct {
+	value           string
+	caseInsensitive bool
+}
+
+type characterRange struct {
+	from rune
+	to   rune
+}
+
+type rule struct {
+	name       string
+	position   scanner.Position
+	expression expression
+}
+
+// grammar holds the rules of an imported grammar in the order of their definition
+type grammar struct {
+	rules map[string]*rule
+	order []string
+
+	// key returns the lookup key of a rule name
+	key func(name string) string
+	// fallback returns a predefined rule if a rule is used but not defined
+	fallback func(key string) *rule
+}
+
+func newGrammar(key func(name string) string) *grammar {
+	return &grammar{
+		rules: make(map[string]*rule),
+		key:   key,
+	}
+}
+
+func (g *grammar) add(r *rule) error {
+	k := g.key(r.name)
+
+	if _, ok := g.rules[k]; ok {
+		return &Error{
+			Message:  fmt.Sprintf("rule %q is already defined", r.name),
+			Type:     ErrorRuleAlreadyDefined,
+			Position: r.position,
+		}
+	}
+
+	g.rules[k] = r
+	g.order = append(g.order, k)
+
+	return nil
+}
+
+func (g *grammar) lookup(name string) *rule {
+	k := g.key(name)
+
+	if r, ok := g.rules[k]; ok {
+		return r
+	}
+
+	if g.fallback != nil {
+		if r := g.fallback(k); r != nil {
+			g.rules[k] = r
+			g.order = append(g.order, k)
+
+			return r
+		}
+	}
+
+	return nil
+}
+
+// tavor converts the grammar into the Tavor format beginning with the given start rule
+// Only rules which are reachable from the start rule are converted since the Tavor format does not allow unused token definitions. Every other rule is returned as warning.
+func (g *grammar) tavor(start string) (string, []*Error, error) {
+	if len(g.order) == 0 {
+		return "", nil, &Error{
+			Message: "no rules defined",
+			Type:    ErrorSyntax,
+		}
+	}
+
+	var startRule *rule
+	if start == "" {
+		startRule = g.rules[g.order[0]]
+	} else if startRule = g.lookup(start); startRule == nil {
+		return "", nil, &Error{
+			Message: fmt.Sprintf("start rule %q is not defined", start),
+			Type:    ErrorUndefinedRule,
+		}
+	}
+
+	// collect all reachable rules
+	used := map[*rule]struct{}{
+		startRule: struct{}{},
+	}
+	queue := []*rule{startRule}
+
+	for len(queue) != 0 {
+		r := queue[0]
+		queue = queue[1:]
+
+		var err error
+
+		walkExpression(r.expression, func(e expression) {
+			ref, ok := e.(reference)
+			if !ok || err != nil {
+				return
+			}
+
+			u := g.lookup(ref.name)
+			if u == nil {
+				err = &Error{
+					Message:  fmt.Sprintf("rule %q is not defined", ref.name),
+					Type:     ErrorUndefinedRule,
+					Position: ref.position,
+				}
+
+				return
+			}
+
+			if _, ok := used[u]; !ok {
+				used[u] = struct{}{}
+				queue = append(queue, u)
+			}
+		})
+
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	var warnings []*Error
+
+	for _, k := range g.order {
+		r := g.rules[k]
+
+		if _, ok := used[r]; !ok {
+			warnings = append(warnings, &Error{
+				Message:  fmt.Sprintf("rule %q is not reachable from the start rule %q", r.name, startRule.name),
+				Type:     ErrorUnusedRule,
+				Position: r.position,
+			})
+		}
+	}
+
+	// remove empty rules and empty elements
+	empty := make(map[*rule]struct{})
+
+	for {
+		changed := false
+
+		for r := range used {
+			if _, ok := empty[r]; ok {
+				continue
+			}
+
+			if simplify(r.expression, func(name string) bool {
+				_, ok := empty[g.lookup(name)]
+
+				return ok
+			}) == nil {
+				empty[r] = struct{}{}
+				changed = true
+			}
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	if _, ok := empty[startRule]; ok {
+		return "", nil, &Error{
+			Message:  fmt.Sprintf("start rule %q is empty", startRule.name),
+			Type:     ErrorUnsupported,
+			Position: startRule.position,
+		}
+	}
+
+	// assign unique Tavor token names
+	names := make(map[*rule]string)
+	taken := make(map[string]struct{})
+
+	for _, k := range g.order {
+		r := g.rules[k]
+
+		if _, ok := used[r]; !ok {
+			continue
+		}
+		if _, ok := empty[r]; ok {
+			continue
+		}
+
+		name := tokenName(r.name)
+		if name == "START" && r != startRule {
+			name += "_"
+		}
+		for {
+			if _, ok := taken[name]; !ok {
+				break
+			}
+
+			name += "_"
+		}
+
+		names[r] = name
+		taken[name] = struct{}{}
+	}
+
+	var buf bytes.Buffer
+
+	if names[startRule] != "START" {
+		fmt.Fprintf(&buf, "START = %s\n\n", names[startRule])
+	}
+
+	for _, k := range g.order {
+		r := g.rules[k]
+
+		name, ok := names[r]
+		if !ok {
+			continue
+		}
+
+		e := simplify(r.expression, func(name string) bool {
+			_, ok := empty[g.lookup(name)]
+
+			return ok
+		})
+
+		fmt.Fprintf(&buf, "%s = %s\n", name, writeExpression(e, func(name string) string {
+			return names[g.lookup(name)]
+		}, true))
+	}
+
+	return buf.String(), warnings, nil
+}
+
+// tokenName converts a rule name into a valid Tavor token name
+func tokenName(name string) string {
+	n := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+
+		return '_'
+	}, name)
+
+	if n == "" || !unicode.IsLetter([]rune(n)[0]) {
+		n = "R" + n
+	}
+
+	return n
+}
+
+func walkExpression(e expression, walkFunc func(e expression)) {
+	walkFunc(e)
+
+	switch t := e.(type) {
+	case alternation:
+		for _, c := range t {
+			walkExpression(c, walkFunc)
+		}
+	case concatenation:
+		for _, c := range t {
+			walkExpression(c, walkFunc)
+		}
+	case repetition:
+		walkExpression(t.expression, walkFunc)
+	case optional:
+		walkExpression(t.expression, walkFunc)
+	}
+}
+
+// simplify removes all elements of the expression which are empty and returns nil if the whole expression is empty
+// Alternations with empty alternatives are converted to optionals since the Tavor format does not allow empty alternatives within groups.
+func simplify(e expression, isEmpty func(name string) bool) expression {
+	switch t := e.(type) {
+	case alternation:
+		var alternatives alternation
+		withEmpty := false
+
+		for _, c := range t {
+			if s := simplify(c, isEmpty); s != nil {
+				alternatives = append(alternatives, s)
+			} else {
+				withEmpty = true
+			}
+		}
+
+		var s expression
+
+		switch len(alternatives) {
+		case 0:
+			return nil
+		case 1:
+			s = alternatives[0]
+		default:
+			s = alternatives
+		}
+
+		if withEmpty {
+			if _, ok := s.(optional); !ok {
+				s = optional{s}
+			}
+		}
+
+		return s
+	case concatenation:
+		var elements concatenation
+
+		for _, c := range t {
+			if s := simplify(c, isEmpty); s != nil {
+				elements = append(elements, s)
+			}
+		}
+
+		switch len(elements) {
+		case 0:
+			return nil
+		case 1:
+			return elements[0]
+		}
+
+		return elements
+	case repetition:
+		if t.to == 0 {
+			return nil
+		}
+
+		s := simplify(t.expression, isEmpty)
+		if s == nil {
+			return nil
+		}
+
+		if t.from == 1 && t.to == 1 {
+			return s
+		} else if t.from == 0 && t.to == 1 {
+			return optional{s}
+		}
+
+		return repetition{t.from, t.to, s}
+	case optional:
+		s := simplify(t.expression, isEmpty)
+		if s == nil {
+			return nil
+		}
+
+		return optional{s}
+	case reference:
+		if isEmpty(t.name) {
+			return nil
+		}
+	case terminal:
+		if t.value == "" {
+			return nil
+		}
+	}
+
+	return e
+}
+
+// writeExpression returns the Tavor format of the given simplified expression
+func writeExpression(e expression, name func(name string) string, top bool) string {
+	switch t := e.(type) {
+	case alternation:
+		alternatives := make([]string, len(t))
+		for i, c := range t {
+			alternatives[i] = writeExpression(c, name, true)
+		}
+
+		s := strings.Join(alternatives, " | ")
+		if !top {
+			s = "(" + s + ")"
+		}
+
+		return s
+	case concatenation:
+		elements := make([]string, len(t))
+		for i, c := range t {
+			elements[i] = writeExpression(c, name, false)
+		}
+
+		return strings.Join(elements, " ")
+	case repetition:
+		body := writeExpression(t.expression, name, true)
+
+		switch {
+		case t.from == 0 && t.to == -1:
+			return "*(" + body + ")"
+		case t.from == 1 && t.to == -1:
+			return "+(" + body + ")"
+		case t.to == -1:
+			return fmt.Sprintf("+%d,(%s)", t.from, body)
+		case t.from == t.to:
+			return fmt.Sprintf("+%d(%s)", t.from, body)
+		default:
+			return fmt.Sprintf("+%d,%d(%s)", t.from, t.to, body)
+		}
+	case optional:
+		return "?(" + writeExpression(t.expression, name, true) + ")"
+	case reference:
+		return name(t.name)
+	case terminal:
+		if !t.caseInsensitive {
+			return strconv.Quote(t.value)
+		}
+
+		var elements []string
+		var constant []rune
+
+		for _, r := range t.value {
+			lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+
+			if lower == upper {
+				constant = append(constant, r)
+
+				continue
+			}
+
+			if len(constant) != 0 {
+				elements = append(elements, strconv.Quote(string(constant)))
+				constant = nil
+			}
+
+			elements = append(elements, "["+string(lower)+string(upper)+"]")
+		}
+
+		if len(constant) != 0 {
+			elements = append(elements, strconv.Quote(string(constant)))
+		}
+
+		s := strings.Join(elements, " ")
+		if len(elements) > 1 && !top {
+			s = "(" + s + ")"
+		}
+
+		return s
+	case characterRange:
+		if t.from == t.to {
+			return strconv.Quote(string(t.from))
+		}
+
+		return "[" + classCharacter(t.from) + "-" + classCharacter(t.to) + "]"
+	}
+
+	panic(fmt.Sprintf("unknown expression %#v", e))
+}
+
+// classCharacter returns the character class representation of the given character
+func classCharacter(c rune) string {
+	if c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c)) {
+		return string(c)
+	}
+
+	return fmt.Sprintf("\\x{%X}", c)
+}
+
+// reader reads a document rune by rune and keeps track of the current position
+type reader struct {
+	data []rune
+	i    int
+
+	line   int
+	column int
+}
+
+func newReader(src io.Reader) (*reader, error) {
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reader{
+		data: []rune(string(data)),
+
+		line:   1,
+		column: 1,
+	}, nil
+}
+
+// peek returns the rune at the given offset of the current position or -1 if there is no rune
+func (r *reader) peek(offset int) rune {
+	if r.i+offset >= len(r.data) {
+		return -1
+	}
+
+	return r.data[r.i+offset]
+}
+
+func (r *reader) next() rune {
+	if r.i >= len(r.data) {
+		return -1
+	}
+
+	c := r.data[r.i]
+	r.i++
+
+	if c == '\n' {
+		r.line++
+		r.column = 1
+	} else {
+		r.column++
+	}
+
+	return c
+}
+
+func (r *reader) position() scanner.Position {
+	return scanner.Position{
+		Offset: r.i,
+		Line:   r.line,
+		Column: r.column,
+	}
+}
+
+func (r *reader) errorf(typ ErrorType, format string, args ...interface{}) error {
+	return &Error{
+		Message:  fmt.Sprintf(format, args...),
+		Type:     typ,
+		Position: r.position(),
+	}
+}
+
+// unexpected returns a syntax error for the current rune
+func (r *reader) unexpected(expected string) error {
+	c := r.peek(0)
+	if c == -1 {
+		return r.errorf(ErrorSyntax, "expected %s but got end of file", expected)
+	}
+
+	return r.errorf(ErrorSyntax, "expected %s but got %q", expected, c)
+}
+
+func (r *reader) readInt() (int, bool) {
+	start := r.i
+
+	for unicode.IsDigit(r.peek(0)) {
+		r.next()
+	}
+
+	if start == r.i {
+		return 0, false
+	}
+
+	i, err := strconv.Atoi(string(r.data[start:r.i]))
+	if err != nil {
+		return 0, false
+	}
+
+	return i, true
+}
@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/scanner"
+
+	"github.com/zimmski/tavor/parser"
+	"github.com/zimmski/tavor/token"
+)
+
+// ErrorType the import error type
+type ErrorType int
+
+const (
+	// ErrorSyntax the imported document has a syntax error
+	ErrorSyntax ErrorType = iota
+	// ErrorUnsupported the imported document uses a construct which cannot be converted into the Tavor format
+	ErrorUnsupported
+	// ErrorUndefinedRule a rule is used or extended but never defined
+	ErrorUndefinedRule
+	// ErrorRuleAlreadyDefined a rule is defined more than once
+	ErrorRuleAlreadyDefined
+	// ErrorUnusedRule a rule is not reachable from the start rule
+	ErrorUnusedRule
+)
+
+// Error holds an import error or warning and its position in the imported document
+type Error struct {
+	Message  string
+	Type     ErrorType
+	Position scanner.Position
+	// Pointer holds the JSON pointer of the erroneous node for documents whose nodes have no line and column e.g. JSON Schema documents
+	Pointer string
+}
+
+func (err *Error) Error() string {
+	var location []string
+
+	if err.Position.Filename != "" {
+		location = append(location, err.Position.Filename)
+	}
+	if err.Pointer != "" {
+		location = append(location, err.Pointer)
+	} else if err.Position.Line > 0 {
+		location = append(location, fmt.Sprintf("L:%d, C:%d", err.Position.Line, err.Position.Column))
+	}
+
+	if len(location) == 0 {
+		return err.Message
+	}
+
+	return strings.Join(location, ", ") + " - " + err.Message
+}
+
+// Importer defines a converter of documents of another format into the Tavor format
+type Importer interface {
+	// Import reads the given document and returns its equivalent in the Tavor format.
+	// The start argument names the entry point of the document, an empty start argument chooses the default entry point e.g. the first rule of a grammar. The warnings return argument holds problems which do not prevent the conversion e.g. rules which are not reachable from the start rule. The error return argument is not nil if the document cannot be read or converted.
+	Import(src io.Reader, start string) (format string, warnings []*Error, err error)
+}
+
+var importerLookup = make(map[string]func() Importer)
+
+// New returns a new importer instance given the registered name of the importer.
+// The error return argument is not nil, if the name does not exist in the registered importer list.
+func New(name string) (Importer, error) {
+	imp, ok := importerLookup[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown importer %q", name)
+	}
+
+	return imp(), nil
+}
+
+// List returns a list of all registered importer names.
+func List() []string {
+	keyImporterLookup := make([]string, 0, len(importerLookup))
+
+	for key := range importerLookup {
+		keyImporterLookup = append(keyImporterLookup, key)
+	}
+
+	sort.Strings(keyImporterLookup)
+
+	return keyImporterLookup
+}
+
+// Register registers an importer instance function with the given name.
+func Register(name string, imp func() Importer) {
+	if imp == nil {
+		panic("register importer is nil")
+	}
+
+	if _, ok := importerLookup[name]; ok {
+		panic("importer " + name + " already registered")
+	}
+
+	importerLookup[name] = imp
+}
+
+// ImportFile imports the given file with the given importer.
+// The positions of the returned warnings and errors are prefixed with the filename.
+func ImportFile(imp Importer, filename string, start string) (string, []*Error, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
+	format, warnings, err := imp.Import(file, start)
+
+	for _, w := range warnings {
+		w.Position.Filename = filename
+	}
+	if e, ok := err.(*Error); ok {
+		e.Position.Filename = filename
+	}
+
+	return format, warnings, err
+}
+
+// Parse imports the given document with the given importer and returns the token graph of the resulting Tavor format.
+// Warnings of the import are ignored. The error return argument is not nil if the document cannot be imported or if the resulting Tavor format cannot be parsed.
+func Parse(imp Importer, src io.Reader, start string) (token.Token, error) {
+	format, _, err := imp.Import(src, start)
+	if err != nil {
+		return nil, err
+	}
+
+	return parser.ParseTavor(strings.NewReader(format))
+}
@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+type mockImporter struct{}
+
+func (imp *mockImporter) Import(src io.Reader, start string) (string, []*Error, error) {
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return "START = " + start + "\n" + start + " = \"" + string(data) + "\"\n", nil, nil
+}
+
+func TestImporter(t *testing.T) {
+	// mock is not registered
+	for _, name := range List() {
+		if name == "mock" {
+			Fail(t, "mock should not be in the importer list yet")
+		}
+	}
+
+	imp, err := New("mock")
+	Nil(t, imp)
+	NotNil(t, err)
+
+	// register mock
+	Register("mock", func() Importer {
+		return &mockImporter{}
+	})
+
+	// mock is registered
+	found := false
+	for _, name := range List() {
+		if name == "mock" {
+			found = true
+
+			break
+		}
+	}
+	True(t, found)
+
+	imp, err = New("mock")
+	NotNil(t, imp)
+	Nil(t, err)
+
+	// the imported format is parsed
+	doc, err := Parse(imp, strings.NewReader("mocked"), "Mock")
+	Nil(t, err)
+	Equal(t, "mocked", doc.String())
+
+	_, err = Parse(imp, strings.NewReader("mocked"), "START")
+	NotNil(t, err)
+
+	// register mock a second time
+	Panics(t, func() {
+		Register("mock", func() Importer {
+			return &mockImporter{}
+		})
+	})
+
+	// register nil function
+	Panics(t, func() {
+		Register("mockachino", nil)
+	})
+}
+
+func TestImporterList(t *testing.T) {
+	names := List()
+
+	Contains(t, names, "abnf")
+	Contains(t, names, "ebnf")
+	Contains(t, names, "jsonschema")
+}
+
+func TestImportFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-importer-test")
+	Nil(t, err)
+
+	_, err = f.WriteString("a = \"a\"\nb = \"b\"\nc = d\n")
+	Nil(t, err)
+
+	Nil(t, f.Close())
+
+	defer func() {
+		Nil(t, os.Remove(f.Name()))
+	}()
+
+	// warnings and errors are prefixed with the filename
+	format, warnings, err := ImportFile(NewABNFImporter(), f.Name(), "a")
+	Nil(t, err)
+	Equal(t, "START = a\n\na = [aA]\n", format)
+	if Equal(t, 2, len(warnings)) {
+		Equal(t, f.Name()+", L:2, C:1 - rule \"b\" is not reachable from the start rule \"a\"", warnings[0].Error())
+	}
+
+	_, _, err = ImportFile(NewABNFImporter(), f.Name(), "c")
+	if NotNil(t, err) {
+		Equal(t, f.Name()+", L:3, C:5 - rule \"d\" is not defined", err.Error())
+	}
+
+	_, _, err = ImportFile(NewABNFImporter(), f.Name()+"-unknown", "")
+	NotNil(t, err)
+}
@@ -0,0 +1,947 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"text/scanner"
+	"unicode/utf8"
+)
+
+// jsonSchemaAnnotations holds keywords of JSON Schema which do not constrain documents and are therefore ignored
+var jsonSchemaAnnotations = map[string]struct{}{
+	"$comment":             struct{}{},
+	"$id":                  struct{}{},
+	"$schema":              struct{}{},
+	"additionalProperties": struct{}{},
+	"default":              struct{}{},
+	"deprecated":           struct{}{},
+	"description":          struct{}{},
+	"examples":             struct{}{},
+	"format":               struct{}{},
+	"id":                   struct{}{},
+	"readOnly":             struct{}{},
+	"title":                struct{}{},
+	"writeOnly":            struct{}{},
+}
+
+// jsonSchemaKeywords holds the supported keywords of JSON Schema grouped by the type they apply to
+var jsonSchemaKeywords = map[string][]string{
+	"":        []string{"$defs", "$ref", "allOf", "anyOf", "const", "definitions", "enum", "oneOf", "type"},
+	"array":   []string{"items", "maxItems", "minItems"},
+	"integer": []string{"exclusiveMaximum", "exclusiveMinimum", "maximum", "minimum", "multipleOf"},
+	"number":  []string{"exclusiveMaximum", "exclusiveMinimum", "maximum", "minimum"},
+	"object":  []string{"properties", "required"},
+	"string":  []string{"maxLength", "minLength", "pattern"},
+}
+
+// JSONSchemaImporter implements an importer for JSON Schema documents which generates JSON documents that are valid for the schema
+// The types, properties, required properties, enumerations, constants, minimum and maximum values, lengths and patterns of strings, arrays, the combinations anyOf and oneOf as well as local references are converted. Strings are escaped with the JSON encoding. Integer and number ranges are converted to Int and Float typed tokens which means that the negative boundary-value analysis fuzzing filter generates documents that violate these ranges. Annotations are ignored, all other keywords lead to an error.
+type JSONSchemaImporter struct{}
+
+// NewJSONSchemaImporter returns a new instance of the JSON Schema importer
+func NewJSONSchemaImporter() *JSONSchemaImporter {
+	return &JSONSchemaImporter{}
+}
+
+func init() {
+	Register("jsonschema", func() Importer {
+		return NewJSONSchemaImporter()
+	})
+}
+
+// Import reads the given JSON Schema document and returns a Tavor format which generates JSON documents that are valid for the schema.
+// The start argument names a definition of the schema which is used instead of the root schema, if it is empty the root schema is used. Keywords which are ignored by the conversion are returned as warnings. The error return argument is not nil if the schema cannot be read or converted. Errors and warnings of the schema hold the JSON pointer of their schema.
+func (imp *JSONSchemaImporter) Import(src io.Reader, start string) (string, []*Error, error) {
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var root interface{}
+	if err := dec.Decode(&root); err != nil {
+		var position scanner.Position
+		if serr, ok := err.(*json.SyntaxError); ok {
+			position = jsonPosition(data, serr.Offset)
+		}
+
+		return "", nil, &Error{
+			Message:  fmt.Sprintf("cannot decode schema: %v", err),
+			Type:     ErrorSyntax,
+			Position: position,
+		}
+	}
+
+	c := &jsonSchemaConverter{
+		root: root,
+
+		refs:  make(map[string]string),
+		taken: make(map[string]struct{}),
+	}
+
+	schema := root
+	path := "#"
+
+	if start != "" {
+		path = "#/definitions/" + jsonPointerEscape(start)
+		if _, err := c.resolve(path); err != nil {
+			path = "#/$defs/" + jsonPointerEscape(start)
+		}
+
+		s, err := c.resolve(path)
+		if err != nil {
+			return "", nil, &Error{
+				Message: fmt.Sprintf("start definition %q is not defined", start),
+				Type:    ErrorUndefinedRule,
+			}
+		}
+
+		schema = s
+	}
+
+	// the root schema can be referenced and needs therefore a token definition
+	startName := c.unique("Root")
+	c.refs[path] = startName
+
+	e, err := c.convert(schema, path, startName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	c.define(startName, e)
+
+	var buf bytes.Buffer
+
+	if len(c.encoded) != 0 {
+		fmt.Fprintf(&buf, "encoding \"JSON\" for %s\n\n", strings.Join(c.encoded, ", "))
+	}
+
+	buf.WriteString(c.definitions.String())
+
+	fmt.Fprintf(&buf, "\nSTART = %s\n", startName)
+
+	return buf.String(), c.warnings, nil
+}
+
+// jsonPosition returns the line and column of the character which is read last after the given number of bytes of a JSON document
+func jsonPosition(data []byte, offset int64) scanner.Position {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset > 0 {
+		offset--
+	}
+
+	before := data[:offset]
+
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := utf8.RuneCount(before[bytes.LastIndexByte(before, '\n')+1:]) + 1
+
+	return scanner.Position{
+		Offset: int(offset),
+		Line:   line,
+		Column: column,
+	}
+}
+
+// jsonPointerEscape escapes a key of a JSON object to be used in a JSON pointer
+func jsonPointerEscape(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
+
+type jsonSchemaConverter struct {
+	root interface{}
+
+	definitions bytes.Buffer
+	encoded     []string
+	warnings    []*Error
+
+	refs    map[string]string
+	taken   map[string]struct{}
+	helpers map[string]string
+}
+
+// unique returns a token name based on the given name which is not taken yet
+func (c *jsonSchemaConverter) unique(name string) string {
+	name = tokenName(name)
+
+	n := name
+	for i := 2; ; i++ {
+		if _, ok := c.taken[n]; !ok {
+			break
+		}
+
+		n = fmt.Sprintf("%s%d", name, i)
+	}
+
+	c.taken[n] = struct{}{}
+
+	return n
+}
+
+func (c *jsonSchemaConverter) define(name string, e string) {
+	fmt.Fprintf(&c.definitions, "%s = %s\n", name, e)
+}
+
+func (c *jsonSchemaConverter) defineTyped(name string, typ string, arguments ...string) {
+	fmt.Fprintf(&c.definitions, "$%s %s", name, typ)
+
+	if len(arguments) != 0 {
+		indent := strings.Repeat(" ", len(name)+len(typ)+5)
+
+		fmt.Fprintf(&c.definitions, " = %s", strings.Join(arguments, ",\n"+indent))
+	}
+
+	c.definitions.WriteString("\n")
+}
+
+// helper returns the token name of a generic definition which is defined once on its first usage
+func (c *jsonSchemaConverter) helper(name string) string {
+	if n, ok := c.helpers[name]; ok {
+		return n
+	}
+
+	if c.helpers == nil {
+		c.helpers = make(map[string]string)
+	}
+
+	n := c.unique(name)
+	c.helpers[name] = n
+
+	switch name {
+	case "Character":
+		c.define(n, `[\x{20}-\x{7E}] | [\n\t\x{E4}\x{20AC}\x{1F600}]`)
+		c.encoded = append(c.encoded, n)
+	case "Digits":
+		c.define(n, "+([0-9])")
+	case "Integer":
+		c.define(n, `?("-") ("0" | [1-9] *([0-9]))`)
+	case "Number":
+		c.define(n, c.helper("Integer")+` ?("." `+c.helper("Digits")+`) ?([eE] ?("+" | "-") `+c.helper("Digits")+`)`)
+	case "String":
+		c.define(n, `"\"" *(`+c.helper("Character")+`) "\""`)
+	case "Value":
+		c.define(n, `"null" | "true" | "false" | `+c.helper("Number")+` | `+c.helper("String")+` | "[]" | "{}"`)
+	default:
+		panic("unknown helper " + name)
+	}
+
+	return n
+}
+
+func (c *jsonSchemaConverter) errorf(path string, typ ErrorType, format string, args ...interface{}) error {
+	return &Error{
+		Message: fmt.Sprintf(format, args...),
+		Type:    typ,
+		Pointer: path,
+	}
+}
+
+func (c *jsonSchemaConverter) warnf(path string, typ ErrorType, format string, args ...interface{}) {
+	c.warnings = append(c.warnings, &Error{
+		Message: fmt.Sprintf(format, args...),
+		Type:    typ,
+		Pointer: path,
+	})
+}
+
+// resolve returns the schema of the given local reference
+func (c *jsonSchemaConverter) resolve(ref string) (interface{}, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("only local references are supported")
+	}
+
+	s := c.root
+
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		part = strings.Replace(strings.Replace(part, "~1", "/", -1), "~0", "~", -1)
+
+		m, ok := s.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("reference %q cannot be resolved", ref)
+		}
+
+		if s, ok = m[part]; !ok {
+			return nil, fmt.Errorf("reference %q cannot be resolved", ref)
+		}
+	}
+
+	return s, nil
+}
+
+// literal returns the Tavor format of the given JSON value
+func literal(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return strconv.Quote(string(data))
+}
+
+func number(v interface{}) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+
+	f, err := n.Float64()
+
+	return f, err == nil
+}
+
+// convert returns the Tavor format of the given schema. Token definitions which are needed by the schema are named after the given name.
+func (c *jsonSchemaConverter) convert(schema interface{}, path string, name string) (string, error) {
+	switch s := schema.(type) {
+	case bool:
+		if s {
+			return c.helper("Value"), nil
+		}
+
+		return "", c.errorf(path, ErrorUnsupported, "the schema false does not allow any document")
+	case map[string]interface{}:
+		return c.convertObject(s, path, name)
+	}
+
+	return "", c.errorf(path, ErrorSyntax, "schema must be an object or a boolean")
+}
+
+func (c *jsonSchemaConverter) convertObject(s map[string]interface{}, path string, name string) (string, error) {
+	var types []string
+
+	switch t := s["type"].(type) {
+	case nil:
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			typ, ok := v.(string)
+			if !ok {
+				return "", c.errorf(path, ErrorSyntax, "type must be a string or an array of strings")
+			}
+
+			types = append(types, typ)
+		}
+	default:
+		return "", c.errorf(path, ErrorSyntax, "type must be a string or an array of strings")
+	}
+
+	for _, typ := range types {
+		if _, ok := jsonSchemaKeywords[typ]; !ok && typ != "boolean" && typ != "null" {
+			return "", c.errorf(path, ErrorSyntax, "unknown type %q", typ)
+		}
+	}
+
+	// check that every keyword is supported
+	keywords := make([]string, 0, len(s))
+	for keyword := range s {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+
+KEYWORDS:
+	for _, keyword := range keywords {
+		if _, ok := jsonSchemaAnnotations[keyword]; ok {
+			continue
+		}
+
+		for _, ks := range jsonSchemaKeywords {
+			for _, k := range ks {
+				if k == keyword {
+					continue KEYWORDS
+				}
+			}
+		}
+
+		return "", c.errorf(path, ErrorUnsupported, "keyword %q is not supported", keyword)
+	}
+
+	// keywords which define the whole document
+	if ref, ok := s["$ref"]; ok {
+		r, ok := ref.(string)
+		if !ok {
+			return "", c.errorf(path, ErrorSyntax, "$ref must be a string")
+		}
+
+		if n, ok := c.refs[r]; ok {
+			return n, nil
+		}
+
+		rs, err := c.resolve(r)
+		if err != nil {
+			return "", c.errorf(path, ErrorUnsupported, "%v", err)
+		}
+
+		n := c.unique(r[strings.LastIndex(r, "/")+1:])
+		c.refs[r] = n
+
+		e, err := c.convert(rs, r, n)
+		if err != nil {
+			return "", err
+		}
+
+		c.define(n, e)
+
+		return n, nil
+	}
+	if v, ok := s["const"]; ok {
+		return literal(v), nil
+	}
+	if v, ok := s["enum"]; ok {
+		values, ok := v.([]interface{})
+		if !ok || len(values) == 0 {
+			return "", c.errorf(path, ErrorSyntax, "enum must be a non-empty array")
+		}
+
+		alternatives := make([]string, len(values))
+		for i, v := range values {
+			alternatives[i] = literal(v)
+		}
+
+		return "(" + strings.Join(alternatives, " | ") + ")", nil
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		v, ok := s[keyword]
+		if !ok {
+			continue
+		}
+
+		schemas, ok := v.([]interface{})
+		if !ok || len(schemas) == 0 {
+			return "", c.errorf(path, ErrorSyntax, "%s must be a non-empty array", keyword)
+		}
+
+		if keyword == "allOf" && len(schemas) != 1 {
+			return "", c.errorf(path, ErrorUnsupported, "allOf is only supported with exactly one schema")
+		}
+
+		for _, k := range keywords {
+			if _, ok := jsonSchemaAnnotations[k]; !ok && k != keyword && k != "definitions" && k != "$defs" {
+				return "", c.errorf(path, ErrorUnsupported, "%s cannot be combined with the keyword %q", keyword, k)
+			}
+		}
+
+		alternatives := make([]string, len(schemas))
+		for i, schema := range schemas {
+			e, err := c.convert(schema, fmt.Sprintf("%s/%s/%d", path, keyword, i), fmt.Sprintf("%s_%d", name, i))
+			if err != nil {
+				return "", err
+			}
+
+			alternatives[i] = e
+		}
+
+		return "(" + strings.Join(alternatives, " | ") + ")", nil
+	}
+
+	// infer the type by the used keywords
+	if len(types) == 0 {
+		for _, typ := range []string{"object", "array", "string", "integer"} {
+			for _, k := range jsonSchemaKeywords[typ] {
+				if _, ok := s[k]; ok {
+					types = []string{typ}
+
+					break
+				}
+			}
+
+			if len(types) != 0 {
+				break
+			}
+		}
+
+		if len(types) == 0 {
+			return c.helper("Value"), nil
+		}
+	}
+
+	alternatives := make([]string, len(types))
+	for i, typ := range types {
+		n := name
+		if len(types) > 1 {
+			n += "_" + typ
+		}
+
+		var e string
+		var err error
+
+		switch typ {
+		case "null":
+			e = `"null"`
+		case "boolean":
+			e = `("true" | "false")`
+		case "integer", "number":
+			e, err = c.convertNumber(s, path, n, typ == "integer")
+		case "string":
+			e, err = c.convertString(s, path, n)
+		case "array":
+			e, err = c.convertArray(s, path, n)
+		case "object":
+			e, err = c.convertProperties(s, path, n)
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		alternatives[i] = e
+	}
+
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+
+	return "(" + strings.Join(alternatives, " | ") + ")", nil
+}
+
+// bounds returns the minimum and maximum of the schema. The returned booleans are false if the respective bound is not defined.
+func (c *jsonSchemaConverter) bounds(s map[string]interface{}, path string) (min float64, hasMin bool, max float64, hasMax bool, exclusiveMin bool, exclusiveMax bool, err error) {
+	if v, ok := s["minimum"]; ok {
+		if min, hasMin = number(v); !hasMin {
+			return 0, false, 0, false, false, false, c.errorf(path, ErrorSyntax, "minimum must be a number")
+		}
+	}
+	if v, ok := s["maximum"]; ok {
+		if max, hasMax = number(v); !hasMax {
+			return 0, false, 0, false, false, false, c.errorf(path, ErrorSyntax, "maximum must be a number")
+		}
+	}
+
+	// exclusive bounds are either booleans (draft 4) or numbers (draft 6 and later)
+	switch v := s["exclusiveMinimum"].(type) {
+	case nil:
+	case bool:
+		exclusiveMin = v && hasMin
+	default:
+		f, ok := number(v)
+		if !ok {
+			return 0, false, 0, false, false, false, c.errorf(path, ErrorSyntax, "exclusiveMinimum must be a number or a boolean")
+		}
+
+		if !hasMin || f >= min {
+			min, hasMin, exclusiveMin = f, true, true
+		}
+	}
+	switch v := s["exclusiveMaximum"].(type) {
+	case nil:
+	case bool:
+		exclusiveMax = v && hasMax
+	default:
+		f, ok := number(v)
+		if !ok {
+			return 0, false, 0, false, false, false, c.errorf(path, ErrorSyntax, "exclusiveMaximum must be a number or a boolean")
+		}
+
+		if !hasMax || f <= max {
+			max, hasMax, exclusiveMax = f, true, true
+		}
+	}
+
+	return min, hasMin, max, hasMax, exclusiveMin, exclusiveMax, nil
+}
+
+func (c *jsonSchemaConverter) convertNumber(s map[string]interface{}, path string, name string, integer bool) (string, error) {
+	min, hasMin, max, hasMax, exclusiveMin, exclusiveMax, err := c.bounds(s, path)
+	if err != nil {
+		return "", err
+	}
+
+	step := 1.0
+	if v, ok := s["multipleOf"]; ok {
+		if step, ok = number(v); !ok || step <= 0 || step != math.Trunc(step) {
+			return "", c.errorf(path, ErrorUnsupported, "multipleOf is only supported with positive integers")
+		}
+	}
+
+	if !hasMin && !hasMax && step == 1 {
+		if integer {
+			return c.helper("Integer"), nil
+		}
+
+		return c.helper("Number"), nil
+	}
+
+	// unbounded sides are bounded by the default range of typed tokens
+	if !hasMin {
+		min = math.MinInt32
+	}
+	if !hasMax {
+		max = math.MaxInt32
+	}
+
+	n := c.unique(name + "_range")
+
+	if integer || step != 1 {
+		from := int64(math.Ceil(min))
+		if exclusiveMin && float64(from) == min {
+			from++
+		}
+		to := int64(math.Floor(max))
+		if exclusiveMax && float64(to) == max {
+			to--
+		}
+
+		if st := int64(step); from%st != 0 {
+			from += st - ((from%st)+st)%st
+		}
+
+		if from > to {
+			return "", c.errorf(path, ErrorUnsupported, "the range of the number does not allow any value")
+		}
+
+		arguments := []string{
+			fmt.Sprintf("from: %d", from),
+			fmt.Sprintf("to: %d", to),
+		}
+		if step != 1 {
+			arguments = append(arguments, fmt.Sprintf("step: %d", int64(step)))
+		}
+
+		c.defineTyped(n, "Int", arguments...)
+	} else {
+		if exclusiveMin {
+			min = math.Nextafter(min, math.Inf(1))
+		}
+		if exclusiveMax {
+			max = math.Nextafter(max, math.Inf(-1))
+		}
+
+		if min > max {
+			return "", c.errorf(path, ErrorUnsupported, "the range of the number does not allow any value")
+		}
+
+		c.defineTyped(n, "Float", "from: "+strconv.FormatFloat(min, 'g', -1, 64), "to: "+strconv.FormatFloat(max, 'g', -1, 64))
+	}
+
+	return n, nil
+}
+
+// length returns the given non-negative integer keyword of the schema or the given default value if it is not defined
+func (c *jsonSchemaConverter) length(s map[string]interface{}, path string, keyword string, def int) (int, error) {
+	v, ok := s[keyword]
+	if !ok {
+		return def, nil
+	}
+
+	f, ok := number(v)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return 0, c.errorf(path, ErrorSyntax, "%s must be a non-negative integer", keyword)
+	}
+
+	return int(f), nil
+}
+
+// repeat returns the Tavor format of a repetition of the given expression
+func repeat(e string, from int, to int) string {
+	switch {
+	case to == 0:
+		return ""
+	case from == 0 && to == -1:
+		return "*(" + e + ")"
+	case from == 1 && to == -1:
+		return "+(" + e + ")"
+	case to == -1:
+		return fmt.Sprintf("+%d,(%s)", from, e)
+	case from == 0 && to == 1:
+		return "?(" + e + ")"
+	case from == to:
+		return fmt.Sprintf("+%d(%s)", from, e)
+	}
+
+	return fmt.Sprintf("+%d,%d(%s)", from, to, e)
+}
+
+func (c *jsonSchemaConverter) convertString(s map[string]interface{}, path string, name string) (string, error) {
+	minLength, err := c.length(s, path, "minLength", 0)
+	if err != nil {
+		return "", err
+	}
+	maxLength, err := c.length(s, path, "maxLength", -1)
+	if err != nil {
+		return "", err
+	}
+
+	if maxLength != -1 && minLength > maxLength {
+		return "", c.errorf(path, ErrorUnsupported, "the length of the string does not allow any value")
+	}
+
+	if v, ok := s["pattern"]; ok {
+		pattern, ok := v.(string)
+		if !ok {
+			return "", c.errorf(path, ErrorSyntax, "pattern must be a string")
+		}
+
+		re, err := syntax.Parse(pattern, syntax.Perl)
+		if err != nil {
+			return "", c.errorf(path, ErrorSyntax, "pattern is invalid: %v", err)
+		}
+
+		// the length keywords are only fulfilled if every match of the pattern has a fitting length
+		min, max := regexLength(re)
+
+		if _, ok := s["minLength"]; ok && min < minLength {
+			c.warnf(path, ErrorUnsupported, "keyword \"minLength\" is not supported in combination with a pattern which matches shorter strings and is ignored")
+		}
+		if _, ok := s["maxLength"]; ok && (max == -1 || max > maxLength) {
+			c.warnf(path, ErrorUnsupported, "keyword \"maxLength\" is not supported in combination with a pattern which matches longer strings and is ignored")
+		}
+
+		n := c.unique(name + "_pattern")
+
+		c.defineTyped(n, "Regex", "pattern: "+strconv.Quote(pattern))
+		c.encoded = append(c.encoded, n)
+
+		return `"\"" ` + n + ` "\""`, nil
+	}
+
+	if minLength == 0 && maxLength == -1 {
+		return c.helper("String"), nil
+	}
+
+	if r := repeat(c.helper("Character"), minLength, maxLength); r != "" {
+		return `"\"" ` + r + ` "\""`, nil
+	}
+
+	return `"\"\""`, nil
+}
+
+// regexLength returns the minimum and maximum number of characters which are matched by the given regular expression. The maximum is -1 if it is unbounded.
+func regexLength(re *syntax.Regexp) (int, int) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune), len(re.Rune)
+	case syntax.OpCharClass, syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		return 1, 1
+	case syntax.OpCapture:
+		return regexLength(re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		from, to := re.Min, re.Max
+
+		switch re.Op {
+		case syntax.OpStar:
+			from, to = 0, -1
+		case syntax.OpPlus:
+			from, to = 1, -1
+		case syntax.OpQuest:
+			from, to = 0, 1
+		}
+
+		min, max := regexLength(re.Sub[0])
+
+		if to == 0 || max == 0 {
+			return from * min, 0
+		} else if to == -1 || max == -1 {
+			return from * min, -1
+		}
+
+		return from * min, to * max
+	case syntax.OpConcat:
+		min, max := 0, 0
+
+		for _, sub := range re.Sub {
+			subMin, subMax := regexLength(sub)
+
+			min += subMin
+			if max != -1 {
+				if subMax == -1 {
+					max = -1
+				} else {
+					max += subMax
+				}
+			}
+		}
+
+		return min, max
+	case syntax.OpAlternate:
+		min, max := regexLength(re.Sub[0])
+
+		for _, sub := range re.Sub[1:] {
+			subMin, subMax := regexLength(sub)
+
+			if subMin < min {
+				min = subMin
+			}
+			if max != -1 && (subMax == -1 || subMax > max) {
+				max = subMax
+			}
+		}
+
+		return min, max
+	}
+
+	// empty matches and zero-width assertions
+	return 0, 0
+}
+
+func (c *jsonSchemaConverter) convertArray(s map[string]interface{}, path string, name string) (string, error) {
+	minItems, err := c.length(s, path, "minItems", 0)
+	if err != nil {
+		return "", err
+	}
+	maxItems, err := c.length(s, path, "maxItems", -1)
+	if err != nil {
+		return "", err
+	}
+
+	if maxItems != -1 && minItems > maxItems {
+		return "", c.errorf(path, ErrorUnsupported, "the number of items does not allow any value")
+	}
+
+	v, hasItems := s["items"]
+	if _, ok := v.([]interface{}); ok {
+		return "", c.errorf(path, ErrorUnsupported, "tuple validation of items is not supported")
+	}
+
+	if maxItems == 0 {
+		return `"[]"`, nil
+	}
+
+	var item string
+
+	if hasItems {
+		n := c.unique(name + "_item")
+
+		e, err := c.convert(v, path+"/items", n)
+		if err != nil {
+			return "", err
+		}
+
+		c.define(n, e)
+
+		item = n
+	} else {
+		item = c.helper("Value")
+	}
+
+	to := -1
+	if maxItems != -1 {
+		to = maxItems - 1
+	}
+
+	from := minItems - 1
+	if from < 0 {
+		from = 0
+	}
+
+	items := item
+	if r := repeat(`"," `+item, from, to); r != "" {
+		items += " " + r
+	}
+
+	if minItems == 0 {
+		return `"[" ?(` + items + `) "]"`, nil
+	}
+
+	return `"[" ` + items + ` "]"`, nil
+}
+
+func (c *jsonSchemaConverter) convertProperties(s map[string]interface{}, path string, name string) (string, error) {
+	properties := map[string]interface{}{}
+
+	if v, ok := s["properties"]; ok {
+		if properties, ok = v.(map[string]interface{}); !ok {
+			return "", c.errorf(path, ErrorSyntax, "properties must be an object")
+		}
+	}
+
+	required := map[string]struct{}{}
+
+	if v, ok := s["required"]; ok {
+		r, ok := v.([]interface{})
+		if !ok {
+			return "", c.errorf(path, ErrorSyntax, "required must be an array of strings")
+		}
+
+		for _, p := range r {
+			p, ok := p.(string)
+			if !ok {
+				return "", c.errorf(path, ErrorSyntax, "required must be an array of strings")
+			}
+
+			required[p] = struct{}{}
+		}
+	}
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	for key := range required {
+		if _, ok := properties[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	// required properties are always generated first to not generate dangling commas
+	var requiredMembers []string
+	var optionalMembers []string
+
+	for _, key := range keys {
+		var value string
+
+		if p, ok := properties[key]; ok {
+			n := c.unique(name + "_" + key)
+
+			e, err := c.convert(p, path+"/properties/"+jsonPointerEscape(key), n)
+			if err != nil {
+				return "", err
+			}
+
+			c.define(n, e)
+
+			value = n
+		} else {
+			value = c.helper("Value")
+		}
+
+		member := literal(key) + ` ":" ` + value
+
+		if _, ok := required[key]; ok {
+			requiredMembers = append(requiredMembers, member)
+		} else {
+			optionalMembers = append(optionalMembers, member)
+		}
+	}
+
+	var members []string
+
+	if len(requiredMembers) != 0 {
+		members = append(members, strings.Join(requiredMembers, ` "," `))
+
+		for _, m := range optionalMembers {
+			members = append(members, `?("," `+m+`)`)
+		}
+	} else if len(optionalMembers) != 0 {
+		// every optional property can be the first one
+		alternatives := make([]string, len(optionalMembers))
+
+		for i, m := range optionalMembers {
+			a := []string{m}
+
+			for _, o := range optionalMembers[i+1:] {
+				a = append(a, `?("," `+o+`)`)
+			}
+
+			alternatives[i] = strings.Join(a, " ")
+		}
+
+		members = append(members, "?("+strings.Join(alternatives, " | ")+")")
+	}
+
+	if len(members) == 0 {
+		return `"{}"`, nil
+	}
+
+	return `"{" ` + strings.Join(members, " ") + ` "}"`, nil
+}
@@ -0,0 +1,235 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/fuzz/filter"
+	"github.com/zimmski/tavor/fuzz/strategy"
+)
+
+func TestJSONSchemaImporterToBeImporter(t *testing.T) {
+	var imp *Importer
+
+	Implements(t, imp, &JSONSchemaImporter{})
+}
+
+func TestJSONSchemaImporter(t *testing.T) {
+	imp := NewJSONSchemaImporter()
+
+	for _, tc := range []struct {
+		schema string
+		start  string
+		format string
+	}{
+		// simple types
+		{
+			`{"type": ["null", "boolean"]}`,
+			"",
+			"Root = (\"null\" | (\"true\" | \"false\"))\n\nSTART = Root\n",
+		},
+		// enumerations and constants
+		{
+			`{"anyOf": [{"enum": [1, "a", null]}, {"const": {"b": true}}]}`,
+			"",
+			"Root = ((\"1\" | \"\\\"a\\\"\" | \"null\") | \"{\\\"b\\\":true}\")\n\nSTART = Root\n",
+		},
+		// ranges
+		{
+			`{"type": "array", "items": {"type": "integer", "minimum": 1, "exclusiveMaximum": 10, "multipleOf": 2}, "minItems": 1, "maxItems": 3}`,
+			"",
+			"$Root_item_range Int = from: 2,\n                       to: 9,\n                       step: 2\nRoot_item = Root_item_range\nRoot = \"[\" Root_item +0,2(\",\" Root_item) \"]\"\n\nSTART = Root\n",
+		},
+		// strings
+		{
+			`{"type": "object", "properties": {"b": {"type": "string", "pattern": "[a-c]+"}, "a": {"type": "string", "maxLength": 2}}, "required": ["b"]}`,
+			"",
+			"encoding \"JSON\" for Character, Root_b_pattern\n\nCharacter = [\\x{20}-\\x{7E}] | [\\n\\t\\x{E4}\\x{20AC}\\x{1F600}]\nRoot_a = \"\\\"\" +0,2(Character) \"\\\"\"\n$Root_b_pattern Regex = pattern: \"[a-c]+\"\nRoot_b = \"\\\"\" Root_b_pattern \"\\\"\"\nRoot = \"{\" \"\\\"b\\\"\" \":\" Root_b ?(\",\" \"\\\"a\\\"\" \":\" Root_a) \"}\"\n\nSTART = Root\n",
+		},
+		// recursive references and start definitions
+		{
+			`{"definitions": {"list": {"type": "array", "items": {"$ref": "#/definitions/list"}, "maxItems": 2}}, "$ref": "#/definitions/list"}`,
+			"list",
+			"Root_item = Root\nRoot = \"[\" ?(Root_item ?(\",\" Root_item)) \"]\"\n\nSTART = Root\n",
+		},
+	} {
+		format, _, err := imp.Import(strings.NewReader(tc.schema), tc.start)
+		Nil(t, err, tc.schema)
+		Equal(t, tc.format, format, tc.schema)
+	}
+}
+
+func TestJSONSchemaImporterGenerate(t *testing.T) {
+	schema := `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "integer", "minimum": 0, "maximum": 100},
+		"name": {"type": "string", "minLength": 1},
+		"price": {"type": "number", "exclusiveMinimum": 0, "maximum": 10},
+		"tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}, "maxItems": 3},
+		"code": {"type": "string", "pattern": "[A-Z]{2}\"[0-9]"},
+		"extra": {}
+	},
+	"required": ["id", "name", "price"],
+	"$defs": {
+		"tag": {"type": "string", "enum": ["a", "b\nc"]}
+	}
+}`
+
+	doc, err := Parse(NewJSONSchemaImporter(), strings.NewReader(schema), "")
+	if !Nil(t, err) {
+		return
+	}
+
+	type document struct {
+		ID    *int64
+		Name  *string
+		Price *float64
+		Tags  []string
+		Code  *string
+	}
+
+	check := func() (invalid int) {
+		strat, err := strategy.New("random", doc)
+		Nil(t, err)
+
+		for i := 0; i < 100; i++ {
+			ch, err := strat.Fuzz(rand.New(rand.NewSource(int64(i))))
+			Nil(t, err)
+
+			for i := range ch {
+				data := doc.String()
+
+				var d document
+				if !Nil(t, json.NewDecoder(bytes.NewBufferString(data)).Decode(&d), data) {
+					ch <- i
+
+					continue
+				}
+
+				NotNil(t, d.ID, data)
+				NotNil(t, d.Name, data)
+				NotNil(t, d.Price, data)
+
+				if d.ID != nil && d.Price != nil && (*d.ID < 0 || *d.ID > 100 || *d.Price <= 0 || *d.Price > 10) {
+					invalid++
+				}
+				if d.Code != nil && (len(*d.Code) != 4 || (*d.Code)[2] != '"') {
+					Fail(t, "invalid code", data)
+				}
+
+				ch <- i
+			}
+		}
+
+		return invalid
+	}
+
+	Equal(t, 0, check())
+
+	// the negative boundary-value filter generates numbers which violate the schema
+	filters := []filter.Filter{filter.NewNegativeBoundaryValueAnalysisFilter()}
+	doc, err = filter.ApplyFilters(filters, doc)
+	Nil(t, err)
+
+	True(t, check() > 0)
+}
+
+func TestJSONSchemaImporterErrors(t *testing.T) {
+	imp := NewJSONSchemaImporter()
+
+	for _, tc := range []struct {
+		schema string
+		typ    ErrorType
+	}{
+		{``, ErrorSyntax},
+		{`[]`, ErrorSyntax},
+		{`{"type": "unknown"}`, ErrorSyntax},
+		{`{"minimum": "1"}`, ErrorSyntax},
+		{`false`, ErrorUnsupported},
+		{`{"not": {}}`, ErrorUnsupported},
+		{`{"allOf": [{}, {}]}`, ErrorUnsupported},
+		{`{"anyOf": [{}], "type": "string"}`, ErrorUnsupported},
+		{`{"$ref": "other.json#/definitions/a"}`, ErrorUnsupported},
+		{`{"$ref": "#/definitions/a"}`, ErrorUnsupported},
+		{`{"items": [{}, {}]}`, ErrorUnsupported},
+		{`{"type": "number", "multipleOf": 0.5}`, ErrorUnsupported},
+		{`{"type": "integer", "minimum": 2, "maximum": 1}`, ErrorUnsupported},
+		{`{"pattern": "a("}`, ErrorSyntax},
+	} {
+		_, _, err := imp.Import(strings.NewReader(tc.schema), "")
+		if !NotNil(t, err, tc.schema) {
+			continue
+		}
+
+		Equal(t, tc.typ, err.(*Error).Type, tc.schema)
+	}
+
+	_, _, err := imp.Import(strings.NewReader(`{}`), "unknown")
+	Equal(t, ErrorUndefinedRule, err.(*Error).Type)
+
+	// errors of schemas hold the JSON pointer of the schema
+	_, _, err = imp.Import(strings.NewReader(`{"properties": {"a/b": {"items": {"type": "unknown"}}}}`), "")
+	if NotNil(t, err) {
+		Equal(t, "#/properties/a~1b/items", err.(*Error).Pointer)
+		Equal(t, "#/properties/a~1b/items - unknown type \"unknown\"", err.Error())
+	}
+
+	// syntax errors of the document hold the line and column
+	_, _, err = imp.Import(strings.NewReader("{\n  \"type\": \"string\",\n}"), "")
+	if NotNil(t, err) {
+		Equal(t, 3, err.(*Error).Position.Line)
+		Equal(t, 1, err.(*Error).Position.Column)
+	}
+}
+
+func TestJSONSchemaImporterWarnings(t *testing.T) {
+	imp := NewJSONSchemaImporter()
+
+	for _, tc := range []struct {
+		schema   string
+		format   string
+		keywords []string
+	}{
+		// the pattern fulfills the length keywords
+		{
+			`{"pattern": "^[0-9]{3,5}$", "minLength": 2, "maxLength": 5}`,
+			"encoding \"JSON\" for Root_pattern\n\n$Root_pattern Regex = pattern: \"^[0-9]{3,5}$\"\nRoot = \"\\\"\" Root_pattern \"\\\"\"\n\nSTART = Root\n",
+			nil,
+		},
+		// the pattern matches shorter and longer strings
+		{
+			`{"pattern": "a|b+", "minLength": 2, "maxLength": 5}`,
+			"encoding \"JSON\" for Root_pattern\n\n$Root_pattern Regex = pattern: \"a|b+\"\nRoot = \"\\\"\" Root_pattern \"\\\"\"\n\nSTART = Root\n",
+			[]string{"minLength", "maxLength"},
+		},
+		{
+			`{"properties": {"a": {"pattern": "a{2}(bc)?", "maxLength": 3}}}`,
+			"",
+			[]string{"maxLength"},
+		},
+	} {
+		format, warnings, err := imp.Import(strings.NewReader(tc.schema), "")
+		Nil(t, err, tc.schema)
+		if tc.format != "" {
+			Equal(t, tc.format, format, tc.schema)
+		}
+
+		if Equal(t, len(tc.keywords), len(warnings), tc.schema) {
+			for i, w := range warnings {
+				Equal(t, ErrorUnsupported, w.Type, tc.schema)
+				Contains(t, w.Message, "keyword \""+tc.keywords[i]+"\"", tc.schema)
+			}
+		}
+	}
+
+	_, warnings, _ := imp.Import(strings.NewReader(`{"properties": {"a": {"pattern": "a+", "maxLength": 3}}}`), "")
+	if Equal(t, 1, len(warnings)) {
+		Equal(t, "#/properties/a - keyword \"maxLength\" is not supported in combination with a pattern which matches longer strings and is ignored", warnings[0].Error())
+	}
+}
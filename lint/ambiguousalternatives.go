@@ -0,0 +1,143 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zimmski/tavor/fuzz/strategy"
+	"github.com/zimmski/tavor/rand"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/constraints"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+// DefaultMaxAmbiguityPermutations is the default maximum of permutations of an alternative which is checked for ambiguity
+const DefaultMaxAmbiguityPermutations = 256
+
+// AmbiguousAlternativesRule implements a lint rule which finds alternations with different alternatives that can generate the same value.
+// Parsing an input always chooses the first of the longest matching alternatives, which means that the other alternatives are never chosen for such a value e.g. while learning a profile. Only alternatives which consist of constants, character classes and integer ranges with at most MaxPermutations permutations are checked.
+type AmbiguousAlternativesRule struct {
+	// MaxPermutations holds the maximum of permutations of an alternative which is checked
+	MaxPermutations uint
+}
+
+// NewAmbiguousAlternativesRule returns a new instance of the ambiguous alternatives lint rule with the given maximum of permutations of checked alternatives
+func NewAmbiguousAlternativesRule(maxPermutations uint) *AmbiguousAlternativesRule {
+	return &AmbiguousAlternativesRule{
+		MaxPermutations: maxPermutations,
+	}
+}
+
+func init() {
+	Register("AmbiguousAlternatives", func() Rule {
+		return NewAmbiguousAlternativesRule(DefaultMaxAmbiguityPermutations)
+	})
+}
+
+// Severity returns the severity of the problems which are found by the rule
+func (r *AmbiguousAlternativesRule) Severity() Severity {
+	return SeverityInfo
+}
+
+// Check checks the format and returns the found problems
+func (r *AmbiguousAlternativesRule) Check(format *Format) []Problem {
+	return format.inspect(func(tok token.Token) []finding {
+		one, ok := tok.(*lists.One)
+		if !ok {
+			return nil
+		}
+
+		var findings []finding
+
+		fingerprints := alternatives(one)
+		key := fingerprint(one)
+
+		values := make([]map[string]struct{}, len(fingerprints))
+		for i := range values {
+			alternative, _ := one.InternalGet(i)
+
+			values[i] = r.values(alternative)
+		}
+
+		for i := 0; i < len(values); i++ {
+		ALTERNATIVES:
+			for j := i + 1; j < len(values); j++ {
+				// identical alternatives are reported by their own rule
+				if fingerprints[i] == fingerprints[j] {
+					continue
+				}
+
+				for _, value := range sortedValues(values[i]) {
+					if _, ok := values[j][value]; ok {
+						findings = append(findings, finding{
+							key:     fmt.Sprintf("%s:%d:%d", key, i, j),
+							message: fmt.Sprintf("has the alternatives %d and %d which can both be %q in %s", i+1, j+1, value, expression(one)),
+						})
+
+						continue ALTERNATIVES
+					}
+				}
+			}
+		}
+
+		return findings
+	})
+}
+
+// values returns all values of the given alternative or nil if the alternative cannot be checked
+func (r *AmbiguousAlternativesRule) values(alternative token.Token) map[string]struct{} {
+	if alternative.PermutationsAll() > r.MaxPermutations {
+		return nil
+	}
+
+	static := true
+
+	_ = token.WalkInternal(alternative, func(tok token.Token) error {
+		switch tok.(type) {
+		case *primitives.ConstantInt, *primitives.ConstantString, *primitives.CharacterClass, *primitives.RangeInt:
+		case *lists.All, *lists.One, *lists.Repeat, *constraints.Optional:
+		default:
+			static = false
+		}
+
+		return nil
+	})
+	if !static {
+		return nil
+	}
+
+	tok := alternative.Clone()
+
+	strat, err := strategy.New("AllPermutations", tok)
+	if err != nil {
+		return nil
+	}
+
+	continueFuzzing, err := strat.Fuzz(rand.NewIncrementRand(0))
+	if err != nil {
+		return nil
+	}
+
+	values := make(map[string]struct{})
+
+	for i := range continueFuzzing {
+		values[tok.String()] = struct{}{}
+
+		continueFuzzing <- i
+	}
+
+	return values
+}
+
+func sortedValues(values map[string]struct{}) []string {
+	sorted := make([]string, 0, len(values))
+
+	for value := range values {
+		sorted = append(sorted, value)
+	}
+
+	sort.Strings(sorted)
+
+	return sorted
+}
@@ -0,0 +1,17 @@
+package lint
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestAmbiguousAlternatives(t *testing.T) {
+	Equal(t, []string(nil), check(t, "START = \"a\" | [0-9] | \"bc\"\n", "AmbiguousAlternatives"))
+
+	Equal(t, []string{
+		`token "START" has the alternatives 1 and 2 which can both be "1" in 1 | [0-9] | "1"`,
+		`token "START" has the alternatives 1 and 3 which can both be "1" in 1 | [0-9] | "1"`,
+		`token "START" has the alternatives 2 and 3 which can both be "1" in 1 | [0-9] | "1"`,
+	}, check(t, "START = 1 | [0-9] | \"1\"\n", "AmbiguousAlternatives"))
+}
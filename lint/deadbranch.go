@@ -0,0 +1,89 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/conditions"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+// DeadBranchRule implements a lint rule which finds branches of conditions that are never taken.
+// A branch is never taken if its condition does not depend on variables and is always false, or if the condition of a previous branch does not depend on variables and is always true.
+type DeadBranchRule struct{}
+
+// NewDeadBranchRule returns a new instance of the dead branch lint rule
+func NewDeadBranchRule() *DeadBranchRule {
+	return &DeadBranchRule{}
+}
+
+func init() {
+	Register("DeadBranch", func() Rule {
+		return NewDeadBranchRule()
+	})
+}
+
+// Severity returns the severity of the problems which are found by the rule
+func (r *DeadBranchRule) Severity() Severity {
+	return SeverityWarning
+}
+
+// Check checks the format and returns the found problems
+func (r *DeadBranchRule) Check(format *Format) []Problem {
+	return format.inspect(func(tok token.Token) []finding {
+		c, ok := tok.(*conditions.If)
+		if !ok {
+			return nil
+		}
+
+		var findings []finding
+
+		key := fingerprint(c)
+
+		for i, pair := range c.Pairs {
+			if !constantCondition(pair.Head) {
+				continue
+			}
+
+			if !pair.Head.Evaluate() {
+				findings = append(findings, finding{
+					key:     fmt.Sprintf("%s:%d", key, i),
+					message: fmt.Sprintf("has a condition with the branch %d which is never taken since its condition is always false", i+1),
+				})
+
+				continue
+			}
+
+			for j := i + 1; j < len(c.Pairs); j++ {
+				findings = append(findings, finding{
+					key:     fmt.Sprintf("%s:%d", key, j),
+					message: fmt.Sprintf("has a condition with the branch %d which is never taken since the condition of branch %d is always true", j+1, i+1),
+				})
+			}
+
+			break
+		}
+
+		return findings
+	})
+}
+
+// constantCondition returns true if the given condition does not depend on variables
+func constantCondition(head conditions.BooleanExpression) bool {
+	constant := true
+
+	_ = token.WalkInternal(head, func(tok token.Token) error {
+		switch tok.(type) {
+		case *conditions.BooleanTrue, *conditions.BooleanEqual, *conditions.BooleanNotEqual, *conditions.BooleanLessThan, *conditions.BooleanLessThanOrEqual, *conditions.BooleanGreaterThan, *conditions.BooleanGreaterThanOrEqual:
+		case *conditions.BooleanNot, *conditions.BooleanAnd, *conditions.BooleanOr, *conditions.BooleanIn, *conditions.ExpressionPointer:
+		case *primitives.ConstantInt, *primitives.ConstantString, *lists.All:
+		default:
+			constant = false
+		}
+
+		return nil
+	})
+
+	return constant
+}
@@ -0,0 +1,15 @@
+package lint
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestDeadBranch(t *testing.T) {
+	Equal(t, []string(nil), check(t, "START = 1<x> {if x.Value == 1}\"a\"{else}\"b\"{endif}\n", "DeadBranch"))
+
+	Equal(t, []string{
+		`token "START" has a condition with the branch 2 which is never taken since the condition of branch 1 is always true`,
+	}, check(t, "START = {if 1 == 1}\"a\"{else}\"b\"{endif}\n", "DeadBranch"))
+}
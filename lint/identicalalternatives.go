@@ -0,0 +1,71 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+)
+
+// IdenticalAlternativesRule implements a lint rule which finds alternations with identical alternatives.
+// Identical alternatives only change the probability of an alternative which should be done with weights instead.
+type IdenticalAlternativesRule struct{}
+
+// NewIdenticalAlternativesRule returns a new instance of the identical alternatives lint rule
+func NewIdenticalAlternativesRule() *IdenticalAlternativesRule {
+	return &IdenticalAlternativesRule{}
+}
+
+func init() {
+	Register("IdenticalAlternatives", func() Rule {
+		return NewIdenticalAlternativesRule()
+	})
+}
+
+// Severity returns the severity of the problems which are found by the rule
+func (r *IdenticalAlternativesRule) Severity() Severity {
+	return SeverityWarning
+}
+
+// Check checks the format and returns the found problems
+func (r *IdenticalAlternativesRule) Check(format *Format) []Problem {
+	return format.inspect(func(tok token.Token) []finding {
+		one, ok := tok.(*lists.One)
+		if !ok {
+			return nil
+		}
+
+		var findings []finding
+
+		alternatives := alternatives(one)
+		key := fingerprint(one)
+
+		for i := 0; i < len(alternatives); i++ {
+			for j := i + 1; j < len(alternatives); j++ {
+				if alternatives[i] != alternatives[j] {
+					continue
+				}
+
+				findings = append(findings, finding{
+					key:     fmt.Sprintf("%s:%d:%d", key, i, j),
+					message: fmt.Sprintf("has the identical alternatives %d and %d in %s", i+1, j+1, expression(one)),
+				})
+			}
+		}
+
+		return findings
+	})
+}
+
+// alternatives returns the fingerprints of the alternatives of the given alternation
+func alternatives(one *lists.One) []string {
+	fingerprints := make([]string, one.InternalLen())
+
+	for i := range fingerprints {
+		tok, _ := one.InternalGet(i)
+
+		fingerprints[i] = fingerprint(tok)
+	}
+
+	return fingerprints
+}
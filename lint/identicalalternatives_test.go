@@ -0,0 +1,16 @@
+package lint
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestIdenticalAlternatives(t *testing.T) {
+	Equal(t, []string(nil), check(t, "START = \"a\" | \"b\"\n", "IdenticalAlternatives"))
+
+	// the problem is only reported for the definition which holds the alternatives
+	Equal(t, []string{
+		`token "A" has the identical alternatives 1 and 2 in "a" | "a" | "b"`,
+	}, check(t, "A = \"a\" | \"a\" | \"b\"\nSTART = A A\n", "IdenticalAlternatives"))
+}
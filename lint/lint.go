@@ -0,0 +1,338 @@
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/scanner"
+
+	"github.com/zimmski/tavor/parser"
+	"github.com/zimmski/tavor/printer"
+	"github.com/zimmski/tavor/token"
+)
+
+// Severity the severity of a problem
+type Severity int
+
+const (
+	// SeverityInfo the problem is a hint which can be ignored
+	SeverityInfo Severity = iota
+	// SeverityWarning the problem most likely leads to unwanted generations or parsing results
+	SeverityWarning
+	// SeverityError the format is invalid
+	SeverityError
+)
+
+var severityNames = []string{
+	"info",
+	"warning",
+	"error",
+}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", s)
+	}
+
+	return severityNames[s]
+}
+
+// ParseSeverity returns the severity with the given name.
+// The error return argument is not nil, if there is no severity with the given name.
+func ParseSeverity(name string) (Severity, error) {
+	for i, n := range severityNames {
+		if n == name {
+			return Severity(i), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown severity %q", name)
+}
+
+// ListSeverities returns a list of all severity names ordered from the lowest to the highest severity.
+func ListSeverities() []string {
+	return append([]string(nil), severityNames...)
+}
+
+// ParserRule is the name of the rule which reports the errors of the parser
+const ParserRule = "Parser"
+
+// Problem holds a problem of a format which was found by a rule
+type Problem struct {
+	Rule     string
+	Severity Severity
+	Message  string
+
+	Position scanner.Position
+}
+
+func (p *Problem) String() string {
+	if p.Position.Filename != "" {
+		return fmt.Sprintf("%s, L:%d, C:%d - %s: %s (%s)", p.Position.Filename, p.Position.Line, p.Position.Column, p.Severity, p.Message, p.Rule)
+	}
+
+	return fmt.Sprintf("L:%d, C:%d - %s: %s (%s)", p.Position.Line, p.Position.Column, p.Severity, p.Message, p.Rule)
+}
+
+// Format holds a valid format which is checked by the rules
+type Format struct {
+	// Symbols holds the symbols of the format source in their order of occurrence
+	Symbols []parser.Symbol
+	// Definitions holds the unrolled token graphs of the named token definitions of the format source
+	Definitions map[string]token.Token
+}
+
+// Rule defines a lint rule
+type Rule interface {
+	// Severity returns the severity of the problems which are found by the rule
+	Severity() Severity
+	// Check checks the format and returns the found problems. The rule and severity of the problems are set by the caller.
+	Check(format *Format) []Problem
+}
+
+var ruleLookup = make(map[string]func() Rule)
+
+// New returns a new lint rule instance given the registered name of the rule.
+// The error return argument is not nil, if the name does not exist in the registered lint rule list.
+func New(name string) (Rule, error) {
+	rule, ok := ruleLookup[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown lint rule %q", name)
+	}
+
+	return rule(), nil
+}
+
+// List returns a list of all registered lint rule names.
+func List() []string {
+	keyRuleLookup := make([]string, 0, len(ruleLookup))
+
+	for key := range ruleLookup {
+		keyRuleLookup = append(keyRuleLookup, key)
+	}
+
+	sort.Strings(keyRuleLookup)
+
+	return keyRuleLookup
+}
+
+// Register registers a lint rule instance function with the given name.
+func Register(name string, rule func() Rule) {
+	if rule == nil {
+		panic("register lint rule is nil")
+	}
+
+	if _, ok := ruleLookup[name]; ok {
+		panic("lint rule " + name + " already registered")
+	}
+
+	ruleLookup[name] = rule
+}
+
+// Lint reads and parses a Tavor formatted input and checks it with the lint rules of the given names, all registered rules are applied if no names are given.
+// The filename is used to resolve included files and for the positions of the problems, it can be empty. The found problems are returned sorted by their positions. Since the rules can only check valid formats, the errors of an invalid format are returned as problems of the ParserRule with an error severity.
+// The error return argument is not nil, if a rule does not exist or if an error is encountered during reading the input.
+func Lint(src io.Reader, filename string, names []string) ([]Problem, error) {
+	if len(names) == 0 {
+		names = List()
+	}
+
+	rules := make([]Rule, len(names))
+	for i, name := range names {
+		rule, err := New(name)
+		if err != nil {
+			return nil, err
+		}
+
+		rules[i] = rule
+	}
+
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+
+	_, definitions, err := parser.ParseTavorDefinitions(bytes.NewReader(data), filename)
+	if err != nil {
+		errs := []error{err}
+		if perrs, ok := err.(token.ParserErrors); ok {
+			errs = perrs
+		}
+
+		for _, err := range errs {
+			perr, ok := err.(*token.ParserError)
+			if !ok {
+				return nil, err
+			}
+
+			problems = append(problems, Problem{
+				Rule:     ParserRule,
+				Severity: SeverityError,
+				Message:  perr.Message,
+				Position: perr.Position,
+			})
+		}
+
+		return problems, nil
+	}
+
+	format := &Format{
+		Symbols:     parser.Symbols(string(data), filename),
+		Definitions: definitions,
+	}
+
+	for i, rule := range rules {
+		for _, problem := range rule.Check(format) {
+			problem.Rule = names[i]
+			problem.Severity = rule.Severity()
+
+			problems = append(problems, problem)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i].Position, problems[j].Position
+
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		} else if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+
+		return problems[i].Rule < problems[j].Rule
+	})
+
+	return problems, nil
+}
+
+// Definition returns the symbol of the token definition with the given name or nil if the format source does not define the token
+func (f *Format) Definition(name string) *parser.Symbol {
+	for i := range f.Symbols {
+		s := &f.Symbols[i]
+
+		if (s.Kind == parser.SymbolToken || s.Kind == parser.SymbolTypedToken) && s.Name == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// Uses returns the names of the token definitions which are directly used by the token definition with the given name
+func (f *Format) Uses(name string) []string {
+	var uses []string
+	seen := make(map[string]struct{})
+
+	for _, s := range f.Symbols {
+		if s.Kind != parser.SymbolUsage || s.Definition != name {
+			continue
+		}
+		if _, ok := seen[s.Name]; ok {
+			continue
+		}
+		if f.Definition(s.Name) == nil {
+			continue
+		}
+
+		seen[s.Name] = struct{}{}
+		uses = append(uses, s.Name)
+	}
+
+	return uses
+}
+
+// definitionNames returns the names of the token definitions of the format source in their order of occurrence
+func (f *Format) definitionNames() []string {
+	var names []string
+
+	for _, s := range f.Symbols {
+		if s.Kind == parser.SymbolToken || s.Kind == parser.SymbolTypedToken {
+			if _, ok := f.Definitions[s.Name]; ok {
+				names = append(names, s.Name)
+			}
+		}
+	}
+
+	return names
+}
+
+// finding holds a problem of a token which is identified by a key
+type finding struct {
+	key     string
+	message string
+}
+
+// inspect calls the given function for every token of the token graphs of the token definitions and returns the found problems at the positions of the definitions.
+// Since the token graphs are unrolled, a definition contains the tokens of all definitions it uses. A finding is therefore only reported for the definitions which do not inherit it from a used definition.
+func (f *Format) inspect(check func(tok token.Token) []finding) []Problem {
+	found := make(map[string]map[string]string)
+
+	names := f.definitionNames()
+
+	for _, name := range names {
+		found[name] = make(map[string]string)
+
+		_ = token.WalkInternal(f.Definitions[name], func(tok token.Token) error {
+			for _, fi := range check(tok) {
+				found[name][fi.key] = fi.message
+			}
+
+			return nil
+		})
+	}
+
+	var problems []Problem
+
+	for _, name := range names {
+		var keys []string
+
+	KEYS:
+		for key := range found[name] {
+			for _, use := range f.Uses(name) {
+				if _, ok := found[use][key]; ok && use != name {
+					continue KEYS
+				}
+			}
+
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			problems = append(problems, Problem{
+				Message:  fmt.Sprintf("token %q %s", name, found[name][key]),
+				Position: f.Definition(name).Position,
+			})
+		}
+	}
+
+	return problems
+}
+
+// fingerprint returns the Tavor format of the given token which identifies its structure
+func fingerprint(tok token.Token) string {
+	var buf bytes.Buffer
+
+	if err := printer.WriteTavor(tok, &buf); err != nil {
+		return fmt.Sprintf("%p", tok)
+	}
+
+	return buf.String()
+}
+
+// expression returns the Tavor format of the given token if it fits on one line
+func expression(tok token.Token) string {
+	f := fingerprint(tok)
+
+	if strings.HasPrefix(f, "START = ") && strings.Count(f, "\n") == 1 {
+		return strings.TrimSuffix(strings.TrimPrefix(f, "START = "), "\n")
+	}
+
+	return "..."
+}
@@ -0,0 +1,122 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+type mockRule struct{}
+
+func (r *mockRule) Severity() Severity {
+	return SeverityInfo
+}
+
+func (r *mockRule) Check(format *Format) []Problem {
+	var problems []Problem
+
+	for _, name := range format.definitionNames() {
+		problems = append(problems, Problem{
+			Message:  "mocked " + name,
+			Position: format.Definition(name).Position,
+		})
+	}
+
+	return problems
+}
+
+// check lints the source with the given rule and returns the messages of the found problems
+func check(t *testing.T, src string, rule string) []string {
+	problems, err := Lint(strings.NewReader(src), "", []string{rule})
+	Nil(t, err)
+
+	var messages []string
+	for _, problem := range problems {
+		Equal(t, rule, problem.Rule)
+
+		messages = append(messages, problem.Message)
+	}
+
+	return messages
+}
+
+func TestSeverity(t *testing.T) {
+	for _, name := range ListSeverities() {
+		severity, err := ParseSeverity(name)
+		Nil(t, err)
+		Equal(t, name, severity.String())
+	}
+
+	_, err := ParseSeverity("unknown")
+	NotNil(t, err)
+
+	True(t, SeverityInfo < SeverityWarning)
+	True(t, SeverityWarning < SeverityError)
+}
+
+func TestLint(t *testing.T) {
+	// mock is not registered
+	for _, name := range List() {
+		if name == "mock" {
+			Fail(t, "mock should not be in the lint rule list yet")
+		}
+	}
+
+	rule, err := New("mock")
+	Nil(t, rule)
+	NotNil(t, err)
+
+	_, err = Lint(strings.NewReader("START = 1\n"), "", []string{"mock"})
+	NotNil(t, err)
+
+	// register mock
+	Register("mock", func() Rule {
+		return &mockRule{}
+	})
+
+	rule, err = New("mock")
+	NotNil(t, rule)
+	Nil(t, err)
+
+	// problems are sorted by their positions
+	problems, err := Lint(strings.NewReader("A = 1\nSTART = A B\nB = 2\n"), "test.tavor", []string{"mock"})
+	Nil(t, err)
+	Equal(t, 3, len(problems))
+	Equal(t, "mocked A", problems[0].Message)
+	Equal(t, "mocked START", problems[1].Message)
+	Equal(t, "mocked B", problems[2].Message)
+	Equal(t, "test.tavor, L:2, C:1 - info: mocked START (mock)", problems[1].String())
+
+	// errors of invalid formats are problems of the parser
+	problems, err = Lint(strings.NewReader("START = 1 {endif}\nA = B\n"), "", []string{"mock"})
+	Nil(t, err)
+	Equal(t, []Problem{
+		{
+			Rule:     ParserRule,
+			Severity: SeverityError,
+			Message:  "endif without if",
+			Position: problems[0].Position,
+		},
+		{
+			Rule:     ParserRule,
+			Severity: SeverityError,
+			Message:  `token "A" declared but not used`,
+			Position: problems[1].Position,
+		},
+		{
+			Rule:     ParserRule,
+			Severity: SeverityError,
+			Message:  `token "B" is not defined`,
+			Position: problems[2].Position,
+		},
+	}, problems)
+	Equal(t, "L:1, C:12 - error: endif without if (Parser)", problems[0].String())
+
+	// all rules are applied by default
+	delete(ruleLookup, "mock")
+
+	problems, err = Lint(strings.NewReader("START = 1\n"), "", nil)
+	Nil(t, err)
+	Equal(t, 0, len(problems))
+}
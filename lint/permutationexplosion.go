@@ -0,0 +1,67 @@
+package lint
+
+import (
+	"fmt"
+)
+
+// DefaultMaxPermutations is the default maximum of permutations of a token definition
+const DefaultMaxPermutations = 1000000
+
+// PermutationExplosionRule implements a lint rule which finds token definitions with more permutations than a given maximum.
+// Such definitions make strategies like AllPermutations unusable. A definition is only reported if none of the definitions it uses is reported, since this is where the permutations explode.
+type PermutationExplosionRule struct {
+	// MaxPermutations holds the maximum of permutations of a token definition
+	MaxPermutations uint
+}
+
+// NewPermutationExplosionRule returns a new instance of the permutation explosion lint rule with the given maximum of permutations
+func NewPermutationExplosionRule(maxPermutations uint) *PermutationExplosionRule {
+	return &PermutationExplosionRule{
+		MaxPermutations: maxPermutations,
+	}
+}
+
+func init() {
+	Register("PermutationExplosion", func() Rule {
+		return NewPermutationExplosionRule(DefaultMaxPermutations)
+	})
+}
+
+// Severity returns the severity of the problems which are found by the rule
+func (r *PermutationExplosionRule) Severity() Severity {
+	return SeverityWarning
+}
+
+// Check checks the format and returns the found problems
+func (r *PermutationExplosionRule) Check(format *Format) []Problem {
+	var problems []Problem
+
+	exploded := make(map[string]uint)
+
+	for _, name := range format.definitionNames() {
+		if permutations := format.Definitions[name].PermutationsAll(); permutations > r.MaxPermutations {
+			exploded[name] = permutations
+		}
+	}
+
+NAMES:
+	for _, name := range format.definitionNames() {
+		permutations, ok := exploded[name]
+		if !ok {
+			continue
+		}
+
+		for _, use := range format.Uses(name) {
+			if _, ok := exploded[use]; ok && use != name {
+				continue NAMES
+			}
+		}
+
+		problems = append(problems, Problem{
+			Message:  fmt.Sprintf("token %q has %d permutations which are more than %d", name, permutations, r.MaxPermutations),
+			Position: format.Definition(name).Position,
+		})
+	}
+
+	return problems
+}
@@ -0,0 +1,32 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/parser"
+)
+
+func TestPermutationExplosion(t *testing.T) {
+	Equal(t, []string(nil), check(t, "START = +0,5([0-9])\n", "PermutationExplosion"))
+
+	// the problem is only reported for the definition which causes the explosion
+	Equal(t, []string{
+		`token "Big" has 1887739074256335303 permutations which are more than 1000000`,
+	}, check(t, "Big = +0,20([0-9])\nBigger = Big Big\nSTART = Bigger\n", "PermutationExplosion"))
+
+	// the maximum can be changed
+	src := "START = +0,5([0-9])\n"
+
+	_, definitions, err := parser.ParseTavorDefinitions(strings.NewReader(src), "")
+	Nil(t, err)
+
+	problems := NewPermutationExplosionRule(10).Check(&Format{
+		Symbols:     parser.Symbols(src, ""),
+		Definitions: definitions,
+	})
+	Equal(t, 1, len(problems))
+	Equal(t, `token "START" has 111111 permutations which are more than 10`, problems[0].Message)
+}
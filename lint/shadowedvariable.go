@@ -0,0 +1,47 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/zimmski/tavor/parser"
+)
+
+// ShadowedVariableRule implements a lint rule which finds token definitions that are shadowed by variables and parameters with the same name.
+// Variable names are preferred over token names so such a token cannot be used by its name in the token definition of the variable.
+type ShadowedVariableRule struct{}
+
+// NewShadowedVariableRule returns a new instance of the shadowed variable lint rule
+func NewShadowedVariableRule() *ShadowedVariableRule {
+	return &ShadowedVariableRule{}
+}
+
+func init() {
+	Register("ShadowedVariable", func() Rule {
+		return NewShadowedVariableRule()
+	})
+}
+
+// Severity returns the severity of the problems which are found by the rule
+func (r *ShadowedVariableRule) Severity() Severity {
+	return SeverityWarning
+}
+
+// Check checks the format and returns the found problems
+func (r *ShadowedVariableRule) Check(format *Format) []Problem {
+	var problems []Problem
+
+	for _, s := range format.Symbols {
+		if s.Kind != parser.SymbolVariable {
+			continue
+		}
+
+		if format.Definition(s.Name) != nil {
+			problems = append(problems, Problem{
+				Message:  fmt.Sprintf("token %q is shadowed by variable %q of token %q", s.Name, s.Name, s.Definition),
+				Position: s.Position,
+			})
+		}
+	}
+
+	return problems
+}
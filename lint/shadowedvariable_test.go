@@ -0,0 +1,20 @@
+package lint
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestShadowedVariable(t *testing.T) {
+	Equal(t, []string(nil), check(t, "START = 1<x> ${x.Value}\n", "ShadowedVariable"))
+
+	Equal(t, []string{
+		`token "A" is shadowed by variable "A" of token "START"`,
+	}, check(t, "A = 1\nSTART = A 2<A>\n", "ShadowedVariable"))
+
+	// the usage of A after the variable definition is the variable and not the token
+	Equal(t, []string{
+		`token "A" is shadowed by variable "A" of token "START"`,
+	}, check(t, "A = \"x\"\nB = A\nSTART = B 2<A> A\n", "ShadowedVariable"))
+}
@@ -0,0 +1,64 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/zimmski/tavor/parser"
+)
+
+// UnreachableDefinitionRule implements a lint rule which finds token definitions that are not reachable from the START token.
+// The parser only reports tokens which are not used at all. Tokens which are only used by each other or by other unreachable tokens are never generated.
+type UnreachableDefinitionRule struct{}
+
+// NewUnreachableDefinitionRule returns a new instance of the unreachable definition lint rule
+func NewUnreachableDefinitionRule() *UnreachableDefinitionRule {
+	return &UnreachableDefinitionRule{}
+}
+
+func init() {
+	Register("UnreachableDefinition", func() Rule {
+		return NewUnreachableDefinitionRule()
+	})
+}
+
+// Severity returns the severity of the problems which are found by the rule
+func (r *UnreachableDefinitionRule) Severity() Severity {
+	return SeverityWarning
+}
+
+// Check checks the format and returns the found problems
+func (r *UnreachableDefinitionRule) Check(format *Format) []Problem {
+	reachable := map[string]struct{}{
+		"START": {},
+	}
+	queue := []string{"START"}
+
+	for len(queue) != 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		for _, use := range format.Uses(name) {
+			if _, ok := reachable[use]; !ok {
+				reachable[use] = struct{}{}
+				queue = append(queue, use)
+			}
+		}
+	}
+
+	var problems []Problem
+
+	for _, s := range format.Symbols {
+		if s.Kind != parser.SymbolToken && s.Kind != parser.SymbolTypedToken {
+			continue
+		}
+
+		if _, ok := reachable[s.Name]; !ok {
+			problems = append(problems, Problem{
+				Message:  fmt.Sprintf("token %q is not reachable from START", s.Name),
+				Position: s.Position,
+			})
+		}
+	}
+
+	return problems
+}
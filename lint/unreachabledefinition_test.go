@@ -0,0 +1,16 @@
+package lint
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestUnreachableDefinition(t *testing.T) {
+	Equal(t, []string(nil), check(t, "A = 1\nB = A\nSTART = B\n", "UnreachableDefinition"))
+
+	Equal(t, []string{
+		`token "A" is not reachable from START`,
+		`token "B" is not reachable from START`,
+	}, check(t, "A = B\nB = A | 1\nSTART = 2\n", "UnreachableDefinition"))
+}
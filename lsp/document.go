@@ -0,0 +1,281 @@
+package lsp
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/zimmski/tavor/parser"
+	"github.com/zimmski/tavor/token"
+)
+
+// document holds an opened Tavor format file
+type document struct {
+	uri      string
+	filename string
+	text     string
+	// lines holds the offsets of the line beginnings
+	lines []int
+
+	symbols []parser.Symbol
+
+	// definitions holds the tokens of the named token definitions if the format is valid
+	definitions map[string]token.Token
+	// lastDefinitions holds the tokens of the named token definitions of the last valid version of the format
+	lastDefinitions map[string]token.Token
+
+	diagnostics []diagnostic
+}
+
+func newDocument(uri string, text string, lastDefinitions map[string]token.Token) *document {
+	d := &document{
+		uri:  uri,
+		text: text,
+
+		lastDefinitions: lastDefinitions,
+	}
+
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		d.filename = u.Path
+	}
+
+	d.lines = []int{0}
+	for i, c := range text {
+		if c == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+
+	d.symbols = parser.Symbols(text, d.filename)
+
+	d.parse()
+
+	return d
+}
+
+// parse parses the format of the document and sets its diagnostics and definitions
+func (d *document) parse() {
+	_, definitions, err := parser.ParseTavorDefinitions(strings.NewReader(d.text), d.filename)
+	if err == nil {
+		d.definitions = definitions
+		d.lastDefinitions = definitions
+		d.diagnostics = []diagnostic{}
+
+		return
+	}
+
+	errs := []error{err}
+	if perrs, ok := err.(token.ParserErrors); ok {
+		errs = perrs
+	}
+
+	d.diagnostics = []diagnostic{}
+
+	for _, err := range errs {
+		var r textRange
+		message := err.Error()
+
+		if perr, ok := err.(*token.ParserError); ok {
+			message = perr.Message
+
+			if perr.Position.Line > 0 && (perr.Position.Filename == "" || perr.Position.Filename == d.filename) {
+				r = d.wordRange(d.offsetOfColumn(perr.Position.Line-1, perr.Position.Column-1))
+			} else if perr.Position.Filename != "" {
+				message = perr.Error()
+			}
+		}
+
+		d.diagnostics = append(d.diagnostics, diagnostic{
+			Range:    r,
+			Severity: diagnosticSeverityError,
+			Source:   "tavor",
+			Message:  message,
+		})
+	}
+}
+
+// offsetOfColumn returns the offset of the given zero-based line and rune column
+func (d *document) offsetOfColumn(line int, column int) int {
+	if line >= len(d.lines) {
+		return len(d.text)
+	}
+
+	offset := d.lines[line]
+	for ; column > 0 && offset < len(d.text) && d.text[offset] != '\n'; column-- {
+		_, size := utf8.DecodeRuneInString(d.text[offset:])
+		offset += size
+	}
+
+	return offset
+}
+
+// offset returns the offset of the given position
+func (d *document) offset(pos position) int {
+	if pos.Line < 0 {
+		return 0
+	} else if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+
+	offset := d.lines[pos.Line]
+	for character := pos.Character; character > 0 && offset < len(d.text) && d.text[offset] != '\n'; {
+		c, size := utf8.DecodeRuneInString(d.text[offset:])
+		offset += size
+		character -= len(utf16.Encode([]rune{c}))
+	}
+
+	return offset
+}
+
+// position returns the position of the given offset
+func (d *document) position(offset int) position {
+	line := sort.Search(len(d.lines), func(i int) bool {
+		return d.lines[i] > offset
+	}) - 1
+
+	return position{
+		Line:      line,
+		Character: len(utf16.Encode([]rune(d.text[d.lines[line]:offset]))),
+	}
+}
+
+func (d *document) textRange(from int, to int) textRange {
+	return textRange{
+		Start: d.position(from),
+		End:   d.position(to),
+	}
+}
+
+// wordRange returns the range of the word or character beginning at the given offset
+func (d *document) wordRange(offset int) textRange {
+	end := offset
+	for end < len(d.text) {
+		c, size := utf8.DecodeRuneInString(d.text[end:])
+		if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			break
+		}
+
+		end += size
+	}
+	if end == offset && end < len(d.text) && d.text[end] != '\n' {
+		_, size := utf8.DecodeRuneInString(d.text[end:])
+		end += size
+	}
+
+	return d.textRange(offset, end)
+}
+
+func (d *document) location(s *parser.Symbol) location {
+	return location{
+		URI:   d.uri,
+		Range: d.textRange(s.Position.Offset, s.End()),
+	}
+}
+
+// symbolAt returns the symbol at the given offset or nil if there is none
+func (d *document) symbolAt(offset int) *parser.Symbol {
+	for i := range d.symbols {
+		s := &d.symbols[i]
+
+		if s.Position.Offset <= offset && offset <= s.End() {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// definitionAt returns the name of the token definition at the given offset
+func (d *document) definitionAt(offset int) string {
+	definition := ""
+
+	for _, s := range d.symbols {
+		if s.Position.Offset > offset {
+			break
+		}
+
+		definition = s.Definition
+	}
+
+	return definition
+}
+
+// declarations returns the declarations which are referenced by a name in the given token definition
+// Variables of the token definition are preferred over token names like the parser does. Token names are preferred over variables of other token definitions.
+func (d *document) declarations(name string, definition string) []*parser.Symbol {
+	var tokens, variables, otherVariables []*parser.Symbol
+
+	for i := range d.symbols {
+		s := &d.symbols[i]
+
+		if s.Name != name {
+			continue
+		}
+
+		switch s.Kind {
+		case parser.SymbolToken, parser.SymbolTypedToken:
+			tokens = append(tokens, s)
+		case parser.SymbolVariable:
+			if s.Definition == definition {
+				variables = append(variables, s)
+			} else {
+				otherVariables = append(otherVariables, s)
+			}
+		}
+	}
+
+	switch {
+	case len(variables) != 0:
+		return variables
+	case len(tokens) != 0:
+		return tokens
+	}
+
+	return otherVariables
+}
+
+// resolve returns the declarations of the given symbol
+func (d *document) resolve(s *parser.Symbol) []*parser.Symbol {
+	if s.Kind != parser.SymbolUsage {
+		return []*parser.Symbol{s}
+	}
+
+	return d.declarations(s.Name, s.Definition)
+}
+
+// references returns all symbols which reference the given declaration
+func (d *document) references(declaration *parser.Symbol, includeDeclaration bool) []*parser.Symbol {
+	var references []*parser.Symbol
+
+	for i := range d.symbols {
+		s := &d.symbols[i]
+
+		if s.Name != declaration.Name {
+			continue
+		}
+
+		if s.Kind != parser.SymbolUsage {
+			// all variable definitions with the same name in a token definition reference the same variable
+			if s == declaration || (s.Kind == parser.SymbolVariable && declaration.Kind == parser.SymbolVariable && s.Definition == declaration.Definition) {
+				if includeDeclaration {
+					references = append(references, s)
+				}
+			}
+
+			continue
+		}
+
+		for _, r := range d.resolve(s) {
+			if r == declaration || (r.Kind == parser.SymbolVariable && declaration.Kind == parser.SymbolVariable && r.Definition == declaration.Definition) {
+				references = append(references, s)
+
+				break
+			}
+		}
+	}
+
+	return references
+}
@@ -0,0 +1,115 @@
+package lsp
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/parser"
+)
+
+const testFormat = `$Number Int = from: 1,
+              to: 3
+
+Digit = [0-9] | Number
+Digits = +1,2(Digit)
+
+Quoted(X) = "\"" X "\""
+
+START = Digits<x> " " Quoted(Number) {if x.Count > 1}${x.Value}{endif} Digit
+`
+
+func TestDocument(t *testing.T) {
+	d := newDocument("file:///tmp/test.tavor", testFormat, nil)
+
+	Equal(t, "/tmp/test.tavor", d.filename)
+	Equal(t, []diagnostic{}, d.diagnostics)
+	NotNil(t, d.definitions)
+
+	// positions
+	for _, pos := range []position{
+		{0, 0},
+		{3, 5},
+		{9, 0},
+	} {
+		Equal(t, pos, d.position(d.offset(pos)))
+	}
+	Equal(t, 0, d.offset(position{-1, 0}))
+	Equal(t, len(testFormat), d.offset(position{100, 0}))
+
+	// declarations and references
+	usage := d.symbolAt(d.offset(position{8, 2}))
+	Equal(t, "START", usage.Name)
+
+	usage = d.symbolAt(d.offset(position{8, 71}))
+	Equal(t, "Digit", usage.Name)
+
+	declarations := d.resolve(usage)
+	Equal(t, 1, len(declarations))
+	Equal(t, position{3, 0}, d.position(declarations[0].Position.Offset))
+
+	references := d.references(declarations[0], false)
+	Equal(t, 2, len(references))
+	Equal(t, 3, len(d.references(declarations[0], true)))
+
+	usage = d.symbolAt(d.offset(position{8, 41}))
+	Equal(t, "x", usage.Name)
+
+	declarations = d.resolve(usage)
+	Equal(t, 1, len(declarations))
+	Equal(t, parser.SymbolVariable, declarations[0].Kind)
+	Equal(t, 2, len(d.references(declarations[0], false)))
+
+	// unknown names have no declarations
+	Equal(t, 0, len(d.resolve(&parser.Symbol{Kind: parser.SymbolUsage, Name: "Unknown", Definition: "START"})))
+	Nil(t, d.symbolAt(d.offset(position{8, 19})))
+
+	// keywords are no symbols
+	Nil(t, d.symbolAt(d.offset(position{8, 38})))
+
+	// invalid formats have diagnostics but their symbols are still indexed
+	d = newDocument("file:///tmp/test.tavor", "A = 1\nSTART = B\n", d.lastDefinitions)
+	Nil(t, d.definitions)
+	NotNil(t, d.lastDefinitions)
+	Equal(t, []diagnostic{
+		{
+			Range:    textRange{Start: position{0, 0}, End: position{0, 1}},
+			Severity: diagnosticSeverityError,
+			Source:   "tavor",
+			Message:  `token "A" declared but not used`,
+		},
+		{
+			Range:    textRange{Start: position{1, 8}, End: position{1, 9}},
+			Severity: diagnosticSeverityError,
+			Source:   "tavor",
+			Message:  `token "B" is not defined`,
+		},
+	}, d.diagnostics)
+	Equal(t, 3, len(d.symbols))
+
+	// all errors of a format are reported
+	d = newDocument("untitled:1", "START = {endif}\nA = (\n", nil)
+	Equal(t, "", d.filename)
+	Equal(t, 2, len(d.diagnostics))
+	Equal(t, position{0, 9}, d.diagnostics[0].Range.Start)
+	Equal(t, position{1, 5}, d.diagnostics[1].Range.Start)
+
+	// variables of the token definition shadow tokens with the same name
+	d = newDocument("untitled:1", "A = \"x\"\nB = A\nSTART = B 2<A> A\n", nil)
+	Equal(t, 0, len(d.diagnostics))
+
+	declarations = d.resolve(d.symbolAt(d.offset(position{2, 15})))
+	Equal(t, 1, len(declarations))
+	Equal(t, parser.SymbolVariable, declarations[0].Kind)
+	Equal(t, position{2, 12}, d.position(declarations[0].Position.Offset))
+
+	declarations = d.resolve(d.symbolAt(d.offset(position{1, 4})))
+	Equal(t, 1, len(declarations))
+	Equal(t, parser.SymbolToken, declarations[0].Kind)
+	Equal(t, 2, len(d.references(declarations[0], true)))
+
+	// formats which the parser cannot unroll are reported
+	d = newDocument("untitled:1", "A = \"a\" A\nSTART = A [9-0]\n", nil)
+	Nil(t, d.definitions)
+	Equal(t, 1, len(d.diagnostics))
+}
@@ -0,0 +1,462 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/zimmski/tavor"
+	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/parser"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/variables"
+)
+
+// ErrExitWithoutShutdown is returned by Serve if the client exits the server without shutting it down first
+var ErrExitWithoutShutdown = errors.New("exit without shutdown")
+
+// Server implements a language server for Tavor format files which communicates using the Language Server Protocol
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	documents map[string]*document
+
+	shutdown bool
+}
+
+// NewServer returns a new language server which reads messages from in and writes messages to out
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:  bufio.NewReader(in),
+		out: out,
+
+		documents: make(map[string]*document),
+	}
+}
+
+// Serve handles messages until the client exits the server
+// The error return argument is not nil if the communication fails or if the client exits without shutting down the server first.
+func (s *Server) Serve() error {
+	for {
+		data, err := s.read()
+		if err != nil {
+			return err
+		}
+
+		var m message
+		if err := json.Unmarshal(data, &m); err != nil {
+			if err := s.respondError(nil, errorParse, err.Error()); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if m.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+
+			return nil
+		}
+
+		result, rerr := s.handle(&m)
+
+		// notifications are not answered
+		if m.ID == nil {
+			if rerr != nil {
+				log.Infof("cannot handle notification %q: %s", m.Method, rerr.Message)
+			}
+
+			continue
+		}
+
+		if rerr != nil {
+			err = s.respondError(m.ID, rerr.Code, rerr.Message)
+		} else {
+			err = s.respond(m.ID, result)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// read reads the content of the next message
+func (s *Server) read() ([]byte, error) {
+	header, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %v", err)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(s.in, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *Server) write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(data), data)
+
+	return err
+}
+
+func (s *Server) respond(id *json.RawMessage, result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	return s.write(&response{
+		JSONRPC: jsonrpcVersion,
+		ID:      id,
+		Result:  data,
+	})
+}
+
+func (s *Server) respondError(id *json.RawMessage, code int, message string) error {
+	return s.write(&response{
+		JSONRPC: jsonrpcVersion,
+		ID:      id,
+		Error: &responseError{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return s.write(&notification{
+		JSONRPC: jsonrpcVersion,
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// handle handles a request or notification and returns its result
+func (s *Server) handle(m *message) (interface{}, *responseError) {
+	unmarshal := func(v interface{}) *responseError {
+		if err := json.Unmarshal(m.Params, v); err != nil {
+			return &responseError{
+				Code:    errorInvalidParams,
+				Message: err.Error(),
+			}
+		}
+
+		return nil
+	}
+
+	switch m.Method {
+	case "initialize":
+		return &initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:   textDocumentSyncFull,
+				DefinitionProvider: true,
+				ReferencesProvider: true,
+				HoverProvider:      true,
+				CompletionProvider: completionOptions{
+					TriggerCharacters: []string{"$", "."},
+				},
+			},
+			ServerInfo: serverInfo{
+				Name:    "tavor",
+				Version: tavor.Version,
+			},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenTextDocumentParams
+		if err := unmarshal(&params); err != nil {
+			return nil, err
+		}
+
+		return nil, s.open(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeTextDocumentParams
+		if err := unmarshal(&params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+
+		return nil, s.open(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		var params didCloseTextDocumentParams
+		if err := unmarshal(&params); err != nil {
+			return nil, err
+		}
+
+		delete(s.documents, params.TextDocument.URI)
+
+		return nil, s.publishDiagnostics(params.TextDocument.URI, []diagnostic{})
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := unmarshal(&params); err != nil {
+			return nil, err
+		}
+
+		d, sym, err := s.symbolAt(&params)
+		if err != nil || sym == nil {
+			return nil, err
+		}
+
+		locations := []location{}
+		for _, decl := range d.resolve(sym) {
+			locations = append(locations, d.location(decl))
+		}
+
+		return locations, nil
+	case "textDocument/references":
+		var params referenceParams
+		if err := unmarshal(&params); err != nil {
+			return nil, err
+		}
+
+		d, sym, err := s.symbolAt(&params.textDocumentPositionParams)
+		if err != nil || sym == nil {
+			return nil, err
+		}
+
+		locations := []location{}
+		for _, decl := range d.resolve(sym) {
+			for _, ref := range d.references(decl, params.Context.IncludeDeclaration) {
+				locations = append(locations, d.location(ref))
+			}
+		}
+
+		return locations, nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := unmarshal(&params); err != nil {
+			return nil, err
+		}
+
+		d, sym, err := s.symbolAt(&params)
+		if err != nil || sym == nil {
+			return nil, err
+		}
+
+		return d.hover(sym), nil
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := unmarshal(&params); err != nil {
+			return nil, err
+		}
+
+		d, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.completion(d.offset(params.Position)), nil
+	}
+
+	return nil, &responseError{
+		Code:    errorMethodNotFound,
+		Message: fmt.Sprintf("method %q is not supported", m.Method),
+	}
+}
+
+// open parses the given content of the document and publishes its diagnostics
+func (s *Server) open(uri string, text string) *responseError {
+	var lastDefinitions map[string]token.Token
+	if d, ok := s.documents[uri]; ok {
+		lastDefinitions = d.lastDefinitions
+	}
+
+	d := newDocument(uri, text, lastDefinitions)
+	s.documents[uri] = d
+
+	return s.publishDiagnostics(uri, d.diagnostics)
+}
+
+func (s *Server) publishDiagnostics(uri string, diagnostics []diagnostic) *responseError {
+	err := s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diagnostics,
+	})
+	if err != nil {
+		return &responseError{
+			Code:    errorInvalidRequest,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) document(uri string) (*document, *responseError) {
+	d, ok := s.documents[uri]
+	if !ok {
+		return nil, &responseError{
+			Code:    errorInvalidParams,
+			Message: fmt.Sprintf("document %q is not opened", uri),
+		}
+	}
+
+	return d, nil
+}
+
+func (s *Server) symbolAt(params *textDocumentPositionParams) (*document, *parser.Symbol, *responseError) {
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return d, d.symbolAt(d.offset(params.Position)), nil
+}
+
+// hover returns the description of the declaration of the symbol
+func (d *document) hover(sym *parser.Symbol) *hover {
+	declarations := d.resolve(sym)
+	if len(declarations) == 0 {
+		return nil
+	}
+
+	decl := declarations[0]
+
+	var content string
+
+	switch decl.Kind {
+	case parser.SymbolToken:
+		content = fmt.Sprintf("**%s** token", decl.Name)
+	case parser.SymbolTypedToken:
+		content = fmt.Sprintf("**%s** typed token of type `%s`", decl.Name, decl.Type)
+	case parser.SymbolVariable:
+		content = fmt.Sprintf("**%s** variable of token `%s`", decl.Name, decl.Definition)
+	}
+
+	if decl.Kind != parser.SymbolVariable && d.definitions != nil {
+		if tok, ok := d.definitions[decl.Name]; ok {
+			content += fmt.Sprintf("\n\nPermutations: %d", tok.PermutationsAll())
+		}
+	}
+
+	return &hover{
+		Contents: markupContent{
+			Kind:  "markdown",
+			Value: content,
+		},
+		Range: d.textRange(sym.Position.Offset, sym.End()),
+	}
+}
+
+var (
+	// completionTypedToken matches the type of a typed token definition
+	completionTypedToken = regexp.MustCompile(`^\s*\$[A-Za-z_]\w*\s+\w*$`)
+	// completionTokenAttribute matches a token attribute and returns the token name
+	completionTokenAttribute = regexp.MustCompile(`([A-Za-z_]\w*)\.\w*$`)
+)
+
+// completion returns the completion items for the given offset
+// Types of typed tokens are proposed in typed token definitions, token attributes after a token name and a dot, and otherwise the names of tokens and variables.
+func (d *document) completion(offset int) []completionItem {
+	line := d.text[d.lines[d.position(offset).Line]:offset]
+
+	items := []completionItem{}
+
+	if completionTypedToken.MatchString(line) {
+		for _, name := range token.ListTyped() {
+			items = append(items, completionItem{
+				Label: name,
+				Kind:  completionItemKindClass,
+			})
+		}
+
+		return items
+	}
+
+	definition := d.definitionAt(offset)
+
+	if m := completionTokenAttribute.FindStringSubmatch(line); m != nil {
+		for _, decl := range d.declarations(m[1], definition) {
+			var attributes []string
+
+			switch decl.Kind {
+			case parser.SymbolVariable:
+				attributes = parser.TokenAttributes(variables.NewVariable(decl.Name, nil))
+			default:
+				// the last valid format is used since the format is most likely invalid while an attribute is typed
+				if tok, ok := d.lastDefinitions[decl.Name]; ok {
+					attributes = parser.TokenAttributes(tok)
+				}
+			}
+
+			for _, attribute := range attributes {
+				items = append(items, completionItem{
+					Label: attribute,
+					Kind:  completionItemKindProperty,
+				})
+			}
+
+			break
+		}
+
+		return items
+	}
+
+	names := make(map[string]completionItem)
+
+	for _, s := range d.symbols {
+		switch s.Kind {
+		case parser.SymbolToken:
+			names[s.Name] = completionItem{
+				Label:  s.Name,
+				Kind:   completionItemKindReference,
+				Detail: "token",
+			}
+		case parser.SymbolTypedToken:
+			names[s.Name] = completionItem{
+				Label:  s.Name,
+				Kind:   completionItemKindReference,
+				Detail: s.Type,
+			}
+		case parser.SymbolVariable:
+			if _, ok := names[s.Name]; !ok && s.Definition == definition {
+				names[s.Name] = completionItem{
+					Label:  s.Name,
+					Kind:   completionItemKindVariable,
+					Detail: "variable",
+				}
+			}
+		}
+	}
+
+	// the START token cannot be used
+	delete(names, "START")
+
+	for _, item := range names {
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+
+	return items
+}
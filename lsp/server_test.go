@@ -0,0 +1,166 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func writeMessage(t *testing.T, w io.Writer, id int, method string, params interface{}) {
+	m := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+	}
+	if id != 0 {
+		m["id"] = id
+	}
+	if params != nil {
+		m["params"] = params
+	}
+
+	data, err := json.Marshal(m)
+	Nil(t, err)
+
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	Nil(t, err)
+}
+
+func readMessages(t *testing.T, r io.Reader) []map[string]interface{} {
+	var messages []map[string]interface{}
+
+	in := bufio.NewReader(r)
+
+	for {
+		header, err := textproto.NewReader(in).ReadMIMEHeader()
+		if err == io.EOF {
+			return messages
+		}
+		Nil(t, err)
+
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		Nil(t, err)
+
+		data := make([]byte, length)
+		_, err = io.ReadFull(in, data)
+		Nil(t, err)
+
+		var m map[string]interface{}
+		Nil(t, json.Unmarshal(data, &m))
+
+		messages = append(messages, m)
+	}
+}
+
+func TestServer(t *testing.T) {
+	uri := "file:///tmp/test.tavor"
+	document := map[string]interface{}{
+		"uri": uri,
+	}
+	at := func(line int, character int) map[string]interface{} {
+		return map[string]interface{}{
+			"textDocument": document,
+			"position": map[string]interface{}{
+				"line":      line,
+				"character": character,
+			},
+		}
+	}
+
+	var in bytes.Buffer
+
+	writeMessage(t, &in, 1, "initialize", map[string]interface{}{})
+	writeMessage(t, &in, 0, "initialized", map[string]interface{}{})
+	writeMessage(t, &in, 0, "textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":  uri,
+			"text": testFormat,
+		},
+	})
+	writeMessage(t, &in, 2, "textDocument/definition", at(8, 71))
+	references := at(4, 14)
+	references["context"] = map[string]interface{}{
+		"includeDeclaration": true,
+	}
+	writeMessage(t, &in, 3, "textDocument/references", references)
+	writeMessage(t, &in, 4, "textDocument/hover", at(8, 9))
+	for _, text := range []string{
+		"$Id Sequence\nSTART = $Id.Next\n",
+		"$Id Sequence\n$Number \nSTART = $Id.\n",
+	} {
+		writeMessage(t, &in, 0, "textDocument/didChange", map[string]interface{}{
+			"textDocument": document,
+			"contentChanges": []interface{}{
+				map[string]interface{}{
+					"text": text,
+				},
+			},
+		})
+	}
+	writeMessage(t, &in, 6, "textDocument/completion", at(2, 12))
+	writeMessage(t, &in, 7, "textDocument/completion", at(1, 8))
+	writeMessage(t, &in, 8, "unknown", nil)
+	writeMessage(t, &in, 9, "shutdown", nil)
+	writeMessage(t, &in, 0, "exit", nil)
+
+	var out bytes.Buffer
+
+	Nil(t, NewServer(&in, &out).Serve())
+
+	messages := readMessages(t, &out)
+	Equal(t, 11, len(messages))
+
+	// initialize
+	Equal(t, float64(1), messages[0]["id"])
+	capabilities := messages[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	Equal(t, true, capabilities["hoverProvider"])
+
+	// diagnostics of the opened document
+	Equal(t, "textDocument/publishDiagnostics", messages[1]["method"])
+	Equal(t, []interface{}{}, messages[1]["params"].(map[string]interface{})["diagnostics"])
+
+	// definition
+	Equal(t, []interface{}{
+		map[string]interface{}{
+			"uri": uri,
+			"range": map[string]interface{}{
+				"start": map[string]interface{}{"line": float64(3), "character": float64(0)},
+				"end":   map[string]interface{}{"line": float64(3), "character": float64(5)},
+			},
+		},
+	}, messages[2]["result"])
+
+	// references
+	Equal(t, 3, len(messages[3]["result"].([]interface{})))
+
+	// hover
+	Equal(t, "**Digits** token\n\nPermutations: 182", messages[4]["result"].(map[string]interface{})["contents"].(map[string]interface{})["value"])
+
+	// the changed document is valid and then invalid
+	Equal(t, []interface{}{}, messages[5]["params"].(map[string]interface{})["diagnostics"])
+	Equal(t, "textDocument/publishDiagnostics", messages[6]["method"])
+	Equal(t, 2, len(messages[6]["params"].(map[string]interface{})["diagnostics"].([]interface{})))
+
+	// attributes of the last valid format and types of typed tokens
+	Equal(t, "Existing", messages[7]["result"].([]interface{})[0].(map[string]interface{})["label"])
+	NotEqual(t, 0, len(messages[8]["result"].([]interface{})))
+
+	// unknown methods
+	Equal(t, float64(errorMethodNotFound), messages[9]["error"].(map[string]interface{})["code"])
+
+	// shutdown
+	Equal(t, nil, messages[10]["result"])
+	_, ok := messages[10]["result"]
+	True(t, ok)
+
+	// exit without shutdown
+	in.Reset()
+	writeMessage(t, &in, 0, "exit", nil)
+	Equal(t, ErrExitWithoutShutdown, NewServer(&in, &out).Serve())
+}
@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	fuzzStrategy "github.com/zimmski/tavor/fuzz/strategy"
+	reduceStrategy "github.com/zimmski/tavor/reduce/strategy"
+)
+
+// unvalidatableExamples identifies examples of the format documentation whose generations cannot be validated since values depend on tokens which are not part of the data or which only follow later in the data
+var unvalidatableExamples = []string{
+	"Outer.1.Print: ",             // counts of lists which follow later
+	"START = ${Number.Value + 1}", // embedded token which is not part of the data
+	"${Id.Existing not in (id)}",  // existing sequence values which follow later
+}
+
+// TestTavorFormatDocumentation checks that every format example of the format documentation can be generated, validated and reduced
+func TestTavorFormatDocumentation(t *testing.T) {
+	doc, err := ioutil.ReadFile("../doc/format.md")
+	Nil(t, err)
+
+	examples := regexp.MustCompile("(?s)\n```tavor\n(.*?)```").FindAllStringSubmatch(string(doc), -1)
+	True(t, len(examples) > 50)
+
+	for _, example := range examples {
+		format := example[1]
+
+		// examples without a START token are only fragments and examples with includes or dictionaries need additional files
+		if !strings.Contains(format, "START") || strings.Contains(format, "include") || strings.Contains(format, "Dictionary") {
+			continue
+		}
+
+		skip := false
+		for _, s := range unvalidatableExamples {
+			if strings.Contains(format, s) {
+				skip = true
+			}
+		}
+		if skip {
+			continue
+		}
+
+		for seed := int64(0); seed < 5; seed++ {
+			root, err := ParseTavor(strings.NewReader(format))
+			Nil(t, err, format)
+			if err != nil {
+				break
+			}
+
+			strat := fuzzStrategy.NewRandomStrategy(root)
+			ch, err := strat.Fuzz(rand.New(rand.NewSource(seed)))
+			Nil(t, err)
+
+			var generated string
+			for i := range ch {
+				generated = root.String()
+
+				ch <- i
+			}
+
+			// validate
+			root, err = ParseTavor(strings.NewReader(format))
+			Nil(t, err)
+
+			errs := ParseInternal(root, strings.NewReader(generated))
+			Nil(t, errs, "validate %q of\n%s", generated, format)
+			if errs != nil {
+				break
+			}
+			Equal(t, generated, root.String(), format)
+
+			// reduce
+			reducer := reduceStrategy.NewLinear(root)
+			contin, feedback, err := reducer.Reduce()
+			Nil(t, err)
+
+			steps := 0
+			for i := range contin {
+				reduced := root.String()
+
+				check, err := ParseTavor(strings.NewReader(format))
+				Nil(t, err)
+
+				errs := ParseInternal(check, strings.NewReader(reduced))
+				Nil(t, errs, "reduced %q of %q of\n%s", reduced, generated, format)
+
+				steps++
+				if steps == 100 {
+					close(feedback)
+
+					break
+				}
+
+				feedback <- reduceStrategy.Bad
+				contin <- i
+			}
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package parser
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/zimmski/tavor/test/assert"
 
@@ -154,6 +157,15 @@ func TestInternalParse(t *testing.T) {
 	errs = ParseInternal(o, strings.NewReader(""))
 	Nil(t, errs)
 
+	checkParse(
+		t,
+		lists.NewAll(
+			primitives.NewCharacterClass(`\x{e4}-\x{f6}`),
+			primitives.NewCharacterClass(`a`),
+		),
+		"\u00f6a",
+	)
+
 	// All
 	checkParse(
 		t,
@@ -298,6 +310,29 @@ func TestInternalParse(t *testing.T) {
 		"122222",
 	)
 
+	// binary integers
+	checkParse(
+		t,
+		primitives.NewBinaryInt(2, binary.LittleEndian, 0, 1000),
+		"\xe8\x03",
+	)
+	checkParse(
+		t,
+		lists.NewAll(
+			primitives.NewConstantString("\xca\xfe"),
+			primitives.NewBinaryInt(4, binary.BigEndian, 0, math.MaxUint32),
+		),
+		"\xca\xfe\x00\x00\x01\x00",
+	)
+
+	errs = ParseInternal(primitives.NewBinaryInt(2, binary.BigEndian, 0, 10), strings.NewReader("\x00"))
+	Equal(t, len(errs), 1)
+	Equal(t, token.ParseErrorUnexpectedEOF, errs[0].(*token.ParserError).Type)
+
+	errs = ParseInternal(primitives.NewBinaryInt(2, binary.BigEndian, 0, 10), strings.NewReader("\x00\x0b"))
+	Equal(t, len(errs), 1)
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+
 	// complex repeat
 	o = lists.NewAll(
 		primitives.NewConstantInt(1),
@@ -314,3 +349,49 @@ func TestInternalParse(t *testing.T) {
 		"13232323232323333323232224",
 	)
 }
+
+func TestInternalParseVariablesInRepeats(t *testing.T) {
+	for _, src := range []string{
+		"$N Int = to: 10\nLine = N<t> \" \" ${t.Value} \"\\n\"\nSTART = +2(Line)\n",
+		"$N Int = to: 10\nLine = N<=t> ${t.Value} \" \" ${t.Value} \"\\n\"\nSTART = +2(Line)\n",
+	} {
+		o, err := ParseTavor(strings.NewReader(src))
+		Nil(t, err)
+
+		checkParse(
+			t,
+			o,
+			"3 3\n5 5\n",
+		)
+
+		errs := ParseInternal(o, strings.NewReader("3 3\n5 3\n"))
+		Equal(t, 1, len(errs), src)
+	}
+}
+
+func TestInternalParseDeeplyNestedAlternatives(t *testing.T) {
+	depth := 30
+
+	var o token.Token = primitives.NewConstantString("c")
+	for i := 0; i < depth; i++ {
+		o = lists.NewOne(
+			lists.NewAll(
+				primitives.NewConstantString("a"),
+				o,
+				primitives.NewConstantString("b"),
+			),
+			primitives.NewConstantString("c"),
+		)
+	}
+
+	start := time.Now()
+
+	checkParse(
+		t,
+		o,
+		strings.Repeat("a", depth)+"c"+strings.Repeat("b", depth),
+	)
+
+	// every alternative must be parsed only once or the parsing takes exponential time
+	True(t, time.Since(start) < time.Second, time.Since(start))
+}
@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"strings"
+	"text/scanner"
+)
+
+// SymbolKind the kind of a symbol
+type SymbolKind int
+
+const (
+	// SymbolUsage a token or variable name is used
+	SymbolUsage SymbolKind = iota
+	// SymbolToken a token is defined
+	SymbolToken
+	// SymbolTypedToken a typed token is defined
+	SymbolTypedToken
+	// SymbolVariable a variable or a parameter of a parameterized token is defined
+	SymbolVariable
+)
+
+// Symbol is the occurrence of a token or variable name in a Tavor format source
+type Symbol struct {
+	Kind SymbolKind
+	Name string
+	// Type is the type of a typed token definition
+	Type string
+
+	Position scanner.Position
+	// Definition is the name of the token definition the symbol occurs in
+	Definition string
+}
+
+// End returns the offset after the name of the symbol
+func (s *Symbol) End() int {
+	return s.Position.Offset + len(s.Name)
+}
+
+// statementKeywords holds the keywords which begin a statement outside of token definitions
+var statementKeywords = map[string]bool{
+	"arithmetic": true,
+	"encoding":   true,
+	"include":    true,
+	"loop":       true,
+}
+
+// braceKeywords holds the keywords of statements and expressions in braces
+var braceKeywords = map[string]bool{
+	"and":     true,
+	"by":      true,
+	"connect": true,
+	"defined": true,
+	"else":    true,
+	"endif":   true,
+	"from":    true,
+	"if":      true,
+	"in":      true,
+	"not":     true,
+	"or":      true,
+	"over":    true,
+	"path":    true,
+	"without": true,
+}
+
+type lexeme struct {
+	tok      rune
+	text     string
+	position scanner.Position
+}
+
+// scan returns the lexemes of a Tavor format source like they are scanned by the parser
+func scan(src string, filename string) []lexeme {
+	var s scanner.Scanner
+	s.Init(strings.NewReader(src))
+	s.Filename = filename
+	s.Whitespace = 1<<'\t' | 1<<' ' | 1<<'\r'
+	s.Error = func(s *scanner.Scanner, msg string) {}
+
+	var lexemes []lexeme
+
+	for c := s.Scan(); c != scanner.EOF; c = s.Scan() {
+		lexemes = append(lexemes, lexeme{
+			tok:      c,
+			text:     s.TokenText(),
+			position: s.Position,
+		})
+	}
+
+	return lexemes
+}
+
+// Symbols returns the symbols of a Tavor format source in their order of occurrence.
+// The symbols are found without parsing the source so they are also available for invalid formats. The filename is used for the positions of the symbols and can be empty.
+func Symbols(src string, filename string) []Symbol {
+	lexemes := scan(src, filename)
+
+	var symbols []Symbol
+
+	is := func(i int, tok rune) bool {
+		return i < len(lexemes) && lexemes[i].tok == tok
+	}
+	add := func(kind SymbolKind, i int, definition string) {
+		symbols = append(symbols, Symbol{
+			Kind:       kind,
+			Name:       lexemes[i].text,
+			Position:   lexemes[i].position,
+			Definition: definition,
+		})
+	}
+
+	definition := ""
+	braces := 0
+
+	for i := 0; i < len(lexemes); i++ {
+		l := lexemes[i]
+		lineStart := i == 0 || lexemes[i-1].tok == '\n'
+
+		switch {
+		case lineStart && l.tok == scanner.Ident && is(i+1, '=') && !is(i+2, '='):
+			definition = l.text
+			braces = 0
+
+			add(SymbolToken, i, definition)
+
+			i++
+		case lineStart && l.tok == scanner.Ident && is(i+1, '('):
+			// parameterized token definitions declare their parameters as variables
+			end := i + 2
+			for end < len(lexemes) && lexemes[end].tok != ')' && lexemes[end].tok != '\n' {
+				end++
+			}
+
+			if !is(end, ')') || !is(end+1, '=') {
+				add(SymbolUsage, i, definition)
+
+				continue
+			}
+
+			definition = l.text
+			braces = 0
+
+			add(SymbolToken, i, definition)
+
+			for j := i + 2; j < end; j++ {
+				if lexemes[j].tok == scanner.Ident {
+					add(SymbolVariable, j, definition)
+				}
+			}
+
+			i = end + 1
+		case lineStart && l.tok == '$' && is(i+1, scanner.Ident) && is(i+2, scanner.Ident):
+			definition = lexemes[i+1].text
+			braces = 0
+
+			add(SymbolTypedToken, i+1, definition)
+			symbols[len(symbols)-1].Type = lexemes[i+2].text
+
+			i += 2
+		case lineStart && l.tok == scanner.Ident && statementKeywords[l.text]:
+			definition = ""
+			braces = 0
+		case l.tok == scanner.Ident:
+			switch {
+			case i > 0 && lexemes[i-1].tok == '.':
+				// token attribute
+			case braces > 0 && braceKeywords[l.text]:
+				// keyword of a statement or expression
+			case is(i+1, ':') && definition != "" && lexemes[i+1].position.Offset == l.position.Offset+len(l.text):
+				// argument of a typed token definition
+			default:
+				add(SymbolUsage, i, definition)
+			}
+		case l.tok == '{':
+			braces++
+		case l.tok == '}':
+			if braces > 0 {
+				braces--
+			}
+		case braces == 0 && l.tok == '[':
+			// character classes and lists of arguments hold no names
+			for i+1 < len(lexemes) && lexemes[i+1].tok != ']' && lexemes[i+1].tok != '\n' {
+				i++
+			}
+		case braces == 0 && l.tok == '<':
+			// variable definitions "<name>" and "<=name>"
+			j := i + 1
+			if is(j, '=') {
+				j++
+			}
+
+			if is(j, scanner.Ident) && is(j+1, '>') {
+				add(SymbolVariable, j, definition)
+
+				i = j + 1
+			}
+		}
+	}
+
+	return symbols
+}
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"testing"
+	"text/scanner"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestSymbols(t *testing.T) {
+	testFormat := `$Number Int = from: 1,
+              to: 3
+
+Digit = [0-9] | Number
+Digits = +1,2(Digit)
+
+Quoted(X) = "\"" X "\""
+
+START = Digits<x> " " Quoted(Number) {if x.Count > 1}${x.Value}{endif} Digit
+`
+
+	var symbols []Symbol
+	for _, s := range Symbols(testFormat, "") {
+		s.Position = scanner.Position{}
+
+		symbols = append(symbols, s)
+	}
+
+	Equal(t, []Symbol{
+		{Kind: SymbolTypedToken, Name: "Number", Type: "Int", Definition: "Number"},
+		{Kind: SymbolToken, Name: "Digit", Definition: "Digit"},
+		{Kind: SymbolUsage, Name: "Number", Definition: "Digit"},
+		{Kind: SymbolToken, Name: "Digits", Definition: "Digits"},
+		{Kind: SymbolUsage, Name: "Digit", Definition: "Digits"},
+		{Kind: SymbolToken, Name: "Quoted", Definition: "Quoted"},
+		{Kind: SymbolVariable, Name: "X", Definition: "Quoted"},
+		{Kind: SymbolUsage, Name: "X", Definition: "Quoted"},
+		{Kind: SymbolToken, Name: "START", Definition: "START"},
+		{Kind: SymbolUsage, Name: "Digits", Definition: "START"},
+		{Kind: SymbolVariable, Name: "x", Definition: "START"},
+		{Kind: SymbolUsage, Name: "Quoted", Definition: "START"},
+		{Kind: SymbolUsage, Name: "Number", Definition: "START"},
+		{Kind: SymbolUsage, Name: "x", Definition: "START"},
+		{Kind: SymbolUsage, Name: "x", Definition: "START"},
+		{Kind: SymbolUsage, Name: "Digit", Definition: "START"},
+	}, symbols)
+
+	// keywords of statements and expressions are no symbols
+	symbols = Symbols("A = 1\nSTART = A<x> {if defined x and not x.Value in (1, 2) or x.Value == 3}{else if A}{else}{endif}\n", "")
+	for _, s := range symbols {
+		False(t, braceKeywords[s.Name], s.Name)
+	}
+	Equal(t, 8, len(symbols))
+
+	// positions of the symbols
+	symbols = Symbols("A = 1\nSTART = A\n", "test.tavor")
+	Equal(t, scanner.Position{Filename: "test.tavor", Offset: 14, Line: 2, Column: 9}, symbols[2].Position)
+	Equal(t, 15, symbols[2].End())
+}
@@ -2,11 +2,19 @@ package parser
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"text/scanner"
+	"unicode"
 
 	"github.com/zimmski/container/list/linkedlist"
 
@@ -16,10 +24,15 @@ import (
 	"github.com/zimmski/tavor/token/aggregates"
 	"github.com/zimmski/tavor/token/conditions"
 	"github.com/zimmski/tavor/token/constraints"
+	"github.com/zimmski/tavor/token/dictionaries"
+	"github.com/zimmski/tavor/token/encodings"
 	"github.com/zimmski/tavor/token/expressions"
+	_ "github.com/zimmski/tavor/token/graphs"
 	"github.com/zimmski/tavor/token/lists"
 	"github.com/zimmski/tavor/token/primitives"
+	_ "github.com/zimmski/tavor/token/regexes"
 	"github.com/zimmski/tavor/token/sequences"
+	"github.com/zimmski/tavor/token/sets"
 	"github.com/zimmski/tavor/token/variables"
 )
 
@@ -30,6 +43,8 @@ type tokenUsage struct {
 	position       scanner.Position
 	variableScope  *token.VariableScope
 	definitionName string
+	prefix         string
+	included       bool
 }
 
 type attributeForwardUsage struct {
@@ -42,6 +57,7 @@ type attributeForwardUsage struct {
 	operatorToken     token.Token
 	pointer           *primitives.Pointer
 	variableScope     *token.VariableScope
+	prefix            string
 }
 
 type call struct {
@@ -49,20 +65,98 @@ type call struct {
 	variableScope *token.VariableScope
 }
 
+type include struct {
+	filename string
+	prefix   string
+}
+
+type encodingSetting struct {
+	encoding   encodings.Encoding
+	injections bool
+}
+
+type loopDeclaration struct {
+	loop     *primitives.Loop
+	position scanner.Position
+}
+
+type parameterizedDefinition struct {
+	parameters    []string
+	body          []byte
+	position      scanner.Position
+	directory     string
+	prefix        string
+	included      bool
+	variableScope *token.VariableScope
+}
+
+type parameterizedCall struct {
+	name           string
+	arguments      []token.Token
+	pointer        *primitives.Pointer
+	position       scanner.Position
+	definitionName string
+	expansions     []string
+}
+
+// argument binds a token to a parameter of a parameterized token definition
+type argument struct {
+	token.Token
+
+	used bool
+}
+
+func (a *argument) get() token.Token {
+	if !a.used {
+		a.used = true
+
+		return a.Token
+	}
+
+	return a.Token.Clone()
+}
+
 type tavorParser struct {
-	scan scanner.Scanner
+	scan *scanner.Scanner
+	src  []byte
 
 	err string
 
+	directory    string
+	prefix       string
+	includes     []string
+	includeDepth int
+	included     map[include]struct{}
+
+	parameterized      map[string]*parameterizedDefinition
+	parameterizedCalls []parameterizedCall
+	callArguments      int
+	expansions         []string
+
+	encoding       *encodingSetting
+	tokenEncodings map[string]encodingSetting
+
+	loops map[string]loopDeclaration
+
+	arithmetic *expressions.Semantics
+
 	earlyUse       map[string][]tokenUsage
 	lookup         map[string]tokenUsage
 	lookupUsage    map[token.Token]struct{}
 	used           map[string][]tokenUsage
 	variableUsages []token.Token
+	termScope      *token.VariableScope
 
 	called map[string][]call
 
 	forwardAttributeUsage []attributeForwardUsage
+
+	// definition holds the name of the token definition which is currently parsed
+	definition       string
+	definitionOffset int
+	// failed holds the names of token definitions which could not be parsed
+	failed map[string]struct{}
+	errors []error
 }
 
 func (p *tavorParser) expectRune(expect rune, got rune) (rune, error) {
@@ -70,7 +164,7 @@ func (p *tavorParser) expectRune(expect rune, got rune) (rune, error) {
 		return got, &token.ParserError{
 			Message:  fmt.Sprintf("expected %s but got %s", scanner.TokenString(expect), scanner.TokenString(got)),
 			Type:     token.ParseErrorExpectRune,
-			Position: p.scan.Pos(),
+			Position: p.scan.Position,
 		}
 	}
 
@@ -95,7 +189,7 @@ func (p *tavorParser) expectText(expect string, got rune) (rune, error) {
 		return zeroRune, &token.ParserError{
 			Message:  fmt.Sprintf("expected %q got %q", expect, g),
 			Type:     token.ParseErrorExpectOperator,
-			Position: p.scan.Pos(),
+			Position: p.scan.Position,
 		}
 	}
 
@@ -112,412 +206,2001 @@ func (p *tavorParser) expectScanText(expect string) (rune, error) {
 		return zeroRune, &token.ParserError{
 			Message:  fmt.Sprintf("expected %q got %q", expect, g),
 			Type:     token.ParseErrorExpectOperator,
-			Position: p.scan.Pos(),
+			Position: p.scan.Position,
 		}
 	}
 
 	return got, nil
 }
 
-func (p *tavorParser) parseGlobalScope(variableScope *token.VariableScope) error {
+// parseGlobalScope parses statements and token definitions until EOF is reached
+// Errors are collected in the parser and parsing recovers at the next statement or token definition.
+func (p *tavorParser) parseGlobalScope(variableScope *token.VariableScope) {
 	var err error
 
 	c := p.scan.Scan()
 	log.Debugf("%d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
 	for c != scanner.EOF {
+		p.definition = ""
+		p.definitionOffset = p.scan.Position.Offset
+
 		switch c {
 		case '\n':
 			// ignore new lines in the global scope
 		case scanner.Ident:
-			c, err = p.parseTokenDefinition(variableScope)
+			switch p.scan.TokenText() {
+			case "include":
+				c, err = p.parseInclude(variableScope)
+			case "encoding":
+				c, err = p.parseEncoding()
+			case "loop":
+				c, err = p.parseLoop()
+			case "arithmetic":
+				c, err = p.parseArithmetic()
+			default:
+				c, err = p.parseTokenDefinition(variableScope)
+			}
 			if err != nil {
-				return err
+				c = p.recoverDefinition(err)
 			}
 
 			continue
 		case '$':
 			c, err = p.parseTypedTokenDefinition(variableScope)
 			if err != nil {
-				return err
+				c = p.recoverDefinition(err)
 			}
 
 			continue
 		default:
-			return &token.ParserError{
+			c = p.recoverDefinition(&token.ParserError{
 				Message:  fmt.Sprintf("token names have to start with a letter and not with %s", scanner.TokenString(c)),
 				Type:     token.ParseErrorInvalidTokenName,
-				Position: p.scan.Pos(),
-			}
+				Position: p.scan.Position,
+			})
+
+			continue
 		}
 
 		c = p.scan.Scan()
 		log.Debugf("%d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 	}
-
-	return nil
 }
 
-func (p *tavorParser) getToken(definitionName string, name string, variableScope *token.VariableScope) token.Token {
-	if tok := variableScope.Get(name); tok != nil {
-		if v, ok := tok.(token.VariableToken); ok {
-			tok = variables.NewVariableValue(v)
+// recoverDefinition records the error of the current statement or token definition and skips its remaining lines.
+// Parsing recovers at the next line which begins with a name e.g. of a token definition.
+// The next rune after the skipped lines is returned.
+func (p *tavorParser) recoverDefinition(err error) rune {
+	p.errors = append(p.errors, err)
 
-			p.variableUsages = append(p.variableUsages, v)
+	if p.definition != "" {
+		p.failed[p.definition] = struct{}{}
+	}
 
-			log.Debugf("use variable value (%p)%#v", tok, tok)
-		} else {
-			log.Debugf("use token (%p)%#v", tok, tok)
+	var previous rune
+	var c rune
+
+	if p.scan.TokenText() == "\n" {
+		c = '\n'
+	} else {
+		c = p.scan.Scan()
+	}
+
+SKIP:
+	for {
+		switch c {
+		case scanner.EOF:
+			break SKIP
+		case '\n':
+			// lines ending with a comma are continued and lines which do not begin with a name belong to the skipped definition
+			if previous != ',' {
+				if r := p.scan.Peek(); r == scanner.EOF || r == '$' || r == '_' || unicode.IsLetter(r) {
+					break SKIP
+				}
+			}
 		}
 
-		return tok
+		previous = c
+		c = p.scan.Scan()
 	}
 
-	_, ok := p.lookup[name]
-	if !ok {
-		log.Debugf("getToken use empty pointer for %s", name)
+	// tokens which are used by the skipped definition are marked as used so they are not reported as unused
+	var s scanner.Scanner
+	s.Init(bytes.NewReader(p.src[p.definitionOffset:p.scan.Position.Offset]))
+	s.Error = func(s *scanner.Scanner, msg string) {}
 
-		var tokenInterface *token.Token
-		b := primitives.NewEmptyPointer(tokenInterface)
-		n := primitives.NewPointer(b)
+	for t := s.Scan(); t != scanner.EOF; t = s.Scan() {
+		if t == scanner.Ident {
+			name := p.prefix + s.TokenText()
 
-		p.lookup[name] = tokenUsage{
-			token:    n,
-			position: p.scan.Position,
+			p.used[name] = append(p.used[name], tokenUsage{
+				token:    nil,
+				position: p.scan.Position,
+			})
 		}
-		p.earlyUse[name] = append(p.earlyUse[name], tokenUsage{
-			token:          b,
-			position:       p.scan.Position,
-			variableScope:  variableScope,
-			definitionName: definitionName,
-		})
 	}
 
-	p.used[name] = append(p.used[name], tokenUsage{
-		token:         nil,
-		position:      p.scan.Position,
-		variableScope: variableScope,
-	})
+	if c == scanner.EOF {
+		return c
+	}
 
-	/*
+	return p.scan.Scan()
+}
 
-		THIS IS A TERRIBLE HACK THIS SHOULD BE -REMOVED- fixed ASAP
+func (p *tavorParser) initScanner(src []byte, filename string) {
+	p.src = src
 
-		but let me explain
+	p.scan = new(scanner.Scanner)
+	p.scan.Init(bytes.NewReader(src))
 
-		B = 1 | 2
-		A = B B
+	p.scan.Filename = filename
+	p.scan.Error = func(s *scanner.Scanner, msg string) {
+		p.err = msg
+	}
+	p.scan.Whitespace = 1<<'\t' | 1<<' ' | 1<<'\r'
+}
 
-		This should result in 4 permutations 11 21 12 and 22
-		but without this condition this would result in only
-		11s and 22s. The clone alone would be fine but this
-		leads to more problems if the clone is not saved back
-		into the lookup.
+func (p *tavorParser) parseInclude(variableScope *token.VariableScope) (rune, error) {
+	var c rune
+	var err error
 
-		For example
+	log.Debug("START include")
 
-		Bs = +(1)
-		A = $Bs.Count Bs
+	includePosition := p.scan.Position
 
-		would not work without saving back.
+	if _, err = p.expectScanRune(scanner.String); err != nil {
+		return zeroRune, err
+	}
 
-		But what if somebody writes
+	filename, err := strconv.Unquote(p.scan.TokenText())
+	if err != nil || filename == "" {
+		return zeroRune, &token.ParserError{
+			Message:  fmt.Sprintf("invalid include file name %s", p.scan.TokenText()),
+			Type:     token.ParseErrorInvalidArgumentValue,
+			Position: p.scan.Position,
+		}
+	}
 
-		Cs = +(1)
-		B = $Cs.Count Cs
-		A = +(B)
+	prefix := p.prefix
 
-		or even
+	c = p.scan.Scan()
+	if c == scanner.Ident {
+		if _, err = p.expectText("prefix", c); err != nil {
+			return zeroRune, err
+		}
 
-		Cs = +(1)
-		B = $Cs.Count Cs
-		A = $Cs.Count +(B)
+		if _, err = p.expectScanRune(scanner.Ident); err != nil {
+			return zeroRune, err
+		}
 
-		So TODO and FIXME all over this
+		prefix += p.scan.TokenText()
 
-	*/
-	tok := p.lookup[name].token
+		c = p.scan.Scan()
+	}
 
-	if _, ok := p.lookupUsage[tok]; ok {
-		if t, ok := tok.(*primitives.Pointer); ok && t.Get() == nil {
-			// FIXME if tok is directly given to NewPointer we get a panic: reflect: non-interface type passed to Type.Implements
-			var tokInterface *token.Token
-			ntok := primitives.NewEmptyPointer(tokInterface)
-			_ = ntok.Set(tok)
+	// we always want a new line at the end of the file
+	if c == scanner.EOF {
+		return zeroRune, &token.ParserError{
+			Message:  "new line at end of include needed",
+			Type:     token.ParseErrorNewLineNeeded,
+			Position: p.scan.Position,
+		}
+	}
 
-			log.Debugf("token %s (%p)%#v is an empty pointer, better just forward to it (%p)%#v", name, tok, tok, ntok, ntok)
+	if _, err = p.expectRune('\n', c); err != nil {
+		return zeroRune, err
+	}
 
-			tok = ntok
-		} else {
-			ntok := tok.Clone()
+	if err = p.includeFile(filename, prefix, includePosition, variableScope); err != nil {
+		return zeroRune, err
+	}
 
-			log.Debugf("token %s (%p)%#v was already used once. Cloned as (%p)%#v", name, tok, tok, ntok, ntok)
+	c = p.scan.Scan()
+	log.Debugf("parseInclude after newline %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
-			p.lookup[name] = tokenUsage{
-				token:    ntok,
-				position: p.scan.Position,
-			}
+	log.Debug("END include")
 
-			if t, ok := tok.(*primitives.Pointer); ok && t.Resolve() == nil {
-				p.earlyUse[name] = append(p.earlyUse[name], tokenUsage{
-					token:          ntok,
-					position:       p.scan.Position,
-					variableScope:  variableScope,
-					definitionName: definitionName,
-				})
-			}
+	return c, nil
+}
 
-			tok = ntok
-		}
-	} else {
-		log.Debugf("use token (%p)%#v", tok, tok)
+func (p *tavorParser) includeFile(filename string, prefix string, includePosition scanner.Position, variableScope *token.VariableScope) error {
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(p.directory, filename)
 	}
 
-	p.lookupUsage[tok] = struct{}{}
+	absFilename, err := filepath.Abs(filename)
+	if err != nil {
+		return &token.ParserError{
+			Message:  fmt.Sprintf("cannot include %q: %s", filename, err),
+			Type:     token.ParseErrorCannotIncludeFile,
+			Position: includePosition,
+		}
+	}
 
-	p.addCall(definitionName, variableScope, name)
+	for i, f := range p.includes {
+		if f == absFilename {
+			return &token.ParserError{
+				Message:  fmt.Sprintf("include cycle detected: %s", strings.Join(append(p.includes[i:], absFilename), " -> ")),
+				Type:     token.ParseErrorIncludeCycle,
+				Position: includePosition,
+			}
+		}
+	}
 
-	return tok
-}
+	// every file is included only once per prefix, so formats can share includes
+	key := include{
+		filename: absFilename,
+		prefix:   prefix,
+	}
+	if _, ok := p.included[key]; ok {
+		log.Debugf("%q with prefix %q is already included", absFilename, prefix)
 
-func (p *tavorParser) addCall(definitionName string, variableScope *token.VariableScope, name string) {
-	if _, ok := p.called[name]; !ok {
-		p.called[name] = make([]call, 0, 1)
+		return nil
 	}
+	p.included[key] = struct{}{}
 
-	c := call{
-		from:          definitionName,
-		variableScope: variableScope,
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return &token.ParserError{
+			Message:  fmt.Sprintf("cannot include %q: %s", filename, err),
+			Type:     token.ParseErrorCannotIncludeFile,
+			Position: includePosition,
+		}
 	}
 
-	p.called[name] = append(p.called[name], c)
+	log.Debugf("include %q with prefix %q", filename, prefix)
+
+	scan, oldSrc, directory, oldPrefix := p.scan, p.src, p.directory, p.prefix
+	definition, definitionOffset := p.definition, p.definitionOffset
+
+	p.initScanner(src, filename)
+	p.directory = filepath.Dir(filename)
+	p.prefix = prefix
+	p.includes = append(p.includes, absFilename)
+	p.includeDepth++
+
+	p.parseGlobalScope(variableScope)
+
+	p.scan, p.src, p.directory, p.prefix = scan, oldSrc, directory, oldPrefix
+	p.definition, p.definitionOffset = definition, definitionOffset
+	p.includes = p.includes[:len(p.includes)-1]
+	p.includeDepth--
+
+	return nil
 }
 
-func (p *tavorParser) parseTerm(definitionName string, c rune, variableScope *token.VariableScope) (rune, []token.Token, error) {
+func (p *tavorParser) parseEncoding() (rune, error) {
+	var c rune
 	var err error
-	var tokens []token.Token
 
-	addToken := func(tok token.Token) {
-		tokens = append(tokens, tok)
+	log.Debug("START encoding")
+
+	if _, err = p.expectScanRune(scanner.String); err != nil {
+		return zeroRune, err
 	}
 
-OUT:
-	for {
-		switch c {
-		case scanner.Ident:
-			name := p.scan.TokenText()
+	name, _ := strconv.Unquote(p.scan.TokenText())
 
-			variableScope = variableScope.Push()
-			tok := p.getToken(definitionName, name, variableScope)
+	enc, err := encodings.New(name)
+	if err != nil {
+		return zeroRune, &token.ParserError{
+			Message:  fmt.Sprintf("unknown encoding %s, known encodings are %s", p.scan.TokenText(), strings.Join(encodings.List(), ", ")),
+			Type:     token.ParseErrorUnknownEncoding,
+			Position: p.scan.Position,
+		}
+	}
 
-			addToken(tok)
-		case scanner.Int:
-			v, _ := strconv.Atoi(p.scan.TokenText())
+	setting := encodingSetting{
+		encoding: enc,
+	}
 
-			addToken(primitives.NewConstantInt(v))
-		case scanner.String:
-			s := p.scan.TokenText()
+	c = p.scan.Scan()
+	if c == scanner.Ident && p.scan.TokenText() == "invalid" {
+		setting.injections = true
 
-			if s[len(s)-1] != '"' {
-				return zeroRune, nil, &token.ParserError{
-					Message:  "string is not terminated",
-					Type:     token.ParseErrorNonTerminatedString,
-					Position: p.scan.Pos(),
-				}
+		c = p.scan.Scan()
+	}
+
+	if c == scanner.Ident {
+		if _, err = p.expectText("for", c); err != nil {
+			return zeroRune, err
+		}
+
+		for {
+			if _, err = p.expectScanRune(scanner.Ident); err != nil {
+				return zeroRune, err
 			}
 
-			s, _ = strconv.Unquote(s)
+			name := p.prefix + p.scan.TokenText()
 
-			if len(s) == 0 {
-				return zeroRune, nil, &token.ParserError{
-					Message:  "empty strings are not allowed",
-					Type:     token.ParseErrorEmptyString,
-					Position: p.scan.Pos(),
+			if p.isDefined(name) {
+				return zeroRune, &token.ParserError{
+					Message:  fmt.Sprintf("encoding for token %q must be set before its definition", name),
+					Type:     token.ParseErrorEncodingAfterDefinition,
+					Position: p.scan.Position,
 				}
 			}
 
-			addToken(primitives.NewConstantString(s))
-		case '(':
-			log.Debug("NEW group")
+			p.tokenEncodings[name] = setting
 
 			c = p.scan.Scan()
-			log.Debugf("parseTerm Group %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+			if c != ',' {
+				break
+			}
+		}
+	} else {
+		defined := len(p.parameterized) != 0
+		for name := range p.lookup {
+			if p.isDefined(name) {
+				defined = true
 
-			c, toks, err := p.parseScope(definitionName, c, variableScope)
-			if err != nil {
-				return zeroRune, nil, err
+				break
 			}
+		}
 
-			_, err = p.expectRune(')', c)
-			if err != nil {
-				return zeroRune, nil, err
+		if defined {
+			return zeroRune, &token.ParserError{
+				Message:  "document encoding must be set before all token definitions",
+				Type:     token.ParseErrorEncodingAfterDefinition,
+				Position: p.scan.Position,
 			}
+		}
 
-			switch len(toks) {
-			case 0:
-				// ignore
-			case 1:
-				addToken(toks[0])
-			default:
-				addToken(lists.NewAll(toks...))
-			}
+		p.encoding = &setting
+	}
 
-			log.Debug("END group")
-		case '?':
-			log.Debug("NEW optional")
+	// we always want a new line at the end of the file
+	if c == scanner.EOF {
+		return zeroRune, &token.ParserError{
+			Message:  "new line at end of encoding needed",
+			Type:     token.ParseErrorNewLineNeeded,
+			Position: p.scan.Position,
+		}
+	}
 
-			_, err = p.expectScanRune('(')
-			if err != nil {
-				return zeroRune, nil, err
+	if _, err = p.expectRune('\n', c); err != nil {
+		return zeroRune, err
+	}
+
+	c = p.scan.Scan()
+	log.Debugf("parseEncoding after newline %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+	log.Debug("END encoding")
+
+	return c, nil
+}
+
+func (p *tavorParser) parseArithmetic() (rune, error) {
+	var c rune
+	var err error
+
+	log.Debug("START arithmetic")
+
+	if _, err = p.expectScanRune(scanner.Ident); err != nil {
+		return zeroRune, err
+	}
+
+	typ := p.scan.TokenText()
+	typPosition := p.scan.Pos()
+
+	c = p.scan.Scan()
+
+	if typ == "unbounded" {
+		p.arithmetic = &expressions.Semantics{
+			Overflow: expressions.OverflowUnbounded,
+		}
+	} else {
+		overflow := expressions.OverflowWrap
+
+		if c == scanner.Ident {
+			switch o := p.scan.TokenText(); o {
+			case "wrap":
+				overflow = expressions.OverflowWrap
+			case "saturate":
+				overflow = expressions.OverflowSaturate
+			default:
+				return zeroRune, &token.ParserError{
+					Message:  fmt.Sprintf("unknown overflow behaviour %q, known behaviours are saturate and wrap", o),
+					Type:     token.ParseErrorUnknownArithmetic,
+					Position: p.scan.Position,
+				}
 			}
 
 			c = p.scan.Scan()
-			log.Debugf("parseTerm optional after ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+		}
 
-			c, toks, err := p.parseScope(definitionName, c, variableScope)
-			if err != nil {
-				return zeroRune, nil, err
+		semantics, err := expressions.NewSemantics(typ, overflow)
+		if err != nil {
+			return zeroRune, &token.ParserError{
+				Message:  fmt.Sprintf("unknown arithmetic %q, known arithmetics are unbounded, int8, int16, int32, int64, uint8, uint16, uint32 and uint64", typ),
+				Type:     token.ParseErrorUnknownArithmetic,
+				Position: typPosition,
 			}
+		}
 
-			_, err = p.expectRune(')', c)
-			if err != nil {
-				return zeroRune, nil, err
+		p.arithmetic = semantics
+	}
+
+	// we always want a new line at the end of the file
+	if c == scanner.EOF {
+		return zeroRune, &token.ParserError{
+			Message:  "new line at end of arithmetic needed",
+			Type:     token.ParseErrorNewLineNeeded,
+			Position: p.scan.Position,
+		}
+	}
+
+	if _, err = p.expectRune('\n', c); err != nil {
+		return zeroRune, err
+	}
+
+	c = p.scan.Scan()
+
+	log.Debug("END arithmetic")
+
+	return c, nil
+}
+
+func (p *tavorParser) parseLoop() (rune, error) {
+	var c rune
+	var err error
+
+	log.Debug("START loop")
+
+	var names []string
+	var positions []scanner.Position
+
+	for {
+		if _, err = p.expectScanRune(scanner.Ident); err != nil {
+			return zeroRune, err
+		}
+
+		name := p.prefix + p.scan.TokenText()
+
+		if _, ok := p.lookup[name]; ok || p.isDefined(name) {
+			return zeroRune, &token.ParserError{
+				Message:  fmt.Sprintf("loop for token %q must be declared before its usage and definition", name),
+				Type:     token.ParseErrorLoopAfterUsage,
+				Position: p.scan.Position,
 			}
+		}
 
-			switch len(toks) {
-			case 0:
-				// ignore
-			case 1:
-				addToken(constraints.NewOptional(toks[0]))
-			default:
-				addToken(constraints.NewOptional(lists.NewAll(toks...)))
+		names = append(names, name)
+		positions = append(positions, p.scan.Position)
+
+		c = p.scan.Scan()
+		if c != ',' {
+			break
+		}
+	}
+
+	maxDepth := 0
+
+	if c == scanner.Ident {
+		if _, err = p.expectText("depth", c); err != nil {
+			return zeroRune, err
+		}
+
+		if _, err = p.expectScanRune(scanner.Int); err != nil {
+			return zeroRune, err
+		}
+
+		maxDepth, err = strconv.Atoi(p.scan.TokenText())
+		if err != nil || maxDepth < 1 {
+			return zeroRune, &token.ParserError{
+				Message:  fmt.Sprintf("invalid loop depth %s", p.scan.TokenText()),
+				Type:     token.ParseErrorInvalidArgumentValue,
+				Position: p.scan.Position,
 			}
+		}
 
-			log.Debug("END optional")
-		case '+', '*':
-			log.Debug("NEW repeat")
+		c = p.scan.Scan()
+	}
 
-			sym := c
+	// we always want a new line at the end of the file
+	if c == scanner.EOF {
+		return zeroRune, &token.ParserError{
+			Message:  "new line at end of loop needed",
+			Type:     token.ParseErrorNewLineNeeded,
+			Position: p.scan.Position,
+		}
+	}
 
-			c = p.scan.Scan()
-			log.Debugf("parseTerm repeat before ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+	if _, err = p.expectRune('\n', c); err != nil {
+		return zeroRune, err
+	}
 
-			var from, to token.Token
+	for i, name := range names {
+		p.loops[name] = loopDeclaration{
+			loop:     primitives.NewLoop(maxDepth),
+			position: positions[i],
+		}
+	}
 
-			if sym == '*' {
-				from, to = primitives.NewConstantInt(0), primitives.NewConstantInt(tavor.MaxRepeat)
-			} else {
-				if c == scanner.Int {
-					iFrom, _ := strconv.Atoi(p.scan.TokenText())
-					from = primitives.NewConstantInt(iFrom)
+	c = p.scan.Scan()
+	log.Debugf("parseLoop after newline %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
-					c = p.scan.Scan()
-					log.Debugf("parseTerm repeat after from ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+	log.Debug("END loop")
 
-					// until there is an explicit "to" we can assume to==from
-					to = from // do not clone here! since really to==from
-				} else if c == '$' {
-					c = p.scan.Scan()
+	return c, nil
+}
 
-					c, from, err = p.parseTokenAttribute(definitionName, c, variableScope)
+// isDefined returns true if the token with the given name is already defined and not just used
+func (p *tavorParser) isDefined(name string) bool {
+	if _, ok := p.parameterized[name]; ok {
+		return true
+	}
 
-					if err != nil {
-						return zeroRune, nil, err
-					}
+	use, ok := p.lookup[name]
+	if !ok {
+		return false
+	}
 
-					// until there is an explicit "to" we can assume to==from
-					to = from // do not clone here! since really to==from
-				} else {
-					from, to = primitives.NewConstantInt(1), primitives.NewConstantInt(tavor.MaxRepeat)
-				}
+	_, ok = use.token.(*primitives.Pointer)
 
-				if c == ',' {
-					c = p.scan.Scan()
-					log.Debugf("parseTerm repeat after , ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+	return !ok
+}
 
-					if c == scanner.Int {
-						iTo, _ := strconv.Atoi(p.scan.TokenText())
-						to = primitives.NewConstantInt(iTo)
+// encode wraps the token with the encoding of the given token definition
+func (p *tavorParser) encode(definitionName string, tok token.Token) token.Token {
+	setting, ok := p.tokenEncodings[definitionName]
+	if !ok {
+		if p.encoding == nil {
+			return tok
+		}
 
-						c = p.scan.Scan()
-						log.Debugf("parseTerm repeat after to ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
-					} else if c == '$' {
-						c = p.scan.Scan()
+		setting = *p.encoding
+	}
 
-						c, to, err = p.parseTokenAttribute(definitionName, c, variableScope)
+	if setting.injections {
+		return encodings.NewEncodedWithInjections(setting.encoding, tok)
+	}
 
-						if err != nil {
-							return zeroRune, nil, err
-						}
-					} else {
-						to = primitives.NewConstantInt(tavor.MaxRepeat)
-					}
-				}
-			}
+	return encodings.NewEncoded(setting.encoding, tok)
+}
 
-			_, err = p.expectRune('(', c)
-			if err != nil {
-				return zeroRune, nil, err
-			}
+func (p *tavorParser) getToken(definitionName string, name string, variableScope *token.VariableScope) token.Token {
+	if tok := variableScope.Get(name); tok != nil {
+		if a, ok := tok.(*argument); ok {
+			tok = a.get()
 
-			log.Debugf("repeat from %v to %v", from, to)
+			log.Debugf("use argument %s (%p)%#v", name, tok, tok)
+		} else if v, ok := tok.(token.VariableToken); ok {
+			tok = variables.NewVariableValue(v)
 
-			c = p.scan.Scan()
-			log.Debugf("parseTerm repeat after ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+			p.variableUsages = append(p.variableUsages, v)
 
-			c, toks, err := p.parseScope(definitionName, c, variableScope)
-			if err != nil {
-				return zeroRune, nil, err
-			}
+			log.Debugf("use variable value (%p)%#v", tok, tok)
+		} else {
+			log.Debugf("use token (%p)%#v", tok, tok)
+		}
 
-			_, err = p.expectRune(')', c)
-			if err != nil {
-				return zeroRune, nil, err
-			}
+		return tok
+	}
 
-			switch len(toks) {
-			case 0:
-				// ignore
-			case 1:
-				switch t := toks[0].(type) {
-				case *constraints.Optional:
-					return zeroRune, nil, &token.ParserError{
-						Message:  "repeats with an optional are not allowed",
-						Type:     token.ParseErrorRepeatWithOptionalTerm,
-						Position: p.scan.Pos(),
-					}
-				case *lists.One:
-					for i := t.InternalLen() - 1; i >= 0; i-- {
-						v, _ := t.InternalGet(i)
+	name = p.prefix + name
+
+	if l, ok := p.loops[name]; ok {
+		tok := l.loop.Clone()
+
+		log.Debugf("use loop (%p)%#v for %s", tok, tok, name)
+
+		p.used[name] = append(p.used[name], tokenUsage{
+			token:         nil,
+			position:      p.scan.Position,
+			variableScope: variableScope,
+		})
+
+		p.addCall(definitionName, variableScope, name)
+
+		return tok
+	}
+
+	_, ok := p.lookup[name]
+	if !ok {
+		log.Debugf("getToken use empty pointer for %s", name)
+
+		var tokenInterface *token.Token
+		b := primitives.NewEmptyPointer(tokenInterface)
+		n := primitives.NewPointer(b)
+
+		p.lookup[name] = tokenUsage{
+			token:    n,
+			position: p.scan.Position,
+		}
+		p.earlyUse[name] = append(p.earlyUse[name], tokenUsage{
+			token:          b,
+			position:       p.scan.Position,
+			variableScope:  variableScope,
+			definitionName: definitionName,
+			prefix:         p.prefix,
+		})
+	}
+
+	p.used[name] = append(p.used[name], tokenUsage{
+		token:         nil,
+		position:      p.scan.Position,
+		variableScope: variableScope,
+	})
+
+	/*
+
+		THIS IS A TERRIBLE HACK THIS SHOULD BE -REMOVED- fixed ASAP
+
+		but let me explain
+
+		B = 1 | 2
+		A = B B
+
+		This should result in 4 permutations 11 21 12 and 22
+		but without this condition this would result in only
+		11s and 22s. The clone alone would be fine but this
+		leads to more problems if the clone is not saved back
+		into the lookup.
+
+		For example
+
+		Bs = +(1)
+		A = $Bs.Count Bs
+
+		would not work without saving back.
+
+		But what if somebody writes
+
+		Cs = +(1)
+		B = $Cs.Count Cs
+		A = +(B)
+
+		or even
+
+		Cs = +(1)
+		B = $Cs.Count Cs
+		A = $Cs.Count +(B)
+
+		So TODO and FIXME all over this
+
+	*/
+	tok := p.lookup[name].token
+
+	if _, ok := p.lookupUsage[tok]; ok {
+		if t, ok := tok.(*primitives.Pointer); ok && t.Get() == nil {
+			// FIXME if tok is directly given to NewPointer we get a panic: reflect: non-interface type passed to Type.Implements
+			var tokInterface *token.Token
+			ntok := primitives.NewEmptyPointer(tokInterface)
+			_ = ntok.Set(tok)
+
+			log.Debugf("token %s (%p)%#v is an empty pointer, better just forward to it (%p)%#v", name, tok, tok, ntok, ntok)
+
+			tok = ntok
+		} else {
+			ntok := tok.Clone()
+
+			log.Debugf("token %s (%p)%#v was already used once. Cloned as (%p)%#v", name, tok, tok, ntok, ntok)
+
+			p.lookup[name] = tokenUsage{
+				token:    ntok,
+				position: p.scan.Position,
+			}
+
+			if t, ok := tok.(*primitives.Pointer); ok && t.Resolve() == nil {
+				p.earlyUse[name] = append(p.earlyUse[name], tokenUsage{
+					token:          ntok,
+					position:       p.scan.Position,
+					variableScope:  variableScope,
+					definitionName: definitionName,
+					prefix:         p.prefix,
+				})
+			}
+
+			tok = ntok
+		}
+	} else {
+		log.Debugf("use token (%p)%#v", tok, tok)
+	}
+
+	p.lookupUsage[tok] = struct{}{}
+
+	p.addCall(definitionName, variableScope, name)
+
+	return tok
+}
+
+func (p *tavorParser) addCall(definitionName string, variableScope *token.VariableScope, name string) {
+	if _, ok := p.called[name]; !ok {
+		p.called[name] = make([]call, 0, 1)
+	}
+
+	c := call{
+		from:          definitionName,
+		variableScope: variableScope,
+	}
+
+	p.called[name] = append(p.called[name], c)
+}
+
+func (p *tavorParser) parseTerm(definitionName string, c rune, variableScope *token.VariableScope) (rune, []token.Token, error) {
+	var err error
+	var tokens []token.Token
+
+	addToken := func(tok token.Token) {
+		tokens = append(tokens, tok)
+	}
+
+OUT:
+	for {
+		switch c {
+		case scanner.Ident:
+			name := p.scan.TokenText()
+
+			variableScope = variableScope.Push()
+
+			if p.scan.Peek() == '(' {
+				tok, err := p.parseParameterizedTokenCall(definitionName, name, variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				addToken(tok)
+			} else {
+				tok := p.getToken(definitionName, name, variableScope)
+
+				addToken(tok)
+			}
+		case scanner.Int:
+			text := p.scan.TokenText()
+
+			if strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X") {
+				b, err := hex.DecodeString(text[2:])
+				if err != nil || len(b) == 0 {
+					return zeroRune, nil, &token.ParserError{
+						Message:  fmt.Sprintf("invalid byte literal %q, it needs an even number of hexadecimal digits", text),
+						Type:     token.ParseErrorInvalidByteLiteral,
+						Position: p.scan.Position,
+					}
+				}
+
+				addToken(primitives.NewConstantString(string(b)))
+			} else {
+				v, _ := strconv.Atoi(text)
+
+				addToken(p.encode(definitionName, primitives.NewConstantInt(v)))
+			}
+		case scanner.String:
+			s := p.scan.TokenText()
+
+			if s[len(s)-1] != '"' {
+				return zeroRune, nil, &token.ParserError{
+					Message:  "string is not terminated",
+					Type:     token.ParseErrorNonTerminatedString,
+					Position: p.scan.Position,
+				}
+			}
+
+			s, _ = strconv.Unquote(s)
+
+			if len(s) == 0 {
+				return zeroRune, nil, &token.ParserError{
+					Message:  "empty strings are not allowed",
+					Type:     token.ParseErrorEmptyString,
+					Position: p.scan.Position,
+				}
+			}
+
+			addToken(p.encode(definitionName, primitives.NewConstantString(s)))
+		case '(':
+			log.Debug("NEW group")
+
+			c = p.scan.Scan()
+			log.Debugf("parseTerm Group %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+			c, toks, err := p.parseScope(definitionName, c, variableScope)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			_, err = p.expectRune(')', c)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			switch len(toks) {
+			case 0:
+				// ignore
+			case 1:
+				addToken(toks[0])
+			default:
+				addToken(lists.NewAll(toks...))
+			}
+
+			log.Debug("END group")
+		case '?':
+			log.Debug("NEW optional")
+
+			_, err = p.expectScanRune('(')
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			c = p.scan.Scan()
+			log.Debugf("parseTerm optional after ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+			c, toks, err := p.parseScope(definitionName, c, variableScope)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			_, err = p.expectRune(')', c)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			switch len(toks) {
+			case 0:
+				// ignore
+			case 1:
+				addToken(constraints.NewOptional(toks[0]))
+			default:
+				addToken(constraints.NewOptional(lists.NewAll(toks...)))
+			}
+
+			log.Debug("END optional")
+		case '+', '*':
+			log.Debug("NEW repeat")
+
+			sym := c
+
+			c = p.scan.Scan()
+			log.Debugf("parseTerm repeat before ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+			var from, to token.Token
+
+			if sym == '*' {
+				from, to = primitives.NewConstantInt(0), primitives.NewConstantInt(tavor.MaxRepeat)
+			} else {
+				if c == scanner.Int {
+					iFrom, _ := strconv.Atoi(p.scan.TokenText())
+					from = primitives.NewConstantInt(iFrom)
+
+					c = p.scan.Scan()
+					log.Debugf("parseTerm repeat after from ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+					// until there is an explicit "to" we can assume to==from
+					to = from // do not clone here! since really to==from
+				} else if c == '$' {
+					c = p.scan.Scan()
+
+					c, from, err = p.parseTokenAttribute(definitionName, c, variableScope)
+
+					if err != nil {
+						return zeroRune, nil, err
+					}
+
+					// until there is an explicit "to" we can assume to==from
+					to = from // do not clone here! since really to==from
+				} else {
+					from, to = primitives.NewConstantInt(1), primitives.NewConstantInt(tavor.MaxRepeat)
+				}
+
+				if c == ',' {
+					c = p.scan.Scan()
+					log.Debugf("parseTerm repeat after , ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+					if c == scanner.Int {
+						iTo, _ := strconv.Atoi(p.scan.TokenText())
+						to = primitives.NewConstantInt(iTo)
+
+						c = p.scan.Scan()
+						log.Debugf("parseTerm repeat after to ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+					} else if c == '$' {
+						c = p.scan.Scan()
+
+						c, to, err = p.parseTokenAttribute(definitionName, c, variableScope)
+
+						if err != nil {
+							return zeroRune, nil, err
+						}
+					} else {
+						to = primitives.NewConstantInt(tavor.MaxRepeat)
+					}
+				}
+			}
+
+			_, err = p.expectRune('(', c)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			log.Debugf("repeat from %v to %v", from, to)
+
+			c = p.scan.Scan()
+			log.Debugf("parseTerm repeat after ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+			c, toks, err := p.parseScope(definitionName, c, variableScope)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			_, err = p.expectRune(')', c)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			switch len(toks) {
+			case 0:
+				// ignore
+			case 1:
+				switch t := toks[0].(type) {
+				case *constraints.Optional:
+					return zeroRune, nil, &token.ParserError{
+						Message:  "repeats with an optional are not allowed",
+						Type:     token.ParseErrorRepeatWithOptionalTerm,
+						Position: p.scan.Position,
+					}
+				case *lists.One:
+					for i := t.InternalLen() - 1; i >= 0; i-- {
+						v, _ := t.InternalGet(i)
+
+						if _, ok := v.(*constraints.Optional); ok {
+							return zeroRune, nil, &token.ParserError{
+								Message:  "repeats with an optional are not allowed",
+								Type:     token.ParseErrorRepeatWithOptionalTerm,
+								Position: p.scan.Position,
+							}
+						}
+					}
+				}
+
+				addToken(lists.NewRepeatWithTokens(toks[0], from, to))
+			default:
+				addToken(lists.NewRepeatWithTokens(lists.NewAll(toks...), from, to))
+			}
+
+			log.Debug("END repeat")
+		case '@':
+			log.Debug("NEW once")
+
+			_, err = p.expectScanRune('(')
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			c = p.scan.Scan()
+			log.Debugf("parseTerm once after ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+			c, toks, err := p.parseScope(definitionName, c, variableScope)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			_, err = p.expectRune(')', c)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			switch len(toks) {
+			case 1:
+				if t, ok := toks[0].(*lists.One); ok {
+					le := t.InternalLen()
+					tl := make([]token.Token, le)
+					for i := 0; i < le; i++ {
+						tl[i], _ = t.InternalGet(i)
+					}
+
+					addToken(lists.NewOnce(tl...))
+				} else {
+					addToken(lists.NewOnce(toks[0]))
+				}
+			default:
+				addToken(lists.NewOnce(toks...))
+			}
+
+			log.Debug("END once")
+		case '$':
+			c = p.scan.Scan()
+			log.Debugf("parseTerm after $ ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+			var tok token.Token
+
+			if c == '{' {
+				c, tok, err = p.parseExpression(definitionName, variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				_, err = p.expectRune('}', c)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				c = p.scan.Scan()
+			} else {
+				c, tok, err = p.parseTokenAttribute(definitionName, c, variableScope)
+			}
+
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			addToken(p.encode(definitionName, tok))
+
+			continue
+		case '[':
+			log.Debug("NEW character class")
+
+			var pattern bytes.Buffer
+			patternPosition := p.scan.Position
+
+			p.scan.Whitespace ^= 1 << ' '
+
+			c = p.scan.Scan()
+
+			for c != ']' && c != '\n' && c != scanner.EOF {
+				if _, err := pattern.WriteString(p.scan.TokenText()); err != nil {
+					panic(err)
+				}
+
+				c = p.scan.Scan()
+			}
+
+			p.scan.Whitespace |= 1 << ' '
+
+			if c != ']' {
+				_, err := p.expectRune(']', c)
+
+				return zeroRune, nil, err
+			}
+
+			class, err := primitives.ParseCharacterClass(pattern.String())
+			if err != nil {
+				return zeroRune, nil, &token.ParserError{
+					Message:  fmt.Sprintf("invalid character class: %s", err),
+					Type:     token.ParseErrorInvalidCharacterClass,
+					Position: patternPosition,
+				}
+			}
+
+			addToken(p.encode(definitionName, class))
+
+			log.Debug("END character class")
+		case '<':
+			log.Debug("NEW variable")
+
+			c = p.scan.Scan()
+
+			justSave := false
+
+			if c == '=' {
+				justSave = true
+
+				c = p.scan.Scan()
+			}
+
+			_, err = p.expectRune(scanner.Ident, c)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			if len(tokens) == 0 {
+				return zeroRune, nil, &token.ParserError{
+					Message:  "Variable has to be assigned to a token",
+					Type:     token.ParseErrorNoTokenForVariable,
+					Position: p.scan.Position,
+				}
+			}
+
+			variableName := p.scan.TokenText()
+
+			_, err = p.expectScanRune('>')
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			// TODO do not overwrite Token names... this sould lead to an already defined error, only variables can overwrite each other
+
+			var variable token.Token
+
+			if justSave {
+				variable = variables.NewVariableSave(variableName, tokens[len(tokens)-1])
+
+				log.Debugf("just-save variable %q as (%p)%#v", variableName, variable, variable)
+			} else {
+				variable = variables.NewVariable(variableName, tokens[len(tokens)-1])
+
+				log.Debugf("variable %q as (%p)%#v", variableName, variable, variable)
+			}
+
+			tokens[len(tokens)-1] = variable
+			variableScope.Set(variableName, variable)
+
+			p.variableUsages = append(p.variableUsages, variable)
+
+			log.Debug("END variable")
+		case ',': // multi line token
+			if p.callArguments > 0 {
+				log.Debug("break out parseTerm for next argument")
+				break OUT
+			}
+
+			if _, err := p.expectScanRune('\n'); err != nil {
+				return zeroRune, nil, err
+			}
+
+			c = p.scan.Scan()
+			log.Debugf("parseTerm multiline %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+			if c == '\n' {
+				return zeroRune, nil, &token.ParserError{
+					Message:  "multi line token definition unexpectedly terminated",
+					Type:     token.ParseErrorUnexpectedTokenDefinitionTermination,
+					Position: p.scan.Position,
+				}
+			}
+
+			continue
+		default:
+			log.Debug("break out parseTerm")
+			break OUT
+		}
+
+		c = p.scan.Scan()
+		log.Debugf("parseTerm %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+	}
+
+	// variables of the term are visible to conditions which follow the term
+	p.termScope = variableScope
+
+	return c, tokens, nil
+}
+
+// parseWeightedTerm parses a term which can hold distributions for its repeat groups and can end with a weight for an alternation term
+func (p *tavorParser) parseWeightedTerm(definitionName string, c rune, variableScope *token.VariableScope) (rune, []token.Token, float64, error) {
+	c, tokens, err := p.parseTerm(definitionName, c, variableScope)
+	if err != nil {
+		return zeroRune, nil, 0, err
+	}
+
+	for c == '~' {
+		c = p.scan.Scan()
+		log.Debugf("parseWeightedTerm after ~ %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+		switch c {
+		case scanner.Int, scanner.Float:
+			weight, err := strconv.ParseFloat(p.scan.TokenText(), 64)
+			if err != nil || weight <= 0 {
+				return zeroRune, nil, 0, &token.ParserError{
+					Message:  fmt.Sprintf("invalid weight %s, weights must be positive numbers", p.scan.TokenText()),
+					Type:     token.ParseErrorInvalidWeight,
+					Position: p.scan.Position,
+				}
+			}
+			if len(tokens) == 0 {
+				return zeroRune, nil, 0, &token.ParserError{
+					Message:  "empty alternation terms cannot be weighted",
+					Type:     token.ParseErrorInvalidWeight,
+					Position: p.scan.Position,
+				}
+			}
+
+			c = p.scan.Scan()
+
+			if c == ',' { // multi line token
+				if _, err := p.expectScanRune('\n'); err != nil {
+					return zeroRune, nil, 0, err
+				}
+
+				c = p.scan.Scan()
+			}
+
+			return c, tokens, weight, nil
+		case scanner.Ident:
+			var repeat *lists.Repeat
+			if len(tokens) != 0 {
+				repeat, _ = tokens[len(tokens)-1].(*lists.Repeat)
+			}
+			if repeat == nil {
+				return zeroRune, nil, 0, &token.ParserError{
+					Message:  "distributions can only be used with repeat groups",
+					Type:     token.ParseErrorInvalidDistribution,
+					Position: p.scan.Position,
+				}
+			}
+
+			var distribution lists.Distribution
+
+			c, distribution, err = p.parseDistribution()
+			if err != nil {
+				return zeroRune, nil, 0, err
+			}
+
+			repeat.SetDistribution(distribution)
+
+			var toks []token.Token
+
+			c, toks, err = p.parseTerm(definitionName, c, variableScope)
+			if err != nil {
+				return zeroRune, nil, 0, err
+			}
+
+			tokens = append(tokens, toks...)
+		default:
+			return zeroRune, nil, 0, &token.ParserError{
+				Message:  fmt.Sprintf("expected weight or distribution but got %s", scanner.TokenString(c)),
+				Type:     token.ParseErrorInvalidWeight,
+				Position: p.scan.Position,
+			}
+		}
+	}
+
+	return c, tokens, 0, nil
+}
+
+func (p *tavorParser) parseDistribution() (rune, lists.Distribution, error) {
+	var c rune
+	var err error
+
+	name := p.scan.TokenText()
+	namePosition := p.scan.Pos()
+
+	parseNumber := func() (float64, error) {
+		c = p.scan.Scan()
+
+		if c != scanner.Int && c != scanner.Float {
+			return 0, &token.ParserError{
+				Message:  fmt.Sprintf("expected number but got %s", scanner.TokenString(c)),
+				Type:     token.ParseErrorInvalidDistribution,
+				Position: p.scan.Position,
+			}
+		}
+
+		return strconv.ParseFloat(p.scan.TokenText(), 64)
+	}
+
+	if _, err = p.expectScanRune('('); err != nil {
+		return zeroRune, nil, err
+	}
+
+	var distribution lists.Distribution
+
+	switch name {
+	case "geometric":
+		probability, err := parseNumber()
+		if err != nil {
+			return zeroRune, nil, err
+		}
+
+		if probability <= 0 || probability > 1 {
+			return zeroRune, nil, &token.ParserError{
+				Message:  fmt.Sprintf("probability %v of geometric distribution is not in the range (0, 1]", probability),
+				Type:     token.ParseErrorInvalidDistribution,
+				Position: p.scan.Position,
+			}
+		}
+
+		distribution = lists.NewGeometricDistribution(probability)
+
+		c = p.scan.Scan()
+	case "histogram":
+		var buckets []lists.HistogramBucket
+
+		for {
+			from, err := parseNumber()
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			to := from
+
+			c = p.scan.Scan()
+			if c == '-' {
+				if to, err = parseNumber(); err != nil {
+					return zeroRune, nil, err
+				}
+
+				c = p.scan.Scan()
+			}
+
+			if _, err = p.expectRune(':', c); err != nil {
+				return zeroRune, nil, err
+			}
+
+			weight, err := parseNumber()
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			if from < 0 || from > to || from != float64(int64(from)) || to != float64(int64(to)) || weight <= 0 {
+				return zeroRune, nil, &token.ParserError{
+					Message:  "histogram buckets need a valid range of repetitions and a positive weight",
+					Type:     token.ParseErrorInvalidDistribution,
+					Position: p.scan.Position,
+				}
+			}
+
+			buckets = append(buckets, lists.HistogramBucket{
+				From:   int64(from),
+				To:     int64(to),
+				Weight: weight,
+			})
+
+			c = p.scan.Scan()
+			if c != ',' {
+				break
+			}
+		}
+
+		distribution = lists.NewHistogramDistribution(buckets...)
+	default:
+		return zeroRune, nil, &token.ParserError{
+			Message:  fmt.Sprintf("unknown distribution %q", name),
+			Type:     token.ParseErrorInvalidDistribution,
+			Position: namePosition,
+		}
+	}
+
+	if _, err = p.expectRune(')', c); err != nil {
+		return zeroRune, nil, err
+	}
+
+	c = p.scan.Scan()
+
+	return c, distribution, nil
+}
+
+func (p *tavorParser) parseExpression(definitionName string, variableScope *token.VariableScope) (rune, token.Token, error) {
+	log.Debug("START expression")
+
+	c := p.scan.Scan()
+	log.Debugf("parseExpression %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+	c, tok, err := p.parseExpressionTerm(definitionName, c, variableScope)
+	if err != nil {
+		return zeroRune, nil, err
+	} else if tok == nil {
+		return zeroRune, nil, &token.ParserError{
+			Message:  "empty expressions are not allowed",
+			Type:     token.ParseErrorEmptyExpressionIsInvalid,
+			Position: p.scan.Pos(), // TODO correct position
+		}
+	}
+
+	log.Debug("END expression")
+
+	return c, tok, nil
+}
+
+// expressionOperators holds the precedences of the binary expression operators. Operators with a higher precedence bind stronger.
+var expressionOperators = map[string]int{
+	"|":  1,
+	"^":  2,
+	"&":  3,
+	"<<": 4,
+	">>": 4,
+	"+":  5,
+	"-":  5,
+	"*":  6,
+	"/":  6,
+	"%":  6,
+}
+
+// expressionOperator returns the binary expression operator beginning with the current rune
+func (p *tavorParser) expressionOperator(c rune) (string, bool) {
+	switch c {
+	case '<', '>':
+		if p.scan.Peek() != c {
+			return "", false
+		}
+
+		return string([]rune{c, c}), true
+	case '|', '^', '&', '+', '-', '*', '/', '%':
+		return string(c), true
+	}
+
+	return "", false
+}
+
+func (p *tavorParser) newArithmetic(operator string, a, b token.Token) token.Token {
+	var tok expressions.ArithmeticToken
+
+	switch operator {
+	case "+":
+		tok = expressions.NewAddArithmetic(a, b)
+	case "-":
+		tok = expressions.NewSubArithmetic(a, b)
+	case "*":
+		tok = expressions.NewMulArithmetic(a, b)
+	case "/":
+		tok = expressions.NewDivArithmetic(a, b)
+	case "%":
+		tok = expressions.NewModArithmetic(a, b)
+	case "&":
+		tok = expressions.NewBitAndArithmetic(a, b)
+	case "|":
+		tok = expressions.NewBitOrArithmetic(a, b)
+	case "^":
+		tok = expressions.NewBitXorArithmetic(a, b)
+	case "<<":
+		tok = expressions.NewShiftLeftArithmetic(a, b)
+	case ">>":
+		tok = expressions.NewShiftRightArithmetic(a, b)
+	default:
+		panic("unknown operator " + operator)
+	}
+
+	if p.arithmetic != nil {
+		tok.SetSemantics(p.arithmetic)
+	}
+
+	return tok
+}
+
+func (p *tavorParser) parseExpressionTerm(definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
+	return p.parseExpressionBinary(definitionName, c, variableScope, 1)
+}
+
+// parseExpressionBinary parses an expression term with binary operators which have at least the given precedence
+func (p *tavorParser) parseExpressionBinary(definitionName string, c rune, variableScope *token.VariableScope, precedence int) (rune, token.Token, error) {
+	c, tok, err := p.parseExpressionUnary(definitionName, c, variableScope)
+	if err != nil {
+		return zeroRune, nil, err
+	} else if tok == nil {
+		return zeroRune, nil, nil
+	}
+
+	return p.parseExpressionOperators(definitionName, c, variableScope, tok, precedence)
+}
+
+// parseExpressionOperators parses binary operators with at least the given precedence which follow an already parsed expression term
+func (p *tavorParser) parseExpressionOperators(definitionName string, c rune, variableScope *token.VariableScope, tok token.Token, precedence int) (rune, token.Token, error) {
+	var err error
+
+	for {
+		operator, ok := p.expressionOperator(c)
+		if !ok || expressionOperators[operator] < precedence {
+			break
+		}
+
+		operatorPosition := p.scan.Position
+
+		if err := checkArithmeticOperand(operator, tok, operatorPosition); err != nil {
+			return zeroRune, nil, err
+		}
+
+		if len(operator) == 2 {
+			// the operator consists of two characters
+			p.scan.Scan()
+		}
+
+		c = p.scan.Scan()
+		log.Debugf("parseExpressionBinary operator %s %d:%v -> %v", operator, p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+		position := p.scan.Position
+
+		// operators with the same precedence are left associative
+		var t token.Token
+		c, t, err = p.parseExpressionBinary(definitionName, c, variableScope, expressionOperators[operator]+1)
+		if err != nil {
+			return zeroRune, nil, err
+		} else if t == nil {
+			return zeroRune, nil, &token.ParserError{
+				Message:  "expected another expression term after operator",
+				Type:     token.ParseErrorExpectedExpressionTerm,
+				Position: p.scan.Position,
+			}
+		}
+
+		if err := checkArithmeticOperand(operator, t, position); err != nil {
+			return zeroRune, nil, err
+		}
+
+		if v, ok := constantExpressionValue(t); ok {
+			var message string
+
+			switch {
+			case (operator == "/" || operator == "%") && v.Sign() == 0:
+				message = "division by zero"
+			case (operator == "<<" || operator == ">>") && v.Sign() < 0:
+				message = "negative shift count"
+			}
+
+			if message != "" {
+				return zeroRune, nil, &token.ParserError{
+					Message:  message,
+					Type:     token.ParseErrorInvalidArithmeticOperand,
+					Position: position,
+				}
+			}
+		}
+
+		tok = p.newArithmetic(operator, tok, t)
+	}
+
+	return c, tok, nil
+}
+
+// checkArithmeticOperand reports an error if the operand of an arithmetic operator is constant but not an integer
+func checkArithmeticOperand(operator string, tok token.Token, position scanner.Position) error {
+	constant := true
+
+	_ = token.WalkInternal(tok, func(tok token.Token) error {
+		switch t := tok.(type) {
+		case *primitives.ConstantInt, *primitives.ConstantString, *lists.All, expressions.ArithmeticToken:
+		case *primitives.Pointer:
+			// unresolved references are not constant
+			if t.InternalGet() == nil {
+				constant = false
+			}
+		case *primitives.Scope, *variables.Variable, *variables.VariableSave, *variables.VariableValue:
+		default:
+			constant = false
+		}
+
+		return nil
+	})
+
+	if !constant {
+		return nil
+	}
+
+	if _, ok := new(big.Int).SetString(tok.String(), 10); ok {
+		return nil
+	}
+
+	return &token.ParserError{
+		Message:  fmt.Sprintf("operand %q of operator %q is not an integer", tok.String(), operator),
+		Type:     token.ParseErrorInvalidArithmeticOperand,
+		Position: position,
+	}
+}
+
+// constantExpressionValue returns the value of an expression term which consists only of constant integers
+func constantExpressionValue(tok token.Token) (*big.Int, bool) {
+	constant := true
+
+	_ = token.WalkInternal(tok, func(tok token.Token) error {
+		switch tok.(type) {
+		case *primitives.ConstantInt, expressions.ArithmeticToken:
+		default:
+			constant = false
+		}
+
+		return nil
+	})
+
+	if !constant {
+		return nil, false
+	}
+
+	return new(big.Int).SetString(tok.String(), 10)
+}
+
+// parseExpressionUnary parses a single expression term with its unary operators
+func (p *tavorParser) parseExpressionUnary(definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
+	var tok token.Token
+	var err error
+
+	// single term
+	switch c {
+	case '-':
+		c = p.scan.Scan()
+
+		position := p.scan.Position
+
+		if c == scanner.Int {
+			// negative numbers are constants on their own
+			v, err := p.parseExpressionInt("-" + p.scan.TokenText())
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			tok = primitives.NewConstantInt(v)
+
+			c = p.scan.Scan()
+
+			break
+		}
+
+		c, tok, err = p.parseExpressionUnary(definitionName, c, variableScope)
+		if err != nil {
+			return zeroRune, nil, err
+		} else if tok == nil {
+			return zeroRune, nil, &token.ParserError{
+				Message:  "expected another expression term after unary minus",
+				Type:     token.ParseErrorExpectedExpressionTerm,
+				Position: p.scan.Position,
+			}
+		}
+
+		if err := checkArithmeticOperand("-", tok, position); err != nil {
+			return zeroRune, nil, err
+		}
+
+		neg := expressions.NewNegArithmetic(tok)
+		if p.arithmetic != nil {
+			neg.SetSemantics(p.arithmetic)
+		}
+
+		return c, neg, nil
+	case '(':
+		c = p.scan.Scan()
+
+		c, tok, err = p.parseExpressionTerm(definitionName, c, variableScope)
+		if err != nil {
+			return zeroRune, nil, err
+		} else if tok == nil {
+			return zeroRune, nil, &token.ParserError{
+				Message:  "empty parentheses are not allowed in expressions",
+				Type:     token.ParseErrorExpectedExpressionTerm,
+				Position: p.scan.Position,
+			}
+		}
+
+		if _, err = p.expectRune(')', c); err != nil {
+			return zeroRune, nil, err
+		}
+
+		c = p.scan.Scan()
+	case scanner.Ident:
+		attribute := p.scan.TokenText()
+
+		switch attribute {
+		case "defined":
+			_, err := p.expectScanRune(scanner.Ident)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			name := p.scan.TokenText()
+
+			c = p.scan.Scan()
+
+			return c, conditions.NewVariableDefined(name, variableScope), nil
+		default:
+			if p.scan.Peek() == '.' {
+				c, tok, err = p.parseTokenAttribute(definitionName, c, variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+			} else {
+				name := p.scan.TokenText()
+
+				variableScope = variableScope.Push()
+				tok = p.getToken(definitionName, name, variableScope)
+
+				c = p.scan.Scan()
+			}
+		}
+	case scanner.Int:
+		v, err := p.parseExpressionInt(p.scan.TokenText())
+		if err != nil {
+			return zeroRune, nil, err
+		}
+
+		tok = primitives.NewConstantInt(v)
+
+		c = p.scan.Scan()
+	}
+
+	if tok == nil {
+		return zeroRune, nil, nil
+	}
+
+	log.Debugf("parseExpressionUnary %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+	// operators which are not arithmetic
+	if c == scanner.Ident {
+		switch op := p.scan.TokenText(); op {
+		case "path":
+			c, tok, err = p.parseExpressionOperatorPath(tok, definitionName, c, variableScope)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+		case "and", "or", "not", "in":
+			// boolean operators are parsed by conditions
+		default:
+			return zeroRune, nil, &token.ParserError{
+				Message:  fmt.Sprintf("Operator %q is unknown", op),
+				Type:     token.ParseErrorUnkownOperator,
+				Position: p.scan.Position,
+			}
+		}
+	}
+
+	return c, tok, nil
+}
+
+// parseExpressionInt parses a decimal or hexadecimal integer of an expression
+func (p *tavorParser) parseExpressionInt(s string) (int, error) {
+	base := 10
+
+	n := strings.TrimPrefix(s, "-")
+	if strings.HasPrefix(n, "0x") || strings.HasPrefix(n, "0X") {
+		base = 16
+		n = n[2:]
+	}
+
+	v, err := strconv.ParseInt(n, base, 0)
+	if err != nil {
+		return 0, &token.ParserError{
+			Message:  fmt.Sprintf("invalid integer %s", s),
+			Type:     token.ParseErrorInvalidArgumentValue,
+			Position: p.scan.Position,
+		}
+	}
+
+	if s[0] == '-' {
+		v = -v
+	}
+
+	return int(v), nil
+}
+
+func (p *tavorParser) parseExpressionGroup(definitionName string, variableScope *token.VariableScope, max int) ([]token.Token, error) {
+	_, err := p.expectScanRune('(')
+	if err != nil {
+		return nil, err
+	}
+
+	c := p.scan.Scan()
+
+	var tok token.Token
+	var toks []token.Token
+
+	for {
+		c, tok, err = p.parseExpressionTerm(definitionName, c, variableScope) // TODO
+		if err != nil {
+			return nil, err
+		} else if tok == nil {
+			return nil, &token.ParserError{
+				Message:  "expected a expression",
+				Type:     token.ParseErrorExpectedExpressionTerm,
+				Position: p.scan.Position,
+			}
+		}
+
+		toks = append(toks, tok)
+
+		if (max != -1 && len(toks) >= max) || c != ',' {
+			break
+		}
+
+		c = p.scan.Scan()
+	}
+
+	_, err = p.expectRune(')', c)
+	if err != nil {
+		return nil, err
+	}
+
+	return toks, err
+}
+
+func (p *tavorParser) parseExpressionOperatorNotIn(existingItem func(except []token.Token) token.Token, definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
+	log.Debugf("Start not in operator")
+	defer log.Debugf("End not in operator")
+
+	_, err := p.expectText("not", c)
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	_, err = p.expectScanText("in")
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	expectToks, err := p.parseExpressionGroup(definitionName, variableScope, -1)
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	c = p.scan.Scan()
+
+	return c, existingItem(expectToks), nil
+}
+
+func (p *tavorParser) parseExpressionOperatorPath(tok token.Token, definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
+	log.Debug("Start path operator")
+	defer log.Debug("End path operator")
+
+	listPosition := p.scan.Pos()
+	/*l, ok := tok.(token.ListToken)
+	if !ok {
+		return zeroRune, nil, &token.ParserError{
+			Message:  "expected list token",
+			Type:     token.ParseErrorInvalidTokenType,
+			Position: p.scan.Position,
+		}
+	}*/
+
+	_, err := p.expectScanText("from")
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	ts, err := p.parseExpressionGroup(definitionName, variableScope, 1)
+	if err != nil {
+		return zeroRune, nil, err
+	}
+	from := ts[0]
+
+	log.Debugf("path operator from %p(%#v)", from, from)
+
+	nVariableScope := variableScope.Push()
+	nVariableScope.Set("e", variables.NewVariable("e", nil))
+
+	_, err = p.expectScanText("over")
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	ts, err = p.parseExpressionGroup(definitionName, nVariableScope, 1)
+	if err != nil {
+		return zeroRune, nil, err
+	}
+	over := ts[0]
+
+	_, err = p.expectScanText("connect")
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	_, err = p.expectScanText("by")
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	connects, err := p.parseExpressionGroup(definitionName, nVariableScope, -1)
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	_, err = p.expectScanText("without")
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	withouts, err := p.parseExpressionGroup(definitionName, nVariableScope, -1)
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	c = p.scan.Scan()
+
+	tok, err = expressions.NewPath(tok, from, over, connects, withouts)
+	if err != nil {
+		err.(*token.ParserError).Position = listPosition
+	}
+
+	return c, tok, nil
+}
+
+func (p *tavorParser) parseTokenAttribute(definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
+	log.Debug("new token attribute")
+
+	_, err := p.expectRune(scanner.Ident, c)
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	name := p.scan.TokenText()
+
+	tokenPosition := p.scan.Position
+
+	_, err = p.expectScanRune('.')
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	_, err = p.expectScanRune(scanner.Ident)
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	attribute := p.scan.TokenText()
+	attributePosition := p.scan.Position
+
+	var op string
+	var opToken token.Token
+
+	c = p.scan.Scan()
+
+	var tok token.Token
+
+	tokenName := p.prefix + name
+
+	use, ok := p.lookup[tokenName]
+	if a, isArgument := variableScope.Get(name).(*argument); isArgument {
+		tok = a.Token
+
+		// attributes of variable arguments are the attributes of the variable
+		if v, ok := tok.(*variables.VariableValue); ok {
+			tok = v.InternalGet()
+		}
+	} else if ok {
+		tok = use.token
+	} else {
+		tok = variableScope.Get(name)
+
+		isPointer := false
+
+		if tok != nil {
+			if _, pp := tok.(*primitives.Pointer); pp {
+				isPointer = true
+			}
+		}
+
+		if tok == nil || isPointer {
+			log.Debugf("parseTokenAttribute use empty pointer for %s.%s", name, attribute)
+
+			var tokenInterface *token.Token
+
+			pointer := primitives.NewEmptyPointer(tokenInterface)
+			nPointer := primitives.NewPointer(pointer)
+
+			variableScope.Set(name, nPointer)
+
+			nVariableScope := variableScope.Push()
+
+			p.forwardAttributeUsage = append(p.forwardAttributeUsage, attributeForwardUsage{
+				definitionName:    definitionName,
+				tokenName:         tokenName,
+				tokenPosition:     tokenPosition,
+				attribute:         attribute,
+				attributePosition: attributePosition,
+				operator:          op,
+				operatorToken:     opToken,
+				pointer:           pointer,
+				variableScope:     nVariableScope,
+				prefix:            p.prefix,
+			})
+
+			return c, nPointer, nil
+		}
+	}
+
+	p.used[tokenName] = append(p.earlyUse[tokenName], tokenUsage{
+		token:          nil,
+		position:       tokenPosition,
+		variableScope:  variableScope,
+		definitionName: definitionName,
+	})
+
+	c, rtok, err := p.selectTokenAttribute(definitionName, tok, name, attribute, attributePosition, op, opToken, c, variableScope)
 
-						if _, ok := v.(*constraints.Optional); ok {
-							return zeroRune, nil, &token.ParserError{
-								Message:  "repeats with an optional are not allowed",
-								Type:     token.ParseErrorRepeatWithOptionalTerm,
-								Position: p.scan.Pos(),
-							}
-						}
-					}
-				}
+	if err == nil {
+		log.Debugf("Insert token attribute %p(%#v)", rtok, rtok)
+	}
 
-				addToken(lists.NewRepeatWithTokens(toks[0], from, to))
-			default:
-				addToken(lists.NewRepeatWithTokens(lists.NewAll(toks...), from, to))
-			}
+	return c, rtok, err
+}
 
-			log.Debug("END repeat")
-		case '@':
-			log.Debug("NEW once")
+func (p *tavorParser) selectTokenAttribute(definitionName string, tok token.Token, tokenName string, attribute string, attributePosition scanner.Position, operator string, operatorToken token.Token, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
+	if t, ok := tok.(*primitives.Scope); ok {
+		tok = t.Resolve()
+	}
+	if t, ok := tok.(*encodings.Encoded); ok {
+		tok = t.Get()
+	}
 
-			_, err = p.expectScanRune('(')
+	log.Debugf("use (%p)%#v as token", tok, tok)
+
+	log.Debug("finished token attribute (or will be unknown token attribute)")
+
+	switch i := tok.(type) {
+	case token.ListToken:
+		switch attribute {
+		case "Count":
+			return c, aggregates.NewLen(i), nil
+		case "Item":
+			_, err := p.expectRune('(', c)
 			if err != nil {
 				return zeroRune, nil, err
 			}
 
 			c = p.scan.Scan()
-			log.Debugf("parseTerm once after ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
-			c, toks, err := p.parseScope(definitionName, c, variableScope)
+			c, index, err := p.parseExpressionTerm(definitionName, c, variableScope)
 			if err != nil {
 				return zeroRune, nil, err
 			}
@@ -527,886 +2210,1065 @@ OUT:
 				return zeroRune, nil, err
 			}
 
-			switch len(toks) {
-			case 1:
-				if t, ok := toks[0].(*lists.One); ok {
-					le := t.InternalLen()
-					tl := make([]token.Token, le)
-					for i := 0; i < le; i++ {
-						tl[i], _ = t.InternalGet(i)
-					}
+			c = p.scan.Scan()
 
-					addToken(lists.NewOnce(tl...))
-				} else {
-					addToken(lists.NewOnce(toks[0]))
-				}
-			default:
-				addToken(lists.NewOnce(toks...))
+			return c, lists.NewListItem(index, i), nil
+		case "Unique":
+			return c, lists.NewUniqueItem(i), nil
+		}
+	case *sequences.Sequence:
+		switch attribute {
+		case "Existing":
+			if c == scanner.Ident && p.scan.TokenText() == "not" {
+				return p.parseExpressionOperatorNotIn(func(except []token.Token) token.Token {
+					return i.ExistingItem(except)
+				}, definitionName, c, variableScope)
 			}
 
-			log.Debug("END once")
-		case '$':
-			c = p.scan.Scan()
-			log.Debugf("parseTerm after $ ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
-
-			var tok token.Token
+			return c, i.ExistingItem(nil), nil
+		case "Next":
+			return c, i.Item(), nil
+		case "Reset":
+			return c, i.ResetItem(), nil
+		}
+	case *dictionaries.Dictionary:
+		switch attribute {
+		case "Existing":
+			if c == scanner.Ident && p.scan.TokenText() == "not" {
+				return p.parseExpressionOperatorNotIn(func(except []token.Token) token.Token {
+					return i.ExistingItem(except)
+				}, definitionName, c, variableScope)
+			}
 
-			if c == '{' {
-				c, tok, err = p.parseExpression(definitionName, variableScope)
+			return c, i.ExistingItem(nil), nil
+		case "Unique":
+			return c, i.UniqueItem(), nil
+		case "Reset":
+			return c, i.ResetItem(), nil
+		}
+	case *sets.Set:
+		switch attribute {
+		case "Add":
+			if i.IsMap() {
+				c, value, err := p.parseTokenAttributeArgument(definitionName, c, variableScope)
 				if err != nil {
 					return zeroRune, nil, err
 				}
 
-				_, err = p.expectRune('}', c)
-				if err != nil {
-					return zeroRune, nil, err
-				}
+				return c, i.AddValueItem(value), nil
+			}
 
-				c = p.scan.Scan()
-			} else {
-				c, tok, err = p.parseTokenAttribute(definitionName, c, variableScope)
+			return c, i.AddItem(), nil
+		case "Count":
+			return c, i.CountItem(), nil
+		case "Existing":
+			if c == scanner.Ident && p.scan.TokenText() == "not" {
+				return p.parseExpressionOperatorNotIn(func(except []token.Token) token.Token {
+					return i.ExistingItem(except)
+				}, definitionName, c, variableScope)
+			}
+
+			return c, i.ExistingItem(nil), nil
+		case "Missing":
+			return c, i.MissingItem(), nil
+		case "Remove":
+			return c, i.RemoveItem(), nil
+		case "Replace":
+			if !i.IsMap() {
+				break
 			}
 
+			c, value, err := p.parseTokenAttributeArgument(definitionName, c, variableScope)
 			if err != nil {
 				return zeroRune, nil, err
 			}
 
-			addToken(tok)
-
-			continue
-		case '[':
-			log.Debug("NEW character class")
-
-			var pattern bytes.Buffer
-
-			p.scan.Whitespace ^= 1 << ' '
-
-			c = p.scan.Scan()
-
-			for c != ']' && c != '\n' && c != scanner.EOF {
-				if _, err := pattern.WriteString(p.scan.TokenText()); err != nil {
-					panic(err)
-				}
-
-				c = p.scan.Scan()
+			return c, i.ReplaceItem(value), nil
+		case "Reset":
+			return c, i.ResetItem(), nil
+		case "Value":
+			if !i.IsMap() {
+				break
 			}
 
-			p.scan.Whitespace |= 1 << ' '
-
-			if c != ']' {
-				_, err := p.expectRune(']', c)
-
+			c, key, err := p.parseTokenAttributeArgument(definitionName, c, variableScope)
+			if err != nil {
 				return zeroRune, nil, err
 			}
 
-			addToken(primitives.NewCharacterClass(pattern.String()))
-
-			log.Debug("END character class")
-		case '<':
-			log.Debug("NEW variable")
+			return c, i.ValueItem(key), nil
+		}
+	case *primitives.RangeInt:
+		switch attribute {
+		case "Value":
+			return c, i.Clone(), nil
+		}
+	case *primitives.RangeFloat:
+		switch attribute {
+		case "Value":
+			return c, i.Clone(), nil
+		}
+	case *primitives.RangeTime:
+		switch attribute {
+		case "Value":
+			return c, i.Clone(), nil
+		}
+	case *primitives.RangeDuration:
+		switch attribute {
+		case "Value":
+			return c, i.Clone(), nil
+		}
+	case token.VariableToken:
+		switch attribute {
+		case "Count":
+			return c, aggregates.NewLen(i), nil
+		case "Index":
+			return c, lists.NewIndexItem(variables.NewVariableValue(i)), nil
+		case "Item":
+			_, err := p.expectRune('(', c)
+			if err != nil {
+				return zeroRune, nil, err
+			}
 
 			c = p.scan.Scan()
 
-			justSave := false
-
-			if c == '=' {
-				justSave = true
-
-				c = p.scan.Scan()
-			}
-
-			_, err = p.expectRune(scanner.Ident, c)
+			c, index, err := p.parseExpressionTerm(definitionName, c, variableScope) // TODO
 			if err != nil {
 				return zeroRune, nil, err
 			}
 
-			if len(tokens) == 0 {
-				return zeroRune, nil, &token.ParserError{
-					Message:  "Variable has to be assigned to a token",
-					Type:     token.ParseErrorNoTokenForVariable,
-					Position: p.scan.Pos(),
-				}
-			}
-
-			variableName := p.scan.TokenText()
-
-			_, err = p.expectScanRune('>')
+			_, err = p.expectRune(')', c)
 			if err != nil {
 				return zeroRune, nil, err
 			}
 
-			// TODO do not overwrite Token names... this sould lead to an already defined error, only variables can overwrite each other
+			c = p.scan.Scan()
 
-			var variable token.Token
+			return c, variables.NewVariableItem(index, i), nil
+		case "Reference":
+			return c, variables.NewVariableReference(variables.NewVariable(tokenName, nil)), nil
+		case "Value":
+			v := variables.NewVariableValue(i)
 
-			if justSave {
-				variable = variables.NewVariableSave(variableName, tokens[len(tokens)-1])
+			p.variableUsages = append(p.variableUsages, i)
 
-				log.Debugf("just-save variable %q as (%p)%#v", variableName, variable, variable)
-			} else {
-				variable = variables.NewVariable(variableName, tokens[len(tokens)-1])
+			return c, v, nil
+		}
+	}
 
-				log.Debugf("variable %q as (%p)%#v", variableName, variable, variable)
-			}
+	return zeroRune, nil, &token.ParserError{
+		Message:  fmt.Sprintf("unknown token attribute %q for token type %q", attribute, reflect.TypeOf(tok)),
+		Type:     token.ParseErrorUnknownTokenAttribute,
+		Position: attributePosition,
+	}
+}
 
-			tokens[len(tokens)-1] = variable
-			variableScope.Set(variableName, variable)
+// parseTokenAttributeArgument parses the expression term argument of a token attribute which is enclosed in parentheses
+func (p *tavorParser) parseTokenAttributeArgument(definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
+	_, err := p.expectRune('(', c)
+	if err != nil {
+		return zeroRune, nil, err
+	}
 
-			p.variableUsages = append(p.variableUsages, variable)
+	c = p.scan.Scan()
 
-			log.Debug("END variable")
-		case ',': // multi line token
-			if _, err := p.expectScanRune('\n'); err != nil {
-				return zeroRune, nil, err
-			}
+	c, argument, err := p.parseExpressionTerm(definitionName, c, variableScope)
+	if err != nil {
+		return zeroRune, nil, err
+	} else if argument == nil {
+		return zeroRune, nil, &token.ParserError{
+			Message:  "expected an expression term as argument",
+			Type:     token.ParseErrorExpectedExpressionTerm,
+			Position: p.scan.Position,
+		}
+	}
 
-			c = p.scan.Scan()
-			log.Debugf("parseTerm multiline %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+	_, err = p.expectRune(')', c)
+	if err != nil {
+		return zeroRune, nil, err
+	}
 
-			if c == '\n' {
-				return zeroRune, nil, &token.ParserError{
-					Message:  "multi line token definition unexpectedly terminated",
-					Type:     token.ParseErrorUnexpectedTokenDefinitionTermination,
-					Position: p.scan.Pos(),
-				}
-			}
+	return p.scan.Scan(), argument, nil
+}
 
-			continue
-		default:
-			log.Debug("break out parseTerm")
-			break OUT
+// TokenAttributes returns the names of the token attributes which can be selected for the given token e.g. "Count" for list tokens
+func TokenAttributes(tok token.Token) []string {
+	if t, ok := tok.(*primitives.Scope); ok {
+		tok = t.Resolve()
+	}
+	if t, ok := tok.(*encodings.Encoded); ok {
+		tok = t.Get()
+	}
+
+	switch t := tok.(type) {
+	case token.ListToken:
+		return []string{"Count", "Item", "Unique"}
+	case *sequences.Sequence:
+		return []string{"Existing", "Next", "Reset"}
+	case *dictionaries.Dictionary:
+		return []string{"Existing", "Reset", "Unique"}
+	case *sets.Set:
+		if t.IsMap() {
+			return []string{"Add", "Count", "Existing", "Missing", "Remove", "Replace", "Reset", "Value"}
 		}
 
-		c = p.scan.Scan()
-		log.Debugf("parseTerm %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+		return []string{"Add", "Count", "Existing", "Missing", "Remove", "Reset"}
+	case *primitives.RangeInt, *primitives.RangeFloat, *primitives.RangeTime, *primitives.RangeDuration:
+		return []string{"Value"}
+	case token.VariableToken:
+		return []string{"Count", "Index", "Item", "Reference", "Value"}
 	}
 
-	return c, tokens, nil
+	return nil
 }
 
-func (p *tavorParser) parseExpression(definitionName string, variableScope *token.VariableScope) (rune, token.Token, error) {
-	log.Debug("START expression")
+func (p *tavorParser) parseScope(definitionName string, c rune, variableScope *token.VariableScope) (rune, []token.Token, error) {
+	var err error
+	var tokens []token.Token
 
-	c := p.scan.Scan()
-	log.Debugf("parseExpression %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+	var toks []token.Token
+	var weight float64
 
-	c, tok, err := p.parseExpressionTerm(definitionName, c, variableScope)
+	c, toks, weight, err = p.parseWeightedTerm(definitionName, c, variableScope)
 	if err != nil {
 		return zeroRune, nil, err
-	} else if tok == nil {
+	} else if toks != nil {
+		tokens = toks
+	}
+
+	if weight != 0 && c != '|' {
 		return zeroRune, nil, &token.ParserError{
-			Message:  "empty expressions are not allowed",
-			Type:     token.ParseErrorEmptyExpressionIsInvalid,
-			Position: p.scan.Pos(), // TODO correct position
+			Message:  "weights can only be used with alternation terms",
+			Type:     token.ParseErrorInvalidWeight,
+			Position: p.scan.Position,
 		}
 	}
 
-	log.Debug("END expression")
+	var ifPairs []conditions.IfPair
+	var ifPosition scanner.Position
 
-	return c, tok, nil
-}
+SCOPE:
+	for {
+		switch c {
+		case '|':
+			log.Debug("NEW or")
 
-func (p *tavorParser) parseExpressionTerm(definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
-	var tok token.Token
-	var err error
+			var orTerms []token.Token
+			var orWeights []float64
+			optional := false
+			weighted := false
 
-	// single term
-	switch c {
-	case scanner.Ident:
-		attribute := p.scan.TokenText()
+			toks = tokens
 
-		switch attribute {
-		case "defined":
-			_, err := p.expectScanRune(scanner.Ident)
-			if err != nil {
-				return zeroRune, nil, err
-			}
+		OR:
+			for {
+				switch len(toks) {
+				case 0:
+					optional = true
+				case 1:
+					orTerms = append(orTerms, toks[0])
+				default:
+					orTerms = append(orTerms, lists.NewAll(toks...))
+				}
 
-			name := p.scan.TokenText()
+				if len(toks) != 0 {
+					if weight == 0 {
+						orWeights = append(orWeights, 1)
+					} else {
+						orWeights = append(orWeights, weight)
+						weighted = true
+					}
+				}
 
-			c = p.scan.Scan()
+				if c == '|' {
+					c = p.scan.Scan()
+					log.Debugf("parseScope Or %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+				} else {
+					log.Debug("parseScope break out or")
 
-			return c, conditions.NewVariableDefined(name, variableScope), nil
-		default:
-			if p.scan.Peek() == '.' {
-				c, tok, err = p.parseTokenAttribute(definitionName, c, variableScope)
+					break OR
+				}
+
+				c, toks, weight, err = p.parseWeightedTerm(definitionName, c, variableScope)
 				if err != nil {
 					return zeroRune, nil, err
 				}
-			} else {
-				name := p.scan.TokenText()
-
-				variableScope = variableScope.Push()
-				tok = p.getToken(definitionName, name, variableScope)
+			}
 
-				c = p.scan.Scan()
+			var or *lists.One
+			if weighted {
+				or = lists.NewOneWithWeights(orWeights, orTerms...)
+			} else {
+				or = lists.NewOne(orTerms...)
 			}
-		}
-	case scanner.Int:
-		v, _ := strconv.Atoi(p.scan.TokenText())
 
-		tok = primitives.NewConstantInt(v)
+			if optional {
+				tokens = []token.Token{constraints.NewOptional(or)}
+			} else {
+				tokens = []token.Token{or}
+			}
 
-		c = p.scan.Scan()
-	}
+			log.Debug("END or")
+		case '{':
+			log.Debug("NEW condition")
 
-	if tok == nil {
-		return zeroRune, nil, nil
-	}
+			c = p.scan.Scan()
+			condition := p.scan.TokenText()
+			conditionPosition := p.scan.Position
 
-	log.Debugf("parseExpressionTerm %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+			var conditionExpression conditions.BooleanExpression
 
-	// operators
-	switch c {
-	case '+', '-', '*', '/':
-		sym := c
+			switch condition {
+			case "if":
+				log.Debug("found IF")
 
-		c = p.scan.Scan()
-		log.Debugf("parseExpressionTerm operator %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+				ifPosition = conditionPosition
 
-		var t token.Token
-		c, t, err = p.parseExpressionTerm(definitionName, c, variableScope)
-		if err != nil {
-			return zeroRune, nil, err
-		} else if t == nil {
-			return zeroRune, nil, &token.ParserError{
-				Message:  "expected another expression term after operator",
-				Type:     token.ParseErrorExpectedExpressionTerm,
-				Position: p.scan.Pos(),
-			}
-		}
+				c, conditionExpression, err = p.parseConditionExpression(definitionName, p.termScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+			case "else":
+				if len(ifPairs) == 0 {
+					return zeroRune, nil, &token.ParserError{
+						Message:  "else without if",
+						Type:     token.ParseErrorConditionWithoutIf,
+						Position: conditionPosition,
+					}
+				}
 
-		switch sym {
-		case '+':
-			tok = expressions.NewAddArithmetic(tok, t)
-		case '-':
-			tok = expressions.NewSubArithmetic(tok, t)
-		case '*':
-			tok = expressions.NewMulArithmetic(tok, t)
-		case '/':
-			tok = expressions.NewDivArithmetic(tok, t)
-		}
-	case scanner.Ident:
-		switch op := p.scan.TokenText(); op {
-		case "path":
-			c, tok, err = p.parseExpressionOperatorPath(tok, definitionName, c, variableScope)
-			if err != nil {
-				return zeroRune, nil, err
-			}
-		default:
-			return zeroRune, nil, &token.ParserError{
-				Message:  fmt.Sprintf("Operator %q is unknown", op),
-				Type:     token.ParseErrorUnkownOperator,
-				Position: p.scan.Pos(),
-			}
-		}
-	}
+				c = p.scan.Scan()
 
-	return c, tok, nil
-}
+				if p.scan.TokenText() == "if" {
+					log.Debug("found ELSEIF")
 
-func (p *tavorParser) parseExpressionGroup(definitionName string, variableScope *token.VariableScope, max int) ([]token.Token, error) {
-	_, err := p.expectScanRune('(')
-	if err != nil {
-		return nil, err
-	}
+					c, conditionExpression, err = p.parseConditionExpression(definitionName, p.termScope)
+					if err != nil {
+						return zeroRune, nil, err
+					}
+				} else {
+					log.Debug("found ELSE")
 
-	c := p.scan.Scan()
+					conditionExpression = conditions.NewBooleanTrue()
+				}
+			case "endif":
+				log.Debug("found ENDIF")
 
-	var tok token.Token
-	var toks []token.Token
+				if len(ifPairs) == 0 {
+					return zeroRune, nil, &token.ParserError{
+						Message:  "endif without if",
+						Type:     token.ParseErrorConditionWithoutIf,
+						Position: conditionPosition,
+					}
+				}
 
-	for {
-		c, tok, err = p.parseExpressionTerm(definitionName, c, variableScope) // TODO
-		if err != nil {
-			return nil, err
-		} else if tok == nil {
-			return nil, &token.ParserError{
-				Message:  "expected a expression",
-				Type:     token.ParseErrorExpectedExpressionTerm,
-				Position: p.scan.Pos(),
+				c = p.scan.Scan()
+			default:
+				return zeroRune, nil, &token.ParserError{
+					Message:  fmt.Sprintf("unknown condition %q", condition),
+					Type:     token.ParseErrorUnknownCondition,
+					Position: p.scan.Position,
+				}
 			}
-		}
 
-		toks = append(toks, tok)
+			_, err = p.expectRune('}', c)
+			if err != nil {
+				return zeroRune, nil, err
+			}
 
-		if (max != -1 && len(toks) >= max) || c != ',' {
-			break
-		}
+			c = p.scan.Scan()
 
-		c = p.scan.Scan()
-	}
+			if condition != "endif" {
+				c, toks, err = p.parseTerm(definitionName, c, p.termScope) // TODO this should be a a global scope or so ... we can do nesting
+				if err != nil {
+					return zeroRune, nil, err
+				}
 
-	_, err = p.expectRune(')', c)
-	if err != nil {
-		return nil, err
-	}
+				var tok token.Token
 
-	return toks, err
-}
+				switch len(toks) {
+				case 0:
+					return zeroRune, nil, &token.ParserError{
+						Message:  fmt.Sprintf("%s statement has an empty body", condition),
+						Type:     token.ParseErrorEmptyConditionBody,
+						Position: conditionPosition,
+					}
+				case 1:
+					tok = toks[0]
+				default:
+					tok = lists.NewAll(toks...)
+				}
 
-func (p *tavorParser) parseExpressionOperatorNotIn(tok *sequences.Sequence, definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
-	log.Debugf("Start not in operator")
-	defer log.Debugf("End not in operator")
+				ifPart := conditions.IfPair{
+					Head: conditionExpression,
+					Body: tok,
+				}
 
-	_, err := p.expectText("not", c)
-	if err != nil {
-		return zeroRune, nil, err
-	}
+				log.Debugf("Add if part %#v", ifPart)
 
-	_, err = p.expectScanText("in")
-	if err != nil {
-		return zeroRune, nil, err
-	}
+				ifPairs = append(ifPairs, ifPart)
+			} else {
+				tokens = append(tokens, conditions.NewIf(ifPairs...))
 
-	expectToks, err := p.parseExpressionGroup(definitionName, variableScope, -1)
-	if err != nil {
-		return zeroRune, nil, err
-	}
+				ifPairs = nil
 
-	c = p.scan.Scan()
+				c, toks, err = p.parseTerm(definitionName, c, p.termScope) // TODO this should be a a global scope or so ... we can do nesting
+				if err != nil {
+					return zeroRune, nil, err
+				}
 
-	return c, tok.ExistingItem(expectToks), nil
-}
+				tokens = append(tokens, toks...)
+			}
 
-func (p *tavorParser) parseExpressionOperatorPath(tok token.Token, definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
-	log.Debug("Start path operator")
-	defer log.Debug("End path operator")
+			log.Debug("END condition")
 
-	listPosition := p.scan.Pos()
-	/*l, ok := tok.(token.ListToken)
-	if !ok {
-		return zeroRune, nil, &token.ParserError{
-			Message:  "expected list token",
-			Type:     token.ParseErrorInvalidTokenType,
-			Position: p.scan.Pos(),
+			continue SCOPE
 		}
-	}*/
 
-	_, err := p.expectScanText("from")
-	if err != nil {
-		return zeroRune, nil, err
+		break SCOPE
 	}
 
-	ts, err := p.parseExpressionGroup(definitionName, variableScope, 1)
-	if err != nil {
-		return zeroRune, nil, err
+	if len(ifPairs) > 0 {
+		return zeroRune, nil, &token.ParserError{
+			Message:  "if without endif",
+			Type:     token.ParseErrorMissingEndif,
+			Position: ifPosition,
+		}
 	}
-	from := ts[0]
 
-	log.Debugf("path operator from %p(%#v)", from, from)
+	return c, tokens, nil
+}
 
-	nVariableScope := variableScope.Push()
-	nVariableScope.Set("e", variables.NewVariable("e", nil))
+func (p *tavorParser) parseConditionExpression(definitionName string, variableScope *token.VariableScope) (rune, conditions.BooleanExpression, error) {
+	c := p.scan.Scan()
+	log.Debugf("parseConditionExpression %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
-	_, err = p.expectScanText("over")
+	c, tok, err := p.parseConditionOr(definitionName, c, variableScope)
 	if err != nil {
 		return zeroRune, nil, err
 	}
 
-	ts, err = p.parseExpressionGroup(definitionName, nVariableScope, 1)
+	ex, err := p.expectBooleanExpression(tok, c)
 	if err != nil {
 		return zeroRune, nil, err
 	}
-	over := ts[0]
 
-	_, err = p.expectScanText("connect")
-	if err != nil {
-		return zeroRune, nil, err
-	}
+	return c, ex, nil
+}
 
-	_, err = p.expectScanText("by")
-	if err != nil {
-		return zeroRune, nil, err
+// expectBooleanExpression returns the given token as boolean expression or an error if the token is no boolean expression
+func (p *tavorParser) expectBooleanExpression(tok token.Token, c rune) (conditions.BooleanExpression, error) {
+	if ex, ok := tok.(conditions.BooleanExpression); ok {
+		return ex, nil
 	}
 
-	connects, err := p.parseExpressionGroup(definitionName, nVariableScope, -1)
-	if err != nil {
-		return zeroRune, nil, err
+	op := scanner.TokenString(c)
+	if c == scanner.Ident {
+		op = p.scan.TokenText()
 	}
 
-	_, err = p.expectScanText("without")
-	if err != nil {
-		return zeroRune, nil, err
+	return nil, &token.ParserError{
+		Message:  fmt.Sprintf("unknown boolean operator %s", op),
+		Type:     token.ParseErrorUnknownBooleanOperator,
+		Position: p.scan.Position,
 	}
+}
 
-	withouts, err := p.parseExpressionGroup(definitionName, nVariableScope, -1)
+func (p *tavorParser) parseConditionOr(definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
+	c, tok, err := p.parseConditionAnd(definitionName, c, variableScope)
 	if err != nil {
 		return zeroRune, nil, err
 	}
 
-	c = p.scan.Scan()
+	for c == scanner.Ident && p.scan.TokenText() == "or" {
+		a, err := p.expectBooleanExpression(tok, c)
+		if err != nil {
+			return zeroRune, nil, err
+		}
 
-	tok, err = expressions.NewPath(tok, from, over, connects, withouts)
-	if err != nil {
-		err.(*token.ParserError).Position = listPosition
+		c = p.scan.Scan()
+
+		c, tok, err = p.parseConditionAnd(definitionName, c, variableScope)
+		if err != nil {
+			return zeroRune, nil, err
+		}
+
+		b, err := p.expectBooleanExpression(tok, c)
+		if err != nil {
+			return zeroRune, nil, err
+		}
+
+		tok = conditions.NewBooleanOr(a, b)
 	}
 
 	return c, tok, nil
 }
 
-func (p *tavorParser) parseTokenAttribute(definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
-	log.Debug("new token attribute")
-
-	_, err := p.expectRune(scanner.Ident, c)
+func (p *tavorParser) parseConditionAnd(definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
+	c, tok, err := p.parseConditionNot(definitionName, c, variableScope)
 	if err != nil {
 		return zeroRune, nil, err
 	}
 
-	name := p.scan.TokenText()
+	for c == scanner.Ident && p.scan.TokenText() == "and" {
+		a, err := p.expectBooleanExpression(tok, c)
+		if err != nil {
+			return zeroRune, nil, err
+		}
 
-	tokenPosition := p.scan.Position
+		c = p.scan.Scan()
 
-	_, err = p.expectScanRune('.')
+		c, tok, err = p.parseConditionNot(definitionName, c, variableScope)
+		if err != nil {
+			return zeroRune, nil, err
+		}
+
+		b, err := p.expectBooleanExpression(tok, c)
+		if err != nil {
+			return zeroRune, nil, err
+		}
+
+		tok = conditions.NewBooleanAnd(a, b)
+	}
+
+	return c, tok, nil
+}
+
+func (p *tavorParser) parseConditionNot(definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
+	if c != scanner.Ident || p.scan.TokenText() != "not" {
+		return p.parseConditionComparison(definitionName, c, variableScope)
+	}
+
+	c = p.scan.Scan()
+
+	c, tok, err := p.parseConditionNot(definitionName, c, variableScope)
 	if err != nil {
 		return zeroRune, nil, err
 	}
 
-	_, err = p.expectScanRune(scanner.Ident)
+	a, err := p.expectBooleanExpression(tok, c)
 	if err != nil {
 		return zeroRune, nil, err
 	}
 
-	attribute := p.scan.TokenText()
-	attributePosition := p.scan.Position
+	return c, conditions.NewBooleanNot(a), nil
+}
 
-	var op string
-	var opToken token.Token
+// parseConditionComparison parses a comparison of two expressions, a membership test or a boolean expression in parentheses
+// If no boolean operator follows the first expression, the expression itself is returned.
+func (p *tavorParser) parseConditionComparison(definitionName string, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
+	var a token.Token
+	var err error
 
-	c = p.scan.Scan()
+	if c == '(' {
+		c = p.scan.Scan()
 
-	var tok token.Token
+		c, a, err = p.parseConditionOr(definitionName, c, variableScope)
+		if err != nil {
+			return zeroRune, nil, err
+		}
 
-	use, ok := p.lookup[name]
-	if ok {
-		tok = use.token
-	} else {
-		tok = variableScope.Get(name)
+		if _, err = p.expectRune(')', c); err != nil {
+			return zeroRune, nil, err
+		}
 
-		isPointer := false
+		c = p.scan.Scan()
 
-		if tok != nil {
-			if _, pp := tok.(*primitives.Pointer); pp {
-				isPointer = true
+		if _, ok := a.(conditions.BooleanExpression); ok {
+			return c, a, nil
+		}
+
+		// the parentheses grouped an arithmetic expression which can be continued
+		c, a, err = p.parseExpressionOperators(definitionName, c, variableScope, a, 1)
+		if err != nil {
+			return zeroRune, nil, err
+		}
+	} else {
+		c, a, err = p.parseExpressionTerm(definitionName, c, variableScope)
+		if err != nil {
+			return zeroRune, nil, err
+		} else if a == nil {
+			return zeroRune, nil, &token.ParserError{
+				Message:  "empty expressions are not allowed",
+				Type:     token.ParseErrorEmptyExpressionIsInvalid,
+				Position: p.scan.Position,
 			}
 		}
 
-		if tok == nil || isPointer {
-			log.Debugf("parseTokenAttribute use empty pointer for %s.%s", name, attribute)
+		if _, ok := a.(conditions.BooleanExpression); ok {
+			return c, a, nil
+		}
+	}
 
-			var tokenInterface *token.Token
+	var operator string
 
-			pointer := primitives.NewEmptyPointer(tokenInterface)
-			nPointer := primitives.NewPointer(pointer)
+	switch c {
+	case '=', '!':
+		if p.scan.Peek() != '=' {
+			return c, a, nil
+		}
 
-			variableScope.Set(name, nPointer)
+		operator = string(c) + "="
 
-			nVariableScope := variableScope.Push()
+		p.scan.Scan()
+	case '<', '>':
+		operator = string(c)
 
-			p.forwardAttributeUsage = append(p.forwardAttributeUsage, attributeForwardUsage{
-				definitionName:    definitionName,
-				tokenName:         name,
-				tokenPosition:     tokenPosition,
-				attribute:         attribute,
-				attributePosition: attributePosition,
-				operator:          op,
-				operatorToken:     opToken,
-				pointer:           pointer,
-				variableScope:     nVariableScope,
-			})
+		if p.scan.Peek() == '=' {
+			operator += "="
 
-			return c, nPointer, nil
+			p.scan.Scan()
 		}
-	}
+	case scanner.Ident:
+		negate := false
 
-	p.used[name] = append(p.earlyUse[name], tokenUsage{
-		token:          nil,
-		position:       tokenPosition,
-		variableScope:  variableScope,
-		definitionName: definitionName,
-	})
+		if p.scan.TokenText() == "not" {
+			negate = true
 
-	c, rtok, err := p.selectTokenAttribute(definitionName, tok, name, attribute, attributePosition, op, opToken, c, variableScope)
+			if _, err = p.expectScanText("in"); err != nil {
+				return zeroRune, nil, err
+			}
+		} else if p.scan.TokenText() != "in" {
+			return c, a, nil
+		}
 
-	if err == nil {
-		log.Debugf("Insert token attribute %p(%#v)", rtok, rtok)
-	}
+		list, err := p.parseExpressionGroup(definitionName, variableScope, -1)
+		if err != nil {
+			return zeroRune, nil, err
+		}
 
-	return c, rtok, err
-}
+		c = p.scan.Scan()
 
-func (p *tavorParser) selectTokenAttribute(definitionName string, tok token.Token, tokenName string, attribute string, attributePosition scanner.Position, operator string, operatorToken token.Token, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
-	if t, ok := tok.(*primitives.Scope); ok {
-		tok = t.Resolve()
+		var ex conditions.BooleanExpression = conditions.NewBooleanIn(a, list...)
+		if negate {
+			ex = conditions.NewBooleanNot(ex)
+		}
+
+		return c, ex, nil
+	default:
+		return c, a, nil
 	}
 
-	log.Debugf("use (%p)%#v as token", tok, tok)
+	c = p.scan.Scan()
 
-	log.Debug("finished token attribute (or will be unknown token attribute)")
+	var b token.Token
 
-	switch i := tok.(type) {
-	case token.ListToken:
-		switch attribute {
-		case "Count":
-			return c, aggregates.NewLen(i), nil
-		case "Item":
-			_, err := p.expectRune('(', c)
-			if err != nil {
-				return zeroRune, nil, err
-			}
+	c, b, err = p.parseExpressionTerm(definitionName, c, variableScope)
+	if err != nil {
+		return zeroRune, nil, err
+	} else if b == nil {
+		return zeroRune, nil, &token.ParserError{
+			Message:  fmt.Sprintf("expected another expression term after operator %s", operator),
+			Type:     token.ParseErrorExpectedExpressionTerm,
+			Position: p.scan.Position,
+		}
+	}
 
-			c = p.scan.Scan()
+	switch operator {
+	case "==":
+		return c, conditions.NewBooleanEqual(a, b), nil
+	case "!=":
+		return c, conditions.NewBooleanNotEqual(a, b), nil
+	case "<":
+		return c, conditions.NewBooleanLessThan(a, b), nil
+	case "<=":
+		return c, conditions.NewBooleanLessThanOrEqual(a, b), nil
+	case ">":
+		return c, conditions.NewBooleanGreaterThan(a, b), nil
+	default:
+		return c, conditions.NewBooleanGreaterThanOrEqual(a, b), nil
+	}
+}
 
-			c, index, err := p.parseExpressionTerm(definitionName, c, variableScope)
-			if err != nil {
-				return zeroRune, nil, err
-			}
+func (p *tavorParser) parseTokenDefinition(variableScope *token.VariableScope) (rune, error) {
+	var c rune
+	var err error
 
-			_, err = p.expectRune(')', c)
-			if err != nil {
-				return zeroRune, nil, err
-			}
+	name := p.prefix + p.scan.TokenText()
 
-			c = p.scan.Scan()
+	if err := p.checkTokenAlreadyDefined(name); err != nil {
+		return zeroRune, err
+	}
 
-			return c, lists.NewListItem(index, i), nil
-		case "Unique":
-			return c, lists.NewUniqueItem(i), nil
-		}
-	case *sequences.Sequence:
-		switch attribute {
-		case "Existing":
-			if c == scanner.Ident && p.scan.TokenText() == "not" {
-				return p.parseExpressionOperatorNotIn(i, definitionName, c, variableScope)
-			}
+	p.definition = name
 
-			return c, i.ExistingItem(nil), nil
-		case "Next":
-			return c, i.Item(), nil
-		case "Reset":
-			return c, i.ResetItem(), nil
-		}
-	case *primitives.RangeInt:
-		switch attribute {
-		case "Value":
-			return c, i.Clone(), nil
-		}
-	case token.VariableToken:
-		switch attribute {
-		case "Count":
-			return c, aggregates.NewLen(i), nil
-		case "Index":
-			return c, lists.NewIndexItem(variables.NewVariableValue(i)), nil
-		case "Item":
-			_, err := p.expectRune('(', c)
-			if err != nil {
-				return zeroRune, nil, err
-			}
+	if p.scan.Peek() == '(' {
+		return p.parseParameterizedTokenDefinition(name, variableScope)
+	}
 
-			c = p.scan.Scan()
+	tokenPosition := p.scan.Position
 
-			c, index, err := p.parseExpressionTerm(definitionName, c, variableScope) // TODO
-			if err != nil {
-				return zeroRune, nil, err
+	if c, err = p.expectScanRune('='); err != nil {
+		// unexpected new line?
+		if c == '\n' {
+			return zeroRune, &token.ParserError{
+				Message:  "new line inside single line token definitions is not allowed",
+				Type:     token.ParseErrorEarlyNewLine,
+				Position: p.scan.Position,
 			}
+		}
 
-			_, err = p.expectRune(')', c)
-			if err != nil {
-				return zeroRune, nil, err
-			}
+		return zeroRune, err
+	}
 
-			c = p.scan.Scan()
+	// each definition start its own scope
+	variableScope = variableScope.Push()
 
-			return c, variables.NewVariableItem(index, i), nil
-		case "Reference":
-			return c, variables.NewVariableReference(variables.NewVariable(tokenName, nil)), nil
-		case "Value":
-			v := variables.NewVariableValue(i)
+	// start reading definition
+	c = p.scan.Scan()
+	log.Debugf("parseTokenDefinition after = %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
-			p.variableUsages = append(p.variableUsages, i)
+	c, tokens, err := p.parseScope(name, c, variableScope)
+	if err != nil {
+		return zeroRune, err
+	}
+
+	log.Debugf("back to token definition with c=%c", c)
 
-			return c, v, nil
+	// we always want a new line at the end of the file
+	if c == scanner.EOF {
+		return zeroRune, &token.ParserError{
+			Message:  "new line at end of token definition needed",
+			Type:     token.ParseErrorNewLineNeeded,
+			Position: p.scan.Position,
 		}
 	}
 
-	return zeroRune, nil, &token.ParserError{
-		Message:  fmt.Sprintf("unknown token attribute %q for token type %q", attribute, reflect.TypeOf(tok)),
-		Type:     token.ParseErrorUnknownTokenAttribute,
-		Position: attributePosition,
+	if c, err = p.expectRune('\n', c); err != nil {
+		return zeroRune, err
 	}
-}
 
-func (p *tavorParser) parseScope(definitionName string, c rune, variableScope *token.VariableScope) (rune, []token.Token, error) {
-	var err error
-	var tokens []token.Token
+	var tok token.Token
 
-	var toks []token.Token
+	switch len(tokens) {
+	case 0:
+		return zeroRune, &token.ParserError{
+			Message:  "empty token definition",
+			Type:     token.ParseErrorEmptyTokenDefinition,
+			Position: p.scan.Position,
+		}
+	case 1:
+		tok = tokens[0]
+	default:
+		tok = lists.NewAll(tokens...)
+	}
 
-	c, toks, err = p.parseTerm(definitionName, c, variableScope)
+	err = p.registerNamedToken(name, tok, tokenPosition, variableScope)
 	if err != nil {
-		return zeroRune, nil, err
-	} else if toks != nil {
-		tokens = toks
+		return zeroRune, err
 	}
 
-	var ifPairs []conditions.IfPair
+	c = p.scan.Scan()
+	log.Debugf("parseTokenDefinition after newline %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
-SCOPE:
-	for {
-		switch c {
-		case '|':
-			log.Debug("NEW or")
+	return c, nil
+}
 
-			var orTerms []token.Token
-			optional := false
+func (p *tavorParser) checkTokenAlreadyDefined(name string) error {
+	_, parameterized := p.parameterized[name]
 
-			toks = tokens
+	if use, ok := p.lookup[name]; ok || parameterized {
+		// if there is a pointer in the lookup hash we can say that it was just used before
+		if _, ok := use.token.(*primitives.Pointer); !ok {
+			return &token.ParserError{
+				Message:  "token already defined",
+				Type:     token.ParseErrorTokenAlreadyDefined,
+				Position: p.scan.Position,
+			}
+		}
+	}
 
-		OR:
-			for {
-				switch len(toks) {
-				case 0:
-					optional = true
-				case 1:
-					orTerms = append(orTerms, toks[0])
-				default:
-					orTerms = append(orTerms, lists.NewAll(toks...))
-				}
+	return nil
+}
 
-				if c == '|' {
-					c = p.scan.Scan()
-					log.Debugf("parseScope Or %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
-				} else {
-					log.Debug("parseScope break out or")
+func (p *tavorParser) parseParameterizedTokenDefinition(name string, variableScope *token.VariableScope) (rune, error) {
+	var c rune
+	var err error
 
-					break OR
-				}
+	log.Debugf("START parameterized token definition %s", name)
 
-				c, toks, err = p.parseTerm(definitionName, c, variableScope)
-				if err != nil {
-					return zeroRune, nil, err
-				}
-			}
+	tokenPosition := p.scan.Position
 
-			or := lists.NewOne(orTerms...)
+	if _, err = p.expectScanRune('('); err != nil {
+		return zeroRune, err
+	}
 
-			if optional {
-				tokens = []token.Token{constraints.NewOptional(or)}
-			} else {
-				tokens = []token.Token{or}
-			}
+	var parameters []string
 
-			log.Debug("END or")
-		case '{': // TODO make conditions work with ORs...
-			log.Debug("NEW condition")
+	for {
+		if _, err = p.expectScanRune(scanner.Ident); err != nil {
+			return zeroRune, err
+		}
 
-			c = p.scan.Scan()
-			condition := p.scan.TokenText()
+		parameter := p.scan.TokenText()
 
-			var conditionExpression conditions.BooleanExpression
+		for _, pa := range parameters {
+			if pa == parameter {
+				return zeroRune, &token.ParserError{
+					Message:  fmt.Sprintf("parameter %q already defined", parameter),
+					Type:     token.ParseErrorTokenAlreadyDefined,
+					Position: p.scan.Position,
+				}
+			}
+		}
 
-			switch condition {
-			case "if":
-				log.Debug("found IF")
+		parameters = append(parameters, parameter)
 
-				c, conditionExpression, err = p.parseConditionExpression(definitionName, variableScope)
-				if err != nil {
-					return zeroRune, nil, err
-				}
-			case "else":
-				if len(ifPairs) == 0 {
-					panic("TODO else/elseif without if")
-				}
+		if c = p.scan.Scan(); c != ',' {
+			break
+		}
+	}
 
-				c = p.scan.Scan()
+	if _, err = p.expectRune(')', c); err != nil {
+		return zeroRune, err
+	}
 
-				if p.scan.TokenText() == "if" {
-					log.Debug("found ELSEIF")
+	if c, err = p.expectScanRune('='); err != nil {
+		// unexpected new line?
+		if c == '\n' {
+			return zeroRune, &token.ParserError{
+				Message:  "new line inside single line token definitions is not allowed",
+				Type:     token.ParseErrorEarlyNewLine,
+				Position: p.scan.Position,
+			}
+		}
 
-					c, conditionExpression, err = p.parseConditionExpression(definitionName, variableScope)
-					if err != nil {
-						return zeroRune, nil, err
-					}
-				} else {
-					log.Debug("found ELSE")
+		return zeroRune, err
+	}
 
-					conditionExpression = conditions.NewBooleanTrue()
-				}
-			case "endif":
-				log.Debug("found ENDIF")
+	// the body is parsed on every call so we just remember where it is
+	c = p.scan.Scan()
 
-				if len(ifPairs) == 0 {
-					panic("TODO endif without if")
-				}
+	bodyPosition := p.scan.Position
 
-				c = p.scan.Scan()
-			default:
-				return zeroRune, nil, &token.ParserError{
-					Message:  fmt.Sprintf("unknown condition %q", condition),
-					Type:     token.ParseErrorUnknownCondition,
-					Position: p.scan.Pos(),
-				}
+	for c != '\n' {
+		switch c {
+		case scanner.EOF:
+			return zeroRune, &token.ParserError{
+				Message:  "new line at end of token definition needed",
+				Type:     token.ParseErrorNewLineNeeded,
+				Position: p.scan.Position,
 			}
-
-			_, err = p.expectRune('}', c)
-			if err != nil {
-				return zeroRune, nil, err
+		case ',': // multi line token
+			if c = p.scan.Scan(); c == '\n' {
+				c = p.scan.Scan()
 			}
 
-			c = p.scan.Scan()
+			continue
+		}
 
-			if condition != "endif" {
-				c, toks, err = p.parseTerm(definitionName, c, variableScope) // TODO this should be a a global scope or so ... we can do nesting
-				if err != nil {
-					return zeroRune, nil, err
-				}
+		c = p.scan.Scan()
+	}
 
-				var tok token.Token
+	if p.scan.Position.Offset == bodyPosition.Offset {
+		return zeroRune, &token.ParserError{
+			Message:  "empty token definition",
+			Type:     token.ParseErrorEmptyTokenDefinition,
+			Position: p.scan.Position,
+		}
+	}
 
-				switch len(toks) {
-				case 0:
-					panic("empty body is not allowed") // TODO catch this
-				case 1:
-					tok = toks[0]
-				default:
-					tok = lists.NewAll(toks...)
-				}
+	p.parameterized[name] = &parameterizedDefinition{
+		parameters:    parameters,
+		body:          p.src[bodyPosition.Offset:p.scan.Position.Offset],
+		position:      bodyPosition,
+		directory:     p.directory,
+		prefix:        p.prefix,
+		included:      p.includeDepth > 0,
+		variableScope: variableScope,
+	}
 
-				ifPart := conditions.IfPair{
-					Head: conditionExpression,
-					Body: tok,
-				}
+	log.Debugf("added parameterized token definition %s with parameters %v at %s", name, parameters, tokenPosition)
 
-				log.Debugf("Add if part %#v", ifPart)
+	c = p.scan.Scan()
+	log.Debugf("parseParameterizedTokenDefinition after newline %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
-				ifPairs = append(ifPairs, ifPart)
-			} else {
-				tokens = append(tokens, conditions.NewIf(ifPairs...))
+	log.Debugf("END parameterized token definition %s", name)
 
-				ifPairs = nil
+	return c, nil
+}
 
-				c, toks, err = p.parseTerm(definitionName, c, variableScope) // TODO this should be a a global scope or so ... we can do nesting
-				if err != nil {
-					return zeroRune, nil, err
-				}
+func (p *tavorParser) parseParameterizedTokenCall(definitionName string, name string, variableScope *token.VariableScope) (token.Token, error) {
+	var c rune
+	var err error
 
-				tokens = append(tokens, toks...)
-			}
+	name = p.prefix + name
 
-			log.Debug("END condition")
+	log.Debugf("START parameterized token call %s", name)
 
-			continue SCOPE
-		}
+	callPosition := p.scan.Position
 
-		break SCOPE
+	if _, err = p.expectScanRune('('); err != nil {
+		return nil, err
 	}
 
-	if len(ifPairs) > 0 {
-		panic("TODO if without endif")
-	}
+	var arguments []token.Token
 
-	return c, tokens, nil
-}
+	p.callArguments++
+	defer func() {
+		p.callArguments--
+	}()
 
-func (p *tavorParser) parseConditionExpression(definitionName string, variableScope *token.VariableScope) (rune, conditions.BooleanExpression, error) {
-	c, a, err := p.parseExpression(definitionName, variableScope)
-	if err != nil {
-		return zeroRune, nil, err
-	}
+	for {
+		c = p.scan.Scan()
 
-	if ex, ok := a.(conditions.BooleanExpression); ok {
-		return c, ex, nil
-	}
+		// arguments can be spread over multiple lines
+		for c == '\n' && len(arguments) != 0 {
+			c = p.scan.Scan()
+		}
 
-	switch c {
-	case '=':
-		_, err = p.expectScanRune('=')
+		var toks []token.Token
+
+		c, toks, err = p.parseScope(definitionName, c, variableScope)
 		if err != nil {
-			return zeroRune, nil, err
+			return nil, err
 		}
-	default:
-		return zeroRune, nil, &token.ParserError{
-			Message:  fmt.Sprintf("unknown boolean operator %q", c),
-			Type:     token.ParseErrorUnknownBooleanOperator,
-			Position: p.scan.Pos(),
+
+		switch len(toks) {
+		case 0:
+			return nil, &token.ParserError{
+				Message:  fmt.Sprintf("empty argument for %q", name),
+				Type:     token.ParseErrorExpectedExpressionTerm,
+				Position: p.scan.Position,
+			}
+		case 1:
+			arguments = append(arguments, toks[0])
+		default:
+			arguments = append(arguments, lists.NewAll(toks...))
+		}
+
+		if c != ',' {
+			break
 		}
 	}
 
-	c, b, err := p.parseExpression(definitionName, variableScope)
-	if err != nil {
-		return zeroRune, nil, err
+	if _, err = p.expectRune(')', c); err != nil {
+		return nil, err
 	}
 
-	return c, conditions.NewBooleanEqual(a, b), nil
-}
+	// calls are expanded after all token definitions are known
+	var tokenInterface *token.Token
+	b := primitives.NewEmptyPointer(tokenInterface)
+	n := primitives.NewPointer(b)
 
-func (p *tavorParser) parseTokenDefinition(variableScope *token.VariableScope) (rune, error) {
-	var c rune
-	var err error
+	p.parameterizedCalls = append(p.parameterizedCalls, parameterizedCall{
+		name:           name,
+		arguments:      arguments,
+		pointer:        b,
+		position:       callPosition,
+		definitionName: definitionName,
+		expansions:     p.expansions,
+	})
 
-	name := p.scan.TokenText()
+	p.used[name] = append(p.used[name], tokenUsage{
+		token:         nil,
+		position:      callPosition,
+		variableScope: variableScope,
+	})
 
-	if use, ok := p.lookup[name]; ok {
-		// if there is a pointer in the lookup hash we can say that it was just used before
-		if _, ok := use.token.(*primitives.Pointer); !ok {
-			return zeroRune, &token.ParserError{
-				Message:  "token already defined",
-				Type:     token.ParseErrorTokenAlreadyDefined,
-				Position: p.scan.Pos(),
+	log.Debugf("END parameterized token call %s", name)
+
+	return n, nil
+}
+
+func (p *tavorParser) expandParameterizedCalls() error {
+	for len(p.parameterizedCalls) != 0 {
+		call := p.parameterizedCalls[0]
+		p.parameterizedCalls = p.parameterizedCalls[1:]
+
+		definition, ok := p.parameterized[call.name]
+		if !ok {
+			if _, ok := p.lookup[call.name]; ok {
+				return &token.ParserError{
+					Message:  fmt.Sprintf("token %q is not a parameterized token definition", call.name),
+					Type:     token.ParseErrorInvalidTokenType,
+					Position: call.position,
+				}
+			}
+
+			return &token.ParserError{
+				Message:  fmt.Sprintf("parameterized token %q is not defined", call.name),
+				Type:     token.ParseErrorTokenNotDefined,
+				Position: call.position,
 			}
 		}
-	}
 
-	tokenPosition := p.scan.Position
+		if len(call.arguments) != len(definition.parameters) {
+			return &token.ParserError{
+				Message:  fmt.Sprintf("parameterized token %q needs %d arguments but got %d", call.name, len(definition.parameters), len(call.arguments)),
+				Type:     token.ParseErrorWrongArgumentCount,
+				Position: call.position,
+			}
+		}
 
-	if c, err = p.expectScanRune('='); err != nil {
-		// unexpected new line?
-		if c == '\n' {
-			return zeroRune, &token.ParserError{
-				Message:  "new line inside single line token definitions is not allowed",
-				Type:     token.ParseErrorEarlyNewLine,
-				Position: p.scan.Pos(),
+		for _, e := range call.expansions {
+			if e == call.name {
+				return &token.ParserError{
+					Message:  fmt.Sprintf("parameterized token %q calls itself", call.name),
+					Type:     token.ParseErrorRecursiveParameterizedToken,
+					Position: call.position,
+				}
 			}
 		}
 
-		return zeroRune, err
+		tok, err := p.expandParameterizedCall(definition, call)
+		if err != nil {
+			return err
+		}
+
+		if err := call.pointer.Set(tok); err != nil {
+			return err
+		}
 	}
 
-	// each definition start its own scope
-	variableScope = variableScope.Push()
+	return nil
+}
 
-	// start reading definition
-	c = p.scan.Scan()
-	log.Debugf("parseTokenDefinition after = %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+func (p *tavorParser) expandParameterizedCall(definition *parameterizedDefinition, call parameterizedCall) (token.Token, error) {
+	log.Debugf("START expansion of parameterized token %s", call.name)
 
-	c, tokens, err := p.parseScope(name, c, variableScope)
-	if err != nil {
-		return zeroRune, err
+	variableScope := definition.variableScope.Push()
+	for i, parameter := range definition.parameters {
+		variableScope.Set(parameter, &argument{
+			Token: call.arguments[i],
+		})
 	}
 
-	log.Debugf("back to token definition with c=%c", c)
+	// pad the body so that it is scanned at its original position
+	pos := definition.position
+	src := append([]byte(strings.Repeat("\n", pos.Line-1)+strings.Repeat(" ", pos.Column-1)), definition.body...)
+	src = append(src, '\n')
 
-	// we always want a new line at the end of the file
-	if c == scanner.EOF {
-		return zeroRune, &token.ParserError{
-			Message:  "new line at end of token definition needed",
-			Type:     token.ParseErrorNewLineNeeded,
-			Position: p.scan.Pos(),
-		}
+	scan, oldSrc, directory, prefix, expansions := p.scan, p.src, p.directory, p.prefix, p.expansions
+
+	p.initScanner(src, pos.Filename)
+	p.directory = definition.directory
+	p.prefix = definition.prefix
+	p.expansions = append(append([]string{}, call.expansions...), call.name)
+
+	p.scan.Whitespace |= 1 << '\n'
+	c := p.scan.Scan()
+	p.scan.Whitespace &^= 1 << '\n'
+
+	c, tokens, err := p.parseScope(call.definitionName, c, variableScope)
+	if err == nil {
+		_, err = p.expectRune('\n', c)
 	}
 
-	if c, err = p.expectRune('\n', c); err != nil {
-		return zeroRune, err
+	p.scan, p.src, p.directory, p.prefix, p.expansions = scan, oldSrc, directory, prefix, expansions
+
+	if err != nil {
+		return nil, err
 	}
 
 	var tok token.Token
 
 	switch len(tokens) {
 	case 0:
-		return zeroRune, &token.ParserError{
+		return nil, &token.ParserError{
 			Message:  "empty token definition",
 			Type:     token.ParseErrorEmptyTokenDefinition,
-			Position: p.scan.Pos(),
+			Position: pos,
 		}
 	case 1:
 		tok = tokens[0]
@@ -1414,15 +3276,9 @@ func (p *tavorParser) parseTokenDefinition(variableScope *token.VariableScope) (
 		tok = lists.NewAll(tokens...)
 	}
 
-	err = p.registerNamedToken(name, tok, tokenPosition, variableScope)
-	if err != nil {
-		return zeroRune, err
-	}
-
-	c = p.scan.Scan()
-	log.Debugf("parseTokenDefinition after newline %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+	log.Debugf("END expansion of parameterized token %s", call.name)
 
-	return c, nil
+	return primitives.NewScope(tok), nil
 }
 
 func (p *tavorParser) setEarlyUsage(name string, tok token.Token) error {
@@ -1438,7 +3294,7 @@ func (p *tavorParser) setEarlyUsage(name string, tok token.Token) error {
 				return &token.ParserError{
 					Message:  fmt.Sprintf("wrong token type for %s because of earlier usage: %s", name, err),
 					Type:     token.ParseErrorInvalidTokenType,
-					Position: p.scan.Pos(),
+					Position: p.scan.Position,
 				}
 			}
 		}
@@ -1461,6 +3317,7 @@ func (p *tavorParser) registerNamedToken(name string, tok token.Token, tokenPosi
 		token:         sTok,
 		position:      tokenPosition,
 		variableScope: variableScope,
+		included:      p.includeDepth > 0,
 	}
 
 	log.Debugf("added (%p)%#v as token %s", tok, tok, name)
@@ -1477,18 +3334,12 @@ func (p *tavorParser) parseTypedTokenDefinition(variableScope *token.VariableSco
 	c = p.scan.Scan()
 	log.Debugf("parseTypedTokenDefinition after $ %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
-	name := p.scan.TokenText()
-	if use, ok := p.lookup[name]; ok {
-		// if there is a pointer in the lookup hash we can say that it was just used before
-		if _, ok := use.token.(*primitives.Pointer); !ok {
-			return zeroRune, &token.ParserError{
-				Message:  "token already defined",
-				Type:     token.ParseErrorTokenAlreadyDefined,
-				Position: p.scan.Pos(),
-			}
-		}
+	name := p.prefix + p.scan.TokenText()
+	if err := p.checkTokenAlreadyDefined(name); err != nil {
+		return zeroRune, err
 	}
 
+	p.definition = name
 	tokenPosition := p.scan.Position
 
 	c, err = p.expectScanRune(scanner.Ident)
@@ -1496,13 +3347,13 @@ func (p *tavorParser) parseTypedTokenDefinition(variableScope *token.VariableSco
 		return zeroRune, &token.ParserError{
 			Message:  "typed token has no type",
 			Type:     token.ParseErrorTypeNotDefinedForTypedToken,
-			Position: p.scan.Pos(),
+			Position: p.scan.Position,
 		}
 	}
 
 	typ := p.scan.TokenText()
 
-	arguments := make(map[string]string)
+	arguments := make(map[string]typedArgument)
 
 	c = p.scan.Scan()
 
@@ -1527,14 +3378,47 @@ func (p *tavorParser) parseTypedTokenDefinition(variableScope *token.VariableSco
 			c = p.scan.Scan()
 			log.Debugf("parseTypedTokenDefinition argument value %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
-			switch c {
-			case scanner.Ident, scanner.String, scanner.Int:
-				arguments[arg] = p.scan.TokenText()
-			default:
-				return zeroRune, &token.ParserError{
-					Message:  fmt.Sprintf("invalid argument value %v", c),
-					Type:     token.ParseErrorInvalidArgumentValue,
-					Position: p.scan.Pos(),
+			if c == '[' {
+				var values []string
+
+				c = p.scan.Scan()
+
+				for c != ']' {
+					if len(values) != 0 {
+						if _, err = p.expectRune(',', c); err != nil {
+							return zeroRune, err
+						}
+
+						c = p.scan.Scan()
+
+						// list values can be spread over multiple lines
+						for c == '\n' {
+							c = p.scan.Scan()
+						}
+					}
+
+					value, err := p.parseTypedArgumentValue(c)
+					if err != nil {
+						return zeroRune, err
+					}
+
+					values = append(values, value)
+
+					c = p.scan.Scan()
+				}
+
+				arguments[arg] = typedArgument{
+					values: values,
+					list:   true,
+				}
+			} else {
+				value, err := p.parseTypedArgumentValue(c)
+				if err != nil {
+					return zeroRune, err
+				}
+
+				arguments[arg] = typedArgument{
+					values: []string{value},
 				}
 			}
 
@@ -1556,7 +3440,7 @@ func (p *tavorParser) parseTypedTokenDefinition(variableScope *token.VariableSco
 		return zeroRune, &token.ParserError{
 			Message:  "new line at end of token definition needed",
 			Type:     token.ParseErrorNewLineNeeded,
-			Position: p.scan.Pos(),
+			Position: p.scan.Position,
 		}
 	}
 
@@ -1565,7 +3449,9 @@ func (p *tavorParser) parseTypedTokenDefinition(variableScope *token.VariableSco
 	}
 
 	// construct the typed token
-	argParser := newArgumentsParser(arguments)
+	argParser := newArgumentsParser(arguments, p.directory, func(tokenName string) token.Token {
+		return p.getToken(name, tokenName, variableScope)
+	})
 	tok, err := token.NewTyped(typ, argParser, p.scan.Pos())
 	if err != nil {
 		return zeroRune, err
@@ -1576,10 +3462,19 @@ func (p *tavorParser) parseTypedTokenDefinition(variableScope *token.VariableSco
 		return zeroRune, &token.ParserError{
 			Message:  fmt.Sprintf("unknown typed token argument %q", arg),
 			Type:     token.ParseErrorUnknownTypedTokenArgument,
-			Position: p.scan.Pos(),
+			Position: p.scan.Position,
 		}
 	}
 
+	switch tok.(type) {
+	case *primitives.BinaryInt:
+		// binary integers are never encoded
+	case *sequences.Sequence, *sets.Set:
+		// sequences and sets are only used through their attributes which are encoded on their own
+	default:
+		tok = p.encode(name, tok)
+	}
+
 	err = p.registerNamedToken(name, tok, tokenPosition, variableScope)
 	if err != nil {
 		return zeroRune, err
@@ -1593,6 +3488,35 @@ func (p *tavorParser) parseTypedTokenDefinition(variableScope *token.VariableSco
 	return c, nil
 }
 
+// parseTypedArgumentValue parses a single value of a typed token argument beginning with the given scanned rune and returns its raw text
+func (p *tavorParser) parseTypedArgumentValue(c rune) (string, error) {
+	sign := ""
+
+	if c == '-' {
+		sign = "-"
+
+		c = p.scan.Scan()
+		if c != scanner.Int && c != scanner.Float {
+			return "", &token.ParserError{
+				Message:  fmt.Sprintf("invalid argument value -%v", scanner.TokenString(c)),
+				Type:     token.ParseErrorInvalidArgumentValue,
+				Position: p.scan.Position,
+			}
+		}
+	}
+
+	switch c {
+	case scanner.Ident, scanner.String, scanner.RawString, scanner.Int, scanner.Float:
+		return sign + p.scan.TokenText(), nil
+	default:
+		return "", &token.ParserError{
+			Message:  fmt.Sprintf("invalid argument value %v", c),
+			Type:     token.ParseErrorInvalidArgumentValue,
+			Position: p.scan.Position,
+		}
+	}
+}
+
 func (p *tavorParser) getVariable(fromDefinition string, name string, pos scanner.Position) (token.VariableToken, error) {
 	calls, ok := p.called[fromDefinition]
 	if !ok {
@@ -1654,39 +3578,166 @@ func (p *tavorParser) getVariable(fromDefinition string, name string, pos scanne
 	return v, nil
 }
 
+// error returns the collected errors of the parser
+// A single error is returned as it is, multiple errors are returned as token.ParserErrors.
+func (p *tavorParser) error() error {
+	switch len(p.errors) {
+	case 0:
+		return nil
+	case 1:
+		return p.errors[0]
+	}
+
+	return token.ParserErrors(p.errors)
+}
+
+// sortErrors sorts parser errors by their positions, errors without a position are sorted to the end
+func sortErrors(errs []error) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		a, ok := errs[i].(*token.ParserError)
+		if !ok {
+			return false
+		}
+		b, ok := errs[j].(*token.ParserError)
+		if !ok {
+			return true
+		}
+
+		if a.Position.Filename != b.Position.Filename {
+			return a.Position.Filename < b.Position.Filename
+		} else if a.Position.Line != b.Position.Line {
+			return a.Position.Line < b.Position.Line
+		}
+
+		return a.Position.Column < b.Position.Column
+	})
+}
+
 // ParseTavor reads and parses a Tavor formatted input and returns its token graph representation beginning with the START token.
+// Included files are resolved relative to the current working directory.
 // The error return argument is not nil if an error is encountered during reading or parsing the file e.g. a syntax or semantic error.
+// Parsing recovers from errors at the next token definition so all errors are reported. A single error is returned as it is, multiple errors are returned as token.ParserErrors.
 func ParseTavor(src io.Reader) (token.Token, error) {
+	return parseTavor(src, "")
+}
+
+// ParseTavorFile reads and parses a Tavor format file and returns its token graph representation beginning with the START token.
+// Included files are resolved relative to the directory of the including file and error positions hold the name of the file they occurred in.
+// The error return argument is not nil if the file cannot be opened or if an error is encountered during reading or parsing the file.
+func ParseTavorFile(filename string) (token.Token, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
+	return parseTavor(file, filename)
+}
+
+// ParseTavorDefinitions reads and parses a Tavor formatted input like ParseTavor and returns additionally the token graphs of all named token definitions by their names.
+// Included files are resolved relative to the directory of the given filename which is also used for error positions, the filename can be empty. Definitions of included files are not returned.
+// The token graphs of the definitions are unrolled and minimized. They can be used to inspect a definition e.g. to count its permutations.
+func ParseTavorDefinitions(src io.Reader, filename string) (token.Token, map[string]token.Token, error) {
+	start, p, err := parseTavorDocument(src, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	definitions := make(map[string]token.Token)
+
+	for name, use := range p.lookup {
+		if use.included {
+			continue
+		}
+
+		tok, err := token.UnrollPointers(use.token)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tok, err = token.MinimizeTokens(tok)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		definitions[name] = tok
+	}
+
+	return start, definitions, nil
+}
+
+// ParseTavorFileDefinitions reads and parses a Tavor format file like ParseTavorFile and returns additionally the token graphs of all named token definitions by their names like ParseTavorDefinitions.
+// The error return argument is not nil if the file cannot be opened or if an error is encountered during reading or parsing the file.
+func ParseTavorFileDefinitions(filename string) (token.Token, map[string]token.Token, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
+	return ParseTavorDefinitions(file, filename)
+}
+
+func parseTavor(src io.Reader, filename string) (token.Token, error) {
+	start, _, err := parseTavorDocument(src, filename)
+
+	return start, err
+}
+
+func parseTavorDocument(src io.Reader, filename string) (token.Token, *tavorParser, error) {
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	p := &tavorParser{
+		included:      make(map[include]struct{}),
+		parameterized: make(map[string]*parameterizedDefinition),
+
+		tokenEncodings: make(map[string]encodingSetting),
+		loops:          make(map[string]loopDeclaration),
+
 		earlyUse:    make(map[string][]tokenUsage),
 		lookup:      make(map[string]tokenUsage),
 		lookupUsage: make(map[token.Token]struct{}),
 		used:        make(map[string][]tokenUsage),
 
 		called: make(map[string][]call),
+
+		failed: make(map[string]struct{}),
 	}
 
 	log.Debug("start parsing tavor file")
 
-	p.scan.Init(src)
+	p.initScanner(data, filename)
 
-	p.scan.Error = func(s *scanner.Scanner, msg string) {
-		p.err = msg
+	if filename != "" {
+		p.directory = filepath.Dir(filename)
+
+		if absFilename, err := filepath.Abs(filename); err == nil {
+			p.includes = append(p.includes, absFilename)
+		}
 	}
-	p.scan.Whitespace = 1<<'\t' | 1<<' ' | 1<<'\r'
 
 	variableScope := token.NewVariableScope()
 
-	if err := p.parseGlobalScope(variableScope); err != nil {
-		return nil, err
-	}
+	p.parseGlobalScope(variableScope)
 
-	if _, ok := p.lookup["START"]; !ok {
-		return nil, &token.ParserError{
+	// a missing START token is only reported if all definitions could be parsed since it could be one of the failed definitions
+	if _, ok := p.lookup["START"]; !ok && len(p.errors) == 0 {
+		p.errors = append(p.errors, &token.ParserError{
 			Message:  "no START token defined",
 			Type:     token.ParseErrorNoStart,
 			Position: p.scan.Pos(), // TODO correct position
-		}
+		})
 	}
 
 	p.used["START"] = append(p.used["START"], tokenUsage{
@@ -1695,51 +3746,85 @@ func ParseTavor(src io.Reader) (token.Token, error) {
 		variableScope: variableScope,
 	})
 
+	if err := p.expandParameterizedCalls(); err != nil {
+		p.errors = append(p.errors, err)
+	}
+
 	for name, uses := range p.earlyUse {
+		// uses of token definitions which could not be parsed are not reported
+		if _, ok := p.failed[name]; ok {
+			continue
+		}
+
 	USE:
 		for _, use := range uses {
 			if use.token.(*primitives.Pointer).Get() == nil {
-				if v := use.variableScope.Get(name); v != nil {
+				variableName := strings.TrimPrefix(name, use.prefix)
+
+				if v := use.variableScope.Get(variableName); v != nil {
 					if vv, ok := v.(token.VariableToken); ok {
 						err := p.setEarlyUsage(name, variables.NewVariableValue(vv))
 						if err != nil {
-							return nil, err
+							p.errors = append(p.errors, err)
 						}
 
 						break USE
 					} else {
-						return nil, &token.ParserError{
+						p.errors = append(p.errors, &token.ParserError{
 							Message:  fmt.Sprintf("variable token %q is not always used as a variable", name),
 							Type:     token.ParseErrorNotAlwaysUsedAsAVariable,
 							Position: use.position,
-						}
+						})
+
+						break USE
 					}
 				}
 
 				// last chance that this token is a variable but it must be ALWAYS a variable
-				if v, err := p.getVariable(use.definitionName, name, use.position); err != nil {
-					return nil, err
+				if v, err := p.getVariable(use.definitionName, variableName, use.position); err != nil {
+					p.errors = append(p.errors, err)
+
+					break USE
 				} else if v != nil {
 					err = p.setEarlyUsage(name, variables.NewVariableValue(v))
 					if err != nil {
-						return nil, err
+						p.errors = append(p.errors, err)
 					}
 
 					break USE
 				}
 
-				return nil, &token.ParserError{
+				if _, ok := p.parameterized[name]; ok {
+					p.errors = append(p.errors, &token.ParserError{
+						Message:  fmt.Sprintf("parameterized token %q has to be called with arguments", name),
+						Type:     token.ParseErrorWrongArgumentCount,
+						Position: use.position,
+					})
+
+					break USE
+				}
+
+				p.errors = append(p.errors, &token.ParserError{
 					Message:  fmt.Sprintf("token %q is not defined", name),
 					Type:     token.ParseErrorTokenNotDefined,
 					Position: use.position,
-				}
+				})
+
+				break USE
 			}
 		}
 	}
 
+FORWARD:
 	for _, forwardUse := range p.forwardAttributeUsage {
 		var tok token.Token
 
+		if _, ok := p.failed[forwardUse.tokenName]; ok {
+			continue
+		}
+
+		variableName := strings.TrimPrefix(forwardUse.tokenName, forwardUse.prefix)
+
 		// look for the token in the global table
 		use, ok := p.lookup[forwardUse.tokenName]
 		if ok {
@@ -1750,15 +3835,17 @@ func ParseTavor(src io.Reader) (token.Token, error) {
 		}
 		// look for the token in the forward scope
 		if tok == nil {
-			tok = forwardUse.variableScope.Get(forwardUse.tokenName)
+			tok = forwardUse.variableScope.Get(variableName)
 			if t, ok := tok.(*primitives.Pointer); ok {
 				tok = t.Resolve()
 			}
 		}
 		// look for the token in the call scope
 		if tok == nil {
-			if v, err := p.getVariable(forwardUse.definitionName, forwardUse.tokenName, forwardUse.tokenPosition); err != nil {
-				return nil, err
+			if v, err := p.getVariable(forwardUse.definitionName, variableName, forwardUse.tokenPosition); err != nil {
+				p.errors = append(p.errors, err)
+
+				continue FORWARD
 			} else if v != nil {
 				tok = v
 				if t, ok := tok.(*primitives.Pointer); ok {
@@ -1769,41 +3856,70 @@ func ParseTavor(src io.Reader) (token.Token, error) {
 
 		// give up, there is no token we can use
 		if tok == nil {
-			return nil, &token.ParserError{
+			p.errors = append(p.errors, &token.ParserError{
 				Message:  fmt.Sprintf("token or variable %q is not defined", forwardUse.tokenName),
 				Type:     token.ParseErrorTokenNotDefined,
 				Position: forwardUse.tokenPosition,
-			}
+			})
+
+			continue
 		}
 
+		p.used[forwardUse.tokenName] = append(p.used[forwardUse.tokenName], tokenUsage{
+			token:         nil,
+			position:      forwardUse.tokenPosition,
+			variableScope: forwardUse.variableScope,
+		})
+
 		// TODO zeroRune must be replaced with "c" we cannot scan in this selectTokenAttribute call
-		_, rtok, err := p.selectTokenAttribute(forwardUse.definitionName, tok, forwardUse.tokenName, forwardUse.attribute, forwardUse.attributePosition, forwardUse.operator, forwardUse.operatorToken, zeroRune, variableScope)
+		_, rtok, err := p.selectTokenAttribute(forwardUse.definitionName, tok, variableName, forwardUse.attribute, forwardUse.attributePosition, forwardUse.operator, forwardUse.operatorToken, zeroRune, variableScope)
 		if err != nil {
-			return nil, err
+			p.errors = append(p.errors, err)
+
+			continue
 		}
 
 		err = forwardUse.pointer.Set(rtok)
 		if err != nil {
-			return nil, err
+			p.errors = append(p.errors, err)
 		}
-
-		p.used[forwardUse.tokenName] = append(p.used[forwardUse.tokenName], tokenUsage{
-			token:         nil,
-			position:      forwardUse.tokenPosition,
-			variableScope: forwardUse.variableScope,
-		})
 	}
 
 	for name, use := range p.lookup {
+		// tokens of included files do not need to be used since included files are libraries
+		if use.included {
+			continue
+		}
+
 		if _, ok := p.used[name]; !ok {
-			return nil, &token.ParserError{
+			p.errors = append(p.errors, &token.ParserError{
 				Message:  fmt.Sprintf("token %q declared but not used", name),
 				Type:     token.ParseErrorUnusedToken,
 				Position: use.position,
-			}
+			})
+		}
+	}
+
+	for name, definition := range p.parameterized {
+		if definition.included {
+			continue
+		}
+
+		if _, ok := p.used[name]; !ok {
+			p.errors = append(p.errors, &token.ParserError{
+				Message:  fmt.Sprintf("token %q declared but not used", name),
+				Type:     token.ParseErrorUnusedToken,
+				Position: definition.position,
+			})
 		}
 	}
 
+	sortErrors(p.errors)
+
+	if err := p.error(); err != nil {
+		return nil, nil, err
+	}
+
 	for _, variable := range p.variableUsages {
 		tok := variable.(token.ForwardToken).InternalGet()
 
@@ -1819,7 +3935,7 @@ func ParseTavor(src io.Reader) (token.Token, error) {
 
 					err := variable.(token.ForwardToken).InternalReplace(tok, c)
 					if err != nil {
-						return nil, err
+						return nil, nil, err
 					}
 
 					break
@@ -1838,7 +3954,16 @@ func ParseTavor(src io.Reader) (token.Token, error) {
 			tok = t.Resolve()
 		}
 
-		if tok, ok := tok.(*sequences.Sequence); ok {
+		if t, ok := tok.(*encodings.Encoded); ok {
+			tok = t.Get()
+		}
+
+		switch tok := tok.(type) {
+		case *sequences.Sequence:
+			automaticResets = append(automaticResets, tok.ResetItem())
+		case *dictionaries.Dictionary:
+			automaticResets = append(automaticResets, tok.ResetItem())
+		case *sets.Set:
 			automaticResets = append(automaticResets, tok.ResetItem())
 		}
 	}
@@ -1848,19 +3973,42 @@ func ParseTavor(src io.Reader) (token.Token, error) {
 		start = lists.NewAll(automaticResets...)
 	}
 
-	start, err := token.UnrollPointers(start)
+	start, err = token.UnrollPointers(start)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	start, err = token.MinimizeTokens(start)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	for name, l := range p.loops {
+		use, ok := p.lookup[name]
+		if !ok {
+			return nil, nil, &token.ParserError{
+				Message:  fmt.Sprintf("token %q is not defined", name),
+				Type:     token.ParseErrorTokenNotDefined,
+				Position: l.position,
+			}
+		}
+
+		tok, err := token.UnrollPointers(use.token)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tok, err = token.MinimizeTokens(tok)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		l.loop.SetTarget(tok)
 	}
 
 	token.ResetScope(start)
 
 	log.Debug("finished parsing")
 
-	return start, nil
+	return start, p, nil
 }
@@ -1,10 +1,19 @@
 package parser
 
 import (
+	"encoding/binary"
 	"fmt"
+	"io/ioutil"
 	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
+	"text/scanner"
+	"time"
 
 	. "github.com/zimmski/tavor/test/assert"
 
@@ -15,7 +24,9 @@ import (
 	"github.com/zimmski/tavor/token/aggregates"
 	"github.com/zimmski/tavor/token/conditions"
 	"github.com/zimmski/tavor/token/constraints"
+	"github.com/zimmski/tavor/token/encodings"
 	"github.com/zimmski/tavor/token/expressions"
+	"github.com/zimmski/tavor/token/graphs"
 	"github.com/zimmski/tavor/token/lists"
 	"github.com/zimmski/tavor/token/primitives"
 	"github.com/zimmski/tavor/token/sequences"
@@ -168,6 +179,27 @@ func TestTavorParseErrors(t *testing.T) {
 	Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
 	Nil(t, tok)
 
+	tok, err = ParseTavor(strings.NewReader("$START Int = from:456,\nto:123\n"))
+	Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+	Nil(t, tok)
+
+	tok, err = ParseTavor(strings.NewReader("$START Int = from:123,\nto:456,\nstep:0\n"))
+	Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+	Nil(t, tok)
+
+	// invalid argument values
+	tok, err = ParseTavor(strings.NewReader("$START Int = from: -abc\n"))
+	Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+	Nil(t, tok)
+
+	tok, err = ParseTavor(strings.NewReader("$START Int = from: [1 2]\n"))
+	Equal(t, token.ParseErrorExpectRune, err.(*token.ParserError).Type)
+	Nil(t, tok)
+
+	tok, err = ParseTavor(strings.NewReader("$START Int = from: [1, 2]\n"))
+	Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+	Nil(t, tok)
+
 	// invalid arguments for typed token Sequence
 	tok, err = ParseTavor(strings.NewReader("$START Sequence = start:abc\n"))
 	Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
@@ -259,6 +291,103 @@ func TestTavorParseErrors(t *testing.T) {
 		`))
 	Equal(t, token.ParseErrorEndlessLoopDetected, err.(*token.ParserError).Type)
 	Nil(t, tok)
+
+	// loops without an exit are not allowed
+	for _, format := range []string{
+		"A = \"a\" A\nSTART = A\n",
+		"A = \"a\" (\"b\" A)\nSTART = A\n",
+		"START = Idle\nIdle = \"Idle\" Action\nAction = \"Action\" Idle\n",
+	} {
+		tok, err = ParseTavor(strings.NewReader(format))
+		Equal(t, token.ParseErrorEndlessLoopDetected, err.(*token.ParserError).Type, format)
+		Nil(t, tok)
+
+		_, _, err = ParseTavorDefinitions(strings.NewReader(format), "")
+		Equal(t, token.ParseErrorEndlessLoopDetected, err.(*token.ParserError).Type, format)
+	}
+
+	// invalid character classes
+	for _, format := range []string{
+		"START = [-9]\n",
+		"START = [9-0]\n",
+		"START = [\\2]\n",
+		"START = [\\xF]\n",
+		"START = [\\x{F]\n",
+		"START = [a{]\n",
+	} {
+		tok, err = ParseTavor(strings.NewReader(format))
+		Equal(t, token.ParseErrorInvalidCharacterClass, err.(*token.ParserError).Type, format)
+		Equal(t, 9, err.(*token.ParserError).Position.Column, format)
+		Nil(t, tok)
+	}
+
+	// conditions without if
+	tok, err = ParseTavor(strings.NewReader("START = 1 {else} 2 {endif}\n"))
+	Equal(t, token.ParseErrorConditionWithoutIf, err.(*token.ParserError).Type)
+	Equal(t, 12, err.(*token.ParserError).Position.Column)
+	Nil(t, tok)
+	tok, err = ParseTavor(strings.NewReader("START = 1 {endif}\n"))
+	Equal(t, token.ParseErrorConditionWithoutIf, err.(*token.ParserError).Type)
+	Nil(t, tok)
+
+	// empty condition body
+	tok, err = ParseTavor(strings.NewReader("START = {if 1 == 1}{endif}\n"))
+	Equal(t, token.ParseErrorEmptyConditionBody, err.(*token.ParserError).Type)
+	Nil(t, tok)
+
+	// if without endif
+	tok, err = ParseTavor(strings.NewReader("START = 1 {if 1 == 1} 2\n"))
+	Equal(t, token.ParseErrorMissingEndif, err.(*token.ParserError).Type)
+	Equal(t, 12, err.(*token.ParserError).Position.Column)
+	Nil(t, tok)
+}
+
+func TestTavorParseMultipleErrors(t *testing.T) {
+	tok, err := ParseTavor(strings.NewReader(`A = B
+C =
+D = (1
+	2
+START = A E {endif}
+F = 1
+G = D
+`))
+	Nil(t, tok)
+
+	errs, ok := err.(token.ParserErrors)
+	True(t, ok)
+
+	var got []string
+	for _, err := range errs {
+		perr := err.(*token.ParserError)
+
+		got = append(got, fmt.Sprintf("%d:%d %s", perr.Position.Line, perr.Position.Column, perr.Type))
+	}
+
+	Equal(t, []string{
+		"1:5 ParseErrorTokenNotDefined",
+		"2:4 ParseErrorEmptyTokenDefinition",
+		"3:7 ParseErrorExpectRune",
+		"5:11 ParseErrorTokenNotDefined",
+		"5:14 ParseErrorConditionWithoutIf",
+		"6:1 ParseErrorUnusedToken",
+		"7:1 ParseErrorUnusedToken",
+	}, got)
+	Equal(t, 7, len(strings.Split(err.Error(), "\n")))
+
+	// a missing START token is not reported if it could be a definition which could not be parsed
+	_, err = ParseTavor(strings.NewReader("3TART = 1\nA = 2\n"))
+	Equal(t, []error{
+		&token.ParserError{
+			Message:  "token names have to start with a letter and not with Int",
+			Type:     token.ParseErrorInvalidTokenName,
+			Position: scanner.Position{Offset: 0, Line: 1, Column: 1},
+		},
+		&token.ParserError{
+			Message:  `token "A" declared but not used`,
+			Type:     token.ParseErrorUnusedToken,
+			Position: scanner.Position{Offset: 10, Line: 2, Column: 1},
+		},
+	}, []error(err.(token.ParserErrors)))
 }
 
 func TestTavorParserSimple(t *testing.T) {
@@ -635,6 +764,12 @@ func TestTavorParserTypedTokens(t *testing.T) {
 	Nil(t, err)
 	Equal(t, tok, primitives.NewScope(primitives.NewRangeIntWithStep(2, math.MaxInt32, 2)))
 
+	tok, err = ParseTavor(strings.NewReader(
+		"$Spec Int = from: -10,\nto: 0x10\nSTART = Spec\n",
+	))
+	Nil(t, err)
+	Equal(t, tok, primitives.NewScope(primitives.NewRangeInt(-10, 16)))
+
 	// Sequence
 	{
 		s := sequences.NewSequence(1, 1)
@@ -687,6 +822,387 @@ func TestTavorParserTypedTokens(t *testing.T) {
 			primitives.NewScope(s.ResetItem()),
 		))
 	}
+	// Binary integers
+	{
+		tok, err := ParseTavor(strings.NewReader(
+			"$Len UInt16BE = from: 0x0102,\nto: 0x0104\nSTART = Len\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(primitives.NewBinaryInt(2, binary.BigEndian, 0x0102, 0x0104)))
+		Equal(t, "\x01\x02", tok.String())
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$Len UInt32LE\nSTART = Len\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(primitives.NewBinaryInt(4, binary.LittleEndian, 0, math.MaxUint32)))
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$Len UInt64LE\nSTART = Len\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(primitives.NewBinaryInt(8, binary.LittleEndian, 0, math.MaxUint64)))
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$Len UInt8 = to: 256\nSTART = Len\n",
+		))
+		Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+		Nil(t, tok)
+	}
+	// Float
+	{
+		tok, err := ParseTavor(strings.NewReader(
+			"$F Float = from: -1.5,\nto: 2,\nprecision: 1\nSTART = F\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(primitives.NewRangeFloat(-1.5, 2, primitives.FloatFormatDecimal, 1)))
+		Equal(t, "-1.5", tok.String())
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$F Float = to: 1,\nformat: scientific,\nspecials: [\"-Inf\", -0, max]\nSTART = F\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(primitives.NewRangeFloatWithSpecials(0, 1, primitives.FloatFormatScientific, -1, []float64{
+			math.Inf(-1),
+			math.Copysign(0, -1),
+			math.MaxFloat64,
+		})))
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$F Float = to: 1\nSTART = $F.Value\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(primitives.NewRangeFloat(0, 1, primitives.FloatFormatDecimal, -1)))
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$F Float = specials: [unknown]\nSTART = F\n",
+		))
+		Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$F Float = format: hex\nSTART = F\n",
+		))
+		Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+		Nil(t, tok)
+	}
+	// Date, time and duration
+	{
+		tok, err := ParseTavor(strings.NewReader(
+			"$D Date = from: \"2000-02-28\",\nto: \"2000-03-01\"\nSTART = D\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(primitives.NewRangeTime(
+			time.Date(2000, time.February, 28, 0, 0, 0, 0, time.UTC),
+			time.Date(2000, time.March, 1, 0, 0, 0, 0, time.UTC),
+			24*time.Hour,
+			"2006-01-02",
+			true,
+		)))
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$T DateTime = layout: \"02/01/2006 15:04\",\nto: \"01/01/1970 01:00\",\nstep: \"30m\",\nedges: false\nSTART = $T.Value\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(primitives.NewRangeTime(
+			time.Unix(0, 0).UTC(),
+			time.Unix(3600, 0).UTC(),
+			30*time.Minute,
+			"02/01/2006 15:04",
+			false,
+		)))
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$T Time = from: \"12:00:00\"\nSTART = T\n",
+		))
+		Nil(t, err)
+		Equal(t, "12:00:00", tok.String())
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$D Duration = from: \"-1s\",\nto: \"1m\",\nunit: ms\nSTART = D\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(primitives.NewRangeDuration(-time.Second, time.Minute, time.Second, time.Millisecond, true)))
+		Equal(t, "-1000", tok.String())
+
+		for _, src := range []string{
+			"$D Date = from: \"2000-13-01\"\nSTART = D\n",
+			"$D Date = from: \"2000-01-02\",\nto: \"2000-01-01\"\nSTART = D\n",
+			"$D DateTime = location: \"Nowhere/Nothing\"\nSTART = D\n",
+			"$D Duration = step: \"0s\"\nSTART = D\n",
+			"$D Duration = unit: days\nSTART = D\n",
+		} {
+			tok, err = ParseTavor(strings.NewReader(src))
+			Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type, src)
+			Nil(t, tok)
+		}
+
+		// reuse a generated timestamp
+		tok, err = ParseTavor(strings.NewReader(
+			"$T DateTime\nSTART = T<t> \" \" ${t.Value}\n",
+		))
+		Nil(t, err)
+
+		strat := strategy.NewRandomStrategy(tok)
+		ch, err := strat.Fuzz(test.NewRandTest(1))
+		Nil(t, err)
+
+		for i := range ch {
+			s := strings.Split(tok.String(), " ")
+			Equal(t, s[0], s[1])
+
+			ch <- i
+		}
+	}
+	// Dictionary
+	{
+		dir := writeTavorFiles(t, map[string]string{
+			"users.tavor":    "$Users Dictionary = file: \"data/users.txt\"\n\nSTART = +3($Users.Unique \" \") $Users.Unique<u> \" \" ${Users.Existing not in (u)}\n",
+			"data/users.txt": "admin\nroot\nguest\nnobody\n",
+			"missing.tavor":  "$Users Dictionary = file: \"missing.txt\"\nSTART = Users\n",
+			"empty.tavor":    "$Users Dictionary = file: \"empty.txt\"\nSTART = Users\n",
+			"empty.txt":      "\n",
+		})
+		defer os.RemoveAll(dir)
+
+		tok, err := ParseTavorFile(filepath.Join(dir, "users.tavor"))
+		Nil(t, err)
+
+		strat := strategy.NewRandomStrategy(tok)
+		ch, err := strat.Fuzz(test.NewRandTest(1))
+		Nil(t, err)
+
+		for i := range ch {
+			data := tok.String()
+			s := strings.Split(data, " ")
+			Equal(t, 5, len(s), data)
+
+			// unique values are unique and existing values are taken but not excepted
+			unique := append([]string{}, s[:4]...)
+			sort.Strings(unique)
+			Equal(t, []string{"admin", "guest", "nobody", "root"}, unique, data)
+			NotEqual(t, s[3], s[4], data)
+
+			errs := ParseInternal(tok, strings.NewReader(data))
+			Nil(t, errs, data)
+			Equal(t, data, tok.String())
+
+			ch <- i
+		}
+
+		for _, data := range []string{
+			"admin root guest admin root",
+			"admin root guest nobody nobody",
+			"admin root guest nobody unknown",
+		} {
+			errs := ParseInternal(tok, strings.NewReader(data))
+			NotNil(t, errs, data)
+		}
+
+		for _, name := range []string{"missing.tavor", "empty.tavor"} {
+			tok, err = ParseTavorFile(filepath.Join(dir, name))
+			Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type, name)
+			Nil(t, tok)
+		}
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$Users Dictionary\nSTART = Users\n",
+		))
+		Equal(t, token.ParseErrorMissingTypedTokenArgument, err.(*token.ParserError).Type)
+		Nil(t, tok)
+	}
+	// Set
+	{
+		tok, err := ParseTavor(strings.NewReader(
+			"$Users Set\nSTART = +2,4(\"create \" $Users.Add \"\\n\") \"delete \" $Users.Remove<d> \"\\n\" \"get \" $Users.Missing \"\\n\" \"count \" $Users.Count \"\\n\" \"show \" ${Users.Existing not in (d)} \"\\n\"\n",
+		))
+		Nil(t, err)
+
+		for seed := int64(0); seed < 10; seed++ {
+			strat := strategy.NewRandomStrategy(tok)
+			ch, err := strat.Fuzz(rand.New(rand.NewSource(seed)))
+			Nil(t, err)
+
+			for i := range ch {
+				data := tok.String()
+
+				live := make(map[string]struct{})
+				var deleted string
+
+				for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+					s := strings.Split(line, " ")
+
+					switch s[0] {
+					case "create":
+						live[s[1]] = struct{}{}
+					case "delete":
+						deleted = s[1]
+						delete(live, deleted)
+					case "get":
+						_, ok := live[s[1]]
+						False(t, ok, data)
+					case "count":
+						Equal(t, strconv.Itoa(len(live)), s[1], data)
+					case "show":
+						_, ok := live[s[1]]
+						True(t, ok, data)
+						NotEqual(t, deleted, s[1], data)
+					}
+				}
+
+				errs := ParseInternal(tok, strings.NewReader(data))
+				Nil(t, errs, data)
+				Equal(t, data, tok.String())
+
+				ch <- i
+			}
+		}
+
+		for _, data := range []string{
+			"create 1\ncreate 2\ndelete 3\nget 1\ncount 1\nshow 2\n",
+			"create 1\ncreate 2\ndelete 1\nget 2\ncount 1\nshow 2\n",
+			"create 1\ncreate 2\ndelete 1\nget 1\ncount 2\nshow 2\n",
+			"create 1\ncreate 2\ndelete 1\nget 1\ncount 1\nshow 1\n",
+		} {
+			errs := ParseInternal(tok, strings.NewReader(data))
+			NotNil(t, errs, data)
+		}
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$Users Set = step: 0\nSTART = $Users.Add\n",
+		))
+		Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$Users Set\nSTART = $Users.Next\n",
+		))
+		Equal(t, token.ParseErrorUnknownTokenAttribute, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		// values are only available for maps
+		for _, format := range []string{
+			"$Users Set\nSTART = $Users.Replace(1)\n",
+			"$Users Set\nSTART = $Users.Value(1)\n",
+		} {
+			tok, err = ParseTavor(strings.NewReader(format))
+			Equal(t, token.ParseErrorUnknownTokenAttribute, err.(*token.ParserError).Type, format)
+			Nil(t, tok)
+		}
+	}
+	// Map
+	{
+		tok, err := ParseTavor(strings.NewReader(
+			"$Users Map\nName = \"alice\" | \"bob\" | \"carol\"\nSTART = +2,4(\"create \" $Users.Add(Name)<k> \" \" ${Users.Value(k.Value)} \"\\n\") \"rename \" $Users.Replace(Name)<r> \" \" ${Users.Value(r.Value)} \"\\n\" \"delete \" $Users.Remove \"\\n\" \"show \" $Users.Existing<u> \" \" ${Users.Value(u.Value)} \"\\n\"\n",
+		))
+		Nil(t, err)
+
+		for seed := int64(0); seed < 10; seed++ {
+			strat := strategy.NewRandomStrategy(tok)
+			ch, err := strat.Fuzz(rand.New(rand.NewSource(seed)))
+			Nil(t, err)
+
+			for i := range ch {
+				data := tok.String()
+
+				live := make(map[string]string)
+
+				for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+					s := strings.Split(line, " ")
+
+					switch s[0] {
+					case "create":
+						_, ok := live[s[1]]
+						False(t, ok, data)
+						NotEqual(t, "", s[2], data)
+
+						live[s[1]] = s[2]
+					case "rename":
+						_, ok := live[s[1]]
+						True(t, ok, data)
+						NotEqual(t, "", s[2], data)
+
+						live[s[1]] = s[2]
+					case "delete":
+						_, ok := live[s[1]]
+						True(t, ok, data)
+
+						delete(live, s[1])
+					case "show":
+						Equal(t, live[s[1]], s[2], data)
+					}
+				}
+
+				ch <- i
+			}
+		}
+
+		for _, format := range []string{
+			"$Users Map\nSTART = $Users.Add\n",
+			"$Users Map\nSTART = $Users.Value()\n",
+		} {
+			tok, err = ParseTavor(strings.NewReader(format))
+			NotNil(t, err, format)
+			Nil(t, tok)
+		}
+	}
+	// Graph and Tree
+	{
+		tok, err := ParseTavor(strings.NewReader(
+			"$G Graph = nodes: 3,\ndensity: 1\nSTART = G\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(graphs.NewGraph(3, 1, false, false, 0, " ", "\n")))
+		Equal(t, "1 2\n1 3\n2 1\n2 3\n3 1\n3 2", tok.String())
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$T Tree = nodes: 4,\ndegree: 1,\nseparator: \"->\",\ndelimiter: \";\"\nSTART = T\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(graphs.NewTree(4, 1, "->", ";")))
+
+		for _, src := range []string{
+			"$G Graph = nodes: 0\nSTART = G\n",
+			"$G Graph = density: 1.5\nSTART = G\n",
+			"$G Graph = degree: -1\nSTART = G\n",
+			"$G Graph = connected: true,\ndegree: 1\nSTART = G\n",
+			"$G Graph = separator: \"1\"\nSTART = G\n",
+			"$T Tree = delimiter: \"\"\nSTART = T\n",
+		} {
+			tok, err = ParseTavor(strings.NewReader(src))
+			Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type, src)
+			Nil(t, tok)
+		}
+	}
+	// Regex
+	{
+		tok, err := ParseTavor(strings.NewReader(
+			"$Id Regex = pattern: \"[a-c]{2}|x\"\nSTART = Id\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(lists.NewOne(
+			lists.NewRepeat(primitives.NewCharacterClass(`\x{61}-\x{63}`), 2, 2),
+			primitives.NewConstantString("x"),
+		)))
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$Id Regex = pattern: `a+`,\nmax: 5\nSTART = Id\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(lists.NewRepeat(primitives.NewConstantString("a"), 1, 5)))
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$Id Regex\nSTART = Id\n",
+		))
+		Equal(t, token.ParseErrorMissingTypedTokenArgument, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$Id Regex = pattern: \"a(\"\nSTART = Id\n",
+		))
+		Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+		Nil(t, tok)
+	}
 }
 
 func TestTavorParserExpressions(t *testing.T) {
@@ -799,12 +1315,134 @@ func TestTavorParserExpressions(t *testing.T) {
 	))
 	Nil(t, err)
 	Equal(t, tok, primitives.NewScope(expressions.NewAddArithmetic(
-		primitives.NewConstantInt(1),
 		expressions.NewAddArithmetic(
+			primitives.NewConstantInt(1),
+			primitives.NewConstantInt(2),
+		),
+		primitives.NewConstantInt(3),
+	)))
+
+	// operator precedence
+	tok, err = ParseTavor(strings.NewReader(
+		"START = ${2 * 3 + 4}\n",
+	))
+	Nil(t, err)
+	Equal(t, tok, primitives.NewScope(expressions.NewAddArithmetic(
+		expressions.NewMulArithmetic(
 			primitives.NewConstantInt(2),
 			primitives.NewConstantInt(3),
 		),
+		primitives.NewConstantInt(4),
+	)))
+	Equal(t, "10", tok.String())
+
+	// parentheses and unary minus
+	tok, err = ParseTavor(strings.NewReader(
+		"START = ${-(2 * (3 + 4))}\n",
+	))
+	Nil(t, err)
+	Equal(t, tok, primitives.NewScope(expressions.NewNegArithmetic(
+		expressions.NewMulArithmetic(
+			primitives.NewConstantInt(2),
+			expressions.NewAddArithmetic(
+				primitives.NewConstantInt(3),
+				primitives.NewConstantInt(4),
+			),
+		),
 	)))
+	Equal(t, "-14", tok.String())
+
+	for expression, expect := range map[string]string{
+		"1 - 2 - 3":               "-4",
+		"-1 - -2":                 "1",
+		"24 / 4 / 2":              "3",
+		"-7 / 2":                  "-3",
+		"7 % 3":                   "1",
+		"-7 % 3":                  "-1",
+		"2 + 7 % 3 * 4":           "6",
+		"0xF0 | 0x0F":             "255",
+		"0xFF & 0x0F":             "15",
+		"0xFF ^ 0x0F":             "240",
+		"1 << 4":                  "16",
+		"256 >> 4":                "16",
+		"-16 >> 2":                "-4",
+		"1 << 2 + 1":              "8",
+		"1 | 2 ^ 3 & 4":           "3",
+		"(1 | 2) ^ 3":             "0",
+		"(300 + 12) & 0xFF":       "56",
+		"9223372036854775807 + 1": "-9223372036854775808",
+	} {
+		tok, err = ParseTavor(strings.NewReader("START = ${" + expression + "}\n"))
+		Nil(t, err, expression)
+		Equal(t, expect, tok.String(), expression)
+	}
+
+	// arithmetic semantics
+	for format, expect := range map[string]string{
+		"arithmetic uint8\nSTART = ${250 + 10}\n":                    "4",
+		"arithmetic uint8 wrap\nSTART = ${0 - 1}\n":                  "255",
+		"arithmetic uint8 saturate\nSTART = ${250 + 10}\n":           "255",
+		"arithmetic uint8 saturate\nSTART = ${1 - 2}\n":              "0",
+		"arithmetic int8\nSTART = ${127 + 1}\n":                      "-128",
+		"arithmetic int8\nSTART = ${-(-128)}\n":                      "-128",
+		"arithmetic int16 saturate\nSTART = ${-200 * 200}\n":         "-32768",
+		"arithmetic uint32\nSTART = ${1 << 32}\n":                    "0",
+		"arithmetic uint32\nSTART = ${(1 << 31) * 2 + 5}\n":          "5",
+		"arithmetic unbounded\nSTART = ${1 << 64}\n":                 "18446744073709551616",
+		"arithmetic unbounded\nSTART = ${9223372036854775807 + 1}\n": "9223372036854775808",
+	} {
+		tok, err = ParseTavor(strings.NewReader(format))
+		Nil(t, err, format)
+		Equal(t, expect, tok.String(), format)
+	}
+
+	// invalid expressions
+	for format, expect := range map[string]token.ParserErrorType{
+		"START = ${1 +}\n":                      token.ParseErrorExpectedExpressionTerm,
+		"START = ${(1 + 2}\n":                   token.ParseErrorExpectRune,
+		"START = ${()}\n":                       token.ParseErrorExpectedExpressionTerm,
+		"START = ${-}\n":                        token.ParseErrorExpectedExpressionTerm,
+		"arithmetic int7\nSTART = 1\n":          token.ParseErrorUnknownArithmetic,
+		"arithmetic int8 clamp\nSTART = 1\n":    token.ParseErrorUnknownArithmetic,
+		"START = ${1 / 0}\n":                    token.ParseErrorInvalidArithmeticOperand,
+		"START = ${1 % (2 - 2)}\n":              token.ParseErrorInvalidArithmeticOperand,
+		"START = ${1 << -1}\n":                  token.ParseErrorInvalidArithmeticOperand,
+		"START = ${1 >> -(1 + 1)}\n":            token.ParseErrorInvalidArithmeticOperand,
+		"START = \"a\"<x> ${x.Value + 1}\n":     token.ParseErrorInvalidArithmeticOperand,
+		"START = \"a\"<x> ${1 * x.Value}\n":     token.ParseErrorInvalidArithmeticOperand,
+		"START = \"a\"<x> ${-x.Value}\n":        token.ParseErrorInvalidArithmeticOperand,
+		"A = \"a\" \"b\"\nSTART = A ${A / 2}\n": token.ParseErrorInvalidArithmeticOperand,
+	} {
+		tok, err = ParseTavor(strings.NewReader(format))
+		Equal(t, expect, err.(*token.ParserError).Type, format)
+		Nil(t, tok)
+	}
+
+	// operands which are only known during generation have defined results
+	for format, expect := range map[string][]string{
+		"START = (\"-1\" | \"70\")<x> ${1 << x.Value}\n":   {"-10", "700"},
+		"START = (\"-1\" | \"70\")<x> ${256 >> x.Value}\n": {"-1512", "700"},
+		"START = (\"0\" | \"2\")<x> ${7 % x.Value}\n":      {"07", "21"},
+		"START = (\"0\" | \"2\")<x> ${7 / x.Value}\n":      {"0-1", "23"},
+		"START = (\"a\" | \"2\")<x> ${7 + x.Value}\n":      {"a7", "29"},
+	} {
+		tok, err = ParseTavor(strings.NewReader(format))
+		Nil(t, err, format)
+
+		strat := strategy.NewAllPermutationsStrategy(tok)
+
+		ch, err := strat.Fuzz(test.NewRandTest(1))
+		Nil(t, err, format)
+
+		var got []string
+		for i := range ch {
+			got = append(got, tok.String())
+
+			ch <- i
+		}
+
+		Equal(t, expect, got, format)
+	}
 
 	// mixed operator
 	{
@@ -838,6 +1476,55 @@ func TestTavorParserExpressions(t *testing.T) {
 			`))
 		Nil(t, err)
 		Equal(t, "103123->231", tok.String())
+
+		// entries with the same identifier add up their connections
+		tok, err = ParseTavor(strings.NewReader(`
+				START = ${Pairs path from (1) over (e.Item(0)) connect by (e.Item(1)) without (0)}
+
+				Pairs = (,
+					(1 2),
+					(1 3),
+					(3 4),
+				)
+			`))
+		Nil(t, err)
+		Equal(t, "1324", tok.String())
+	}
+	// path operator over graphs
+	{
+		tok, err = ParseTavor(strings.NewReader(`
+				$Deps Graph = nodes:     6,
+				              density:   0.3,
+				              acyclic:   true,
+				              connected: true
+
+				START = Deps<deps> "\n" ${deps.Count} "\n" ${deps path from (1) over (e.Item(0)) connect by (e.Item(1)) without (0)}
+			`))
+		Nil(t, err)
+
+		for seed := int64(0); seed < 10; seed++ {
+			strat := strategy.NewRandomStrategy(tok)
+			ch, err := strat.Fuzz(rand.New(rand.NewSource(seed)))
+			Nil(t, err)
+
+			for i := range ch {
+				data := tok.String()
+				s := strings.Split(data, "\n")
+
+				// every node is reached from node 1
+				path := s[len(s)-1]
+				Equal(t, 6, len(path), data)
+				for _, n := range "123456" {
+					True(t, strings.ContainsRune(path, n), data)
+				}
+				Equal(t, strconv.Itoa(len(s)-2), s[len(s)-2], data)
+
+				errs := ParseInternal(tok, strings.NewReader(data))
+				Nil(t, errs, data)
+
+				ch <- i
+			}
+		}
 	}
 }
 
@@ -1309,6 +1996,113 @@ func TestTavorParserLoops(t *testing.T) {
 	}
 }
 
+func TestTavorParserRealLoops(t *testing.T) {
+	// loops are expanded lazily up to their depth
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			loop Expr depth 3
+
+			Expr = "(" ?("-") Expr ")" | "x"
+
+			START = Expr
+		`))
+		Nil(t, err)
+		False(t, token.LoopExists(tok))
+		Equal(t, "(-(-x))", tok.String())
+
+		var got []string
+
+		strat := strategy.NewAllPermutationsStrategy(tok)
+		ch, err := strat.Fuzz(test.NewRandTest(1))
+		Nil(t, err)
+
+		for i := range ch {
+			got = append(got, tok.String())
+
+			ch <- i
+		}
+
+		Equal(t, []string{"((x))", "(-(x))", "((-x))", "(-(-x))", "(x)", "(-x)", "x"}, got)
+
+		errs := ParseInternal(tok, strings.NewReader("(-(x))"))
+		Nil(t, errs)
+		Equal(t, "(-(x))", tok.String())
+
+		errs = ParseInternal(tok, strings.NewReader("(((x)))"))
+		NotNil(t, errs)
+	}
+	// deep loops do not explode
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			loop Value depth 100
+
+			Value = "[" ?(Value *("," Value)) "]" | 1
+
+			START = Value
+		`))
+		Nil(t, err)
+
+		for i := int64(0); i < 10; i++ {
+			strat := strategy.NewRandomStrategy(tok)
+			ch, err := strat.Fuzz(rand.New(rand.NewSource(i)))
+			Nil(t, err)
+
+			for i := range ch {
+				out := tok.String()
+
+				errs := ParseInternal(tok, strings.NewReader(out))
+				Nil(t, errs)
+				Equal(t, out, tok.String())
+
+				ch <- i
+			}
+		}
+	}
+	// default depth and mutual recursion
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			loop List
+
+			List = "(" Items ")"
+			Items = ?(List) "a"
+
+			START = List
+		`))
+		Nil(t, err)
+		Equal(t, strings.Repeat("(", tavor.MaxRepeat)+strings.Repeat("a)", tavor.MaxRepeat), tok.String())
+	}
+	// errors
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			Expr = "(" Expr ")" | "x"
+
+			loop Expr
+
+			START = Expr
+		`))
+		Equal(t, token.ParseErrorLoopAfterUsage, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			loop Expr depth 0
+
+			Expr = "(" Expr ")" | "x"
+
+			START = Expr
+		`))
+		Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			loop Expr
+
+			START = Expr
+		`))
+		Equal(t, token.ParseErrorTokenNotDefined, err.(*token.ParserError).Type)
+		Nil(t, tok)
+	}
+}
+
 func TestTavorParserCornerCases(t *testing.T) {
 	// early usage used twice deeper in token
 	{
@@ -1415,6 +2209,123 @@ func TestTavorParserCornerCases(t *testing.T) {
 	}
 }
 
+func TestTavorParserBinaryData(t *testing.T) {
+	// byte literals and escapes
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = 0xCAFEbabe "\x00\xff" 0x00
+		`))
+		Nil(t, err)
+		Equal(t, "\xca\xfe\xba\xbe\x00\xff\x00", tok.String())
+
+		tok, err = ParseTavor(strings.NewReader(`
+			START = 0xABC
+		`))
+		Equal(t, token.ParseErrorInvalidByteLiteral, err.(*token.ParserError).Type)
+		Nil(t, tok)
+	}
+	// validate binary data with the format
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			$Type UInt8 = from: 1,
+				to: 3
+			$Length UInt16LE = to: 1000
+
+			Field = Type Length +(0x00 | 0xff)
+
+			START = 0x89 "PNG" +(Field)
+		`))
+		Nil(t, err)
+
+		errs := ParseInternal(tok, strings.NewReader("\x89PNG\x01\xe8\x03\x00\xff\x03\x00\x00\xff"))
+		Nil(t, errs)
+		Equal(t, "\x89PNG\x01\xe8\x03\x00\xff\x03\x00\x00\xff", tok.String())
+
+		errs = ParseInternal(tok, strings.NewReader("\x89PNG\x04\xe8\x03\x00"))
+		NotNil(t, errs)
+	}
+}
+
+func TestTavorParserEncodings(t *testing.T) {
+	// document encoding
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			encoding "UTF-16LE"
+
+			START = "ab" [\x{e4}] 12
+		`))
+		Nil(t, err)
+		Equal(t, "a\x00b\x00\xe4\x001\x002\x00", tok.String())
+
+		errs := ParseInternal(tok, strings.NewReader("a\x00b\x00\xe4\x001\x002\x00"))
+		Nil(t, errs)
+
+		errs = ParseInternal(tok, strings.NewReader("ab\xe412"))
+		NotNil(t, errs)
+	}
+	// token encodings
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			encoding "Latin-1" for Name
+			encoding "UTF-16BE" for Number
+
+			$Number Int = from: 1,
+				to: 9
+			Name = "J\u00f6rg"
+
+			START = Name ":" Number "\u00f6"
+		`))
+		Nil(t, err)
+		Equal(t, "J\xf6rg:\x001\u00f6", tok.String())
+
+		errs := ParseInternal(tok, strings.NewReader("J\xf6rg:\x007\u00f6"))
+		Nil(t, errs)
+		Equal(t, "J\xf6rg:\x007\u00f6", tok.String())
+
+		errs = ParseInternal(tok, strings.NewReader("J\u00f6rg:\x007\u00f6"))
+		NotNil(t, errs)
+	}
+	// invalid encoding injections
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			encoding "UTF-8" invalid
+
+			START = "a"
+		`))
+		Nil(t, err)
+		Equal(t, "a", tok.String())
+
+		enc, _ := encodings.New("UTF-8")
+		Equal(t, uint(len(enc.Injections())+1), tok.PermutationsAll())
+	}
+	// errors
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			encoding "EBCDIC"
+
+			START = "a"
+		`))
+		Equal(t, token.ParseErrorUnknownEncoding, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			START = "a"
+
+			encoding "UTF-16LE"
+		`))
+		Equal(t, token.ParseErrorEncodingAfterDefinition, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			START = "a"
+
+			encoding "UTF-16LE" for START
+		`))
+		Equal(t, token.ParseErrorEncodingAfterDefinition, err.(*token.ParserError).Type)
+		Nil(t, tok)
+	}
+}
+
 func TestTavorParserCharacterClasses(t *testing.T) {
 	{
 		tok, err := ParseTavor(strings.NewReader(`
@@ -1668,3 +2579,419 @@ func TestTavorParserIfElseIfElsedd(t *testing.T) {
 		Equal(t, 1, tok.Permutations())
 	}
 }
+
+func writeTavorFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "tavor-include")
+	Nil(t, err)
+
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+
+		Nil(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		Nil(t, ioutil.WriteFile(filename, []byte(content), 0644))
+	}
+
+	return dir
+}
+
+func TestTavorParserConditions(t *testing.T) {
+	// comparisons, logical operators and membership tests
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = Choose<var> Print
+
+			Choose = 1 | 2 | 3 | 4 | 5 | 6
+
+			Print = {if var.Value in (1, 2)} "small" {else if var.Value >= 3 and not (var.Value == 4 or var.Value > 5)} "medium" {else if var.Value != 6} "four" {else} "big" {endif}
+		`))
+		Nil(t, err)
+
+		var got []string
+
+		strat := strategy.NewAllPermutationsStrategy(tok)
+		ch, err := strat.Fuzz(test.NewRandTest(1))
+		Nil(t, err)
+
+		for i := range ch {
+			got = append(got, tok.String())
+
+			ch <- i
+		}
+
+		Equal(t, []string{"1small", "2small", "3medium", "4four", "5medium", "6big"}, got)
+
+		for _, data := range got {
+			errs := ParseInternal(tok, strings.NewReader(data))
+			Nil(t, errs, data)
+			Equal(t, data, tok.String())
+		}
+
+		for _, data := range []string{"1medium", "3small", "4big", "6four"} {
+			errs := ParseInternal(tok, strings.NewReader(data))
+			NotNil(t, errs, data)
+		}
+	}
+	// comparisons of arithmetic expressions
+	for condition, expect := range map[string]string{
+		"1 < 2":                         "yes",
+		"2 < 2":                         "no",
+		"2 <= 2":                        "yes",
+		"3 > 2 * 2":                     "no",
+		"(1 + 2) * 2 >= 6":              "yes",
+		"((1 + 2) * 2 >= 6)":            "yes",
+		"10 > 9":                        "yes",
+		"1 != 1 or 2 == 2 and 3 == 4":   "no",
+		"(1 != 1 or 2 == 2) and 3 != 4": "yes",
+		"not 1 == 1 or 2 == 2":          "yes",
+		"not not 1 == 1":                "yes",
+		"3 not in (1, 2)":               "yes",
+		"1 + 1 in (1, 2)":               "yes",
+		"1 < 2 and 2 < 3 and 3 < 4":     "yes",
+	} {
+		tok, err := ParseTavor(strings.NewReader("START = {if " + condition + "} \"yes\" {else} \"no\" {endif}\n"))
+		Nil(t, err, condition)
+		Equal(t, expect, tok.String(), condition)
+	}
+	// invalid conditions
+	for condition, expect := range map[string]token.ParserErrorType{
+		"1":            token.ParseErrorUnknownBooleanOperator,
+		"1 == 1 and 2": token.ParseErrorUnknownBooleanOperator,
+		"not 1":        token.ParseErrorUnknownBooleanOperator,
+		"1 <":          token.ParseErrorExpectedExpressionTerm,
+		"(1 == 1":      token.ParseErrorExpectRune,
+		"1 in 2":       token.ParseErrorExpectRune,
+		"1 not 2":      token.ParseErrorExpectRune,
+		"1 not x":      token.ParseErrorExpectOperator,
+		"1 == 1 or":    token.ParseErrorEmptyExpressionIsInvalid,
+	} {
+		tok, err := ParseTavor(strings.NewReader("START = {if " + condition + "} \"yes\" {endif}\n"))
+		NotNil(t, err, condition)
+		if err != nil {
+			Equal(t, expect, err.(*token.ParserError).Type, condition)
+		}
+		Nil(t, tok)
+	}
+}
+
+func TestTavorParserIncludes(t *testing.T) {
+	// include relative to the including file and shared includes
+	{
+		dir := writeTavorFiles(t, map[string]string{
+			"main.tavor":           "include \"formats/http.tavor\"\ninclude \"formats/json.tavor\"\n\nSTART = Request \" \" Body\n",
+			"formats/http.tavor":   "include \"common.tavor\"\n\nRequest = \"GET\" Space Digits\n",
+			"formats/json.tavor":   "include \"./common.tavor\"\n\nBody = \"{\" Digits \"}\"\n",
+			"formats/common.tavor": "Space = \" \"\nDigits = \"123\"\nUnused = \"abc\"\n",
+		})
+		defer os.RemoveAll(dir)
+
+		tok, err := ParseTavorFile(filepath.Join(dir, "main.tavor"))
+		Nil(t, err)
+		Equal(t, "GET 123 {123}", tok.String())
+	}
+	// prefixed includes
+	{
+		dir := writeTavorFiles(t, map[string]string{
+			"main.tavor":  "include \"auth.tavor\" prefix Auth\ninclude \"auth.tavor\" prefix Other\n\nHeader = \"main\"\n\nSTART = AuthHeader \" \" OtherHeader \" \" Header\n",
+			"auth.tavor":  "include \"token.tavor\"\n\nHeader = \"Bearer \" Token\n",
+			"token.tavor": "Token = \"abc\"\n",
+		})
+		defer os.RemoveAll(dir)
+
+		tok, err := ParseTavorFile(filepath.Join(dir, "main.tavor"))
+		Nil(t, err)
+		Equal(t, "Bearer abc Bearer abc main", tok.String())
+	}
+	// errors
+	{
+		dir := writeTavorFiles(t, map[string]string{
+			"cycle.tavor":   "include \"a.tavor\"\n\nSTART = A\n",
+			"a.tavor":       "include \"b.tavor\"\n\nA = B\n",
+			"b.tavor":       "include \"a.tavor\"\n\nB = 1\n",
+			"missing.tavor": "include \"does-not-exist.tavor\"\n\nSTART = 1\n",
+			"clash.tavor":   "include \"c.tavor\"\n\nC = 2\n\nSTART = C\n",
+			"c.tavor":       "C = 1\n",
+			"syntax.tavor":  "include \"broken.tavor\"\n\nSTART = 1\n",
+			"broken.tavor":  "\nBroken = (1\n",
+		})
+		defer os.RemoveAll(dir)
+
+		tok, err := ParseTavorFile(filepath.Join(dir, "cycle.tavor"))
+		Equal(t, token.ParseErrorIncludeCycle, err.(*token.ParserError).Type)
+		Equal(t, filepath.Join(dir, "b.tavor"), err.(*token.ParserError).Position.Filename)
+		Nil(t, tok)
+
+		tok, err = ParseTavorFile(filepath.Join(dir, "missing.tavor"))
+		Equal(t, token.ParseErrorCannotIncludeFile, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavorFile(filepath.Join(dir, "clash.tavor"))
+		Equal(t, token.ParseErrorTokenAlreadyDefined, err.(*token.ParserError).Type)
+		Equal(t, filepath.Join(dir, "clash.tavor"), err.(*token.ParserError).Position.Filename)
+		Equal(t, 3, err.(*token.ParserError).Position.Line)
+		Nil(t, tok)
+
+		tok, err = ParseTavorFile(filepath.Join(dir, "syntax.tavor"))
+		Equal(t, token.ParseErrorExpectRune, err.(*token.ParserError).Type)
+		Equal(t, filepath.Join(dir, "broken.tavor"), err.(*token.ParserError).Position.Filename)
+		True(t, strings.HasPrefix(err.Error(), filepath.Join(dir, "broken.tavor")+", L:"))
+		Nil(t, tok)
+	}
+}
+
+func TestTavorParserParameterizedTokens(t *testing.T) {
+	// simple call with forward definition
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = Quoted(Name) " " Quoted("b")
+
+			Name = "a"
+
+			Quoted(X) = "'" X "'"
+		`))
+		Nil(t, err)
+		Equal(t, "'a' 'b'", tok.String())
+	}
+	// multiple parameters, nested calls and multiple usages of a parameter
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			Pair(Key, Value) = Key "=" Value
+			Twice(X) = X X
+			Quoted(X) = "'" X "'"
+
+			START = Pair(Twice(1), Quoted(2)) "," Pair(3,
+				4)
+		`))
+		Nil(t, err)
+		Equal(t, "11='2',3=4", tok.String())
+	}
+	// arguments with alternations are permutated independently
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			Twice(X) = X "-" X
+
+			START = Twice(1 | 2)
+		`))
+		Nil(t, err)
+
+		var got []string
+
+		strat := strategy.NewAllPermutationsStrategy(tok)
+		ch, err := strat.Fuzz(test.NewRandTest(1))
+		Nil(t, err)
+
+		for i := range ch {
+			got = append(got, tok.String())
+
+			ch <- i
+		}
+
+		Equal(t, []string{"1-1", "2-1", "1-2", "2-2"}, got)
+	}
+	// parameters with attributes, expressions and variables
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			Counted(X) = $X.Count ":" X
+			Plus(X) = ${X.Value + 1}
+
+			Digits = +2(1)
+
+			START = Counted(Digits) " " 3<var> Plus(var)
+		`))
+		Nil(t, err)
+		Equal(t, "2:11 34", tok.String())
+	}
+	// errors
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			Quoted(X) = "'" X "'"
+
+			START = Quoted(1, 2)
+		`))
+		Equal(t, token.ParseErrorWrongArgumentCount, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			Quoted(X) = "'" X "'"
+
+			START = Quoted
+		`))
+		Equal(t, token.ParseErrorWrongArgumentCount, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			A(X) = X B(X)
+			B(X) = A(X)
+
+			START = A(1)
+		`))
+		Equal(t, token.ParseErrorRecursiveParameterizedToken, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			START = Quoted(1)
+		`))
+		Equal(t, token.ParseErrorTokenNotDefined, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			A = 1
+
+			START = A(1)
+		`))
+		Equal(t, token.ParseErrorInvalidTokenType, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			Quoted(X) = "'" X "'"
+
+			START = 1
+		`))
+		Equal(t, token.ParseErrorUnusedToken, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			Quoted = 1
+			Quoted(X) = "'" X "'"
+
+			START = Quoted
+		`))
+		Equal(t, token.ParseErrorTokenAlreadyDefined, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			Quoted(X, X) = "'" X "'"
+
+			START = Quoted(1, 2)
+		`))
+		Equal(t, token.ParseErrorTokenAlreadyDefined, err.(*token.ParserError).Type)
+		Nil(t, tok)
+
+		// errors inside the body are reported at their original position
+		tok, err = ParseTavor(strings.NewReader("Quoted(X) = \"'\" X (\n\nSTART = Quoted(1)\n"))
+		Equal(t, token.ParseErrorExpectRune, err.(*token.ParserError).Type)
+		Equal(t, 1, err.(*token.ParserError).Position.Line)
+		Nil(t, tok)
+	}
+}
+
+func TestTavorParserWeightsAndDistributions(t *testing.T) {
+	var tok token.Token
+	var err error
+
+	// weighted alternation terms
+	tok, err = ParseTavor(strings.NewReader("START = 1 ~3 | 2 | 3 4 ~0.5\n"))
+	Nil(t, err)
+	Equal(t, tok, primitives.NewScope(lists.NewOneWithWeights(
+		[]float64{3, 1, 0.5},
+		primitives.NewConstantInt(1),
+		primitives.NewConstantInt(2),
+		lists.NewAll(
+			primitives.NewConstantInt(3),
+			primitives.NewConstantInt(4),
+		),
+	)))
+
+	errs := ParseInternal(tok, strings.NewReader("34"))
+	Nil(t, errs)
+	Equal(t, "34", tok.String())
+
+	// weights of optional and multi line alternations
+	tok, err = ParseTavor(strings.NewReader(`
+		START = 1 ~3,
+			| 2 ~2,
+			|
+	`))
+	Nil(t, err)
+	Equal(t, tok, primitives.NewScope(constraints.NewOptional(lists.NewOneWithWeights(
+		[]float64{3, 2},
+		primitives.NewConstantInt(1),
+		primitives.NewConstantInt(2),
+	))))
+
+	// distributions of repeat groups
+	tok, err = ParseTavor(strings.NewReader("START = +1,3(1) ~geometric(0.5) 2 +0,5(3) ~histogram(0-2: 10, 5: 1)\n"))
+	Nil(t, err)
+
+	a := lists.NewRepeat(primitives.NewConstantInt(1), 1, 3)
+	a.SetDistribution(lists.NewGeometricDistribution(0.5))
+	c := lists.NewRepeat(primitives.NewConstantInt(3), 0, 5)
+	c.SetDistribution(lists.NewHistogramDistribution(
+		lists.HistogramBucket{From: 0, To: 2, Weight: 10},
+		lists.HistogramBucket{From: 5, To: 5, Weight: 1},
+	))
+	Equal(t, tok, primitives.NewScope(lists.NewAll(
+		a,
+		primitives.NewConstantInt(2),
+		c,
+	)))
+	Equal(t, []float64{0.5, 0.25, 0.125}, a.Weights())
+	Equal(t, []float64{10, 10, 10, 0, 0, 1}, c.Weights())
+
+	errs = ParseInternal(tok, strings.NewReader("112333"))
+	Nil(t, errs)
+	Equal(t, "112333", tok.String())
+
+	// invalid weights
+	for _, format := range []string{
+		"START = 1 ~0 | 2\n",
+		"START = 1 ~-1 | 2\n",
+		"START = 1 ~2\n",
+		"START = 1 | ~2 | 2\n",
+		"START = 1 ~ | 2\n",
+	} {
+		tok, err = ParseTavor(strings.NewReader(format))
+		Equal(t, token.ParseErrorInvalidWeight, err.(*token.ParserError).Type, format)
+		Nil(t, tok)
+	}
+
+	// invalid distributions
+	for _, format := range []string{
+		"START = 1 ~geometric(0.5)\n",
+		"START = +(1) ~geometric(0)\n",
+		"START = +(1) ~geometric(2)\n",
+		"START = +(1) ~poisson(2)\n",
+		"START = +(1) ~histogram(2-1: 1)\n",
+		"START = +(1) ~histogram(1: 0)\n",
+		"START = +(1) ~histogram(1.5: 1)\n",
+	} {
+		tok, err = ParseTavor(strings.NewReader(format))
+		Equal(t, token.ParseErrorInvalidDistribution, err.(*token.ParserError).Type, format)
+		Nil(t, tok)
+	}
+}
+
+func TestTavorParserDefinitions(t *testing.T) {
+	root, definitions, err := ParseTavorDefinitions(strings.NewReader(`
+		$Id Sequence
+		$Number Int = from: 1,
+		              to: 3
+
+		Digit = 1 | 2 | 3
+		Digits = +1,2(Digit)
+
+		START = Digits<x> Number $Id.Next ${x.Count}
+	`), "")
+	Nil(t, err)
+	NotNil(t, root)
+
+	keys := make([]string, 0, len(definitions))
+	for name := range definitions {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	Equal(t, []string{"Digit", "Digits", "Id", "Number", "START"}, keys)
+
+	Equal(t, 3, definitions["Digit"].PermutationsAll())
+	Equal(t, 12, definitions["Digits"].PermutationsAll())
+	Equal(t, 3, definitions["Number"].PermutationsAll())
+
+	Equal(t, []string{"Count", "Item", "Unique"}, TokenAttributes(definitions["Digits"]))
+	Equal(t, []string{"Existing", "Next", "Reset"}, TokenAttributes(definitions["Id"]))
+	Equal(t, []string{"Value"}, TokenAttributes(definitions["Number"]))
+	Equal(t, []string{"Count", "Index", "Item", "Reference", "Value"}, TokenAttributes(variables.NewVariable("x", nil)))
+	Nil(t, TokenAttributes(primitives.NewConstantInt(1)))
+
+	_, definitions, err = ParseTavorDefinitions(strings.NewReader("START = Unknown\n"), "")
+	NotNil(t, err)
+	Nil(t, definitions)
+}
@@ -2,38 +2,151 @@ package parser
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
+	"strings"
+
+	"github.com/zimmski/tavor/token"
 )
 
+// typedArgument holds the raw values of a typed token argument
+type typedArgument struct {
+	values []string
+	list   bool
+}
+
 type argumentsParser struct {
-	arguments     map[string]string
+	arguments     map[string]typedArgument
 	usedArguments map[string]struct{}
+	directory     string
+	lookupToken   func(name string) token.Token
 	err           error
 }
 
-func newArgumentsParser(arguments map[string]string) *argumentsParser {
+func newArgumentsParser(arguments map[string]typedArgument, directory string, lookupToken func(name string) token.Token) *argumentsParser {
 	return &argumentsParser{
 		arguments:     arguments,
 		usedArguments: make(map[string]struct{}),
+		directory:     directory,
+		lookupToken:   lookupToken,
 		err:           nil,
 	}
 }
 
+// get returns the raw value of the argument name or false if the argument is not found or an error was already encountered
+func (ap *argumentsParser) get(name string, typ string) (string, bool) {
+	if ap.err != nil {
+		return "", false
+	}
+
+	arg, found := ap.arguments[name]
+	if !found {
+		return "", false
+	}
+
+	if arg.list {
+		ap.err = fmt.Errorf("%q needs %s value but got a list", name, typ)
+
+		return "", false
+	}
+
+	return arg.values[0], true
+}
+
+// getList returns the raw values of the argument name or false if the argument is not found or an error was already encountered
+// A single value is a list with one element.
+func (ap *argumentsParser) getList(name string) ([]string, bool) {
+	if ap.err != nil {
+		return nil, false
+	}
+
+	arg, found := ap.arguments[name]
+	if !found {
+		return nil, false
+	}
+
+	return arg.values, true
+}
+
+func parseInt(raw string) (int, error) {
+	var val int64
+	var err error
+
+	neg := strings.HasPrefix(raw, "-")
+	if neg {
+		raw = raw[1:]
+	}
+
+	if strings.HasPrefix(raw, "0x") || strings.HasPrefix(raw, "0X") {
+		val, err = strconv.ParseInt(raw[2:], 16, 0)
+	} else {
+		val, err = strconv.ParseInt(raw, 10, 0)
+	}
+
+	if neg {
+		val = -val
+	}
+
+	return int(val), err
+}
+
+func parseString(raw string) string {
+	if strings.HasPrefix(raw, "\"") || strings.HasPrefix(raw, "`") {
+		if s, err := strconv.Unquote(raw); err == nil {
+			return s
+		}
+	}
+
+	return raw
+}
+
 // GetInt tries to parse the argument name and returns its integer value or defaultValue if the argument is not found.
+// Hexadecimal values are allowed using the prefix 0x.
 // The return value is valid only if Err returns nil.
 func (ap *argumentsParser) GetInt(name string, defaultValue int) int {
-	if ap.err != nil {
+	raw, found := ap.get(name, "an integer")
+	if !found {
+		if ap.err != nil {
+			return -1
+		}
+
+		return defaultValue
+	}
+
+	val, err := parseInt(raw)
+	if err != nil {
+		ap.err = fmt.Errorf("%q needs an integer value", name)
 		return -1
 	}
 
-	raw, found := ap.arguments[name]
+	ap.usedArguments[name] = struct{}{}
+	return val
+}
+
+// GetString tries to parse the argument name and returns its string value or defaultValue if the argument is not found.
+// Quoted strings are unquoted, every other value is returned as it is written.
+// The return value is valid only if Err returns nil.
+func (ap *argumentsParser) GetString(name string, defaultValue string) string {
+	raw, found := ap.get(name, "a string")
+	if !found {
+		return defaultValue
+	}
+
+	ap.usedArguments[name] = struct{}{}
+	return parseString(raw)
+}
+
+// GetFloat tries to parse the argument name and returns its floating point value or defaultValue if the argument is not found.
+// The return value is valid only if Err returns nil.
+func (ap *argumentsParser) GetFloat(name string, defaultValue float64) float64 {
+	raw, found := ap.get(name, "a float")
 	if !found {
 		return defaultValue
 	}
 
-	val, err := strconv.Atoi(raw)
+	val, err := strconv.ParseFloat(raw, 64)
 	if err != nil {
-		ap.err = fmt.Errorf("%q needs an integer value", name)
+		ap.err = fmt.Errorf("%q needs a float value", name)
 		return -1
 	}
 
@@ -41,6 +154,132 @@ func (ap *argumentsParser) GetInt(name string, defaultValue int) int {
 	return val
 }
 
+// GetBool tries to parse the argument name and returns its boolean value or defaultValue if the argument is not found.
+// Valid values are true and false.
+// The return value is valid only if Err returns nil.
+func (ap *argumentsParser) GetBool(name string, defaultValue bool) bool {
+	raw, found := ap.get(name, "a boolean")
+	if !found {
+		return defaultValue
+	}
+
+	switch raw {
+	case "true":
+		ap.usedArguments[name] = struct{}{}
+		return true
+	case "false":
+		ap.usedArguments[name] = struct{}{}
+		return false
+	}
+
+	ap.err = fmt.Errorf("%q needs a boolean value", name)
+	return false
+}
+
+// GetIntList tries to parse the argument name and returns its integer values or defaultValue if the argument is not found.
+// A single value is a list with one element.
+// The return value is valid only if Err returns nil.
+func (ap *argumentsParser) GetIntList(name string, defaultValue []int) []int {
+	raws, found := ap.getList(name)
+	if !found {
+		return defaultValue
+	}
+
+	vals := make([]int, len(raws))
+
+	for i, raw := range raws {
+		val, err := parseInt(raw)
+		if err != nil {
+			ap.err = fmt.Errorf("%q needs integer values", name)
+			return nil
+		}
+
+		vals[i] = val
+	}
+
+	ap.usedArguments[name] = struct{}{}
+	return vals
+}
+
+// GetStringList tries to parse the argument name and returns its string values or defaultValue if the argument is not found.
+// A single value is a list with one element.
+// The return value is valid only if Err returns nil.
+func (ap *argumentsParser) GetStringList(name string, defaultValue []string) []string {
+	raws, found := ap.getList(name)
+	if !found {
+		return defaultValue
+	}
+
+	vals := make([]string, len(raws))
+
+	for i, raw := range raws {
+		vals[i] = parseString(raw)
+	}
+
+	ap.usedArguments[name] = struct{}{}
+	return vals
+}
+
+// GetFile tries to parse the argument name and returns the file name it references or defaultValue if the argument is not found.
+// Relative file names are resolved relative to the directory of the format file, or the current working directory if the format is not read from a file.
+// The return value is valid only if Err returns nil.
+func (ap *argumentsParser) GetFile(name string, defaultValue string) string {
+	raw, found := ap.get(name, "a file name")
+	if !found {
+		return defaultValue
+	}
+
+	filename := parseString(raw)
+	if filename == "" {
+		ap.err = fmt.Errorf("%q needs a file name", name)
+		return ""
+	}
+
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(ap.directory, filename)
+	}
+
+	ap.usedArguments[name] = struct{}{}
+	return filename
+}
+
+// GetToken tries to parse the argument name and returns the token it references or defaultValue if the argument is not found.
+// The referenced token can be defined after the typed token in which case a pointer to the token is returned.
+// The return value is valid only if Err returns nil.
+func (ap *argumentsParser) GetToken(name string, defaultValue token.Token) token.Token {
+	raw, found := ap.get(name, "a token")
+	if !found {
+		return defaultValue
+	}
+
+	if ap.lookupToken == nil || !isTokenName(raw) {
+		ap.err = fmt.Errorf("%q needs a token name", name)
+		return nil
+	}
+
+	ap.usedArguments[name] = struct{}{}
+	return ap.lookupToken(raw)
+}
+
+// Required checks that the arguments are defined
+// The error returned by Err is of the type ParseErrorMissingTypedTokenArgument if one argument is not defined.
+func (ap *argumentsParser) Required(names ...string) {
+	if ap.err != nil {
+		return
+	}
+
+	for _, name := range names {
+		if _, found := ap.arguments[name]; !found {
+			ap.err = &token.ParserError{
+				Message: fmt.Sprintf("typed token argument %q is missing", name),
+				Type:    token.ParseErrorMissingTypedTokenArgument,
+			}
+
+			return
+		}
+	}
+}
+
 // Err returns the first error encountered by the ArgumentsParser
 func (ap *argumentsParser) Err() error {
 	return ap.err
@@ -55,3 +294,16 @@ func (ap *argumentsParser) firstUnusedArgument() string {
 
 	return ""
 }
+
+func isTokenName(s string) bool {
+	for i, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && (c == '_' || (c >= '0' && c <= '9')):
+		default:
+			return false
+		}
+	}
+
+	return s != ""
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func parseTypedArguments(t *testing.T, src string) *argumentsParser {
+	var ap *argumentsParser
+
+	token.RegisterTyped("ArgumentsTest"+t.Name(), func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+		ap = argParser.(*argumentsParser)
+
+		// mark all arguments as used
+		for name := range ap.arguments {
+			ap.usedArguments[name] = struct{}{}
+		}
+
+		return primitives.NewConstantInt(1), nil
+	})
+
+	_, err := ParseTavor(strings.NewReader("$A ArgumentsTest" + t.Name() + " = " + src + "\nSTART = A\n"))
+	Nil(t, err)
+
+	return ap
+}
+
+func TestArgumentsParser(t *testing.T) {
+	ap := parseTypedArguments(t, `i: 0x10,
+	n: -3,
+	f: -1.5,
+	b: true,
+	s: "a\tb",
+	r: `+"`raw`"+`,
+	ident: abc,
+	is: [1, -2,
+		3],
+	ss: ["a", b],
+	file: "words.txt",
+	abs: "/words.txt",
+	tok: START`)
+
+	Equal(t, 16, ap.GetInt("i", 0))
+	Equal(t, -3, ap.GetInt("n", 0))
+	Equal(t, 7, ap.GetInt("missing", 7))
+	Equal(t, -1.5, ap.GetFloat("f", 0))
+	Equal(t, -3.0, ap.GetFloat("n", 0))
+	Equal(t, 0.5, ap.GetFloat("missing", 0.5))
+	Equal(t, true, ap.GetBool("b", false))
+	Equal(t, true, ap.GetBool("missing", true))
+	Equal(t, "a\tb", ap.GetString("s", ""))
+	Equal(t, "raw", ap.GetString("r", ""))
+	Equal(t, "abc", ap.GetString("ident", ""))
+	Equal(t, "16", ap.GetString("missing", "16"))
+	Equal(t, []int{1, -2, 3}, ap.GetIntList("is", nil))
+	Equal(t, []int{16}, ap.GetIntList("i", nil))
+	Equal(t, []string{"a", "b"}, ap.GetStringList("ss", nil))
+	Equal(t, []string{"x"}, ap.GetStringList("missing", []string{"x"}))
+	Equal(t, "words.txt", ap.GetFile("file", ""))
+	Equal(t, "/words.txt", ap.GetFile("abs", ""))
+	Equal(t, "x.txt", ap.GetFile("missing", "x.txt"))
+	NotNil(t, ap.GetToken("tok", nil))
+	Nil(t, ap.GetToken("missing", nil))
+	ap.Required("i", "tok")
+	Nil(t, ap.Err())
+
+	// invalid values
+	for _, get := range []func(){
+		func() { ap.GetInt("f", 0) },
+		func() { ap.GetInt("is", 0) },
+		func() { ap.GetFloat("ident", 0) },
+		func() { ap.GetBool("i", false) },
+		func() { ap.GetString("is", "") },
+		func() { ap.GetIntList("ss", nil) },
+		func() { ap.GetFile("is", "") },
+		func() { ap.GetToken("s", nil) },
+	} {
+		ap.err = nil
+
+		get()
+		NotNil(t, ap.Err())
+	}
+
+	// required arguments
+	ap.err = nil
+
+	ap.Required("i", "missing")
+	Equal(t, token.ParseErrorMissingTypedTokenArgument, ap.Err().(*token.ParserError).Type)
+}
@@ -0,0 +1,1446 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zimmski/tavor"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/aggregates"
+	"github.com/zimmski/tavor/token/conditions"
+	"github.com/zimmski/tavor/token/constraints"
+	"github.com/zimmski/tavor/token/dictionaries"
+	"github.com/zimmski/tavor/token/encodings"
+	"github.com/zimmski/tavor/token/expressions"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+	"github.com/zimmski/tavor/token/sequences"
+	"github.com/zimmski/tavor/token/sets"
+	"github.com/zimmski/tavor/token/variables"
+)
+
+// encodingSetting holds an encoding and if it generates invalid byte sequences
+type encodingSetting struct {
+	name       string
+	injections bool
+}
+
+func newEncodingSetting(e *encodings.Encoded) encodingSetting {
+	return encodingSetting{
+		name:       e.Encoding().Name(),
+		injections: e.Injections(),
+	}
+}
+
+func (s encodingSetting) String() string {
+	if s.injections {
+		return strconv.Quote(s.name) + " invalid"
+	}
+
+	return strconv.Quote(s.name)
+}
+
+// context holds the state of the position in a token definition which is printed
+type context struct {
+	// encoded is true if the tokens of the position are encoded
+	encoded bool
+	// termOnly is true if the position does not allow weights and distributions
+	termOnly bool
+}
+
+type printer struct {
+	// documentEncoding is the encoding of the whole document or nil if tokens are encoded individually or not at all
+	documentEncoding *encodingSetting
+	// violation is true if a token is not encoded although the document encoding would encode it
+	violation bool
+
+	definitions bytes.Buffer
+	taken       map[string]struct{}
+
+	// definitionNames holds the names of the token definitions which the tokens were parsed from
+	definitionNames map[token.Token]string
+	// reserved holds the names of definitionNames which must not be generated for other tokens
+	reserved map[string]struct{}
+
+	// names holds the names of definitions which must be unique for their token, e.g. lists with attributes
+	names map[token.Token]string
+	// deduplicated holds the names of definitions which can be shared by equal tokens
+	deduplicated map[string]string
+	// targets holds the tokens which are referenced by token attributes
+	targets map[token.Token]struct{}
+
+	loops     []token.Token
+	loopNames map[token.Token]string
+
+	encodings     []encodingSetting
+	encodingNames map[encodingSetting][]string
+
+	err error
+}
+
+// WriteTavor writes the token graph in the Tavor format to dst
+// The written format can be parsed again and results in an equal token graph. Token definitions are generated for tokens which need a name, e.g. typed tokens, lists which are referenced by token attributes and scopes which define variables. An error is returned if the graph holds tokens which cannot be written in the Tavor format.
+func WriteTavor(root token.Token, dst io.Writer) error {
+	return WriteTavorDefinitions(root, nil, dst)
+}
+
+// WriteTavorDefinitions writes the token graph in the Tavor format to dst like WriteTavor
+// The tokens of the given token definitions, e.g. returned by parser.ParseTavorDefinitions, and their copies in the token graph are written as token definitions with their original names. This holds also for token definitions which are not part of the token graph, e.g. sequences which are only used by token attributes.
+func WriteTavorDefinitions(root token.Token, definitions map[string]token.Token, dst io.Writer) error {
+	settings, semantics, variableNames, definitionNames := analyze(root, definitions)
+
+	var arithmetic string
+	for _, s := range semantics {
+		if s != expressions.DefaultSemantics.String() {
+			if arithmetic != "" && arithmetic != s {
+				return fmt.Errorf("cannot serialize arithmetic tokens with different semantics %q and %q", arithmetic, s)
+			}
+
+			arithmetic = s
+		}
+	}
+	if arithmetic != "" && len(semantics) > 1 {
+		return fmt.Errorf("cannot serialize arithmetic tokens with different semantics %q", strings.Join(semantics, "\", \""))
+	}
+
+	p := newPrinter(variableNames, definitionNames)
+	if len(settings) == 1 {
+		p.documentEncoding = &settings[0]
+	}
+
+	start := p.start(root)
+
+	if p.violation {
+		// the document encoding would encode tokens which are not encoded, so encode the tokens individually
+		p = newPrinter(variableNames, definitionNames)
+
+		start = p.start(root)
+	}
+
+	if p.err != nil {
+		return p.err
+	}
+
+	var statements bytes.Buffer
+
+	if arithmetic != "" {
+		fmt.Fprintf(&statements, "arithmetic %s\n", arithmetic)
+	}
+	if p.documentEncoding != nil {
+		fmt.Fprintf(&statements, "encoding %s\n", p.documentEncoding)
+	}
+	for _, setting := range p.encodings {
+		fmt.Fprintf(&statements, "encoding %s for %s\n", setting, strings.Join(p.encodingNames[setting], ", "))
+	}
+	for _, target := range p.loops {
+		fmt.Fprintf(&statements, "loop %s depth %d\n", p.loopNames[target], loopDepth(root, target))
+	}
+
+	if statements.Len() != 0 {
+		statements.WriteString("\n")
+	}
+	if p.definitions.Len() != 0 {
+		p.definitions.WriteString("\n")
+	}
+
+	for _, b := range [][]byte{statements.Bytes(), p.definitions.Bytes(), []byte("START = " + start + "\n")} {
+		if _, err := dst.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newPrinter(variableNames map[string]struct{}, definitionNames map[token.Token]string) *printer {
+	taken := map[string]struct{}{
+		"START": struct{}{},
+	}
+	for name := range variableNames {
+		taken[name] = struct{}{}
+	}
+
+	reserved := make(map[string]struct{})
+	for _, name := range definitionNames {
+		reserved[name] = struct{}{}
+	}
+
+	return &printer{
+		taken: taken,
+
+		definitionNames: definitionNames,
+		reserved:        reserved,
+
+		names:        make(map[token.Token]string),
+		deduplicated: make(map[string]string),
+		targets:      make(map[token.Token]struct{}),
+
+		loopNames: make(map[token.Token]string),
+
+		encodingNames: make(map[encodingSetting][]string),
+	}
+}
+
+// analyze collects the encoding settings, the arithmetic semantics, the variable names and the names of the token definitions of the token graph
+func analyze(root token.Token, definitions map[string]token.Token) ([]encodingSetting, []string, map[string]struct{}, map[token.Token]string) {
+	var settings []encodingSetting
+	var semantics []string
+	variableNames := make(map[string]struct{})
+	definitionNames := make(map[token.Token]string)
+
+	nameDefinition := func(name string, tok token.Token) {
+		// the START token is always written as the START token definition
+		if name == "START" {
+			return
+		}
+
+		setName := func(tok token.Token) {
+			if _, ok := definitionNames[tok]; !ok {
+				definitionNames[tok] = name
+			}
+		}
+
+		setName(tok)
+
+		// the defined token and an encoded token get the name too since they can be written as definitions on their own
+		if s, ok := tok.(*primitives.Scope); ok {
+			tok = s.Get()
+			setName(tok)
+		}
+		if e, ok := tok.(*encodings.Encoded); ok {
+			setName(e.Get())
+		}
+	}
+
+	seenSettings := make(map[encodingSetting]struct{})
+	seenSemantics := make(map[string]struct{})
+
+	walk(root, func(tok token.Token) {
+		switch t := tok.(type) {
+		case *encodings.Encoded:
+			s := newEncodingSetting(t)
+			if _, ok := seenSettings[s]; !ok {
+				seenSettings[s] = struct{}{}
+				settings = append(settings, s)
+			}
+		case expressions.ArithmeticToken:
+			s := t.Semantics().String()
+			if _, ok := seenSemantics[s]; !ok {
+				seenSemantics[s] = struct{}{}
+				semantics = append(semantics, s)
+			}
+		case *expressions.NegArithmetic:
+			s := t.Semantics().String()
+			if _, ok := seenSemantics[s]; !ok {
+				seenSemantics[s] = struct{}{}
+				semantics = append(semantics, s)
+			}
+		case *variables.Variable:
+			variableNames[t.Name()] = struct{}{}
+		case *variables.VariableSave:
+			variableNames[t.Name()] = struct{}{}
+		case *conditions.VariableDefined:
+			variableNames[t.Name()] = struct{}{}
+		}
+	})
+
+	// tokens are named in the order of their names, so the first name is kept if tokens share names
+	named := token.DefinitionNames(root, definitions)
+	toks := make([]token.Token, 0, len(named))
+	for tok := range named {
+		toks = append(toks, tok)
+	}
+	sort.SliceStable(toks, func(i, j int) bool {
+		return named[toks[i]] < named[toks[j]]
+	})
+
+	for _, tok := range toks {
+		nameDefinition(named[tok], tok)
+	}
+
+	// copies of typed tokens, e.g. of Value attributes, get the name of the copied typed token
+	typedNames := make(map[string]string)
+	for tok, n := range definitionNames {
+		if t, ok := tok.(token.TypedToken); ok {
+			k := typedKey(t)
+
+			if m, ok := typedNames[k]; !ok || n < m {
+				typedNames[k] = n
+			}
+		}
+	}
+	walk(root, func(tok token.Token) {
+		if t, ok := tok.(token.TypedToken); ok {
+			if _, ok := definitionNames[t]; !ok {
+				if n, ok := typedNames[typedKey(t)]; ok {
+					definitionNames[t] = n
+				}
+			}
+		}
+	})
+
+	return settings, semantics, variableNames, definitionNames
+}
+
+// typedKey returns the type and the arguments of the typed token definition of the token
+func typedKey(tok token.TypedToken) string {
+	typ, arguments := tok.TypedDefinition()
+
+	args := make([]string, len(arguments))
+	for i, a := range arguments {
+		args[i] = a.Name + ": " + a.Value
+	}
+
+	return typ + " = " + strings.Join(args, ", ")
+}
+
+// walk calls walkFunc for every token of the graph including conditions, expressions and the definitions of loops
+func walk(root token.Token, walkFunc func(tok token.Token)) {
+	visited := make(map[token.Token]struct{})
+
+	var rek func(tok token.Token)
+	rek = func(tok token.Token) {
+		if tok == nil {
+			return
+		}
+		if _, ok := visited[tok]; ok {
+			return
+		}
+		visited[tok] = struct{}{}
+
+		walkFunc(tok)
+
+		switch t := tok.(type) {
+		case *primitives.Loop:
+			rek(t.Target())
+		case *lists.Repeat:
+			rek(t.FromToken())
+			rek(t.ToToken())
+
+			c, _ := t.InternalGet(0)
+			rek(c)
+		case *lists.ListItem:
+			rek(t.IndexToken())
+		case *variables.VariableItem:
+			rek(t.IndexToken())
+		case *expressions.Path:
+			rek(t.List())
+			rek(t.From())
+			rek(t.Over())
+			for _, c := range t.ConnectBy() {
+				rek(c)
+			}
+			for _, c := range t.Without() {
+				rek(c)
+			}
+		case token.ForwardToken:
+			rek(t.InternalGet())
+		case token.ListToken:
+			for i := 0; i < t.InternalLen(); i++ {
+				c, _ := t.InternalGet(i)
+
+				rek(c)
+			}
+		}
+	}
+
+	rek(root)
+}
+
+// loopDepth returns the depth bound of the loop tokens with the given definition
+func loopDepth(root token.Token, target token.Token) int {
+	depth := tavor.MaxRepeat
+
+	walk(root, func(tok token.Token) {
+		if l, ok := tok.(*primitives.Loop); ok && l.Target() == target {
+			depth = l.MaxDepth()
+		}
+	})
+
+	return depth
+}
+
+func (p *printer) errorf(format string, a ...interface{}) string {
+	if p.err == nil {
+		p.err = fmt.Errorf(format, a...)
+	}
+
+	return ""
+}
+
+// name returns an unused definition name beginning with the given prefix
+func (p *printer) name(prefix string) string {
+	for i := 1; ; i++ {
+		n := prefix + strconv.Itoa(i)
+
+		if _, ok := p.taken[n]; ok {
+			continue
+		}
+		if _, ok := p.reserved[n]; ok {
+			continue
+		}
+
+		p.taken[n] = struct{}{}
+
+		return n
+	}
+}
+
+// definitionName returns the name of the token definition which the token was parsed from
+// If the token has no such name or the name is already taken by another token, an unused name beginning with the given prefix or the original name is returned.
+func (p *printer) definitionName(tok token.Token, prefix string) string {
+	if n, ok := p.definitionNames[tok]; ok {
+		if _, ok := p.taken[n]; !ok {
+			p.taken[n] = struct{}{}
+
+			return n
+		}
+
+		prefix = n
+	}
+
+	return p.name(prefix)
+}
+
+// named returns the name of the token definition of a scope which is a named token definition
+// Scopes of the same definition share their token definition if their tokens are written equally.
+func (p *printer) named(s *primitives.Scope, ctx context) string {
+	body := p.scope(s.Get(), context{encoded: ctx.encoded})
+
+	key := p.definitionNames[s] + " = " + body
+	if n, ok := p.deduplicated[key]; ok {
+		return n
+	}
+
+	n := p.definitionName(s, "Token")
+	p.deduplicated[key] = n
+
+	p.define(n, body)
+
+	return n
+}
+
+// isNamed returns true if the token is a scope which is a named token definition
+func (p *printer) isNamed(tok token.Token) bool {
+	s, ok := tok.(*primitives.Scope)
+	if !ok {
+		return false
+	}
+
+	_, ok = p.definitionNames[s]
+
+	return ok
+}
+
+// define adds a token definition with the given body
+func (p *printer) define(name string, body string) {
+	if body == "" {
+		p.errorf("cannot serialize empty token definition %s", name)
+
+		return
+	}
+
+	fmt.Fprintf(&p.definitions, "%s = %s\n", name, body)
+}
+
+// defineDeduplicated returns the name of a token definition with the given body which is added if there is no equal definition
+func (p *printer) defineDeduplicated(prefix string, body string) string {
+	if n, ok := p.deduplicated[body]; ok {
+		return n
+	}
+
+	n := p.name(prefix)
+	p.deduplicated[body] = n
+
+	p.define(n, body)
+
+	return n
+}
+
+// start returns the body of the START token definition
+func (p *printer) start(root token.Token) string {
+	// the parser resets sequences, dictionaries and sets automatically at the beginning of the START token
+	if l, ok := root.(*lists.All); ok && l.InternalLen() > 1 {
+		automatic := true
+
+		for i := 0; i < l.InternalLen()-1; i++ {
+			c, _ := l.InternalGet(i)
+
+			switch c.(type) {
+			case *sequences.SequenceResetItem, *sets.SetResetItem, *dictionaries.DictionaryResetItem:
+			default:
+				automatic = false
+			}
+		}
+
+		if automatic {
+			root, _ = l.InternalGet(l.InternalLen() - 1)
+		}
+	}
+
+	if s, ok := root.(*primitives.Scope); ok && !p.isNamed(s) {
+		if _, ok := p.typedUsage(s.Get()); !ok {
+			if _, ok := p.targets[s.Get()]; !ok {
+				root = s.Get()
+			}
+		}
+	}
+
+	p.collectTargets(root)
+
+	body := p.scope(root, context{})
+	if body == "" {
+		p.errorf("cannot serialize empty START token")
+	}
+
+	return body
+}
+
+// collectTargets collects all tokens which are referenced by token attributes
+func (p *printer) collectTargets(root token.Token) {
+	walk(root, func(tok token.Token) {
+		switch t := tok.(type) {
+		case *aggregates.Len:
+			if _, ok := t.Token().(token.VariableToken); !ok {
+				p.targets[t.Token()] = struct{}{}
+			}
+		case *lists.ListItem:
+			p.targets[t.List()] = struct{}{}
+		case *lists.UniqueItem:
+			p.targets[t.List()] = struct{}{}
+		}
+	})
+}
+
+// scope returns the token as content of a token definition or a group which allows alternations
+func (p *printer) scope(tok token.Token, ctx context) string {
+	tok = p.transparent(tok)
+
+	o, ok := tok.(*lists.One)
+	if !ok || p.isTarget(o) {
+		return p.sequence(tok, ctx)
+	}
+
+	weights := o.Weights()
+	weighted := false
+	for _, w := range weights {
+		if w != 1 {
+			weighted = true
+		}
+	}
+
+	var alternatives []string
+	optional := false
+
+	for i := 0; i < o.InternalLen(); i++ {
+		c, _ := o.InternalGet(i)
+
+		s := p.alternative(c, ctx)
+		if s == "" {
+			optional = true
+
+			continue
+		}
+
+		if weighted && weights[i] != 1 {
+			s += " ~" + strconv.FormatFloat(weights[i], 'g', -1, 64)
+		}
+
+		alternatives = append(alternatives, s)
+	}
+
+	if optional {
+		if weighted {
+			return p.errorf("cannot serialize weighted alternation with empty alternative")
+		}
+		if len(alternatives) == 0 {
+			return ""
+		}
+
+		return "?(" + strings.Join(alternatives, " | ") + ")"
+	}
+
+	return strings.Join(alternatives, " | ")
+}
+
+// alternative returns the token as an alternative of an alternation
+func (p *printer) alternative(tok token.Token, ctx context) string {
+	s := p.sequence(tok, ctx)
+
+	// conditions end the alternation
+	if containsIf(p.transparent(tok)) {
+		return "(" + s + ")"
+	}
+
+	return s
+}
+
+// containsIf returns true if the token is or directly holds a condition
+func containsIf(tok token.Token) bool {
+	switch t := tok.(type) {
+	case *conditions.If:
+		return true
+	case *lists.All:
+		for i := 0; i < t.InternalLen(); i++ {
+			c, _ := t.InternalGet(i)
+
+			if _, ok := c.(*conditions.If); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sequence returns the token as a concatenation of terms
+func (p *printer) sequence(tok token.Token, ctx context) string {
+	tok = p.transparent(tok)
+
+	l, ok := tok.(*lists.All)
+	if !ok || p.isTarget(l) {
+		return p.term(tok, ctx)
+	}
+
+	var terms []string
+
+	for i := 0; i < l.InternalLen(); i++ {
+		c, _ := l.InternalGet(i)
+
+		s := p.term(c, ctx)
+		if s == "" {
+			continue
+		}
+
+		terms = append(terms, s)
+
+		// a condition ends the weighted term of its scope
+		if _, ok := c.(*conditions.If); ok {
+			ctx.termOnly = true
+		}
+	}
+
+	return strings.Join(terms, " ")
+}
+
+// transparent returns the token which is referenced by scopes that do not need their own token definition
+func (p *printer) transparent(tok token.Token) token.Token {
+	for {
+		s, ok := tok.(*primitives.Scope)
+		if !ok || p.isNamed(s) || p.isTarget(s.Get()) || definesVariable(s.Get()) {
+			return tok
+		}
+		if _, ok := p.typedUsage(s.Get()); ok {
+			return tok
+		}
+
+		tok = s.Get()
+	}
+}
+
+// definesVariable returns true if the token defines a variable in its scope
+func definesVariable(tok token.Token) bool {
+	switch t := tok.(type) {
+	case *variables.Variable, *variables.VariableSave:
+		return true
+	case *primitives.Scope, *primitives.Loop:
+		return false
+	case *conditions.If:
+		for _, pair := range t.Pairs {
+			if definesVariable(pair.Body) {
+				return true
+			}
+		}
+	case *lists.Repeat:
+		c, _ := t.InternalGet(0)
+
+		return definesVariable(c)
+	case *lists.All, *lists.One, *lists.Once:
+		l := t.(token.ListToken)
+
+		for i := 0; i < l.InternalLen(); i++ {
+			c, _ := l.InternalGet(i)
+
+			if definesVariable(c) {
+				return true
+			}
+		}
+	case *constraints.Optional:
+		return definesVariable(t.InternalGet())
+	case *encodings.Encoded:
+		return definesVariable(t.InternalGet())
+	}
+
+	return false
+}
+
+func (p *printer) isTarget(tok token.Token) bool {
+	_, ok := p.targets[tok]
+
+	return ok
+}
+
+// group returns the token as group if needed
+func (p *printer) group(tok token.Token, ctx context) string {
+	return "(" + p.scope(tok, context{encoded: ctx.encoded}) + ")"
+}
+
+// term returns the token as a single term
+func (p *printer) term(tok token.Token, ctx context) string {
+	if p.isTarget(tok) {
+		return p.target(tok)
+	}
+
+	switch t := tok.(type) {
+	case *primitives.Scope:
+		if typed, ok := p.typedUsage(t.Get()); ok {
+			return p.typed(typed, t.Get(), ctx)
+		}
+		if p.isTarget(t.Get()) {
+			return p.target(t.Get())
+		}
+		if p.isNamed(t) {
+			if _, ok := t.Get().(*encodings.Encoded); ok && p.documentEncoding == nil {
+				// the encoded token is written as the token definition
+				return p.term(t.Get(), ctx)
+			}
+
+			return p.named(t, ctx)
+		}
+		if definesVariable(t.Get()) {
+			return p.defineDeduplicated("Token", p.scope(t.Get(), context{encoded: ctx.encoded}))
+		}
+
+		return p.term(t.Get(), ctx)
+	case *lists.All:
+		if t.InternalLen() == 0 {
+			return ""
+		}
+
+		return p.group(t, ctx)
+	case *lists.One:
+		return p.group(t, ctx)
+	case *lists.Once:
+		var alternatives []string
+
+		for i := 0; i < t.InternalLen(); i++ {
+			c, _ := t.InternalGet(i)
+
+			if s := p.alternative(c, ctx); s != "" {
+				alternatives = append(alternatives, s)
+			}
+		}
+
+		return "@(" + strings.Join(alternatives, " | ") + ")"
+	case *constraints.Optional:
+		s := p.scope(t.InternalGet(), context{encoded: ctx.encoded})
+		if s == "" {
+			return ""
+		}
+
+		return "?(" + s + ")"
+	case *lists.Repeat:
+		return p.repeat(t, ctx)
+	case *conditions.If:
+		return p.condition(t, ctx)
+	case *variables.Variable:
+		return p.variable(t.InternalGet(), ctx) + "<" + t.Name() + ">"
+	case *variables.VariableSave:
+		return p.variable(t.InternalGet(), ctx) + "<=" + t.Name() + ">"
+	case *variables.VariableValue:
+		if !ctx.encoded {
+			// variable values are not encoded
+			return p.variableName(t)
+		}
+
+		return p.encodable("${"+p.expression(t, 0)+"}", ctx)
+	case *primitives.Loop:
+		return p.loop(t)
+	case *encodings.Encoded:
+		return p.encoded(t, ctx)
+	case *primitives.ConstantString:
+		v := t.String()
+		if v == "" {
+			return ""
+		}
+
+		if p.documentEncoding != nil && !ctx.encoded {
+			// byte literals are never encoded
+			return "0x" + hex.EncodeToString([]byte(v))
+		}
+
+		return strconv.Quote(v)
+	case *primitives.ConstantInt:
+		if t.Value() < 0 {
+			return p.encodable("${"+strconv.Itoa(t.Value())+"}", ctx)
+		}
+
+		return p.encodable(strconv.Itoa(t.Value()), ctx)
+	case *primitives.ConstantFloat:
+		return p.encodable(strconv.Quote(t.String()), ctx)
+	case *primitives.CharacterClass:
+		return p.encodable("["+t.Pattern()+"]", ctx)
+	case *primitives.RangeInt, *primitives.RangeFloat, *primitives.RangeTime, *primitives.RangeDuration:
+		return p.encodable("${"+p.expression(t, 0)+"}", ctx)
+	case token.TypedToken:
+		return p.typed(t, t, ctx)
+	}
+
+	if s, ok := p.attribute(tok); ok {
+		return p.encodable("${"+s+"}", ctx)
+	}
+
+	switch tok.(type) {
+	case expressions.ArithmeticToken, *expressions.NegArithmetic, *expressions.Path:
+		return p.encodable("${"+p.expression(tok, 0)+"}", ctx)
+	}
+
+	return p.errorf("cannot serialize token %T", tok)
+}
+
+// encodable returns the given term of a token which is encoded by the document encoding
+func (p *printer) encodable(s string, ctx context) string {
+	if p.documentEncoding != nil && !ctx.encoded {
+		p.violation = true
+	}
+
+	return s
+}
+
+// variable returns the token which is saved by a variable
+func (p *printer) variable(tok token.Token, ctx context) string {
+	t := p.transparent(tok)
+
+	switch t.(type) {
+	case *lists.All, *lists.One, *conditions.If:
+		if !p.isTarget(t) {
+			return p.group(t, ctx)
+		}
+	case *lists.Repeat:
+		if t.(*lists.Repeat).Distribution() != nil {
+			return "(" + p.term(t, context{encoded: ctx.encoded}) + ")"
+		}
+	}
+
+	return p.term(tok, ctx)
+}
+
+// repeat returns the repeat group of the token
+func (p *printer) repeat(r *lists.Repeat, ctx context) string {
+	var s string
+
+	from, fromConstant := r.FromToken().(*primitives.ConstantInt)
+	to, toConstant := r.ToToken().(*primitives.ConstantInt)
+
+	switch {
+	case fromConstant && toConstant:
+		switch {
+		case from.Value() == 0 && to.Value() == tavor.MaxRepeat:
+			s = "*"
+		case from.Value() == 1 && to.Value() == tavor.MaxRepeat:
+			s = "+"
+		case from.Value() == to.Value():
+			s = "+" + strconv.Itoa(from.Value())
+		case to.Value() == tavor.MaxRepeat:
+			s = "+" + strconv.Itoa(from.Value()) + ","
+		default:
+			s = "+" + strconv.Itoa(from.Value()) + "," + strconv.Itoa(to.Value())
+		}
+	default:
+		s = "+" + p.bound(r.FromToken())
+
+		if r.ToToken() != r.FromToken() {
+			if toConstant && to.Value() == tavor.MaxRepeat {
+				s += ","
+			} else {
+				s += "," + p.bound(r.ToToken())
+			}
+		}
+	}
+
+	c, _ := r.InternalGet(0)
+	body := p.repeatBody(c, ctx)
+
+	s += "(" + body + ")"
+
+	if d := r.Distribution(); d != nil {
+		s += " ~" + d.String()
+
+		if ctx.termOnly {
+			s = "(" + s + ")"
+		}
+	}
+
+	return s
+}
+
+// bound returns the token of a repeat range
+func (p *printer) bound(tok token.Token) string {
+	if t, ok := tok.(*primitives.ConstantInt); ok && t.Value() >= 0 {
+		return strconv.Itoa(t.Value())
+	}
+
+	s, ok := p.attribute(tok)
+	if !ok {
+		return p.errorf("cannot serialize repeat range token %T", tok)
+	}
+
+	return "$" + s
+}
+
+// repeatBody returns the repeated token of a repeat group
+func (p *printer) repeatBody(tok token.Token, ctx context) string {
+	inner := p.transparent(tok)
+
+	optional := false
+
+	switch t := inner.(type) {
+	case *constraints.Optional:
+		optional = true
+	case *lists.One:
+		for i := 0; i < t.InternalLen(); i++ {
+			c, _ := t.InternalGet(i)
+
+			if _, ok := c.(*constraints.Optional); ok {
+				optional = true
+			}
+		}
+	}
+
+	if optional && !p.isTarget(inner) {
+		// repeat groups cannot hold optional terms directly
+		return p.defineDeduplicated("Token", p.scope(inner, context{encoded: ctx.encoded}))
+	}
+
+	return p.scope(tok, context{encoded: ctx.encoded})
+}
+
+// condition returns the if statement of the token
+func (p *printer) condition(c *conditions.If, ctx context) string {
+	var s bytes.Buffer
+
+	for i, pair := range c.Pairs {
+		switch {
+		case i == 0:
+			if _, ok := pair.Head.(*conditions.BooleanTrue); ok {
+				return p.errorf("cannot serialize if statement without condition")
+			}
+
+			s.WriteString("{if " + p.booleanExpression(pair.Head, 0) + "}")
+		default:
+			if _, ok := pair.Head.(*conditions.BooleanTrue); ok {
+				s.WriteString("{else}")
+			} else {
+				s.WriteString("{else if " + p.booleanExpression(pair.Head, 0) + "}")
+			}
+		}
+
+		body := p.transparent(pair.Body)
+
+		var b string
+		switch body.(type) {
+		case *lists.One, *conditions.If:
+			b = p.group(body, ctx)
+		default:
+			if containsIf(body) {
+				b = p.group(body, ctx)
+			} else {
+				b = p.sequence(body, context{encoded: ctx.encoded, termOnly: true})
+			}
+		}
+
+		if b == "" {
+			return p.errorf("cannot serialize if statement with empty body")
+		}
+
+		s.WriteString(b)
+	}
+
+	s.WriteString("{endif}")
+
+	return s.String()
+}
+
+// loop returns the name of the loop token definition
+func (p *printer) loop(l *primitives.Loop) string {
+	target := l.Target()
+
+	if n, ok := p.loopNames[target]; ok {
+		return n
+	}
+
+	n := p.definitionName(target, "Loop")
+	p.loopNames[target] = n
+	p.loops = append(p.loops, target)
+
+	if s, ok := target.(*primitives.Scope); ok && p.isNamed(s) {
+		p.define(n, p.scope(s.Get(), context{}))
+	} else {
+		p.define(n, p.scope(target, context{}))
+	}
+
+	return n
+}
+
+// encoded returns the encoded token
+func (p *printer) encoded(e *encodings.Encoded, ctx context) string {
+	setting := newEncodingSetting(e)
+
+	if p.documentEncoding != nil {
+		if *p.documentEncoding != setting {
+			p.violation = true
+		}
+
+		return p.term(e.Get(), context{encoded: true, termOnly: ctx.termOnly})
+	}
+
+	var n string
+
+	if typed, ok := e.Get().(token.TypedToken); ok && !isValueToken(typed) {
+		n = p.typedDefinition(typed, &setting)
+	} else {
+		n = p.definitionName(e, "Encoded")
+
+		p.define(n, p.scope(e.Get(), context{encoded: true}))
+		p.encodeDefinition(n, setting)
+	}
+
+	return n
+}
+
+// encodeDefinition adds the token definition to the definitions of the given encoding
+func (p *printer) encodeDefinition(name string, setting encodingSetting) {
+	if _, ok := p.encodingNames[setting]; !ok {
+		p.encodings = append(p.encodings, setting)
+	}
+
+	p.encodingNames[setting] = append(p.encodingNames[setting], name)
+}
+
+// isValueToken returns true if the typed token is a value of a typed token attribute when it is not directly referenced by a scope
+func isValueToken(tok token.Token) bool {
+	switch tok.(type) {
+	case *primitives.RangeInt, *primitives.RangeFloat, *primitives.RangeTime, *primitives.RangeDuration:
+		return true
+	}
+
+	return false
+}
+
+// typedUsage returns the typed token if the token is a usage of a typed token definition
+func (p *printer) typedUsage(tok token.Token) (token.TypedToken, bool) {
+	if e, ok := tok.(*encodings.Encoded); ok {
+		tok = e.Get()
+	}
+
+	t, ok := tok.(token.TypedToken)
+
+	return t, ok
+}
+
+// typed returns the name of the typed token definition of a typed token usage
+func (p *printer) typed(typed token.TypedToken, tok token.Token, ctx context) string {
+	var setting *encodingSetting
+
+	if e, ok := tok.(*encodings.Encoded); ok {
+		s := newEncodingSetting(e)
+
+		if p.documentEncoding != nil {
+			if *p.documentEncoding != s {
+				p.violation = true
+			}
+		} else {
+			setting = &s
+		}
+	} else if p.documentEncoding != nil && !ctx.encoded {
+		switch typed.(type) {
+		case *primitives.BinaryInt, *sequences.Sequence, *sets.Set:
+			// never encoded
+		default:
+			p.violation = true
+		}
+	}
+
+	return p.typedDefinition(typed, setting)
+}
+
+// typedDefinition returns the name of the typed token definition of the token
+func (p *printer) typedDefinition(tok token.TypedToken, setting *encodingSetting) string {
+	typ, arguments := tok.TypedDefinition()
+
+	if d, ok := tok.(*dictionaries.Dictionary); ok && len(arguments) == 0 {
+		return p.errorf("cannot serialize dictionary %v without a file", d.Values())
+	}
+
+	// definitions of tokens with a state or which are referenced by attributes must be unique for their token
+	unique := p.isTarget(tok)
+	switch tok.(type) {
+	case *sequences.Sequence, *sets.Set, *dictionaries.Dictionary:
+		unique = true
+	}
+
+	if unique {
+		if n, ok := p.names[tok]; ok {
+			return n
+		}
+	}
+
+	args := make([]string, len(arguments))
+	for i, a := range arguments {
+		args[i] = a.Name + ": " + a.Value
+	}
+
+	key := p.definitionNames[tok] + " " + typedKey(tok)
+	if setting != nil {
+		key = setting.String() + " " + key
+	}
+
+	if !unique {
+		if n, ok := p.deduplicated[key]; ok {
+			return n
+		}
+	}
+
+	n := p.definitionName(tok, typ)
+
+	if unique {
+		p.names[tok] = n
+	} else {
+		p.deduplicated[key] = n
+	}
+
+	fmt.Fprintf(&p.definitions, "$%s %s", n, typ)
+	if len(args) != 0 {
+		indent := strings.Repeat(" ", len(n)+len(typ)+5)
+
+		fmt.Fprintf(&p.definitions, " = %s", strings.Join(args, ",\n"+indent))
+	}
+	p.definitions.WriteString("\n")
+
+	if setting != nil {
+		p.encodeDefinition(n, *setting)
+	}
+
+	return n
+}
+
+// target returns the name of the token definition of a token which is referenced by token attributes
+func (p *printer) target(tok token.Token) string {
+	if n, ok := p.names[tok]; ok {
+		return n
+	}
+
+	if typed, ok := p.typedUsage(tok); ok {
+		return p.typed(typed, tok, context{})
+	}
+
+	n := p.definitionName(tok, "Token")
+	p.names[tok] = n
+
+	// the token is printed without its own name
+	inner := tok
+	if s, ok := tok.(*primitives.Scope); ok && p.isNamed(s) {
+		inner = s.Get()
+	}
+
+	delete(p.targets, tok)
+	body := p.scope(inner, context{})
+	p.targets[tok] = struct{}{}
+
+	p.define(n, body)
+
+	return n
+}
+
+// variableName returns the name of the variable which is referenced by the token
+func (p *printer) variableName(tok token.Token) string {
+	var v token.Token
+
+	switch t := tok.(type) {
+	case *variables.VariableValue:
+		v = t.InternalGet()
+	case *variables.VariableItem:
+		v = t.InternalGet()
+	case *variables.VariableReference:
+		v = t.Variable()
+	default:
+		v = tok
+	}
+
+	if n, ok := v.(token.VariableToken); ok {
+		return n.Name()
+	}
+
+	return p.errorf("cannot serialize variable token %T", v)
+}
+
+// attribute returns the token attribute of the token if it is one
+func (p *printer) attribute(tok token.Token) (string, bool) {
+	switch t := tok.(type) {
+	case *aggregates.Len:
+		if v, ok := t.Token().(token.VariableToken); ok {
+			return v.Name() + ".Count", true
+		}
+
+		return p.target(t.Token()) + ".Count", true
+	case *lists.ListItem:
+		return p.target(t.List()) + ".Item(" + p.expression(t.IndexToken(), 0) + ")", true
+	case *lists.UniqueItem:
+		return p.target(t.List()) + ".Unique", true
+	case *lists.IndexItem:
+		if v, ok := t.Token().(*variables.VariableValue); ok {
+			return p.variableName(v) + ".Index", true
+		}
+
+		return p.errorf("cannot serialize index of token %T", t.Token()), true
+	case *variables.VariableValue:
+		return p.variableName(t) + ".Value", true
+	case *variables.VariableItem:
+		return p.variableName(t) + ".Item(" + p.expression(t.IndexToken(), 0) + ")", true
+	case *variables.VariableReference:
+		return p.variableName(t) + ".Reference", true
+	case *primitives.RangeInt, *primitives.RangeFloat, *primitives.RangeTime, *primitives.RangeDuration:
+		return p.typedDefinition(t.(token.TypedToken), nil) + ".Value", true
+	case *sequences.SequenceItem:
+		return p.typedDefinition(t.Sequence(), nil) + ".Next", true
+	case *sequences.SequenceExistingItem:
+		return p.typedDefinition(t.Sequence(), nil) + ".Existing" + p.except(t), true
+	case *sequences.SequenceResetItem:
+		return p.typedDefinition(t.Sequence(), nil) + ".Reset", true
+	case *sets.SetAddItem:
+		if v := t.ValueToken(); v != nil {
+			return p.typedDefinition(t.Set(), nil) + ".Add(" + p.expression(v, 0) + ")", true
+		}
+
+		return p.typedDefinition(t.Set(), nil) + ".Add", true
+	case *sets.SetCountItem:
+		return p.typedDefinition(t.Set(), nil) + ".Count", true
+	case *sets.SetExistingItem:
+		return p.typedDefinition(t.Set(), nil) + ".Existing" + p.except(t), true
+	case *sets.SetMissingItem:
+		return p.typedDefinition(t.Set(), nil) + ".Missing", true
+	case *sets.SetRemoveItem:
+		return p.typedDefinition(t.Set(), nil) + ".Remove", true
+	case *sets.SetReplaceItem:
+		return p.typedDefinition(t.Set(), nil) + ".Replace(" + p.expression(t.ValueToken(), 0) + ")", true
+	case *sets.SetResetItem:
+		return p.typedDefinition(t.Set(), nil) + ".Reset", true
+	case *sets.SetValueItem:
+		return p.typedDefinition(t.Set(), nil) + ".Value(" + p.expression(t.KeyToken(), 0) + ")", true
+	case *dictionaries.DictionaryUniqueItem:
+		return p.dictionary(t.Dictionary()) + ".Unique", true
+	case *dictionaries.DictionaryExistingItem:
+		return p.dictionary(t.Dictionary()) + ".Existing" + p.except(t), true
+	case *dictionaries.DictionaryResetItem:
+		return p.dictionary(t.Dictionary()) + ".Reset", true
+	}
+
+	return "", false
+}
+
+// dictionary returns the name of the typed token definition of the dictionary
+func (p *printer) dictionary(d *dictionaries.Dictionary) string {
+	return p.typedDefinition(d, nil)
+}
+
+// except returns the not in operator of an existing item
+func (p *printer) except(tok token.Token) string {
+	l, ok := tok.(token.ListToken)
+	if !ok || l.InternalLen() == 0 {
+		return ""
+	}
+
+	return " not in " + p.expressionList(l, 0)
+}
+
+// expressionList returns the tokens of the list beginning with the given index as expression list
+func (p *printer) expressionList(l token.ListToken, from int) string {
+	var expressions []string
+
+	for i := from; i < l.InternalLen(); i++ {
+		c, _ := l.InternalGet(i)
+
+		expressions = append(expressions, p.expression(c, 0))
+	}
+
+	return "(" + strings.Join(expressions, ", ") + ")"
+}
+
+// expressionOperators holds the operators and precedences of the binary expression operators
+var expressionOperators = map[string]struct {
+	operator   string
+	precedence int
+}{
+	"*expressions.BitOrArithmetic":      {"|", 1},
+	"*expressions.BitXorArithmetic":     {"^", 2},
+	"*expressions.BitAndArithmetic":     {"&", 3},
+	"*expressions.ShiftLeftArithmetic":  {"<<", 4},
+	"*expressions.ShiftRightArithmetic": {">>", 4},
+	"*expressions.AddArithmetic":        {"+", 5},
+	"*expressions.SubArithmetic":        {"-", 5},
+	"*expressions.MulArithmetic":        {"*", 6},
+	"*expressions.DivArithmetic":        {"/", 6},
+	"*expressions.ModArithmetic":        {"%", 6},
+}
+
+// expression returns the token as expression
+// Expressions with a lower precedence than the given one are grouped.
+func (p *printer) expression(tok token.Token, precedence int) string {
+	if s, ok := p.attribute(tok); ok {
+		return s
+	}
+
+	switch t := tok.(type) {
+	case *primitives.ConstantInt:
+		return strconv.Itoa(t.Value())
+	case *expressions.NegArithmetic:
+		a, _ := t.InternalGet(0)
+
+		switch a.(type) {
+		case *primitives.ConstantInt, *expressions.NegArithmetic:
+			return "-(" + p.expression(a, 0) + ")"
+		}
+
+		return "-" + p.expression(a, 7)
+	case expressions.ArithmeticToken:
+		op, ok := expressionOperators[fmt.Sprintf("%T", t)]
+		if !ok {
+			break
+		}
+
+		a, _ := t.InternalGet(0)
+		b, _ := t.InternalGet(1)
+
+		s := p.expression(a, op.precedence) + " " + op.operator + " " + p.expression(b, op.precedence+1)
+		if op.precedence < precedence {
+			s = "(" + s + ")"
+		}
+
+		return s
+	case *expressions.Path:
+		s := p.expression(t.List(), 7) + " path from (" + p.expression(t.From(), 0) + ") over (" + p.expression(t.Over(), 0) + ") connect by " + p.expressions(t.ConnectBy()) + " without " + p.expressions(t.Without())
+		if precedence > 0 {
+			s = "(" + s + ")"
+		}
+
+		return s
+	case *conditions.ExpressionPointer:
+		return p.expression(t.Get(), precedence)
+	case *encodings.Encoded:
+		return p.expression(t.Get(), precedence)
+	case *primitives.Loop:
+		return p.loop(t)
+	case *primitives.Scope:
+		if typed, ok := p.typedUsage(t.Get()); ok {
+			return p.typed(typed, t.Get(), context{encoded: true})
+		}
+		if p.isTarget(t.Get()) {
+			return p.target(t.Get())
+		}
+		if p.isNamed(t) {
+			return p.named(t, context{})
+		}
+
+		return p.defineDeduplicated("Token", p.scope(t.Get(), context{}))
+	case *expressions.FuncExpression:
+		return p.errorf("cannot serialize token %T", tok)
+	}
+
+	if p.isTarget(tok) {
+		return p.target(tok)
+	}
+
+	return p.defineDeduplicated("Token", p.scope(tok, context{}))
+}
+
+// expressions returns the tokens as expression list
+func (p *printer) expressions(toks []token.Token) string {
+	s := make([]string, len(toks))
+
+	for i, tok := range toks {
+		s[i] = p.expression(tok, 0)
+	}
+
+	return "(" + strings.Join(s, ", ") + ")"
+}
+
+// booleanExpression returns the token as boolean expression with the given precedence
+// Boolean expressions with a lower precedence are grouped.
+func (p *printer) booleanExpression(tok token.Token, precedence int) string {
+	var s string
+	var level int
+
+	switch t := tok.(type) {
+	case *conditions.BooleanOr:
+		a, _ := t.InternalGet(0)
+		b, _ := t.InternalGet(1)
+
+		s, level = p.booleanExpression(a, 1)+" or "+p.booleanExpression(b, 2), 1
+	case *conditions.BooleanAnd:
+		a, _ := t.InternalGet(0)
+		b, _ := t.InternalGet(1)
+
+		s, level = p.booleanExpression(a, 2)+" and "+p.booleanExpression(b, 3), 2
+	case *conditions.BooleanNot:
+		if in, ok := t.InternalGet().(*conditions.BooleanIn); ok {
+			a, _ := in.InternalGet(0)
+
+			if !isExistingItem(a) {
+				return p.expression(a, 0) + " not in " + p.expressionList(in, 1)
+			}
+		}
+
+		s, level = "not "+p.booleanExpression(t.InternalGet(), 3), 3
+	case *conditions.BooleanIn:
+		a, _ := t.InternalGet(0)
+
+		return p.expression(a, 0) + " in " + p.expressionList(t, 1)
+	case *conditions.BooleanEqual:
+		return p.comparison(t, "==")
+	case *conditions.BooleanNotEqual:
+		return p.comparison(t, "!=")
+	case *conditions.BooleanLessThan:
+		return p.comparison(t, "<")
+	case *conditions.BooleanLessThanOrEqual:
+		return p.comparison(t, "<=")
+	case *conditions.BooleanGreaterThan:
+		return p.comparison(t, ">")
+	case *conditions.BooleanGreaterThanOrEqual:
+		return p.comparison(t, ">=")
+	case *conditions.VariableDefined:
+		return "defined " + t.Name()
+	case *conditions.ExpressionPointer:
+		return p.booleanExpression(t.Get(), precedence)
+	default:
+		return p.errorf("cannot serialize boolean expression %T", tok)
+	}
+
+	if level < precedence {
+		s = "(" + s + ")"
+	}
+
+	return s
+}
+
+// comparison returns the comparison of the two operands of the token
+func (p *printer) comparison(tok token.ListToken, operator string) string {
+	a, _ := tok.InternalGet(0)
+	b, _ := tok.InternalGet(1)
+
+	return p.expression(a, 0) + " " + operator + " " + p.expression(b, 0)
+}
+
+// isExistingItem returns true if the token is an Existing token attribute which would take a not in operator as its own
+func isExistingItem(tok token.Token) bool {
+	switch tok.(type) {
+	case *sequences.SequenceExistingItem, *sets.SetExistingItem, *dictionaries.DictionaryExistingItem:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,129 @@
+package printer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/parser"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/aggregates"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func writeTavor(t *testing.T, root token.Token) string {
+	return writeTavorDefinitions(t, root, nil)
+}
+
+func writeTavorDefinitions(t *testing.T, root token.Token, definitions map[string]token.Token) string {
+	var buf bytes.Buffer
+
+	Nil(t, WriteTavorDefinitions(root, definitions, &buf))
+
+	return buf.String()
+}
+
+// roundTrip checks that the written format parses into an equal token graph which is written again exactly the same
+func roundTrip(t *testing.T, format string) string {
+	root, definitions, err := parser.ParseTavorDefinitions(strings.NewReader(format), "")
+	Nil(t, err, format)
+	if err != nil {
+		return ""
+	}
+
+	written := writeTavorDefinitions(t, root, definitions)
+
+	again, againDefinitions, err := parser.ParseTavorDefinitions(strings.NewReader(written), "")
+	Nil(t, err, "%s\nwritten as\n%s", format, written)
+	if err != nil {
+		return written
+	}
+
+	Equal(t, written, writeTavorDefinitions(t, again, againDefinitions), format)
+	Equal(t, root.PermutationsAll(), again.PermutationsAll(), "%s\nwritten as\n%s", format, written)
+
+	return written
+}
+
+func TestWriteTavor(t *testing.T) {
+	for _, c := range []struct {
+		format   string
+		expected string
+	}{
+		{
+			"START = \"a\" | \"b\" \"c\"\n",
+			"START = \"a\" | \"b\" \"c\"\n",
+		},
+		{
+			"A = \"a\" | \"b\"\nSTART = A A\n",
+			"A = \"a\" | \"b\"\n\nSTART = A A\n",
+		},
+		{
+			"START = \"a\" ~2 | ?(\"b\") +2,4(\"c\") ~geometric(0.5)\n",
+			"START = \"a\" ~2 | ?(\"b\") +2,4(\"c\") ~geometric(0.5)\n",
+		},
+		{
+			"$Number Int = from: 1,\n              to: 10\nSTART = Number \" \" ${Number.Value + 1}\n",
+			"$Number Int = from: 1,\n              to: 10,\n              step: 1\n\nSTART = Number \" \" ${Number.Value + 1}\n",
+		},
+		{
+			"Items = +1,3(\"a\")\nSTART = Items \" \" ${Items.Count}\n",
+			"Items = +1,3(\"a\")\n\nSTART = Items \" \" ${Items.Count}\n",
+		},
+		{
+			"START = (1 | 2)<x> {if x.Value == 1}\"c\"{else if not (x.Value > 1 and x.Value < 3) or defined y}\"d\"{else}\"e\"{endif}\n",
+			"START = (1 | 2)<x> {if x.Value == 1}\"c\"{else if not (x.Value > 1 and x.Value < 3) or defined y}\"d\"{else}\"e\"{endif}\n",
+		},
+		{
+			"encoding \"UTF-16LE\"\nSTART = \"a\" 0x62\n",
+			"encoding \"UTF-16LE\"\n\nSTART = \"a\" 0x62\n",
+		},
+		{
+			"$Users Map = start: 5\nName = \"a\" | \"b\"\nSTART = $Users.Add(Name) $Users.Replace(1) $Users.Existing<u> ${Users.Value(u.Value)}\n",
+			"$Users Map = start: 5,\n             step: 1\nName = \"a\" | \"b\"\n\nSTART = ${Users.Add(Name)} ${Users.Replace(1)} ${Users.Existing}<u> ${Users.Value(u.Value)}\n",
+		},
+	} {
+		Equal(t, c.expected, roundTrip(t, c.format), c.format)
+	}
+
+	// definitions which are not part of the token graph keep their names too
+	format := "$Id Sequence = start: 1,\n               step: 1\nItem = \"item \" ${Id.Next}\nItems = +1,3(Item \"\\n\")\nSTART = Items ${Items.Count}\n"
+	root, definitions, err := parser.ParseTavorDefinitions(strings.NewReader(format), "")
+	Nil(t, err)
+	var buf bytes.Buffer
+	Nil(t, WriteTavorDefinitions(root, definitions, &buf))
+	Equal(t, "$Id Sequence = start: 1,\n               step: 1\nItem = \"item \" ${Id.Next}\nItems = +1,3(Item \"\\n\")\n\nSTART = Items ${Items.Count}\n", buf.String())
+
+	// tokens which were not parsed from named token definitions get generated names
+	items := lists.NewRepeat(primitives.NewConstantString("a"), 1, 3)
+	Equal(t, "Token1 = +1,3(\"a\")\n\nSTART = Token1 ${Token1.Count}\n", writeTavor(t, lists.NewAll(items, aggregates.NewLen(items))))
+
+	// tokens without a Tavor format representation
+	buf.Reset()
+	NotNil(t, WriteTavor(primitives.NewPointer(primitives.NewConstantString("a")), &buf))
+}
+
+// TestWriteTavorFormatDocumentation checks that every format example of the format documentation can be written and parsed again
+func TestWriteTavorFormatDocumentation(t *testing.T) {
+	doc, err := ioutil.ReadFile("../doc/format.md")
+	Nil(t, err)
+
+	examples := regexp.MustCompile("(?s)\n```tavor\n(.*?)```").FindAllStringSubmatch(string(doc), -1)
+	True(t, len(examples) > 50)
+
+	for _, example := range examples {
+		format := example[1]
+
+		// examples without a START token are only fragments and examples with includes or dictionaries need additional files
+		if !strings.Contains(format, "START") || strings.Contains(format, "include") || strings.Contains(format, "Dictionary") {
+			continue
+		}
+
+		roundTrip(t, format)
+	}
+}
@@ -0,0 +1,73 @@
+package rand
+
+// BytesRand implements a random generator which draws its values from a byte slice.
+// Every value consumes as many bytes as are needed to represent the highest value of its bound, which means that a bound of 1 consumes no bytes at all. The bytes are read in big-endian order and the value is the read number modulo the bound. If the byte slice is exhausted, the missing bytes are 0. The generator is therefore deterministic and suitable for coverage-guided fuzzing engines, since mutating a byte of the slice changes the value of a single decision.
+type BytesRand struct {
+	data   []byte
+	offset int
+}
+
+// NewBytesRand returns a new instance of the bytes random generator which draws its values from the given byte slice
+func NewBytesRand(data []byte) *BytesRand {
+	return &BytesRand{
+		data: data,
+	}
+}
+
+// Exhausted returns true if all bytes of the byte slice have been consumed
+func (r *BytesRand) Exhausted() bool {
+	return r.offset >= len(r.data)
+}
+
+// read returns a number out of the bytes which are needed to represent the given maximum
+func (r *BytesRand) read(max uint64) uint64 {
+	var v uint64
+
+	for ; max > 0; max >>= 8 {
+		v <<= 8
+
+		if r.offset < len(r.data) {
+			v |= uint64(r.data[r.offset])
+
+			r.offset++
+		}
+	}
+
+	return v
+}
+
+// Int returns a non-negative pseudo-random int
+func (r *BytesRand) Int() int {
+	u := uint(r.Int63())
+
+	return int(u << 1 >> 1)
+}
+
+// Intn returns, as an int, a non-negative pseudo-random number in [0,n). It panics if n <= 0.
+func (r *BytesRand) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+
+	return int(r.Int63n(int64(n)))
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer as an int64.
+func (r *BytesRand) Int63() int64 {
+	return int64(r.read(1<<63-1) & (1<<63 - 1))
+}
+
+// Int63n returns, as an int64, a non-negative pseudo-random number in [0,n). It panics if n <= 0.
+func (r *BytesRand) Int63n(n int64) int64 {
+	if n <= 0 {
+		panic("invalid argument to Int63n")
+	}
+
+	return int64(r.read(uint64(n-1)) % uint64(n))
+}
+
+// Seed resets the generator to the beginning of the byte slice.
+// The seed value itself is ignored since all values are defined by the byte slice.
+func (r *BytesRand) Seed(seed int64) {
+	r.offset = 0
+}
@@ -0,0 +1,37 @@
+package rand
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestBytesRand(t *testing.T) {
+	r := NewBytesRand([]byte{1, 7, 0x01, 0x02, 0xff})
+
+	// a bound of 1 consumes no bytes
+	Equal(t, 0, r.Intn(1))
+
+	Equal(t, 1, r.Intn(10))
+	Equal(t, int64(1), r.Int63n(3))
+	Equal(t, int64(0x0102%300), r.Int63n(300))
+	False(t, r.Exhausted())
+
+	// missing bytes are 0
+	Equal(t, 0xff00%1000, r.Intn(1000))
+	True(t, r.Exhausted())
+	Equal(t, 0, r.Intn(10))
+	Equal(t, 0, r.Int())
+
+	// the same bytes lead to the same values
+	r.Seed(0)
+	False(t, r.Exhausted())
+	Equal(t, 1, r.Intn(10))
+
+	r = NewBytesRand([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	Equal(t, int64(1<<63-1), r.Int63())
+	True(t, r.Exhausted())
+
+	r.Seed(0)
+	Equal(t, 1<<63-1, r.Int())
+}
@@ -13,3 +13,11 @@ type Rand interface {
 	// Seed uses the provided seed value to initialize the generator to a deterministic state.
 	Seed(seed int64)
 }
+
+// TokenRand defines a random generator which is told for which token the following random values are drawn
+type TokenRand interface {
+	Rand
+
+	// SetToken sets the identifier of the token for which the following random values are drawn
+	SetToken(id string)
+}
@@ -0,0 +1,90 @@
+package rand
+
+// Decision holds a random value which was drawn for a token
+type Decision struct {
+	// Token identifies the token the value was drawn for
+	Token string `json:"token"`
+	// Method is the name of the method which drew the value
+	Method string `json:"method"`
+	// N is the bound of the method or 0 if the method has no bound
+	N int64 `json:"n,omitempty"`
+	// Value is the drawn value
+	Value int64 `json:"value"`
+}
+
+// RecordingRand implements a recording random generator.
+// This generator draws its values from another random generator and records every value as a decision along with the token it was drawn for. The recorded decisions can be replayed with the replay random generator.
+type RecordingRand struct {
+	r     Rand
+	token string
+
+	decisions []Decision
+}
+
+// NewRecordingRand returns a new instance of the recording random generator which draws its values from the given random generator
+func NewRecordingRand(r Rand) *RecordingRand {
+	return &RecordingRand{
+		r: r,
+	}
+}
+
+// Decisions returns the recorded decisions in the order they were made
+func (r *RecordingRand) Decisions() []Decision {
+	return append([]Decision(nil), r.decisions...)
+}
+
+func (r *RecordingRand) record(method string, n int64, value int64) {
+	r.decisions = append(r.decisions, Decision{
+		Token:  r.token,
+		Method: method,
+		N:      n,
+		Value:  value,
+	})
+}
+
+// Int returns a non-negative pseudo-random int
+func (r *RecordingRand) Int() int {
+	v := r.r.Int()
+
+	r.record("Int", 0, int64(v))
+
+	return v
+}
+
+// Intn returns, as an int, a non-negative pseudo-random number in [0,n). It panics if n <= 0.
+func (r *RecordingRand) Intn(n int) int {
+	v := r.r.Intn(n)
+
+	r.record("Intn", int64(n), int64(v))
+
+	return v
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer as an int64.
+func (r *RecordingRand) Int63() int64 {
+	v := r.r.Int63()
+
+	r.record("Int63", 0, v)
+
+	return v
+}
+
+// Int63n returns, as an int64, a non-negative pseudo-random number in [0,n). It panics if n <= 0.
+func (r *RecordingRand) Int63n(n int64) int64 {
+	v := r.r.Int63n(n)
+
+	r.record("Int63n", n, v)
+
+	return v
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+// The recorded decisions are kept.
+func (r *RecordingRand) Seed(seed int64) {
+	r.r.Seed(seed)
+}
+
+// SetToken sets the identifier of the token for which the following random values are drawn
+func (r *RecordingRand) SetToken(id string) {
+	r.token = id
+}
@@ -0,0 +1,30 @@
+package rand
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestRecordingRand(t *testing.T) {
+	var r TokenRand = NewRecordingRand(NewIncrementRand(0))
+
+	r.SetToken("a")
+	Equal(t, 0, r.Intn(5))
+	Equal(t, int64(1), r.Int63n(5))
+
+	r.SetToken("b")
+	Equal(t, 2, r.Int())
+	Equal(t, int64(3), r.Int63())
+
+	r.Seed(0)
+	Equal(t, 0, r.Intn(5))
+
+	Equal(t, []Decision{
+		{Token: "a", Method: "Intn", N: 5, Value: 0},
+		{Token: "a", Method: "Int63n", N: 5, Value: 1},
+		{Token: "b", Method: "Int", Value: 2},
+		{Token: "b", Method: "Int63", Value: 3},
+		{Token: "b", Method: "Intn", N: 5, Value: 0},
+	}, r.(*RecordingRand).Decisions())
+}
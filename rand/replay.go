@@ -0,0 +1,145 @@
+package rand
+
+import (
+	"fmt"
+)
+
+// Divergence holds a difference between the recorded and the replayed decisions
+type Divergence struct {
+	// Decision is the index of the recorded decision which diverges
+	Decision int
+	// Token identifies the token which diverges
+	Token string
+	// Message describes the divergence
+	Message string
+}
+
+func (d *Divergence) String() string {
+	return fmt.Sprintf("decision %d of token %q: %s", d.Decision, d.Token, d.Message)
+}
+
+// ReplayRand implements a replaying random generator.
+// This generator returns the values of recorded decisions in their order, which reproduces a generation if the same token graph is used. Every drawn value is compared to the next recorded decision. If the token or the method differs, the generator skips ahead to the next recorded decision which fits. If there is no such decision or if the recorded value is out of the bound, the value is drawn from the fallback random generator instead. All differences are reported as divergences.
+type ReplayRand struct {
+	decisions []Decision
+	next      int
+	fallback  Rand
+	token     string
+
+	divergences []Divergence
+}
+
+// NewReplayRand returns a new instance of the replaying random generator which replays the given decisions and draws the values which cannot be replayed from the given fallback random generator
+func NewReplayRand(decisions []Decision, fallback Rand) *ReplayRand {
+	return &ReplayRand{
+		decisions: decisions,
+		fallback:  fallback,
+	}
+}
+
+// Divergences returns the divergences between the recorded and the replayed decisions so far including the recorded decisions which were not replayed yet
+func (r *ReplayRand) Divergences() []Divergence {
+	divergences := append([]Divergence(nil), r.divergences...)
+
+	if r.next < len(r.decisions) {
+		divergences = append(divergences, Divergence{
+			Decision: r.next,
+			Token:    r.decisions[r.next].Token,
+			Message:  fmt.Sprintf("%d recorded decisions were not replayed", len(r.decisions)-r.next),
+		})
+	}
+
+	return divergences
+}
+
+func (r *ReplayRand) diverge(decision int, message string, a ...interface{}) {
+	r.divergences = append(r.divergences, Divergence{
+		Decision: decision,
+		Token:    r.token,
+		Message:  fmt.Sprintf(message, a...),
+	})
+}
+
+// replay returns the recorded value for the current token and method or draws it from the fallback random generator
+func (r *ReplayRand) replay(method string, n int64, fallback func() int64) int64 {
+	next := -1
+	for i := r.next; i < len(r.decisions); i++ {
+		if d := r.decisions[i]; d.Token == r.token && d.Method == method {
+			next = i
+
+			break
+		}
+	}
+
+	if next == -1 {
+		r.diverge(r.next, "%s(%d) was not recorded", method, n)
+
+		return fallback()
+	}
+
+	if next != r.next {
+		r.diverge(r.next, "%d recorded decisions were skipped", next-r.next)
+	}
+
+	d := r.decisions[next]
+	r.next = next + 1
+
+	if d.N != n {
+		r.diverge(next, "bound of %s changed from %d to %d", method, d.N, n)
+	}
+
+	if n > 0 && d.Value >= n {
+		r.diverge(next, "recorded value %d is out of the bound %d", d.Value, n)
+
+		return fallback()
+	}
+
+	return d.Value
+}
+
+// Int returns a non-negative pseudo-random int
+func (r *ReplayRand) Int() int {
+	return int(r.replay("Int", 0, func() int64 {
+		return int64(r.fallback.Int())
+	}))
+}
+
+// Intn returns, as an int, a non-negative pseudo-random number in [0,n). It panics if n <= 0.
+func (r *ReplayRand) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+
+	return int(r.replay("Intn", int64(n), func() int64 {
+		return int64(r.fallback.Intn(n))
+	}))
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer as an int64.
+func (r *ReplayRand) Int63() int64 {
+	return r.replay("Int63", 0, r.fallback.Int63)
+}
+
+// Int63n returns, as an int64, a non-negative pseudo-random number in [0,n). It panics if n <= 0.
+func (r *ReplayRand) Int63n(n int64) int64 {
+	if n <= 0 {
+		panic("invalid argument to Int63n")
+	}
+
+	return r.replay("Int63n", n, func() int64 {
+		return r.fallback.Int63n(n)
+	})
+}
+
+// Seed uses the provided seed value to initialize the fallback random generator to a deterministic state.
+// The replay begins again with the first recorded decision.
+func (r *ReplayRand) Seed(seed int64) {
+	r.fallback.Seed(seed)
+	r.next = 0
+	r.divergences = nil
+}
+
+// SetToken sets the identifier of the token for which the following random values are drawn
+func (r *ReplayRand) SetToken(id string) {
+	r.token = id
+}
@@ -0,0 +1,60 @@
+package rand
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestReplayRand(t *testing.T) {
+	decisions := []Decision{
+		{Token: "a", Method: "Intn", N: 5, Value: 3},
+		{Token: "b", Method: "Int63n", N: 5, Value: 4},
+		{Token: "c", Method: "Int", Value: 7},
+		{Token: "d", Method: "Int63n", N: 2, Value: 1},
+	}
+
+	// the recorded decisions are replayed
+	var r TokenRand = NewReplayRand(decisions, NewConstantRand(0))
+
+	r.SetToken("a")
+	Equal(t, 3, r.Intn(5))
+	r.SetToken("b")
+	Equal(t, int64(4), r.Int63n(5))
+	r.SetToken("c")
+	Equal(t, 7, r.Int())
+	r.SetToken("d")
+	Equal(t, int64(1), r.Int63n(2))
+
+	Equal(t, 0, len(r.(*ReplayRand).Divergences()))
+
+	// changed bounds keep the recorded value if possible
+	r.Seed(0)
+
+	r.SetToken("a")
+	Equal(t, 3, r.Intn(4))
+	r.SetToken("b")
+	Equal(t, int64(0), r.Int63n(3))
+
+	Equal(t, []Divergence{
+		{Decision: 0, Token: "a", Message: "bound of Intn changed from 5 to 4"},
+		{Decision: 1, Token: "b", Message: "bound of Int63n changed from 5 to 3"},
+		{Decision: 1, Token: "b", Message: "recorded value 4 is out of the bound 3"},
+		{Decision: 2, Token: "c", Message: "2 recorded decisions were not replayed"},
+	}, r.(*ReplayRand).Divergences())
+
+	// unknown tokens are drawn from the fallback and unused decisions are skipped
+	r.Seed(0)
+
+	r.SetToken("x")
+	Equal(t, int64(0), r.Int63())
+	r.SetToken("d")
+	Equal(t, int64(1), r.Int63n(2))
+
+	divergences := r.(*ReplayRand).Divergences()
+	Equal(t, []Divergence{
+		{Decision: 0, Token: "x", Message: "Int63(0) was not recorded"},
+		{Decision: 0, Token: "d", Message: "3 recorded decisions were skipped"},
+	}, divergences)
+	Equal(t, `decision 0 of token "d": 3 recorded decisions were skipped`, divergences[1].String())
+}
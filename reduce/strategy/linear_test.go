@@ -170,6 +170,62 @@ func TestLinearStrategy(t *testing.T) {
 			"b",
 		)
 	}
+	{
+		// parameterized token definitions
+		tok, err := parser.ParseTavor(bytes.NewBufferString(`
+			START = Opt("a") Opt("b")
+
+			Opt(X) = ?(X)
+		`))
+		Nil(t, err)
+
+		validateTavorLinear(
+			t,
+			tok,
+			"ab",
+			func(out string) ReduceFeedbackType {
+				if strings.Contains(out, "b") {
+					return Good
+				}
+
+				return Bad
+			},
+			[]string{
+				"b",
+				"",
+			},
+			"b",
+		)
+	}
+	{
+		// real loops
+		tok, err := parser.ParseTavor(bytes.NewBufferString(`
+			loop Expr depth 4
+
+			Expr = "(" ?("-") Expr ")" | "x"
+
+			START = Expr
+		`))
+		Nil(t, err)
+
+		validateTavorLinear(
+			t,
+			tok,
+			"(-(-(x)))",
+			func(out string) ReduceFeedbackType {
+				if strings.Count(out, "-") == 1 {
+					return Good
+				}
+
+				return Bad
+			},
+			[]string{
+				"((-(x)))",
+				"(((x)))",
+			},
+			"((-(x)))",
+		)
+	}
 
 	/*
 		TODO read this files in with the aag.tavor file.
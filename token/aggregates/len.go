@@ -18,6 +18,11 @@ func NewLen(token token.LenToken) *Len {
 	}
 }
 
+// Token returns the referenced LenToken token
+func (a *Len) Token() token.LenToken {
+	return a.token
+}
+
 // Clone returns a copy of the token and all its children
 func (a *Len) Clone() token.Token {
 	return &Len{
@@ -28,7 +33,7 @@ func (a *Len) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (a *Len) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	return pars.ParseValue(a.String(), cur)
 }
 
 // Permutation sets a specific permutation for this token
@@ -54,3 +54,27 @@ func TestConstantInt(t *testing.T) {
 
 	Equal(t, o.Permutation(2).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
 }
+
+func TestLenParse(t *testing.T) {
+	list := lists.NewRepeat(primitives.NewConstantInt(1), 1, 10)
+
+	o := lists.NewAll(list, primitives.NewConstantString(":"), NewLen(list))
+
+	pars := &token.InternalParser{
+		Data:    "111:3",
+		DataLen: 5,
+	}
+
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 5, nex)
+
+	pars = &token.InternalParser{
+		Data:    "111:2",
+		DataLen: 5,
+	}
+
+	nex, errs = o.Parse(pars, 0)
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+	Equal(t, 4, nex)
+}
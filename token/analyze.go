@@ -1,6 +1,9 @@
 package token
 
 import (
+	"reflect"
+	"sort"
+
 	"github.com/zimmski/container/list/linkedlist"
 
 	"github.com/zimmski/tavor/log"
@@ -45,3 +48,100 @@ func LoopExists(root Token) bool {
 
 	return false
 }
+
+// DefinitionNames returns the names of the given token definitions for the tokens of the token graph which are token definitions or copies of them
+// A token is matched to a token definition if it is the token definition itself or if it has the same type and content as the token definition, which is the case for clones that were not yet permutated. If several token definitions match a token, only the token definitions which are used by the nearest token definition of the token are considered. Tokens which still match several token definitions get no name.
+func DefinitionNames(root Token, definitions map[string]Token) map[Token]string {
+	keys := make([]string, 0, len(definitions))
+	for name := range definitions {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+
+	names := make(map[Token]string, len(definitions))
+	for _, name := range keys {
+		if _, ok := names[definitions[name]]; !ok {
+			names[definitions[name]] = name
+		}
+	}
+
+	if root == nil {
+		return names
+	}
+
+	used := make(map[string]map[Token]struct{})
+	usedBy := func(name string) map[Token]struct{} {
+		if u, ok := used[name]; ok {
+			return u
+		}
+
+		u := make(map[Token]struct{})
+		_ = Walk(definitions[name], func(tok Token) error {
+			u[tok] = struct{}{}
+
+			return nil
+		})
+		used[name] = u
+
+		return u
+	}
+
+	walked := make(map[Token]struct{})
+
+	var walk func(tok Token, definition string)
+	walk = func(tok Token, definition string) {
+		if _, ok := walked[tok]; ok {
+			return
+		}
+		walked[tok] = struct{}{}
+
+		if _, ok := names[tok]; !ok {
+			var matches []string
+			for _, name := range keys {
+				def := definitions[name]
+
+				if reflect.TypeOf(tok) == reflect.TypeOf(def) && reflect.DeepEqual(tok, def) {
+					matches = append(matches, name)
+				}
+			}
+
+			if len(matches) > 1 && definition != "" {
+				u := usedBy(definition)
+
+				var local []string
+				for _, name := range matches {
+					if _, ok := u[definitions[name]]; ok {
+						local = append(local, name)
+					}
+				}
+
+				matches = local
+			}
+
+			if len(matches) == 1 {
+				names[tok] = matches[0]
+			}
+		}
+
+		if name, ok := names[tok]; ok {
+			definition = name
+		}
+
+		switch t := tok.(type) {
+		case ForwardToken:
+			if v := t.Get(); v != nil {
+				walk(v, definition)
+			}
+		case ListToken:
+			for i := 0; i < t.Len(); i++ {
+				c, _ := t.Get(i)
+
+				walk(c, definition)
+			}
+		}
+	}
+
+	walk(root, "")
+
+	return names
+}
@@ -125,8 +125,15 @@ func (c *If) Clone() token.Token {
 
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
+// The conditions are evaluated with the data which is parsed so far and the body of the first true condition is parsed.
 func (c *If) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	for _, pair := range c.Pairs {
+		if pair.Head.Evaluate() {
+			return pair.Body.Parse(pars, cur)
+		}
+	}
+
+	return cur, nil
 }
 
 // Permutation sets a specific permutation for this token
@@ -70,3 +70,41 @@ func TestVariableElse(t *testing.T) {
 
 	Equal(t, o.Permutation(2).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
 }
+
+func TestIfParse(t *testing.T) {
+	o := NewIf(
+		IfPair{
+			Head: NewBooleanEqual(primitives.NewConstantInt(1), primitives.NewConstantInt(2)),
+			Body: primitives.NewConstantString("a"),
+		},
+		IfPair{
+			Head: NewBooleanLessThan(primitives.NewConstantInt(1), primitives.NewConstantInt(2)),
+			Body: primitives.NewConstantString("b"),
+		},
+	)
+
+	pars := &token.InternalParser{
+		Data:    "bc",
+		DataLen: 2,
+	}
+
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 1, nex)
+
+	nex, errs = o.Parse(pars, 1)
+	NotNil(t, errs)
+	Equal(t, 1, nex)
+
+	// no condition is true
+	o = NewIf(
+		IfPair{
+			Head: NewBooleanEqual(primitives.NewConstantInt(1), primitives.NewConstantInt(2)),
+			Body: primitives.NewConstantString("a"),
+		},
+	)
+
+	nex, errs = o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 0, nex)
+}
@@ -2,6 +2,8 @@ package conditions
 
 import (
 	"fmt"
+	"math/big"
+	"strings"
 
 	"github.com/zimmski/tavor/log"
 	"github.com/zimmski/tavor/token"
@@ -100,77 +102,64 @@ func (c *BooleanTrue) InternalReplace(oldToken, newToken token.Token) error {
 	panic("This should never happen")
 }
 
-// BooleanEqual implements a boolean expression which compares the value of two tokens
-type BooleanEqual struct {
-	a, b token.Token
-}
+// compareValues compares the values of two tokens. Integers are compared numerically and every other value is compared lexicographically.
+func compareValues(a, b token.Token) int {
+	as := a.String()
+	bs := b.String()
 
-// NewBooleanEqual returns a new instance of a BooleanEqual token referencing two tokens
-func NewBooleanEqual(a, b token.Token) *BooleanEqual {
-	return &BooleanEqual{
-		a: a,
-		b: b,
+	if ai, ok := new(big.Int).SetString(as, 10); ok {
+		if bi, ok := new(big.Int).SetString(bs, 10); ok {
+			return ai.Cmp(bi)
+		}
 	}
-}
 
-// Evaluate evaluates the boolean expression and returns its result
-func (c *BooleanEqual) Evaluate() bool {
-	return c.a.String() == c.b.String()
+	return strings.Compare(as, bs)
 }
 
-// Token interface methods
-
-// Clone returns a copy of the token and all its children
-func (c *BooleanEqual) Clone() token.Token {
-	return &BooleanEqual{
-		a: c.a,
-		b: c.b,
-	}
+// booleanCompare implements the methods shared by all boolean expressions which compare the values of two tokens
+type booleanCompare struct {
+	a, b token.Token
 }
 
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
-func (c *BooleanEqual) Parse(pars *token.InternalParser, cur int) (int, []error) {
+func (c *booleanCompare) Parse(pars *token.InternalParser, cur int) (int, []error) {
 	panic("This should never happen")
 }
 
 // Permutation sets a specific permutation for this token
-func (c *BooleanEqual) Permutation(i uint) error {
+func (c *booleanCompare) Permutation(i uint) error {
 	// do nothing
 
 	return nil
 }
 
 // Permutations returns the number of permutations for this token
-func (c *BooleanEqual) Permutations() uint {
+func (c *booleanCompare) Permutations() uint {
 	return 1
 }
 
 // PermutationsAll returns the number of all possible permutations for this token including its children
-func (c *BooleanEqual) PermutationsAll() uint {
+func (c *booleanCompare) PermutationsAll() uint {
 	return 1
 }
 
-func (c *BooleanEqual) String() string {
-	return fmt.Sprintf("(%p)%#v == (%p)%#v", c.a, c.a, c.b, c.b)
-}
-
 // List interface methods
 
 // Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
-func (c *BooleanEqual) Get(i int) (token.Token, error) {
+func (c *booleanCompare) Get(i int) (token.Token, error) {
 	return nil, &lists.ListError{
 		Type: lists.ListErrorOutOfBound,
 	}
 }
 
 // Len returns the number of the current referenced tokens
-func (c *BooleanEqual) Len() int {
+func (c *booleanCompare) Len() int {
 	return 0
 }
 
 // InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
-func (c *BooleanEqual) InternalGet(i int) (token.Token, error) {
+func (c *booleanCompare) InternalGet(i int) (token.Token, error) {
 	switch i {
 	case 0:
 		return c.a, nil
@@ -184,10 +173,58 @@ func (c *BooleanEqual) InternalGet(i int) (token.Token, error) {
 }
 
 // InternalLen returns the number of referenced internal tokens
-func (c *BooleanEqual) InternalLen() int {
+func (c *booleanCompare) InternalLen() int {
 	return 2
 }
 
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (c *booleanCompare) InternalReplace(oldToken, newToken token.Token) error {
+	if oldToken == c.a {
+		c.a = newToken
+	}
+	if oldToken == c.b {
+		c.b = newToken
+	}
+
+	return nil
+}
+
+// BooleanEqual implements a boolean expression which compares the value of two tokens
+type BooleanEqual struct {
+	booleanCompare
+}
+
+// NewBooleanEqual returns a new instance of a BooleanEqual token referencing two tokens
+func NewBooleanEqual(a, b token.Token) *BooleanEqual {
+	return &BooleanEqual{
+		booleanCompare: booleanCompare{
+			a: a,
+			b: b,
+		},
+	}
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (c *BooleanEqual) Evaluate() bool {
+	return c.a.String() == c.b.String()
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *BooleanEqual) Clone() token.Token {
+	return &BooleanEqual{
+		booleanCompare: booleanCompare{
+			a: c.a,
+			b: c.b,
+		},
+	}
+}
+
+func (c *BooleanEqual) String() string {
+	return fmt.Sprintf("(%p)%#v == (%p)%#v", c.a, c.a, c.b, c.b)
+}
+
 // InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
 func (c *BooleanEqual) InternalLogicalRemove(tok token.Token) token.Token {
 	if tok == c.a || tok == c.b {
@@ -197,16 +234,229 @@ func (c *BooleanEqual) InternalLogicalRemove(tok token.Token) token.Token {
 	return c
 }
 
-// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
-func (c *BooleanEqual) InternalReplace(oldToken, newToken token.Token) error {
-	if oldToken == c.a {
-		c.a = newToken
+// BooleanNotEqual implements a boolean expression which evaluates if the values of two tokens differ
+type BooleanNotEqual struct {
+	booleanCompare
+}
+
+// NewBooleanNotEqual returns a new instance of a BooleanNotEqual token referencing two tokens
+func NewBooleanNotEqual(a, b token.Token) *BooleanNotEqual {
+	return &BooleanNotEqual{
+		booleanCompare: booleanCompare{
+			a: a,
+			b: b,
+		},
 	}
-	if oldToken == c.b {
-		c.b = newToken
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (c *BooleanNotEqual) Evaluate() bool {
+	return c.a.String() != c.b.String()
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *BooleanNotEqual) Clone() token.Token {
+	return &BooleanNotEqual{
+		booleanCompare: booleanCompare{
+			a: c.a,
+			b: c.b,
+		},
 	}
+}
 
-	return nil
+func (c *BooleanNotEqual) String() string {
+	return fmt.Sprintf("(%p)%#v != (%p)%#v", c.a, c.a, c.b, c.b)
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (c *BooleanNotEqual) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == c.a || tok == c.b {
+		return nil
+	}
+
+	return c
+}
+
+// BooleanLessThan implements a boolean expression which evaluates if the value of a token is less than the value of another token
+type BooleanLessThan struct {
+	booleanCompare
+}
+
+// NewBooleanLessThan returns a new instance of a BooleanLessThan token referencing two tokens
+func NewBooleanLessThan(a, b token.Token) *BooleanLessThan {
+	return &BooleanLessThan{
+		booleanCompare: booleanCompare{
+			a: a,
+			b: b,
+		},
+	}
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (c *BooleanLessThan) Evaluate() bool {
+	return compareValues(c.a, c.b) < 0
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *BooleanLessThan) Clone() token.Token {
+	return &BooleanLessThan{
+		booleanCompare: booleanCompare{
+			a: c.a,
+			b: c.b,
+		},
+	}
+}
+
+func (c *BooleanLessThan) String() string {
+	return fmt.Sprintf("(%p)%#v < (%p)%#v", c.a, c.a, c.b, c.b)
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (c *BooleanLessThan) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == c.a || tok == c.b {
+		return nil
+	}
+
+	return c
+}
+
+// BooleanLessThanOrEqual implements a boolean expression which evaluates if the value of a token is less than or equal to the value of another token
+type BooleanLessThanOrEqual struct {
+	booleanCompare
+}
+
+// NewBooleanLessThanOrEqual returns a new instance of a BooleanLessThanOrEqual token referencing two tokens
+func NewBooleanLessThanOrEqual(a, b token.Token) *BooleanLessThanOrEqual {
+	return &BooleanLessThanOrEqual{
+		booleanCompare: booleanCompare{
+			a: a,
+			b: b,
+		},
+	}
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (c *BooleanLessThanOrEqual) Evaluate() bool {
+	return compareValues(c.a, c.b) <= 0
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *BooleanLessThanOrEqual) Clone() token.Token {
+	return &BooleanLessThanOrEqual{
+		booleanCompare: booleanCompare{
+			a: c.a,
+			b: c.b,
+		},
+	}
+}
+
+func (c *BooleanLessThanOrEqual) String() string {
+	return fmt.Sprintf("(%p)%#v <= (%p)%#v", c.a, c.a, c.b, c.b)
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (c *BooleanLessThanOrEqual) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == c.a || tok == c.b {
+		return nil
+	}
+
+	return c
+}
+
+// BooleanGreaterThan implements a boolean expression which evaluates if the value of a token is greater than the value of another token
+type BooleanGreaterThan struct {
+	booleanCompare
+}
+
+// NewBooleanGreaterThan returns a new instance of a BooleanGreaterThan token referencing two tokens
+func NewBooleanGreaterThan(a, b token.Token) *BooleanGreaterThan {
+	return &BooleanGreaterThan{
+		booleanCompare: booleanCompare{
+			a: a,
+			b: b,
+		},
+	}
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (c *BooleanGreaterThan) Evaluate() bool {
+	return compareValues(c.a, c.b) > 0
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *BooleanGreaterThan) Clone() token.Token {
+	return &BooleanGreaterThan{
+		booleanCompare: booleanCompare{
+			a: c.a,
+			b: c.b,
+		},
+	}
+}
+
+func (c *BooleanGreaterThan) String() string {
+	return fmt.Sprintf("(%p)%#v > (%p)%#v", c.a, c.a, c.b, c.b)
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (c *BooleanGreaterThan) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == c.a || tok == c.b {
+		return nil
+	}
+
+	return c
+}
+
+// BooleanGreaterThanOrEqual implements a boolean expression which evaluates if the value of a token is greater than or equal to the value of another token
+type BooleanGreaterThanOrEqual struct {
+	booleanCompare
+}
+
+// NewBooleanGreaterThanOrEqual returns a new instance of a BooleanGreaterThanOrEqual token referencing two tokens
+func NewBooleanGreaterThanOrEqual(a, b token.Token) *BooleanGreaterThanOrEqual {
+	return &BooleanGreaterThanOrEqual{
+		booleanCompare: booleanCompare{
+			a: a,
+			b: b,
+		},
+	}
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (c *BooleanGreaterThanOrEqual) Evaluate() bool {
+	return compareValues(c.a, c.b) >= 0
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *BooleanGreaterThanOrEqual) Clone() token.Token {
+	return &BooleanGreaterThanOrEqual{
+		booleanCompare: booleanCompare{
+			a: c.a,
+			b: c.b,
+		},
+	}
+}
+
+func (c *BooleanGreaterThanOrEqual) String() string {
+	return fmt.Sprintf("(%p)%#v >= (%p)%#v", c.a, c.a, c.b, c.b)
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (c *BooleanGreaterThanOrEqual) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == c.a || tok == c.b {
+		return nil
+	}
+
+	return c
 }
 
 // VariableDefined implements a boolean expression which evaluates if a variable is defined in a given scope
@@ -228,6 +478,11 @@ func (c *VariableDefined) Evaluate() bool {
 	return c.variableScope.Get(c.name) != nil
 }
 
+// Name returns the name of the variable
+func (c *VariableDefined) Name() string {
+	return c.name
+}
+
 // Token interface methods
 
 // Clone returns a copy of the token and all its children
@@ -5,6 +5,7 @@ import (
 
 	. "github.com/zimmski/tavor/test/assert"
 
+	"github.com/zimmski/tavor/token"
 	"github.com/zimmski/tavor/token/primitives"
 )
 
@@ -13,6 +14,11 @@ func TestBooleanExpressionToBeBooleanExpression(t *testing.T) {
 
 	Implements(t, ex, &BooleanTrue{})
 	Implements(t, ex, &BooleanEqual{})
+	Implements(t, ex, &BooleanNotEqual{})
+	Implements(t, ex, &BooleanLessThan{})
+	Implements(t, ex, &BooleanLessThanOrEqual{})
+	Implements(t, ex, &BooleanGreaterThan{})
+	Implements(t, ex, &BooleanGreaterThanOrEqual{})
 }
 
 func TestBooleanTrue(t *testing.T) {
@@ -27,3 +33,42 @@ func TestBooleanEqual(t *testing.T) {
 	o = NewBooleanEqual(primitives.NewConstantInt(1), primitives.NewConstantInt(2))
 	False(t, o.Evaluate())
 }
+
+func TestBooleanComparisons(t *testing.T) {
+	type newComparison func(a, b token.Token) BooleanExpression
+
+	notEqual := func(a, b token.Token) BooleanExpression { return NewBooleanNotEqual(a, b) }
+	lessThan := func(a, b token.Token) BooleanExpression { return NewBooleanLessThan(a, b) }
+	lessThanOrEqual := func(a, b token.Token) BooleanExpression { return NewBooleanLessThanOrEqual(a, b) }
+	greaterThan := func(a, b token.Token) BooleanExpression { return NewBooleanGreaterThan(a, b) }
+	greaterThanOrEqual := func(a, b token.Token) BooleanExpression { return NewBooleanGreaterThanOrEqual(a, b) }
+
+	for _, c := range []struct {
+		new    newComparison
+		a, b   string
+		expect bool
+	}{
+		{notEqual, "1", "1", false},
+		{notEqual, "1", "2", true},
+		{lessThan, "1", "2", true},
+		{lessThan, "2", "2", false},
+		{lessThan, "9", "10", true},
+		{lessThan, "-10", "9", true},
+		{lessThan, "a", "b", true},
+		{lessThan, "b", "a", false},
+		{lessThan, "9", "a", true},
+		{lessThanOrEqual, "2", "2", true},
+		{lessThanOrEqual, "3", "2", false},
+		{greaterThan, "10", "9", true},
+		{greaterThan, "2", "2", false},
+		{greaterThan, "ab", "a", true},
+		{greaterThanOrEqual, "2", "2", true},
+		{greaterThanOrEqual, "1", "2", false},
+	} {
+		o := c.new(primitives.NewConstantString(c.a), primitives.NewConstantString(c.b))
+		Equal(t, c.expect, o.Evaluate(), "%s %T %s", c.a, o, c.b)
+
+		o2 := o.Clone().(BooleanExpression)
+		Equal(t, c.expect, o2.Evaluate())
+	}
+}
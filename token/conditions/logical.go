@@ -0,0 +1,412 @@
+package conditions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+)
+
+// BooleanNot implements a boolean expression which negates a boolean expression
+type BooleanNot struct {
+	a BooleanExpression
+}
+
+// NewBooleanNot returns a new instance of a BooleanNot token referencing the given boolean expression
+func NewBooleanNot(a BooleanExpression) *BooleanNot {
+	return &BooleanNot{
+		a: a,
+	}
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (c *BooleanNot) Evaluate() bool {
+	return !c.a.Evaluate()
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *BooleanNot) Clone() token.Token {
+	return &BooleanNot{
+		a: c.a.Clone().(BooleanExpression),
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (c *BooleanNot) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	panic("This should never happen")
+}
+
+// Permutation sets a specific permutation for this token
+func (c *BooleanNot) Permutation(i uint) error {
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (c *BooleanNot) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (c *BooleanNot) PermutationsAll() uint {
+	return 1
+}
+
+func (c *BooleanNot) String() string {
+	return fmt.Sprintf("not (%s)", c.a.String())
+}
+
+// ForwardToken interface methods
+
+// Get returns the current referenced token
+func (c *BooleanNot) Get() token.Token {
+	return nil
+}
+
+// InternalGet returns the current referenced internal token
+func (c *BooleanNot) InternalGet() token.Token {
+	return c.a
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (c *BooleanNot) InternalLogicalRemove(tok token.Token) token.Token {
+	if c.a == tok {
+		return nil
+	}
+
+	return c
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (c *BooleanNot) InternalReplace(oldToken, newToken token.Token) error {
+	if c.a == oldToken {
+		c.a = newToken.(BooleanExpression)
+	}
+
+	return nil
+}
+
+// ScopeToken interface methods
+
+// SetScope sets the scope of the token
+func (c *BooleanNot) SetScope(variableScope *token.VariableScope) {
+	token.SetScope(c.a, variableScope)
+}
+
+// booleanLogical implements the methods shared by all boolean expressions which combine two boolean expressions
+type booleanLogical struct {
+	a, b BooleanExpression
+}
+
+func (c *booleanLogical) clone() booleanLogical {
+	return booleanLogical{
+		a: c.a.Clone().(BooleanExpression),
+		b: c.b.Clone().(BooleanExpression),
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (c *booleanLogical) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	panic("This should never happen")
+}
+
+// Permutation sets a specific permutation for this token
+func (c *booleanLogical) Permutation(i uint) error {
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (c *booleanLogical) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (c *booleanLogical) PermutationsAll() uint {
+	return 1
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (c *booleanLogical) Get(i int) (token.Token, error) {
+	return nil, &lists.ListError{
+		Type: lists.ListErrorOutOfBound,
+	}
+}
+
+// Len returns the number of the current referenced tokens
+func (c *booleanLogical) Len() int {
+	return 0
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (c *booleanLogical) InternalGet(i int) (token.Token, error) {
+	switch i {
+	case 0:
+		return c.a, nil
+	case 1:
+		return c.b, nil
+	default:
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (c *booleanLogical) InternalLen() int {
+	return 2
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (c *booleanLogical) InternalReplace(oldToken, newToken token.Token) error {
+	if oldToken == c.a {
+		c.a = newToken.(BooleanExpression)
+	}
+	if oldToken == c.b {
+		c.b = newToken.(BooleanExpression)
+	}
+
+	return nil
+}
+
+// ScopeToken interface methods
+
+// SetScope sets the scope of the token
+func (c *booleanLogical) SetScope(variableScope *token.VariableScope) {
+	token.SetScope(c.a, variableScope)
+	token.SetScope(c.b, variableScope)
+}
+
+// BooleanAnd implements a boolean expression which evaluates to true if both of its boolean expressions are true
+// The second boolean expression is only evaluated if the first boolean expression is true.
+type BooleanAnd struct {
+	booleanLogical
+}
+
+// NewBooleanAnd returns a new instance of a BooleanAnd token referencing two boolean expressions
+func NewBooleanAnd(a, b BooleanExpression) *BooleanAnd {
+	return &BooleanAnd{
+		booleanLogical: booleanLogical{
+			a: a,
+			b: b,
+		},
+	}
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (c *BooleanAnd) Evaluate() bool {
+	return c.a.Evaluate() && c.b.Evaluate()
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *BooleanAnd) Clone() token.Token {
+	return &BooleanAnd{
+		booleanLogical: c.clone(),
+	}
+}
+
+func (c *BooleanAnd) String() string {
+	return fmt.Sprintf("(%s) and (%s)", c.a.String(), c.b.String())
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (c *BooleanAnd) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == c.a || tok == c.b {
+		return nil
+	}
+
+	return c
+}
+
+// BooleanOr implements a boolean expression which evaluates to true if at least one of its boolean expressions is true
+// The second boolean expression is only evaluated if the first boolean expression is false.
+type BooleanOr struct {
+	booleanLogical
+}
+
+// NewBooleanOr returns a new instance of a BooleanOr token referencing two boolean expressions
+func NewBooleanOr(a, b BooleanExpression) *BooleanOr {
+	return &BooleanOr{
+		booleanLogical: booleanLogical{
+			a: a,
+			b: b,
+		},
+	}
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (c *BooleanOr) Evaluate() bool {
+	return c.a.Evaluate() || c.b.Evaluate()
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *BooleanOr) Clone() token.Token {
+	return &BooleanOr{
+		booleanLogical: c.clone(),
+	}
+}
+
+func (c *BooleanOr) String() string {
+	return fmt.Sprintf("(%s) or (%s)", c.a.String(), c.b.String())
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (c *BooleanOr) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == c.a || tok == c.b {
+		return nil
+	}
+
+	return c
+}
+
+// BooleanIn implements a boolean expression which evaluates if the value of a token is equal to the value of at least one token of a list
+type BooleanIn struct {
+	a    token.Token
+	list []token.Token
+}
+
+// NewBooleanIn returns a new instance of a BooleanIn token referencing a token and the list of tokens it is compared to
+func NewBooleanIn(a token.Token, list ...token.Token) *BooleanIn {
+	return &BooleanIn{
+		a:    a,
+		list: list,
+	}
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (c *BooleanIn) Evaluate() bool {
+	v := c.a.String()
+
+	for _, tok := range c.list {
+		if tok.String() == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *BooleanIn) Clone() token.Token {
+	return &BooleanIn{
+		a:    c.a,
+		list: append([]token.Token(nil), c.list...),
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (c *BooleanIn) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	panic("This should never happen")
+}
+
+// Permutation sets a specific permutation for this token
+func (c *BooleanIn) Permutation(i uint) error {
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (c *BooleanIn) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (c *BooleanIn) PermutationsAll() uint {
+	return 1
+}
+
+func (c *BooleanIn) String() string {
+	list := make([]string, len(c.list))
+	for i, tok := range c.list {
+		list[i] = fmt.Sprintf("(%p)%#v", tok, tok)
+	}
+
+	return fmt.Sprintf("(%p)%#v in (%s)", c.a, c.a, strings.Join(list, ", "))
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (c *BooleanIn) Get(i int) (token.Token, error) {
+	return nil, &lists.ListError{
+		Type: lists.ListErrorOutOfBound,
+	}
+}
+
+// Len returns the number of the current referenced tokens
+func (c *BooleanIn) Len() int {
+	return 0
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (c *BooleanIn) InternalGet(i int) (token.Token, error) {
+	if i < 0 || i > len(c.list) {
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+
+	if i == 0 {
+		return c.a, nil
+	}
+
+	return c.list[i-1], nil
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (c *BooleanIn) InternalLen() int {
+	return len(c.list) + 1
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (c *BooleanIn) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == c.a {
+		return nil
+	}
+
+	for i := 0; i < len(c.list); i++ {
+		if c.list[i] == tok {
+			c.list = append(c.list[:i], c.list[i+1:]...)
+
+			i--
+		}
+	}
+
+	if len(c.list) == 0 {
+		return nil
+	}
+
+	return c
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (c *BooleanIn) InternalReplace(oldToken, newToken token.Token) error {
+	if oldToken == c.a {
+		c.a = newToken
+	}
+
+	for i, tok := range c.list {
+		if tok == oldToken {
+			c.list[i] = newToken
+		}
+	}
+
+	return nil
+}
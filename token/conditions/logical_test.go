@@ -0,0 +1,106 @@
+package conditions
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestLogicalBooleanExpressionsToBeBooleanExpressions(t *testing.T) {
+	var ex *BooleanExpression
+
+	Implements(t, ex, &BooleanNot{})
+	Implements(t, ex, &BooleanAnd{})
+	Implements(t, ex, &BooleanOr{})
+	Implements(t, ex, &BooleanIn{})
+
+	var tok *token.ScopeToken
+
+	Implements(t, tok, &BooleanNot{})
+	Implements(t, tok, &BooleanAnd{})
+	Implements(t, tok, &BooleanOr{})
+}
+
+func TestBooleanNot(t *testing.T) {
+	o := NewBooleanNot(NewBooleanTrue())
+	False(t, o.Evaluate())
+
+	o = NewBooleanNot(NewBooleanNot(NewBooleanTrue()))
+	True(t, o.Evaluate())
+
+	o2 := o.Clone().(*BooleanNot)
+	True(t, o2.Evaluate())
+}
+
+func TestBooleanAndOr(t *testing.T) {
+	yes := NewBooleanTrue()
+	no := NewBooleanNot(NewBooleanTrue())
+
+	True(t, NewBooleanAnd(yes, yes).Evaluate())
+	False(t, NewBooleanAnd(yes, no).Evaluate())
+	False(t, NewBooleanAnd(no, yes).Evaluate())
+	False(t, NewBooleanAnd(no, no).Evaluate())
+
+	True(t, NewBooleanOr(yes, yes).Evaluate())
+	True(t, NewBooleanOr(yes, no).Evaluate())
+	True(t, NewBooleanOr(no, yes).Evaluate())
+	False(t, NewBooleanOr(no, no).Evaluate())
+
+	o := NewBooleanOr(no, NewBooleanAnd(yes, yes))
+	True(t, o.Evaluate())
+
+	o2 := o.Clone().(*BooleanOr)
+	True(t, o2.Evaluate())
+
+	i, err := o.InternalGet(0)
+	Nil(t, err)
+	Equal(t, no, i)
+	Equal(t, 2, o.InternalLen())
+	Equal(t, 0, o.Len())
+}
+
+func TestBooleanIn(t *testing.T) {
+	a := primitives.NewRangeInt(1, 5)
+
+	o := NewBooleanIn(a, primitives.NewConstantInt(2), primitives.NewConstantInt(4))
+	False(t, o.Evaluate())
+
+	Nil(t, a.Permutation(2))
+	True(t, o.Evaluate())
+
+	Nil(t, a.Permutation(4))
+	True(t, o.Evaluate())
+
+	Equal(t, 3, o.InternalLen())
+
+	o2 := o.Clone().(*BooleanIn)
+	True(t, o2.Evaluate())
+
+	Nil(t, o.InternalLogicalRemove(a))
+}
+
+func TestBooleanLogicalScope(t *testing.T) {
+	scope := token.NewVariableScope()
+	scope.Set("a", primitives.NewConstantInt(1))
+
+	o := NewBooleanAnd(
+		NewBooleanNot(NewVariableDefined("a", token.NewVariableScope())),
+		NewVariableDefined("a", token.NewVariableScope()),
+	)
+	False(t, o.Evaluate())
+
+	o.SetScope(scope)
+	False(t, o.Evaluate())
+
+	o = NewBooleanAnd(
+		NewBooleanTrue(),
+		NewVariableDefined("a", token.NewVariableScope()),
+	)
+	False(t, o.Evaluate())
+
+	o.SetScope(scope)
+	True(t, o.Evaluate())
+}
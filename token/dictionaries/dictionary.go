@@ -0,0 +1,625 @@
+package dictionaries
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+)
+
+// Dictionary implements a token which holds a list of values
+// Every permutation chooses one of the values. The dictionary can additionally generate item tokens which take unique values out of the dictionary and item tokens which choose one of these taken values.
+type Dictionary struct {
+	filename string
+	values   []string
+	taken    map[int]*DictionaryUniqueItem
+
+	value int
+}
+
+// NewDictionary returns a new instance of a Dictionary token holding the given values
+func NewDictionary(values []string) *Dictionary {
+	if len(values) == 0 {
+		panic("a dictionary needs at least one value")
+	}
+
+	return &Dictionary{
+		values: values,
+		taken:  make(map[int]*DictionaryUniqueItem),
+	}
+}
+
+// LoadDictionary reads the values of a dictionary from a file
+// The file holds either one value per line or a JSON array of strings. Empty lines are ignored.
+func LoadDictionary(filename string) (*Dictionary, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := ParseValues(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %q: %s", filename, err)
+	}
+
+	d := NewDictionary(values)
+	d.filename = filename
+
+	return d, nil
+}
+
+// ParseValues parses dictionary values which are either written one value per line or as a JSON array of strings
+// Empty lines are ignored.
+func ParseValues(data []byte) ([]string, error) {
+	var values []string
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSuffix(line, "\r")
+
+			if line != "" {
+				values = append(values, line)
+			}
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values")
+	}
+
+	return values, nil
+}
+
+func init() {
+	token.RegisterTyped("Dictionary", func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+		argParser.Required("file")
+		filename := argParser.GetFile("file", "")
+
+		if err := argParser.Err(); err != nil {
+			return nil, err
+		}
+
+		return LoadDictionary(filename)
+	})
+}
+
+// Values returns the values of the dictionary
+func (d *Dictionary) Values() []string {
+	return d.values
+}
+
+// parse parses the longest value of the dictionary beginning from the current position for which accept returns true
+func (d *Dictionary) parse(pars *token.InternalParser, cur int, accept func(i int) bool) (int, int, []error) {
+	var candidates []int
+	var values []string
+
+	for i, v := range d.values {
+		if accept(i) {
+			candidates = append(candidates, i)
+			values = append(values, v)
+		}
+	}
+
+	best, nex, errs := pars.ParseLongestValue(values, cur)
+	if best == -1 {
+		return noValue, cur, errs
+	}
+
+	log.Debugf("Parsed %q", values[best])
+
+	return candidates[best], nex, nil
+}
+
+// noValue is the index of the value of an item which holds no value
+const noValue = -1
+
+// existing returns the r-th taken value which is not excepted or noValue if there is none
+func (d *Dictionary) existing(r uint, except []token.Token) (int, error) {
+	exceptLookup, err := lists.ExceptValues(except)
+	if err != nil {
+		return noValue, err
+	}
+
+	taken := d.takenValues()
+
+	for j := range taken {
+		i := taken[(int(r)+j)%len(taken)]
+
+		if _, ok := exceptLookup[d.values[i]]; !ok {
+			return i, nil
+		}
+	}
+
+	// every value is excepted which happens if the excepted tokens have not been permutated yet
+	return noValue, nil
+}
+
+// takenValues returns the indexes of the taken values in ascending order
+func (d *Dictionary) takenValues() []int {
+	var taken []int
+
+	for i := range d.values {
+		if _, ok := d.taken[i]; ok {
+			taken = append(taken, i)
+		}
+	}
+
+	return taken
+}
+
+// take takes the r-th value which is not already taken for the given owner. If the preferred value is not taken or already owned by the owner it is taken instead. The index of the taken value or noValue if every value is already taken is returned.
+func (d *Dictionary) take(r uint, preferred int, owner *DictionaryUniqueItem) int {
+	if preferred != noValue {
+		if o, ok := d.taken[preferred]; !ok || o == owner {
+			d.taken[preferred] = owner
+
+			return preferred
+		}
+	}
+
+	free := len(d.values) - len(d.taken)
+	if free == 0 {
+		return noValue
+	}
+
+	n := int(r) % free
+
+	for i := range d.values {
+		if _, ok := d.taken[i]; ok {
+			continue
+		}
+
+		if n == 0 {
+			d.taken[i] = owner
+
+			return i
+		}
+
+		n--
+	}
+
+	panic("unreachable")
+}
+
+// release releases a taken value if it is still owned by the given owner
+func (d *Dictionary) release(i int, owner *DictionaryUniqueItem) {
+	if o, ok := d.taken[i]; ok && o == owner {
+		delete(d.taken, i)
+	}
+}
+
+// ExistingItem returns a new instance of a DictionaryExistingItem token referencing the dictionary
+func (d *Dictionary) ExistingItem(except []token.Token) *DictionaryExistingItem {
+	return &DictionaryExistingItem{
+		dictionary: d,
+		value:      noValue,
+		except:     except,
+	}
+}
+
+// UniqueItem returns a new instance of a DictionaryUniqueItem token referencing the dictionary and taking a new unique value
+func (d *Dictionary) UniqueItem() *DictionaryUniqueItem {
+	u := &DictionaryUniqueItem{
+		dictionary: d,
+	}
+	u.value = d.take(0, noValue, u)
+
+	return u
+}
+
+// reset releases all taken values
+// This is not the Reset method of the ResetToken interface since the dictionary can be used directly which must not release the values taken by its items.
+func (d *Dictionary) reset() {
+	d.taken = make(map[int]*DictionaryUniqueItem)
+}
+
+// ResetItem returns a new instance of a DictionaryResetItem token referencing the dictionary
+func (d *Dictionary) ResetItem() *DictionaryResetItem {
+	return &DictionaryResetItem{
+		dictionary: d,
+	}
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (d *Dictionary) Clone() token.Token {
+	return &Dictionary{
+		filename: d.filename,
+		values:   d.values,
+		taken:    make(map[int]*DictionaryUniqueItem),
+
+		value: d.value,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (d *Dictionary) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	i, nex, errs := d.parse(pars, cur, func(i int) bool {
+		return true
+	})
+	if errs != nil {
+		return cur, errs
+	}
+
+	d.value = i
+
+	return nex, nil
+}
+
+// Permutation sets a specific permutation for this token
+func (d *Dictionary) Permutation(i uint) error {
+	permutations := d.Permutations()
+
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	d.value = int(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (d *Dictionary) Permutations() uint {
+	return uint(len(d.values))
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (d *Dictionary) PermutationsAll() uint {
+	return d.Permutations()
+}
+
+func (d *Dictionary) String() string {
+	return d.values[d.value]
+}
+
+// Typed interface methods
+
+// TypedDefinition returns the type and the arguments of a typed token definition which creates an equal token
+// Only dictionaries which were loaded from a file have a file argument.
+func (d *Dictionary) TypedDefinition() (string, []token.TypedArgument) {
+	if d.filename == "" {
+		return "Dictionary", nil
+	}
+
+	return "Dictionary", []token.TypedArgument{
+		{Name: "file", Value: strconv.Quote(d.filename)},
+	}
+}
+
+// DictionaryUniqueItem implements a dictionary item token which takes one value of the dictionary that is not taken by other unique items
+// A new value is taken on every token permutation. If every value is already taken the item holds no value.
+type DictionaryUniqueItem struct {
+	dictionary *Dictionary
+	value      int
+}
+
+// Dictionary returns the referenced dictionary
+func (d *DictionaryUniqueItem) Dictionary() *Dictionary {
+	return d.dictionary
+}
+
+// Clone returns a copy of the token and all its children
+// The copy holds no value until it is permutated or reset since values are unique.
+func (d *DictionaryUniqueItem) Clone() token.Token {
+	return &DictionaryUniqueItem{
+		dictionary: d.dictionary,
+		value:      noValue,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (d *DictionaryUniqueItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	d.dictionary.release(d.value, d)
+	d.value = noValue
+
+	i, nex, errs := d.dictionary.parse(pars, cur, func(i int) bool {
+		_, ok := d.dictionary.taken[i]
+
+		return !ok
+	})
+	if errs != nil {
+		return cur, errs
+	}
+
+	d.value = d.dictionary.take(0, i, d)
+
+	return nex, nil
+}
+
+func (d *DictionaryUniqueItem) permutation(i uint) {
+	d.dictionary.release(d.value, d)
+	d.value = d.dictionary.take(i, noValue, d)
+}
+
+// Permutation sets a specific permutation for this token
+func (d *DictionaryUniqueItem) Permutation(i uint) error {
+	permutations := d.Permutations()
+
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	d.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (d *DictionaryUniqueItem) Permutations() uint {
+	return uint(len(d.dictionary.values))
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (d *DictionaryUniqueItem) PermutationsAll() uint {
+	return d.Permutations()
+}
+
+func (d *DictionaryUniqueItem) String() string {
+	if d.value == noValue {
+		return ""
+	}
+
+	return d.dictionary.values[d.value]
+}
+
+// ResetToken interface methods
+
+// Reset resets the (internal) state of this token and its dependences
+// The item keeps its value if it is still available.
+func (d *DictionaryUniqueItem) Reset() {
+	d.value = d.dictionary.take(0, d.value, d)
+}
+
+// DictionaryExistingItem implements a dictionary item token which holds one value taken by the unique items of the dictionary
+// A new existing value is chosen on every token permutation.
+type DictionaryExistingItem struct {
+	dictionary *Dictionary
+	value      int
+	except     []token.Token
+}
+
+// Dictionary returns the referenced dictionary
+func (d *DictionaryExistingItem) Dictionary() *Dictionary {
+	return d.dictionary
+}
+
+// Clone returns a copy of the token and all its children
+func (d *DictionaryExistingItem) Clone() token.Token {
+	c := DictionaryExistingItem{
+		dictionary: d.dictionary,
+		value:      d.value,
+		except:     make([]token.Token, len(d.except)),
+	}
+
+	for i, tok := range d.except {
+		c.except[i] = tok.Clone()
+	}
+
+	return &c
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (d *DictionaryExistingItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	exceptLookup, err := lists.ExceptValues(d.except)
+	if err != nil {
+		return cur, []error{err}
+	}
+
+	i, nex, errs := d.dictionary.parse(pars, cur, func(i int) bool {
+		if _, ok := d.dictionary.taken[i]; !ok {
+			return false
+		}
+		_, ok := exceptLookup[d.dictionary.values[i]]
+
+		return !ok
+	})
+	if errs != nil {
+		return cur, errs
+	}
+
+	d.value = i
+
+	return nex, nil
+}
+
+func (d *DictionaryExistingItem) permutation(i uint) error {
+	v, err := d.dictionary.existing(i, d.except)
+
+	d.value = v
+
+	return err
+}
+
+// Permutation sets a specific permutation for this token
+func (d *DictionaryExistingItem) Permutation(i uint) error {
+	permutations := d.Permutations()
+
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	return d.permutation(i - 1)
+}
+
+// Permutations returns the number of permutations for this token
+// There is always at least one permutation which holds no value if no value is taken.
+// Excepted values are counted too since they change with the excepted tokens. A permutation which would choose an excepted value chooses the next value which is not excepted.
+func (d *DictionaryExistingItem) Permutations() uint {
+	if len(d.dictionary.taken) == 0 {
+		return 1
+	}
+
+	return uint(len(d.dictionary.taken))
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (d *DictionaryExistingItem) PermutationsAll() uint {
+	return d.Permutations()
+}
+
+func (d *DictionaryExistingItem) String() string {
+	if d.value == noValue {
+		return ""
+	}
+
+	return d.dictionary.values[d.value]
+}
+
+// ForwardToken interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (d *DictionaryExistingItem) Get(i int) (token.Token, error) {
+	return nil, &lists.ListError{
+		Type: lists.ListErrorOutOfBound,
+	}
+}
+
+// Len returns the number of the current referenced tokens
+func (d *DictionaryExistingItem) Len() int {
+	return 0
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (d *DictionaryExistingItem) InternalGet(i int) (token.Token, error) {
+	if i < 0 || i >= len(d.except) {
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+
+	return d.except[i], nil
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (d *DictionaryExistingItem) InternalLen() int {
+	return len(d.except)
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (d *DictionaryExistingItem) InternalLogicalRemove(tok token.Token) token.Token {
+	for i := 0; i < len(d.except); i++ {
+		if d.except[i] == tok {
+			d.except = append(d.except[:i], d.except[i+1:]...)
+
+			i--
+		}
+	}
+
+	return d
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (d *DictionaryExistingItem) InternalReplace(oldToken, newToken token.Token) error {
+	for i := 0; i < len(d.except); i++ {
+		if d.except[i] == oldToken {
+			d.except[i] = newToken
+		}
+	}
+
+	return nil
+}
+
+// ResetToken interface methods
+
+// Reset resets the (internal) state of this token and its dependences
+// The item holds no value if its excepted values cannot be determined.
+func (d *DictionaryExistingItem) Reset() {
+	_ = d.permutation(0)
+}
+
+// ScopeToken interface methods
+
+// SetScope sets the scope of the token
+func (d *DictionaryExistingItem) SetScope(variableScope *token.VariableScope) {
+	for i := 0; i < len(d.except); i++ {
+		if tok, ok := d.except[i].(token.ScopeToken); ok {
+			tok.SetScope(variableScope)
+		}
+	}
+}
+
+// DictionaryResetItem implements a dictionary item token which releases all taken values of its referencing dictionary on every permutation
+type DictionaryResetItem struct {
+	dictionary *Dictionary
+}
+
+// Dictionary returns the referenced dictionary
+func (d *DictionaryResetItem) Dictionary() *Dictionary {
+	return d.dictionary
+}
+
+// Clone returns a copy of the token and all its children
+func (d *DictionaryResetItem) Clone() token.Token {
+	return &DictionaryResetItem{
+		dictionary: d.dictionary,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (d *DictionaryResetItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	d.permutation(0)
+
+	return cur, nil
+}
+
+func (d *DictionaryResetItem) permutation(i uint) {
+	d.dictionary.reset()
+}
+
+// Permutation sets a specific permutation for this token
+func (d *DictionaryResetItem) Permutation(i uint) error {
+	permutations := d.Permutations()
+
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	d.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (d *DictionaryResetItem) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (d *DictionaryResetItem) PermutationsAll() uint {
+	return d.Permutations()
+}
+
+func (d *DictionaryResetItem) String() string {
+	return ""
+}
+
+// ResetToken interface methods
+
+// Reset resets the (internal) state of this token and its dependences
+func (d *DictionaryResetItem) Reset() {
+	d.permutation(0)
+}
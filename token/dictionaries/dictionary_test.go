@@ -0,0 +1,161 @@
+package dictionaries
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestDictionaryTokensToBeTokens(t *testing.T) {
+	var tok *token.Token
+
+	Implements(t, tok, &Dictionary{})
+	Implements(t, tok, &DictionaryUniqueItem{})
+	Implements(t, tok, &DictionaryExistingItem{})
+	Implements(t, tok, &DictionaryResetItem{})
+}
+
+func TestParseValues(t *testing.T) {
+	values, err := ParseValues([]byte("admin\r\nroot\n\nguest\n"))
+	Nil(t, err)
+	Equal(t, []string{"admin", "root", "guest"}, values)
+
+	values, err = ParseValues([]byte(` ["a\nb", "", "c"]`))
+	Nil(t, err)
+	Equal(t, []string{"a\nb", "", "c"}, values)
+
+	for _, data := range []string{
+		"",
+		"\n\n",
+		"[]",
+		`["a", 1]`,
+		`["a"`,
+	} {
+		values, err = ParseValues([]byte(data))
+		NotNil(t, err, data)
+		Nil(t, values)
+	}
+}
+
+func TestDictionary(t *testing.T) {
+	d := NewDictionary([]string{"a", "ab", "b"})
+	Equal(t, "a", d.String())
+	Equal(t, 3, d.Permutations())
+
+	Nil(t, d.Permutation(2))
+	Equal(t, "ab", d.String())
+	Equal(t, d.Permutation(4).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	d2 := d.Clone()
+	Equal(t, d.String(), d2.String())
+
+	// the longest value is parsed
+	pars := &token.InternalParser{
+		Data:    "abc",
+		DataLen: 3,
+	}
+
+	nex, errs := d.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 2, nex)
+	Equal(t, "ab", d.String())
+
+	nex, errs = d.Parse(pars, 2)
+	NotNil(t, errs)
+	Equal(t, 2, nex)
+}
+
+func TestDictionaryItems(t *testing.T) {
+	d := NewDictionary([]string{"a", "b", "c"})
+
+	reset := d.ResetItem()
+	Nil(t, reset.Permutation(1))
+
+	// unique values
+	u1 := d.UniqueItem()
+	u2 := d.UniqueItem()
+	Equal(t, "a", u1.String())
+	Equal(t, "b", u2.String())
+
+	Nil(t, u1.Permutation(2))
+	Equal(t, "c", u1.String())
+
+	// every value is taken
+	u3 := d.UniqueItem()
+	Equal(t, "a", u3.String())
+	u4 := d.UniqueItem()
+	Equal(t, "", u4.String())
+
+	// copies hold no value until they are reset
+	u5 := u1.Clone().(*DictionaryUniqueItem)
+	Equal(t, "", u5.String())
+	Nil(t, reset.Permutation(1))
+	u5.Reset()
+	Equal(t, "a", u5.String())
+	u1.Reset()
+	Equal(t, "c", u1.String())
+
+	// existing values
+	e := d.ExistingItem(nil)
+	Equal(t, 2, e.Permutations())
+	Nil(t, e.Permutation(2))
+	Equal(t, "c", e.String())
+
+	e = d.ExistingItem([]token.Token{primitives.NewConstantString("a"), primitives.NewConstantString("b")})
+	Nil(t, e.Permutation(1))
+	Equal(t, "c", e.String())
+
+	Nil(t, reset.Permutation(1))
+	e = d.ExistingItem(nil)
+	Equal(t, 1, e.Permutations())
+	Nil(t, e.Permutation(1))
+	Equal(t, "", e.String())
+}
+
+func TestDictionaryItemsParse(t *testing.T) {
+	d := NewDictionary([]string{"a", "b", "c"})
+
+	reset := d.ResetItem()
+	u1 := d.UniqueItem()
+	u2 := d.UniqueItem()
+	e := d.ExistingItem([]token.Token{u1})
+
+	pars := &token.InternalParser{
+		Data:    "cbb",
+		DataLen: 3,
+	}
+
+	nex, errs := reset.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 0, nex)
+
+	nex, errs = u1.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 1, nex)
+	Equal(t, "c", u1.String())
+
+	nex, errs = u2.Parse(pars, 1)
+	Nil(t, errs)
+	Equal(t, 2, nex)
+	Equal(t, "b", u2.String())
+
+	nex, errs = e.Parse(pars, 2)
+	Nil(t, errs)
+	Equal(t, 3, nex)
+	Equal(t, "b", e.String())
+
+	// taken values are not unique
+	u3 := d.UniqueItem()
+
+	nex, errs = u3.Parse(pars, 1)
+	NotNil(t, errs)
+	Equal(t, 1, nex)
+
+	// excepted values do not exist
+	nex, errs = e.Parse(pars, 0)
+	NotNil(t, errs)
+	Equal(t, 0, nex)
+}
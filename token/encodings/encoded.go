@@ -0,0 +1,212 @@
+package encodings
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/zimmski/tavor/token"
+)
+
+// decodeWindow is the initial number of characters which are decoded for parsing an encoded token
+const decodeWindow = 64
+
+// Encoded implements a token which serializes the text of its referenced token with an encoding
+// If injections are enabled every permutation except the first one appends an invalid byte sequence of the encoding to the serialization.
+type Encoded struct {
+	encoding   Encoding
+	token      token.Token
+	injections bool
+
+	injection int
+}
+
+// NewEncoded returns a new instance of an Encoded token referencing the given token and encoding
+func NewEncoded(enc Encoding, tok token.Token) *Encoded {
+	return &Encoded{
+		encoding: enc,
+		token:    tok,
+	}
+}
+
+// NewEncodedWithInjections returns a new instance of an Encoded token referencing the given token and encoding which additionally injects invalid byte sequences of the encoding
+func NewEncodedWithInjections(enc Encoding, tok token.Token) *Encoded {
+	return &Encoded{
+		encoding:   enc,
+		token:      tok,
+		injections: true,
+	}
+}
+
+// Encoding returns the encoding of the token
+func (e *Encoded) Encoding() Encoding {
+	return e.encoding
+}
+
+// Injections returns if invalid byte sequences are injected
+func (e *Encoded) Injections() bool {
+	return e.injections
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (e *Encoded) Clone() token.Token {
+	return &Encoded{
+		encoding:   e.encoding,
+		token:      e.token.Clone(),
+		injections: e.injections,
+
+		injection: e.injection,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *Encoded) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	data := []byte(pars.Data[cur:])
+
+	for window := decodeWindow; ; window *= 2 {
+		var decoded bytes.Buffer
+
+		// offsets maps the indices of the decoded data to the indices of the parser data
+		var offsets []int
+
+		i := 0
+		for n := 0; n < window && i < len(data); n++ {
+			r, size := e.encoding.DecodeRune(data[i:])
+			if size == 0 {
+				break
+			}
+
+			start := decoded.Len()
+			_, _ = decoded.WriteRune(r)
+			for j := start; j < decoded.Len(); j++ {
+				offsets = append(offsets, cur+i)
+			}
+
+			i += size
+		}
+		offsets = append(offsets, cur+i)
+
+		complete := i == len(data) || decoded.Len() < window
+
+		p := &token.InternalParser{
+			Data:    decoded.String(),
+			DataLen: decoded.Len(),
+		}
+
+		nex, errs := e.token.Parse(p, 0)
+
+		if !complete && (len(errs) != 0 || nex == p.DataLen) {
+			// the token could need more data
+			continue
+		}
+
+		if len(errs) != 0 {
+			for _, err := range errs {
+				if perr, ok := err.(*token.ParserError); ok {
+					perr.Position = pars.GetPosition(cur)
+				}
+			}
+
+			return cur, errs
+		}
+
+		e.injection = 0
+		end := offsets[nex]
+
+		if e.injections {
+			injections := e.encoding.Injections()
+
+			// an injected invalid byte sequence can follow the token, take the longest one
+			for i, injection := range injections {
+				if !strings.HasPrefix(pars.Data[end:], string(injection)) {
+					continue
+				}
+
+				if e.injection == 0 || len(injection) > len(injections[e.injection-1]) {
+					e.injection = i + 1
+				}
+			}
+
+			if e.injection > 0 {
+				end += len(injections[e.injection-1])
+			}
+		}
+
+		return end, nil
+	}
+}
+
+func (e *Encoded) permutation(i uint) {
+	e.injection = int(i)
+}
+
+// Permutation sets a specific permutation for this token
+func (e *Encoded) Permutation(i uint) error {
+	permutations := e.Permutations()
+
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	e.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (e *Encoded) Permutations() uint {
+	if !e.injections {
+		return 1
+	}
+
+	return 1 + uint(len(e.encoding.Injections()))
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (e *Encoded) PermutationsAll() uint {
+	return e.Permutations() * e.token.PermutationsAll()
+}
+
+func (e *Encoded) String() string {
+	b := e.encoding.Encode(e.token.String())
+
+	if e.injection > 0 {
+		b = append(b, e.encoding.Injections()[e.injection-1]...)
+	}
+
+	return string(b)
+}
+
+// ForwardToken interface methods
+
+// Get returns the current referenced token
+func (e *Encoded) Get() token.Token {
+	return e.token
+}
+
+// InternalGet returns the current referenced internal token
+func (e *Encoded) InternalGet() token.Token {
+	return e.token
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *Encoded) InternalLogicalRemove(tok token.Token) token.Token {
+	if e.token == tok {
+		return nil
+	}
+
+	return e
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (e *Encoded) InternalReplace(oldToken, newToken token.Token) error {
+	if e.token == oldToken {
+		e.token = newToken
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package encodings
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestEncodedTokensToBeTokens(t *testing.T) {
+	var tok *token.Token
+
+	Implements(t, tok, &Encoded{})
+
+	var forward *token.ForwardToken
+
+	Implements(t, forward, &Encoded{})
+}
+
+func TestEncoded(t *testing.T) {
+	enc, _ := New("UTF-16BE")
+
+	a := primitives.NewConstantString("ab")
+
+	o := NewEncoded(enc, a)
+	Equal(t, "\x00a\x00b", o.String())
+	True(t, Exactly(t, a, o.Get()))
+	Equal(t, 1, o.Permutations())
+	Equal(t, 1, o.PermutationsAll())
+
+	Equal(t, o.Permutation(2).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	// injections
+	o = NewEncodedWithInjections(enc, a)
+	Equal(t, 4, o.Permutations())
+	Equal(t, 4, o.PermutationsAll())
+
+	Nil(t, o.Permutation(1))
+	Equal(t, "\x00a\x00b", o.String())
+	Nil(t, o.Permutation(2))
+	Equal(t, "\x00a\x00b\xd8\x00", o.String())
+	Nil(t, o.Permutation(4))
+	Equal(t, "\x00a\x00b\x41", o.String())
+
+	o2 = o.Clone()
+	Equal(t, o.String(), o2.String())
+}
+
+func TestEncodedParse(t *testing.T) {
+	enc, _ := New("UTF-16LE")
+
+	o := NewEncoded(enc, primitives.NewCharacterClass(`b\x{e4}`))
+
+	pars := &token.InternalParser{
+		Data:    "\xe4\x00b\x00",
+		DataLen: 4,
+	}
+
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 2, nex)
+	Equal(t, "\xe4\x00", o.String())
+
+	nex, errs = o.Parse(pars, 2)
+	Nil(t, errs)
+	Equal(t, 4, nex)
+	Equal(t, "b\x00", o.String())
+
+	// a constant longer than the initial decode window
+	long := ""
+	for i := 0; i < 3*decodeWindow; i++ {
+		long += "x"
+	}
+
+	o = NewEncoded(enc, primitives.NewConstantString(long))
+
+	data := string(enc.Encode(long + "y"))
+	pars = &token.InternalParser{
+		Data:    data,
+		DataLen: len(data),
+	}
+
+	nex, errs = o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, len(data)-2, nex)
+
+	// invalid data
+	o = NewEncoded(enc, primitives.NewConstantString("ab"))
+
+	pars = &token.InternalParser{
+		Data:    "a\x00\x00\xdc",
+		DataLen: 4,
+	}
+
+	_, errs = o.Parse(pars, 0)
+	NotNil(t, errs)
+}
+
+func TestEncodedParseInjections(t *testing.T) {
+	enc, _ := New("UTF-8")
+
+	o := NewEncodedWithInjections(enc, primitives.NewConstantString("ab"))
+
+	for i, injection := range enc.Injections() {
+		data := "ab" + string(injection)
+
+		pars := &token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}
+
+		nex, errs := o.Parse(pars, 0)
+		Nil(t, errs)
+		Equal(t, len(data), nex)
+		Equal(t, data, o.String())
+		Equal(t, i+1, o.injection)
+	}
+
+	// injections are only parsed if they are enabled
+	o = NewEncoded(enc, primitives.NewConstantString("ab"))
+
+	data := "ab" + string(enc.Injections()[0])
+
+	pars := &token.InternalParser{
+		Data:    data,
+		DataLen: len(data),
+	}
+
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 2, nex)
+}
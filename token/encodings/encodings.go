@@ -0,0 +1,407 @@
+package encodings
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Encoding defines a character encoding which is used to serialize and deserialize text
+type Encoding interface {
+	// Name returns the name of the encoding
+	Name() string
+	// Encode returns the serialization of the given UTF-8 text. Characters which cannot be represented by the encoding are replaced by a question mark.
+	Encode(s string) []byte
+	// DecodeRune decodes the first character of the given data and returns the character and its size in bytes. The size is 0 if the data does not begin with a valid character of the encoding.
+	DecodeRune(b []byte) (rune, int)
+	// Injections returns byte sequences which are invalid for the encoding
+	Injections() [][]byte
+}
+
+type registeredEncoding struct {
+	name string
+	enc  Encoding
+}
+
+var encodingLookup = make(map[string]registeredEncoding)
+
+// New returns the encoding with the given registered name. The lookup is case-insensitive.
+// The error return argument is not nil, if the name does not exist in the registered encoding list.
+func New(name string) (Encoding, error) {
+	r, ok := encodingLookup[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+
+	return r.enc, nil
+}
+
+// List returns a list of all registered encoding names.
+func List() []string {
+	keyEncodingLookup := make([]string, 0, len(encodingLookup))
+
+	for _, r := range encodingLookup {
+		keyEncodingLookup = append(keyEncodingLookup, r.name)
+	}
+
+	sort.Strings(keyEncodingLookup)
+
+	return keyEncodingLookup
+}
+
+// Register registers an encoding with the given name. The name is case-insensitive.
+func Register(name string, enc Encoding) {
+	if enc == nil {
+		panic("register encoding is nil")
+	}
+
+	key := strings.ToLower(name)
+
+	if _, ok := encodingLookup[key]; ok {
+		panic("encoding " + name + " already registered")
+	}
+
+	encodingLookup[key] = registeredEncoding{
+		name: name,
+		enc:  enc,
+	}
+}
+
+func init() {
+	utf8Encoding := &utf8Encoding{}
+	Register("UTF-8", utf8Encoding)
+	Register("UTF8", utf8Encoding)
+
+	latin1 := &latin1Encoding{}
+	Register("Latin-1", latin1)
+	Register("Latin1", latin1)
+	Register("ISO-8859-1", latin1)
+
+	utf16le := &utf16Encoding{bigEndian: false}
+	Register("UTF-16LE", utf16le)
+	Register("UTF16LE", utf16le)
+
+	utf16be := &utf16Encoding{bigEndian: true}
+	Register("UTF-16BE", utf16be)
+	Register("UTF16BE", utf16be)
+
+	shiftJIS := &shiftJISEncoding{}
+	Register("Shift_JIS", shiftJIS)
+	Register("Shift-JIS", shiftJIS)
+	Register("SJIS", shiftJIS)
+
+	Register("JSON", &jsonEncoding{})
+}
+
+type utf8Encoding struct{}
+
+func (e *utf8Encoding) Name() string {
+	return "UTF-8"
+}
+
+func (e *utf8Encoding) Encode(s string) []byte {
+	return []byte(s)
+}
+
+func (e *utf8Encoding) DecodeRune(b []byte) (rune, int) {
+	r, size := utf8.DecodeRune(b)
+	if r == utf8.RuneError && size <= 1 {
+		return r, 0
+	}
+
+	return r, size
+}
+
+func (e *utf8Encoding) Injections() [][]byte {
+	return [][]byte{
+		{0xc0, 0xaf},       // overlong encoding of "/"
+		{0xe0, 0x80, 0x80}, // overlong encoding of NUL
+		{0xed, 0xa0, 0x80}, // lone high surrogate
+		{0xed, 0xb0, 0x80}, // lone low surrogate
+		{0xe2, 0x82},       // truncated sequence
+		{0xff},             // invalid byte
+	}
+}
+
+type latin1Encoding struct{}
+
+func (e *latin1Encoding) Name() string {
+	return "Latin-1"
+}
+
+func (e *latin1Encoding) Encode(s string) []byte {
+	b := make([]byte, 0, len(s))
+
+	for _, r := range s {
+		if r > 0xff {
+			r = '?'
+		}
+
+		b = append(b, byte(r))
+	}
+
+	return b
+}
+
+func (e *latin1Encoding) DecodeRune(b []byte) (rune, int) {
+	if len(b) == 0 {
+		return utf8.RuneError, 0
+	}
+
+	return rune(b[0]), 1
+}
+
+func (e *latin1Encoding) Injections() [][]byte {
+	// every byte is a valid Latin-1 character
+	return nil
+}
+
+type utf16Encoding struct {
+	bigEndian bool
+}
+
+func (e *utf16Encoding) Name() string {
+	if e.bigEndian {
+		return "UTF-16BE"
+	}
+
+	return "UTF-16LE"
+}
+
+func (e *utf16Encoding) put(b []byte, v uint16) []byte {
+	if e.bigEndian {
+		return append(b, byte(v>>8), byte(v))
+	}
+
+	return append(b, byte(v), byte(v>>8))
+}
+
+func (e *utf16Encoding) get(b []byte) uint16 {
+	if e.bigEndian {
+		return uint16(b[0])<<8 | uint16(b[1])
+	}
+
+	return uint16(b[1])<<8 | uint16(b[0])
+}
+
+func (e *utf16Encoding) Encode(s string) []byte {
+	b := make([]byte, 0, 2*len(s))
+
+	for _, v := range utf16.Encode([]rune(s)) {
+		b = e.put(b, v)
+	}
+
+	return b
+}
+
+func (e *utf16Encoding) DecodeRune(b []byte) (rune, int) {
+	if len(b) < 2 {
+		return utf8.RuneError, 0
+	}
+
+	v := e.get(b)
+
+	switch {
+	case v < 0xd800 || v > 0xdfff:
+		return rune(v), 2
+	case v >= 0xdc00 || len(b) < 4:
+		// lone low surrogate or truncated surrogate pair
+		return utf8.RuneError, 0
+	}
+
+	if r := utf16.DecodeRune(rune(v), rune(e.get(b[2:]))); r != utf8.RuneError {
+		return r, 4
+	}
+
+	return utf8.RuneError, 0
+}
+
+func (e *utf16Encoding) Injections() [][]byte {
+	return [][]byte{
+		e.put(nil, 0xd800), // lone high surrogate
+		e.put(nil, 0xdc00), // lone low surrogate
+		{0x41},             // truncated code unit
+	}
+}
+
+type shiftJISEncoding struct{}
+
+func (e *shiftJISEncoding) Name() string {
+	return "Shift_JIS"
+}
+
+func (e *shiftJISEncoding) Encode(s string) []byte {
+	enc := japanese.ShiftJIS.NewEncoder()
+
+	b := make([]byte, 0, len(s))
+
+	for _, r := range s {
+		c, err := enc.Bytes([]byte(string(r)))
+		if err != nil {
+			c = []byte{'?'}
+		}
+
+		b = append(b, c...)
+	}
+
+	return b
+}
+
+func (e *shiftJISEncoding) DecodeRune(b []byte) (rune, int) {
+	if len(b) == 0 {
+		return utf8.RuneError, 0
+	}
+
+	size := 1
+	if c := b[0]; (c >= 0x81 && c <= 0x9f) || (c >= 0xe0 && c <= 0xfc) {
+		size = 2
+	}
+
+	if len(b) < size {
+		return utf8.RuneError, 0
+	}
+
+	d, err := japanese.ShiftJIS.NewDecoder().Bytes(b[:size])
+	if err != nil {
+		return utf8.RuneError, 0
+	}
+
+	r, _ := utf8.DecodeRune(d)
+	if r == utf8.RuneError {
+		return r, 0
+	}
+
+	return r, size
+}
+
+func (e *shiftJISEncoding) Injections() [][]byte {
+	return [][]byte{
+		{0x81, 0x20}, // invalid trail byte
+		{0x85, 0x40}, // unassigned double-byte character
+		{0xa0},       // invalid single byte
+		{0x81},       // truncated double-byte character
+	}
+}
+
+// jsonEncoding escapes text for the content of JSON strings as defined by RFC 8259
+type jsonEncoding struct{}
+
+func (e *jsonEncoding) Name() string {
+	return "JSON"
+}
+
+func (e *jsonEncoding) Encode(s string) []byte {
+	b := make([]byte, 0, len(s))
+
+	for _, r := range s {
+		switch r {
+		case '"':
+			b = append(b, '\\', '"')
+		case '\\':
+			b = append(b, '\\', '\\')
+		case '\b':
+			b = append(b, '\\', 'b')
+		case '\f':
+			b = append(b, '\\', 'f')
+		case '\n':
+			b = append(b, '\\', 'n')
+		case '\r':
+			b = append(b, '\\', 'r')
+		case '\t':
+			b = append(b, '\\', 't')
+		default:
+			if r < 0x20 {
+				b = append(b, fmt.Sprintf("\\u%04x", r)...)
+			} else {
+				b = append(b, string(r)...)
+			}
+		}
+	}
+
+	return b
+}
+
+func (e *jsonEncoding) decodeHex(b []byte) (rune, bool) {
+	if len(b) < 6 || b[0] != '\\' || b[1] != 'u' {
+		return 0, false
+	}
+
+	v, err := strconv.ParseUint(string(b[2:6]), 16, 16)
+	if err != nil {
+		return 0, false
+	}
+
+	return rune(v), true
+}
+
+func (e *jsonEncoding) DecodeRune(b []byte) (rune, int) {
+	if len(b) == 0 {
+		return utf8.RuneError, 0
+	}
+
+	switch c := b[0]; {
+	case c == '"' || c < 0x20:
+		return utf8.RuneError, 0
+	case c != '\\':
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size <= 1 {
+			return r, 0
+		}
+
+		return r, size
+	case len(b) < 2:
+		return utf8.RuneError, 0
+	}
+
+	switch b[1] {
+	case '"', '\\', '/':
+		return rune(b[1]), 2
+	case 'b':
+		return '\b', 2
+	case 'f':
+		return '\f', 2
+	case 'n':
+		return '\n', 2
+	case 'r':
+		return '\r', 2
+	case 't':
+		return '\t', 2
+	case 'u':
+		r, ok := e.decodeHex(b)
+		if !ok {
+			return utf8.RuneError, 0
+		}
+
+		switch {
+		case r < 0xd800 || r > 0xdfff:
+			return r, 6
+		case r >= 0xdc00:
+			// lone low surrogate
+			return utf8.RuneError, 0
+		}
+
+		if l, ok := e.decodeHex(b[6:]); ok {
+			if r := utf16.DecodeRune(r, l); r != utf8.RuneError {
+				return r, 12
+			}
+		}
+	}
+
+	return utf8.RuneError, 0
+}
+
+func (e *jsonEncoding) Injections() [][]byte {
+	return [][]byte{
+		{'"'},                 // unescaped quotation mark
+		{0x01},                // unescaped control character
+		{'\\', 'x'},           // invalid escape sequence
+		{'\\', 'u', '0', '0'}, // truncated unicode escape sequence
+		[]byte(`\ud800`),      // lone high surrogate
+		[]byte(`\udc00`),      // lone low surrogate
+	}
+}
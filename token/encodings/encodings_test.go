@@ -0,0 +1,83 @@
+package encodings
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestEncodingsRegistry(t *testing.T) {
+	enc, err := New("utf-16le")
+	Nil(t, err)
+	Equal(t, "UTF-16LE", enc.Name())
+
+	enc, err = New("EBCDIC")
+	NotNil(t, err)
+	Nil(t, enc)
+
+	Contains(t, List(), "Shift_JIS")
+
+	Panics(t, func() {
+		Register("UTF-8", &utf8Encoding{})
+	})
+	Panics(t, func() {
+		Register("Empty", nil)
+	})
+}
+
+func TestEncodings(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		text    string
+		encoded string
+	}{
+		{"UTF-8", "aä日", "a\xc3\xa4\xe6\x97\xa5"},
+		{"Latin-1", "aä", "a\xe4"},
+		{"UTF-16LE", "aä\U0001f600", "a\x00\xe4\x00\x3d\xd8\x00\xde"},
+		{"UTF-16BE", "aä\U0001f600", "\x00a\x00\xe4\xd8\x3d\xde\x00"},
+		{"Shift_JIS", "a日ｱ", "a\x93\xfa\xb1"},
+		{"JSON", "a\"\\\n\x01ä\U0001f600", "a\\\"\\\\\\n\\u0001ä\U0001f600"},
+	} {
+		enc, err := New(tc.name)
+		Nil(t, err)
+
+		b := enc.Encode(tc.text)
+		Equal(t, tc.encoded, string(b), tc.name)
+
+		var decoded []rune
+		for len(b) != 0 {
+			r, size := enc.DecodeRune(b)
+			if !True(t, size > 0, tc.name) {
+				break
+			}
+
+			decoded = append(decoded, r)
+			b = b[size:]
+		}
+		Equal(t, tc.text, string(decoded), tc.name)
+
+		for _, injection := range enc.Injections() {
+			_, size := enc.DecodeRune(injection)
+			Equal(t, 0, size, tc.name)
+		}
+	}
+
+	// escape sequences of JSON
+	enc, _ := New("JSON")
+	for data, r := range map[string]rune{
+		`\/`:           '/',
+		`\u00e4`:       'ä',
+		`\ud83d\ude00`: 0x1f600,
+		"\U0001f600":   0x1f600,
+	} {
+		d, size := enc.DecodeRune([]byte(data))
+		Equal(t, r, d, data)
+		Equal(t, len(data), size, data)
+	}
+
+	// unrepresentable characters
+	enc, _ = New("Latin-1")
+	Equal(t, "a?", string(enc.Encode("a日")))
+	enc, _ = New("Shift_JIS")
+	Equal(t, "a?", string(enc.Encode("aä")))
+}
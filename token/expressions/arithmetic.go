@@ -1,42 +1,116 @@
 package expressions
 
 import (
-	"strconv"
+	"math/big"
 
 	"github.com/zimmski/tavor/token"
 	"github.com/zimmski/tavor/token/lists"
 )
 
-// AddArithmetic implements an arithmetic token adding the values of two tokens
-type AddArithmetic struct {
-	a token.Token
-	b token.Token
+// ArithmeticToken defines an arithmetic token with selectable integer semantics
+type ArithmeticToken interface {
+	token.ListToken
+
+	// Semantics returns the integer semantics of the token
+	Semantics() *Semantics
+	// SetSemantics sets the integer semantics of the token. Nil sets the default semantics.
+	SetSemantics(semantics *Semantics)
 }
 
-// NewAddArithmetic returns a new instance of a AddArithmetic token
-func NewAddArithmetic(a, b token.Token) *AddArithmetic {
-	return &AddArithmetic{
-		a: a,
-		b: b,
+// arithmetic holds the integer semantics of an arithmetic token
+type arithmetic struct {
+	semantics *Semantics
+}
+
+// Semantics returns the integer semantics of the token
+func (e *arithmetic) Semantics() *Semantics {
+	if e.semantics == nil {
+		return DefaultSemantics
 	}
+
+	return e.semantics
 }
 
-// Clone returns a copy of the token and all its children
-func (e *AddArithmetic) Clone() token.Token {
-	return &AddArithmetic{
+// SetSemantics sets the integer semantics of the token. Nil sets the default semantics.
+func (e *arithmetic) SetSemantics(semantics *Semantics) {
+	e.semantics = semantics
+}
+
+// value returns the integer value of a token or false if the token has no value yet
+// Values which are not integers, e.g. the generated value of a string token, count as 0. Constant operands which are not integers are rejected by the parser.
+func value(tok token.Token) (*big.Int, bool) {
+	s := tok.String()
+
+	if s == "" || s == "TODO" {
+		return nil, false
+	}
+
+	x, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return new(big.Int), true
+	}
+
+	return x, true
+}
+
+// MaxUnboundedShift is the maximum shift count of unbounded integer semantics
+const MaxUnboundedShift = 1 << 16
+
+// shift shifts x by y bits to the left or to the right
+// Negative shift counts shift in the opposite direction. The shift count is limited by the bit size of the integer semantics since every further shift does not change the limited result, or by MaxUnboundedShift for unbounded integer semantics.
+func shift(x, y *big.Int, left bool, semantics *Semantics) *big.Int {
+	if y.Sign() < 0 {
+		y = new(big.Int).Neg(y)
+		left = !left
+	}
+
+	max := big.NewInt(MaxUnboundedShift)
+	if semantics.Overflow != OverflowUnbounded {
+		max = big.NewInt(int64(semantics.Bits))
+	}
+	if y.Cmp(max) > 0 {
+		y = max
+	}
+
+	if left {
+		return new(big.Int).Lsh(x, uint(y.Uint64()))
+	}
+
+	return new(big.Int).Rsh(x, uint(y.Uint64()))
+}
+
+// binaryArithmetic implements the methods shared by all arithmetic tokens with two operands
+type binaryArithmetic struct {
+	arithmetic
+
+	a token.Token
+	b token.Token
+}
+
+func (e *binaryArithmetic) clone() binaryArithmetic {
+	return binaryArithmetic{
+		arithmetic: e.arithmetic,
+
 		a: e.a.Clone(),
 		b: e.b.Clone(),
 	}
 }
 
-// Parse tries to parse the token beginning from the current position in the parser data.
-// If the parsing is successful the error argument is nil and the next current position after the token is returned.
-func (e *AddArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+func (e *binaryArithmetic) evaluate(operator func(a, b *big.Int) *big.Int) string {
+	a, ok := value(e.a)
+	if !ok {
+		return "TODO"
+	}
+	b, ok := value(e.b)
+	if !ok {
+		return "TODO"
+	}
+
+	return e.Semantics().Limit(operator(a, b)).String()
 }
 
 // Permutation sets a specific permutation for this token
-func (e *AddArithmetic) Permutation(i uint) error {
+func (e *binaryArithmetic) Permutation(i uint) error {
 	permutations := e.Permutations()
 
 	if i < 1 || i > permutations {
@@ -50,39 +124,19 @@ func (e *AddArithmetic) Permutation(i uint) error {
 }
 
 // Permutations returns the number of permutations for this token
-func (e *AddArithmetic) Permutations() uint {
+func (e *binaryArithmetic) Permutations() uint {
 	return 1
 }
 
 // PermutationsAll returns the number of all possible permutations for this token including its children
-func (e *AddArithmetic) PermutationsAll() uint {
+func (e *binaryArithmetic) PermutationsAll() uint {
 	return e.a.PermutationsAll() * e.b.PermutationsAll()
 }
 
-func (e *AddArithmetic) String() string {
-	as := e.a.String()
-	bs := e.b.String()
-
-	if as == "" || bs == "" || as == "TODO" || bs == "TODO" {
-		return "TODO"
-	}
-
-	a, err := strconv.Atoi(as)
-	if err != nil {
-		panic(err)
-	}
-	b, err := strconv.Atoi(bs)
-	if err != nil {
-		panic(err)
-	}
-
-	return strconv.Itoa(a + b)
-}
-
 // List interface methods
 
 // Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
-func (e *AddArithmetic) Get(i int) (token.Token, error) {
+func (e *binaryArithmetic) Get(i int) (token.Token, error) {
 	switch i {
 	case 0:
 		return e.a, nil
@@ -96,31 +150,22 @@ func (e *AddArithmetic) Get(i int) (token.Token, error) {
 }
 
 // Len returns the number of the current referenced tokens
-func (e *AddArithmetic) Len() int {
+func (e *binaryArithmetic) Len() int {
 	return 2
 }
 
 // InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
-func (e *AddArithmetic) InternalGet(i int) (token.Token, error) {
+func (e *binaryArithmetic) InternalGet(i int) (token.Token, error) {
 	return e.Get(i)
 }
 
 // InternalLen returns the number of referenced internal tokens
-func (e *AddArithmetic) InternalLen() int {
+func (e *binaryArithmetic) InternalLen() int {
 	return e.Len()
 }
 
-// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
-func (e *AddArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
-	if tok == e.a || tok == e.b {
-		return nil
-	}
-
-	return e
-}
-
 // InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
-func (e *AddArithmetic) InternalReplace(oldToken, newToken token.Token) error {
+func (e *binaryArithmetic) InternalReplace(oldToken, newToken token.Token) error {
 	if oldToken == e.a {
 		e.a = newToken
 	}
@@ -131,111 +176,176 @@ func (e *AddArithmetic) InternalReplace(oldToken, newToken token.Token) error {
 	return nil
 }
 
+// AddArithmetic implements an arithmetic token adding the values of two tokens
+type AddArithmetic struct {
+	binaryArithmetic
+}
+
+// NewAddArithmetic returns a new instance of a AddArithmetic token
+func NewAddArithmetic(a, b token.Token) *AddArithmetic {
+	return &AddArithmetic{
+		binaryArithmetic: binaryArithmetic{
+			a: a,
+			b: b,
+		},
+	}
+}
+
+// Clone returns a copy of the token and all its children
+func (e *AddArithmetic) Clone() token.Token {
+	return &AddArithmetic{
+		binaryArithmetic: e.clone(),
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *AddArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return pars.ParseValue(e.String(), cur)
+}
+
+func (e *AddArithmetic) String() string {
+	return e.evaluate(func(a, b *big.Int) *big.Int {
+		return new(big.Int).Add(a, b)
+	})
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *AddArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == e.a || tok == e.b {
+		return nil
+	}
+
+	return e
+}
+
 // SubArithmetic implements an arithmetic token subtracting the values of two tokens
 type SubArithmetic struct {
-	a token.Token
-	b token.Token
+	binaryArithmetic
 }
 
 // NewSubArithmetic returns a new instance of a SubArithmetic token
 func NewSubArithmetic(a, b token.Token) *SubArithmetic {
 	return &SubArithmetic{
-		a: a,
-		b: b,
+		binaryArithmetic: binaryArithmetic{
+			a: a,
+			b: b,
+		},
 	}
 }
 
 // Clone returns a copy of the token and all its children
 func (e *SubArithmetic) Clone() token.Token {
 	return &SubArithmetic{
-		a: e.a.Clone(),
-		b: e.b.Clone(),
+		binaryArithmetic: e.clone(),
 	}
 }
 
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (e *SubArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	return pars.ParseValue(e.String(), cur)
 }
 
-// Permutation sets a specific permutation for this token
-func (e *SubArithmetic) Permutation(i uint) error {
-	permutations := e.Permutations()
+func (e *SubArithmetic) String() string {
+	return e.evaluate(func(a, b *big.Int) *big.Int {
+		return new(big.Int).Sub(a, b)
+	})
+}
 
-	if i < 1 || i > permutations {
-		return &token.PermutationError{
-			Type: token.PermutationErrorIndexOutOfBound,
-		}
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *SubArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == e.a || tok == e.b {
+		return nil
 	}
-	// do nothing
 
-	return nil
+	return e
 }
 
-// Permutations returns the number of permutations for this token
-func (e *SubArithmetic) Permutations() uint {
-	return 1
+// MulArithmetic implements an arithmetic token multiplying the values of two tokens
+type MulArithmetic struct {
+	binaryArithmetic
 }
 
-// PermutationsAll returns the number of all possible permutations for this token including its children
-func (e *SubArithmetic) PermutationsAll() uint {
-	return e.a.PermutationsAll() * e.b.PermutationsAll()
+// NewMulArithmetic returns a new instance of a MulArithmetic token
+func NewMulArithmetic(a, b token.Token) *MulArithmetic {
+	return &MulArithmetic{
+		binaryArithmetic: binaryArithmetic{
+			a: a,
+			b: b,
+		},
+	}
 }
 
-func (e *SubArithmetic) String() string {
-	as := e.a.String()
-	bs := e.b.String()
-
-	if as == "" || bs == "" || as == "TODO" || bs == "TODO" {
-		return "TODO"
+// Clone returns a copy of the token and all its children
+func (e *MulArithmetic) Clone() token.Token {
+	return &MulArithmetic{
+		binaryArithmetic: e.clone(),
 	}
+}
 
-	a, err := strconv.Atoi(as)
-	if err != nil {
-		panic(err)
-	}
-	b, err := strconv.Atoi(bs)
-	if err != nil {
-		panic(err)
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *MulArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return pars.ParseValue(e.String(), cur)
+}
+
+func (e *MulArithmetic) String() string {
+	return e.evaluate(func(a, b *big.Int) *big.Int {
+		return new(big.Int).Mul(a, b)
+	})
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *MulArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == e.a || tok == e.b {
+		return nil
 	}
 
-	return strconv.Itoa(a - b)
+	return e
 }
 
-// List interface methods
+// DivArithmetic implements an arithmetic token dividing the values of two tokens. The result is truncated towards zero.
+// A division by zero results in -1 so that a generation never fails, see the "Invalid operands" section of the format documentation.
+type DivArithmetic struct {
+	binaryArithmetic
+}
 
-// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
-func (e *SubArithmetic) Get(i int) (token.Token, error) {
-	switch i {
-	case 0:
-		return e.a, nil
-	case 1:
-		return e.b, nil
-	default:
-		return nil, &lists.ListError{
-			Type: lists.ListErrorOutOfBound,
-		}
+// NewDivArithmetic returns a new instance of a DivArithmetic token
+func NewDivArithmetic(a, b token.Token) *DivArithmetic {
+	return &DivArithmetic{
+		binaryArithmetic: binaryArithmetic{
+			a: a,
+			b: b,
+		},
 	}
 }
 
-// Len returns the number of the current referenced tokens
-func (e *SubArithmetic) Len() int {
-	return 2
+// Clone returns a copy of the token and all its children
+func (e *DivArithmetic) Clone() token.Token {
+	return &DivArithmetic{
+		binaryArithmetic: e.clone(),
+	}
 }
 
-// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
-func (e *SubArithmetic) InternalGet(i int) (token.Token, error) {
-	return e.Get(i)
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *DivArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return pars.ParseValue(e.String(), cur)
 }
 
-// InternalLen returns the number of referenced internal tokens
-func (e *SubArithmetic) InternalLen() int {
-	return e.Len()
+func (e *DivArithmetic) String() string {
+	return e.evaluate(func(a, b *big.Int) *big.Int {
+		if b.Sign() == 0 {
+			return big.NewInt(-1)
+		}
+
+		return new(big.Int).Quo(a, b)
+	})
 }
 
 // InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
-func (e *SubArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
+func (e *DivArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
 	if tok == e.a || tok == e.b {
 		return nil
 	}
@@ -243,123 +353,219 @@ func (e *SubArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
 	return e
 }
 
-// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
-func (e *SubArithmetic) InternalReplace(oldToken, newToken token.Token) error {
-	if oldToken == e.a {
-		e.a = newToken
+// ModArithmetic implements an arithmetic token computing the remainder of dividing the values of two tokens. The remainder has the sign of the dividend.
+// The remainder of a division by zero is the dividend, which keeps the identity a = (a / b) * b + a % b with a division by zero resulting in -1.
+type ModArithmetic struct {
+	binaryArithmetic
+}
+
+// NewModArithmetic returns a new instance of a ModArithmetic token
+func NewModArithmetic(a, b token.Token) *ModArithmetic {
+	return &ModArithmetic{
+		binaryArithmetic: binaryArithmetic{
+			a: a,
+			b: b,
+		},
 	}
-	if oldToken == e.b {
-		e.b = newToken
+}
+
+// Clone returns a copy of the token and all its children
+func (e *ModArithmetic) Clone() token.Token {
+	return &ModArithmetic{
+		binaryArithmetic: e.clone(),
 	}
+}
 
-	return nil
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *ModArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return pars.ParseValue(e.String(), cur)
 }
 
-// MulArithmetic implements an arithmetic token multiplying the values of two tokens
-type MulArithmetic struct {
-	a token.Token
-	b token.Token
+func (e *ModArithmetic) String() string {
+	return e.evaluate(func(a, b *big.Int) *big.Int {
+		if b.Sign() == 0 {
+			return a
+		}
+
+		return new(big.Int).Rem(a, b)
+	})
 }
 
-// NewMulArithmetic returns a new instance of a MulArithmetic token
-func NewMulArithmetic(a, b token.Token) *MulArithmetic {
-	return &MulArithmetic{
-		a: a,
-		b: b,
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *ModArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == e.a || tok == e.b {
+		return nil
+	}
+
+	return e
+}
+
+// BitAndArithmetic implements an arithmetic token computing the bitwise and of the values of two tokens
+type BitAndArithmetic struct {
+	binaryArithmetic
+}
+
+// NewBitAndArithmetic returns a new instance of a BitAndArithmetic token
+func NewBitAndArithmetic(a, b token.Token) *BitAndArithmetic {
+	return &BitAndArithmetic{
+		binaryArithmetic: binaryArithmetic{
+			a: a,
+			b: b,
+		},
 	}
 }
 
 // Clone returns a copy of the token and all its children
-func (e *MulArithmetic) Clone() token.Token {
-	return &MulArithmetic{
-		a: e.a.Clone(),
-		b: e.b.Clone(),
+func (e *BitAndArithmetic) Clone() token.Token {
+	return &BitAndArithmetic{
+		binaryArithmetic: e.clone(),
 	}
 }
 
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
-func (e *MulArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+func (e *BitAndArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return pars.ParseValue(e.String(), cur)
 }
 
-// Permutation sets a specific permutation for this token
-func (e *MulArithmetic) Permutation(i uint) error {
-	permutations := e.Permutations()
+func (e *BitAndArithmetic) String() string {
+	return e.evaluate(func(a, b *big.Int) *big.Int {
+		return new(big.Int).And(a, b)
+	})
+}
 
-	if i < 1 || i > permutations {
-		return &token.PermutationError{
-			Type: token.PermutationErrorIndexOutOfBound,
-		}
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *BitAndArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == e.a || tok == e.b {
+		return nil
 	}
-	// do nothing
 
-	return nil
+	return e
 }
 
-// Permutations returns the number of permutations for this token
-func (e *MulArithmetic) Permutations() uint {
-	return 1
+// BitOrArithmetic implements an arithmetic token computing the bitwise or of the values of two tokens
+type BitOrArithmetic struct {
+	binaryArithmetic
 }
 
-// PermutationsAll returns the number of all possible permutations for this token including its children
-func (e *MulArithmetic) PermutationsAll() uint {
-	return e.a.PermutationsAll() * e.b.PermutationsAll()
+// NewBitOrArithmetic returns a new instance of a BitOrArithmetic token
+func NewBitOrArithmetic(a, b token.Token) *BitOrArithmetic {
+	return &BitOrArithmetic{
+		binaryArithmetic: binaryArithmetic{
+			a: a,
+			b: b,
+		},
+	}
 }
 
-func (e *MulArithmetic) String() string {
-	as := e.a.String()
-	bs := e.b.String()
+// Clone returns a copy of the token and all its children
+func (e *BitOrArithmetic) Clone() token.Token {
+	return &BitOrArithmetic{
+		binaryArithmetic: e.clone(),
+	}
+}
 
-	if as == "" || bs == "" || as == "TODO" || bs == "TODO" {
-		return "TODO"
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *BitOrArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return pars.ParseValue(e.String(), cur)
+}
+
+func (e *BitOrArithmetic) String() string {
+	return e.evaluate(func(a, b *big.Int) *big.Int {
+		return new(big.Int).Or(a, b)
+	})
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *BitOrArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == e.a || tok == e.b {
+		return nil
 	}
 
-	a, err := strconv.Atoi(as)
-	if err != nil {
-		panic(err)
+	return e
+}
+
+// BitXorArithmetic implements an arithmetic token computing the bitwise exclusive or of the values of two tokens
+type BitXorArithmetic struct {
+	binaryArithmetic
+}
+
+// NewBitXorArithmetic returns a new instance of a BitXorArithmetic token
+func NewBitXorArithmetic(a, b token.Token) *BitXorArithmetic {
+	return &BitXorArithmetic{
+		binaryArithmetic: binaryArithmetic{
+			a: a,
+			b: b,
+		},
 	}
-	b, err := strconv.Atoi(bs)
-	if err != nil {
-		panic(err)
+}
+
+// Clone returns a copy of the token and all its children
+func (e *BitXorArithmetic) Clone() token.Token {
+	return &BitXorArithmetic{
+		binaryArithmetic: e.clone(),
 	}
+}
 
-	return strconv.Itoa(a * b)
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *BitXorArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return pars.ParseValue(e.String(), cur)
 }
 
-// List interface methods
+func (e *BitXorArithmetic) String() string {
+	return e.evaluate(func(a, b *big.Int) *big.Int {
+		return new(big.Int).Xor(a, b)
+	})
+}
 
-// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
-func (e *MulArithmetic) Get(i int) (token.Token, error) {
-	switch i {
-	case 0:
-		return e.a, nil
-	case 1:
-		return e.b, nil
-	default:
-		return nil, &lists.ListError{
-			Type: lists.ListErrorOutOfBound,
-		}
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *BitXorArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == e.a || tok == e.b {
+		return nil
 	}
+
+	return e
 }
 
-// Len returns the number of the current referenced tokens
-func (e *MulArithmetic) Len() int {
-	return 2
+// ShiftLeftArithmetic implements an arithmetic token shifting the value of a token to the left by the value of another token. Negative shift counts shift to the right.
+type ShiftLeftArithmetic struct {
+	binaryArithmetic
 }
 
-// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
-func (e *MulArithmetic) InternalGet(i int) (token.Token, error) {
-	return e.Get(i)
+// NewShiftLeftArithmetic returns a new instance of a ShiftLeftArithmetic token
+func NewShiftLeftArithmetic(a, b token.Token) *ShiftLeftArithmetic {
+	return &ShiftLeftArithmetic{
+		binaryArithmetic: binaryArithmetic{
+			a: a,
+			b: b,
+		},
+	}
 }
 
-// InternalLen returns the number of referenced internal tokens
-func (e *MulArithmetic) InternalLen() int {
-	return e.Len()
+// Clone returns a copy of the token and all its children
+func (e *ShiftLeftArithmetic) Clone() token.Token {
+	return &ShiftLeftArithmetic{
+		binaryArithmetic: e.clone(),
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *ShiftLeftArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return pars.ParseValue(e.String(), cur)
+}
+
+func (e *ShiftLeftArithmetic) String() string {
+	return e.evaluate(func(a, b *big.Int) *big.Int {
+		return shift(a, b, true, e.Semantics())
+	})
 }
 
 // InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
-func (e *MulArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
+func (e *ShiftLeftArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
 	if tok == e.a || tok == e.b {
 		return nil
 	}
@@ -367,48 +573,80 @@ func (e *MulArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
 	return e
 }
 
-// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
-func (e *MulArithmetic) InternalReplace(oldToken, newToken token.Token) error {
-	if oldToken == e.a {
-		e.a = newToken
+// ShiftRightArithmetic implements an arithmetic token shifting the value of a token arithmetically to the right by the value of another token. Negative shift counts shift to the left.
+type ShiftRightArithmetic struct {
+	binaryArithmetic
+}
+
+// NewShiftRightArithmetic returns a new instance of a ShiftRightArithmetic token
+func NewShiftRightArithmetic(a, b token.Token) *ShiftRightArithmetic {
+	return &ShiftRightArithmetic{
+		binaryArithmetic: binaryArithmetic{
+			a: a,
+			b: b,
+		},
 	}
-	if oldToken == e.b {
-		e.b = newToken
+}
+
+// Clone returns a copy of the token and all its children
+func (e *ShiftRightArithmetic) Clone() token.Token {
+	return &ShiftRightArithmetic{
+		binaryArithmetic: e.clone(),
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *ShiftRightArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return pars.ParseValue(e.String(), cur)
+}
+
+func (e *ShiftRightArithmetic) String() string {
+	return e.evaluate(func(a, b *big.Int) *big.Int {
+		return shift(a, b, false, e.Semantics())
+	})
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *ShiftRightArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == e.a || tok == e.b {
+		return nil
 	}
 
-	return nil
+	return e
 }
 
-// DivArithmetic implements an arithmetic token dividing the values of two tokens
-type DivArithmetic struct {
+// NegArithmetic implements an arithmetic token negating the value of a token
+type NegArithmetic struct {
+	arithmetic
+
 	a token.Token
-	b token.Token
 }
 
-// NewDivArithmetic returns a new instance of a DivArithmetic token
-func NewDivArithmetic(a, b token.Token) *DivArithmetic {
-	return &DivArithmetic{
+// NewNegArithmetic returns a new instance of a NegArithmetic token
+func NewNegArithmetic(a token.Token) *NegArithmetic {
+	return &NegArithmetic{
 		a: a,
-		b: b,
 	}
 }
 
 // Clone returns a copy of the token and all its children
-func (e *DivArithmetic) Clone() token.Token {
-	return &DivArithmetic{
+func (e *NegArithmetic) Clone() token.Token {
+	return &NegArithmetic{
+		arithmetic: e.arithmetic,
+
 		a: e.a.Clone(),
-		b: e.b.Clone(),
 	}
 }
 
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
-func (e *DivArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+func (e *NegArithmetic) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return pars.ParseValue(e.String(), cur)
 }
 
 // Permutation sets a specific permutation for this token
-func (e *DivArithmetic) Permutation(i uint) error {
+func (e *NegArithmetic) Permutation(i uint) error {
 	permutations := e.Permutations()
 
 	if i < 1 || i > permutations {
@@ -422,69 +660,55 @@ func (e *DivArithmetic) Permutation(i uint) error {
 }
 
 // Permutations returns the number of permutations for this token
-func (e *DivArithmetic) Permutations() uint {
+func (e *NegArithmetic) Permutations() uint {
 	return 1
 }
 
 // PermutationsAll returns the number of all possible permutations for this token including its children
-func (e *DivArithmetic) PermutationsAll() uint {
-	return e.a.PermutationsAll() * e.b.PermutationsAll()
+func (e *NegArithmetic) PermutationsAll() uint {
+	return e.a.PermutationsAll()
 }
 
-func (e *DivArithmetic) String() string {
-	as := e.a.String()
-	bs := e.b.String()
-
-	if as == "" || bs == "" || as == "TODO" || bs == "TODO" {
+func (e *NegArithmetic) String() string {
+	a, ok := value(e.a)
+	if !ok {
 		return "TODO"
 	}
 
-	a, err := strconv.Atoi(as)
-	if err != nil {
-		panic(err)
-	}
-	b, err := strconv.Atoi(bs)
-	if err != nil {
-		panic(err)
-	}
-
-	return strconv.Itoa(a / b)
+	return e.Semantics().Limit(a.Neg(a)).String()
 }
 
 // List interface methods
 
 // Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
-func (e *DivArithmetic) Get(i int) (token.Token, error) {
-	switch i {
-	case 0:
-		return e.a, nil
-	case 1:
-		return e.b, nil
-	default:
+func (e *NegArithmetic) Get(i int) (token.Token, error) {
+	if i != 0 {
 		return nil, &lists.ListError{
 			Type: lists.ListErrorOutOfBound,
 		}
 	}
+
+	return e.a, nil
 }
 
 // Len returns the number of the current referenced tokens
-func (e *DivArithmetic) Len() int {
-	return 2
+func (e *NegArithmetic) Len() int {
+	return 1
 }
 
 // InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
-func (e *DivArithmetic) InternalGet(i int) (token.Token, error) {
+func (e *NegArithmetic) InternalGet(i int) (token.Token, error) {
 	return e.Get(i)
 }
 
 // InternalLen returns the number of referenced internal tokens
-func (e *DivArithmetic) InternalLen() int {
+func (e *NegArithmetic) InternalLen() int {
 	return e.Len()
 }
 
 // InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
-func (e *DivArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
-	if tok == e.a || tok == e.b {
+func (e *NegArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == e.a {
 		return nil
 	}
 
@@ -492,13 +716,10 @@ func (e *DivArithmetic) InternalLogicalRemove(tok token.Token) token.Token {
 }
 
 // InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
-func (e *DivArithmetic) InternalReplace(oldToken, newToken token.Token) error {
+func (e *NegArithmetic) InternalReplace(oldToken, newToken token.Token) error {
 	if oldToken == e.a {
 		e.a = newToken
 	}
-	if oldToken == e.b {
-		e.b = newToken
-	}
 
 	return nil
 }
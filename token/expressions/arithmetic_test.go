@@ -1,6 +1,7 @@
 package expressions
 
 import (
+	"math/big"
 	"testing"
 
 	. "github.com/zimmski/tavor/test/assert"
@@ -17,6 +18,18 @@ func TestArithmeticExpressionTokensToBeTokens(t *testing.T) {
 	Implements(t, tok, &SubArithmetic{})
 	Implements(t, tok, &MulArithmetic{})
 	Implements(t, tok, &DivArithmetic{})
+	Implements(t, tok, &ModArithmetic{})
+	Implements(t, tok, &BitAndArithmetic{})
+	Implements(t, tok, &BitOrArithmetic{})
+	Implements(t, tok, &BitXorArithmetic{})
+	Implements(t, tok, &ShiftLeftArithmetic{})
+	Implements(t, tok, &ShiftRightArithmetic{})
+	Implements(t, tok, &NegArithmetic{})
+
+	var atok *ArithmeticToken
+
+	Implements(t, atok, &AddArithmetic{})
+	Implements(t, atok, &NegArithmetic{})
 }
 
 func TestAddArithmetic(t *testing.T) {
@@ -122,3 +135,137 @@ func TestDivArithmetic(t *testing.T) {
 	o2 := o.Clone()
 	Equal(t, o.String(), o2.String())
 }
+
+func TestArithmeticOperators(t *testing.T) {
+	type newArithmetic func(a, b token.Token) ArithmeticToken
+
+	for _, c := range []struct {
+		new    newArithmetic
+		a, b   int
+		expect string
+	}{
+		{func(a, b token.Token) ArithmeticToken { return NewModArithmetic(a, b) }, 7, 3, "1"},
+		{func(a, b token.Token) ArithmeticToken { return NewModArithmetic(a, b) }, -7, 3, "-1"},
+		{func(a, b token.Token) ArithmeticToken { return NewBitAndArithmetic(a, b) }, 12, 10, "8"},
+		{func(a, b token.Token) ArithmeticToken { return NewBitAndArithmetic(a, b) }, -1, 255, "255"},
+		{func(a, b token.Token) ArithmeticToken { return NewBitOrArithmetic(a, b) }, 12, 10, "14"},
+		{func(a, b token.Token) ArithmeticToken { return NewBitXorArithmetic(a, b) }, 12, 10, "6"},
+		{func(a, b token.Token) ArithmeticToken { return NewShiftLeftArithmetic(a, b) }, 3, 4, "48"},
+		{func(a, b token.Token) ArithmeticToken { return NewShiftLeftArithmetic(a, b) }, 1, 64, "0"},
+		{func(a, b token.Token) ArithmeticToken { return NewShiftRightArithmetic(a, b) }, 48, 4, "3"},
+		{func(a, b token.Token) ArithmeticToken { return NewShiftRightArithmetic(a, b) }, -48, 4, "-3"},
+		{func(a, b token.Token) ArithmeticToken { return NewDivArithmetic(a, b) }, -7, 2, "-3"},
+	} {
+		a := primitives.NewConstantInt(c.a)
+		b := primitives.NewConstantInt(c.b)
+
+		o := c.new(a, b)
+		Equal(t, c.expect, o.String())
+		Equal(t, 1, o.Permutations())
+		Equal(t, 1, o.PermutationsAll())
+		Equal(t, 2, o.Len())
+
+		i, err := o.Get(0)
+		Nil(t, err)
+		True(t, Exactly(t, a, i))
+		i, err = o.Get(1)
+		Nil(t, err)
+		True(t, Exactly(t, b, i))
+		i, err = o.Get(2)
+		Equal(t, err.(*lists.ListError).Type, lists.ListErrorOutOfBound)
+		Nil(t, i)
+
+		o2 := o.Clone()
+		Equal(t, o.String(), o2.String())
+		Nil(t, o.InternalLogicalRemove(a))
+	}
+
+	// results of invalid operands are defined
+	Equal(t, "-1", NewDivArithmetic(primitives.NewConstantInt(7), primitives.NewConstantInt(0)).String())
+	Equal(t, "7", NewModArithmetic(primitives.NewConstantInt(7), primitives.NewConstantInt(0)).String())
+	Equal(t, "2", NewShiftLeftArithmetic(primitives.NewConstantInt(4), primitives.NewConstantInt(-1)).String())
+	Equal(t, "8", NewShiftRightArithmetic(primitives.NewConstantInt(4), primitives.NewConstantInt(-1)).String())
+	Equal(t, "0", NewShiftLeftArithmetic(primitives.NewConstantInt(1), primitives.NewConstantInt(1000)).String())
+	Equal(t, "-1", NewShiftRightArithmetic(primitives.NewConstantInt(-4), primitives.NewConstantInt(1000)).String())
+	Equal(t, "1", NewAddArithmetic(primitives.NewConstantString("a"), primitives.NewConstantInt(1)).String())
+
+	o := NewShiftLeftArithmetic(primitives.NewConstantInt(1), primitives.NewConstantInt(1<<20))
+	o.SetSemantics(&Semantics{Overflow: OverflowUnbounded})
+	Equal(t, new(big.Int).Lsh(big.NewInt(1), MaxUnboundedShift).String(), o.String())
+}
+
+func TestNegArithmetic(t *testing.T) {
+	a := primitives.NewRangeInt(1, 10)
+
+	o := NewNegArithmetic(a)
+	Equal(t, "-1", o.String())
+	Equal(t, 1, o.Permutations())
+	Equal(t, 10, o.PermutationsAll())
+	Equal(t, 1, o.Len())
+
+	i, err := o.Get(0)
+	Nil(t, err)
+	True(t, Exactly(t, a, i))
+	i, err = o.Get(1)
+	Equal(t, err.(*lists.ListError).Type, lists.ListErrorOutOfBound)
+	Nil(t, i)
+
+	Nil(t, a.Permutation(3))
+	Equal(t, "-3", o.String())
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+}
+
+func TestArithmeticSemantics(t *testing.T) {
+	o := NewAddArithmetic(primitives.NewConstantInt(250), primitives.NewConstantInt(10))
+	Equal(t, DefaultSemantics, o.Semantics())
+	Equal(t, "260", o.String())
+
+	s, err := NewSemantics("uint8", OverflowWrap)
+	Nil(t, err)
+	o.SetSemantics(s)
+	Equal(t, s, o.Semantics())
+	Equal(t, "4", o.String())
+
+	o2 := o.Clone().(*AddArithmetic)
+	Equal(t, s, o2.Semantics())
+
+	o.SetSemantics(nil)
+	Equal(t, DefaultSemantics, o.Semantics())
+}
+
+func TestArithmeticParse(t *testing.T) {
+	a := primitives.NewRangeInt(1, 9)
+	b := primitives.NewConstantInt(3)
+
+	o := lists.NewAll(a, NewMulArithmetic(a, b), NewNegArithmetic(a))
+
+	pars := &token.InternalParser{
+		Data:    "515-5",
+		DataLen: 5,
+	}
+
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 5, nex)
+	Equal(t, "515-5", o.String())
+
+	pars = &token.InternalParser{
+		Data:    "516-5",
+		DataLen: 5,
+	}
+
+	nex, errs = o.Parse(pars, 0)
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+	Equal(t, 1, nex)
+
+	pars = &token.InternalParser{
+		Data:    "51",
+		DataLen: 2,
+	}
+
+	nex, errs = o.Parse(pars, 0)
+	Equal(t, token.ParseErrorUnexpectedEOF, errs[0].(*token.ParserError).Type)
+	Equal(t, 1, nex)
+}
@@ -44,7 +44,7 @@ func (e *FuncExpression) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (e *FuncExpression) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	return pars.ParseValue(e.String(), cur)
 }
 
 // Permutation sets a specific permutation for this token
@@ -37,6 +37,31 @@ func NewPath(list token.Token, from token.Token, over token.Token, connectBy []t
 	}, nil
 }
 
+// List returns the list token which is traversed
+func (e *Path) List() token.Token {
+	return e.list
+}
+
+// From returns the expression of the starting value of the traversal
+func (e *Path) From() token.Token {
+	return e.from
+}
+
+// Over returns the expression which identifies each entry of the list
+func (e *Path) Over() token.Token {
+	return e.over
+}
+
+// ConnectBy returns the expressions which connect the entries of the list
+func (e *Path) ConnectBy() []token.Token {
+	return e.connectBy
+}
+
+// Without returns the expressions of the ending values of the traversal
+func (e *Path) Without() []token.Token {
+	return e.without
+}
+
 func checkListToken(list token.Token) error {
 	if token.LoopExists(list) {
 		return &token.ParserError{
@@ -68,6 +93,9 @@ func (e *Path) path() []string {
 	if v, ok := tl.(*variables.VariableReference); ok {
 		tl = v.Reference()
 	}
+	if v, ok := tl.(*variables.VariableValue); ok {
+		tl = v.InternalGet().(token.VariableToken).Get()
+	}
 
 	if v, ok := tl.(*primitives.Scope); ok {
 		tl = v.Get()
@@ -104,7 +132,9 @@ func (e *Path) path() []string {
 			cs[j] = e.connectBy[j].String()
 		}
 
-		connects[e.over.String()] = cs
+		// entries with the same identifier add up their connections
+		over := e.over.String()
+		connects[over] = append(connects[over], cs...)
 	}
 
 	token.SetScope(e.from, variableScope)
@@ -163,7 +193,7 @@ func (e *Path) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (e *Path) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO")
+	return pars.ParseValue(e.String(), cur)
 }
 
 // Permutation sets a specific permutation for this token
@@ -0,0 +1,115 @@
+package expressions
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Overflow defines how results of arithmetic tokens are handled which do not fit into their integer type
+type Overflow int
+
+const (
+	// OverflowWrap wraps results around like two's complement integers do
+	OverflowWrap Overflow = iota
+	// OverflowSaturate clamps results to the minimum or maximum of the integer type
+	OverflowSaturate
+	// OverflowUnbounded does not limit results at all
+	OverflowUnbounded
+)
+
+// Semantics defines the integer type and overflow behaviour of arithmetic tokens
+type Semantics struct {
+	Bits     uint
+	Signed   bool
+	Overflow Overflow
+}
+
+// DefaultSemantics holds the semantics of arithmetic tokens which do not have their own semantics
+var DefaultSemantics = &Semantics{
+	Bits:     64,
+	Signed:   true,
+	Overflow: OverflowWrap,
+}
+
+// NewSemantics returns new arithmetic semantics given the name of an integer type and an overflow behaviour
+// Valid integer types are int8, int16, int32, int64, uint8, uint16, uint32 and uint64.
+func NewSemantics(typ string, overflow Overflow) (*Semantics, error) {
+	s := &Semantics{
+		Overflow: overflow,
+	}
+
+	switch typ {
+	case "int8", "int16", "int32", "int64":
+		s.Signed = true
+		typ = typ[3:]
+	case "uint8", "uint16", "uint32", "uint64":
+		typ = typ[4:]
+	default:
+		return nil, fmt.Errorf("unknown integer type %q", typ)
+	}
+
+	_, _ = fmt.Sscan(typ, &s.Bits)
+
+	return s, nil
+}
+
+func (s *Semantics) String() string {
+	if s.Overflow == OverflowUnbounded {
+		return "unbounded"
+	}
+
+	typ := "int"
+	if !s.Signed {
+		typ = "uint"
+	}
+
+	overflow := "wrap"
+	if s.Overflow == OverflowSaturate {
+		overflow = "saturate"
+	}
+
+	return fmt.Sprintf("%s%d %s", typ, s.Bits, overflow)
+}
+
+// bounds returns the minimum and maximum of the integer type
+func (s *Semantics) bounds() (*big.Int, *big.Int) {
+	if s.Signed {
+		max := new(big.Int).Lsh(big.NewInt(1), s.Bits-1)
+
+		return new(big.Int).Neg(max), max.Sub(max, big.NewInt(1))
+	}
+
+	max := new(big.Int).Lsh(big.NewInt(1), s.Bits)
+
+	return big.NewInt(0), max.Sub(max, big.NewInt(1))
+}
+
+// Limit returns the given value limited by the integer type and overflow behaviour
+func (s *Semantics) Limit(x *big.Int) *big.Int {
+	if s.Overflow == OverflowUnbounded {
+		return x
+	}
+
+	min, max := s.bounds()
+
+	if x.Cmp(min) >= 0 && x.Cmp(max) <= 0 {
+		return x
+	}
+
+	if s.Overflow == OverflowSaturate {
+		if x.Cmp(min) < 0 {
+			return min
+		}
+
+		return max
+	}
+
+	m := new(big.Int).Lsh(big.NewInt(1), s.Bits)
+
+	x = new(big.Int).Mod(x, m)
+	if x.Cmp(max) > 0 {
+		x.Sub(x, m)
+	}
+
+	return x
+}
@@ -0,0 +1,49 @@
+package expressions
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestSemantics(t *testing.T) {
+	_, err := NewSemantics("int7", OverflowWrap)
+	NotNil(t, err)
+	_, err = NewSemantics("float32", OverflowWrap)
+	NotNil(t, err)
+
+	for _, c := range []struct {
+		typ      string
+		overflow Overflow
+		in       string
+		expect   string
+		str      string
+	}{
+		{"uint8", OverflowWrap, "256", "0", "uint8 wrap"},
+		{"uint8", OverflowWrap, "-1", "255", "uint8 wrap"},
+		{"uint8", OverflowSaturate, "-1", "0", "uint8 saturate"},
+		{"uint8", OverflowSaturate, "1000", "255", "uint8 saturate"},
+		{"int8", OverflowWrap, "128", "-128", "int8 wrap"},
+		{"int8", OverflowWrap, "-129", "127", "int8 wrap"},
+		{"int8", OverflowSaturate, "-1000", "-128", "int8 saturate"},
+		{"int16", OverflowWrap, "-5", "-5", "int16 wrap"},
+		{"uint64", OverflowWrap, "18446744073709551616", "0", "uint64 wrap"},
+		{"int64", OverflowWrap, "9223372036854775808", "-9223372036854775808", "int64 wrap"},
+	} {
+		s, err := NewSemantics(c.typ, c.overflow)
+		Nil(t, err)
+		Equal(t, c.str, s.String())
+
+		x, _ := new(big.Int).SetString(c.in, 10)
+		Equal(t, c.expect, s.Limit(x).String(), c.typ+" "+c.in)
+	}
+
+	s := &Semantics{
+		Overflow: OverflowUnbounded,
+	}
+	Equal(t, "unbounded", s.String())
+
+	x, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	Equal(t, "123456789012345678901234567890", s.Limit(x).String())
+}
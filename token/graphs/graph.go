@@ -0,0 +1,512 @@
+package graphs
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+// maxGraphPermutations is the number of permutations of a graph token
+// Every permutation generates the graph out of a different random seed.
+const maxGraphPermutations = math.MaxInt32
+
+// Graph implements a token which generates random directed graphs over the nodes 1 to n
+// The graph is a list of its edges. Every edge is a list of its source node followed by its destination node. Edges are formatted as the source node, the separator and the destination node and are delimited by the delimiter.
+type Graph struct {
+	nodes     int
+	density   float64
+	acyclic   bool
+	connected bool
+	tree      bool
+	degree    int
+
+	separator string
+	delimiter string
+
+	value uint
+	edges [][2]int
+}
+
+// NewGraph returns a new instance of a Graph token
+// Every possible edge is added with the probability density. If acyclic is true the graph has no cycles and if connected is true every node can be reached from node 1. The degree of every node, which is the number of its incoming and outgoing edges, is at most degree if degree is not 0.
+func NewGraph(nodes int, density float64, acyclic bool, connected bool, degree int, separator string, delimiter string) *Graph {
+	g := &Graph{
+		nodes:     nodes,
+		density:   density,
+		acyclic:   acyclic,
+		connected: connected,
+		degree:    degree,
+
+		separator: separator,
+		delimiter: delimiter,
+	}
+	g.permutation(0)
+
+	return g
+}
+
+// NewTree returns a new instance of a Graph token which generates random trees with the root node 1
+// Every edge connects a parent node with one of its children. Every node has at most degree children if degree is not 0.
+func NewTree(nodes int, degree int, separator string, delimiter string) *Graph {
+	g := &Graph{
+		nodes:     nodes,
+		acyclic:   true,
+		connected: true,
+		tree:      true,
+		degree:    degree,
+
+		separator: separator,
+		delimiter: delimiter,
+	}
+	g.permutation(0)
+
+	return g
+}
+
+func init() {
+	token.RegisterTyped("Graph", func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+		nodes := argParser.GetInt("nodes", 5)
+		density := argParser.GetFloat("density", 0.5)
+		acyclic := argParser.GetBool("acyclic", false)
+		connected := argParser.GetBool("connected", false)
+		degree := argParser.GetInt("degree", 0)
+		separator := argParser.GetString("separator", " ")
+		delimiter := argParser.GetString("delimiter", "\n")
+
+		if err := argParser.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := checkArguments(nodes, degree, separator, delimiter); err != nil {
+			return nil, err
+		}
+		if density < 0 || density > 1 {
+			return nil, fmt.Errorf("density must be between 0 and 1")
+		}
+		if connected && degree != 0 && nodes > 2 && degree < 2 {
+			return nil, fmt.Errorf("connected graphs with more than two nodes need a degree of at least 2")
+		}
+
+		return NewGraph(nodes, density, acyclic, connected, degree, separator, delimiter), nil
+	})
+	token.RegisterTyped("Tree", func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+		nodes := argParser.GetInt("nodes", 5)
+		degree := argParser.GetInt("degree", 0)
+		separator := argParser.GetString("separator", " ")
+		delimiter := argParser.GetString("delimiter", "\n")
+
+		if err := argParser.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := checkArguments(nodes, degree, separator, delimiter); err != nil {
+			return nil, err
+		}
+
+		return NewTree(nodes, degree, separator, delimiter), nil
+	})
+}
+
+func checkArguments(nodes int, degree int, separator string, delimiter string) error {
+	if nodes < 1 {
+		return fmt.Errorf("nodes must be at least 1")
+	}
+	if degree < 0 {
+		return fmt.Errorf("degree must not be negative")
+	}
+	if separator == "" || delimiter == "" {
+		return fmt.Errorf("separator and delimiter must not be empty")
+	}
+	if strings.ContainsAny(separator, "0123456789") || strings.ContainsAny(delimiter, "0123456789") {
+		return fmt.Errorf("separator and delimiter must not contain digits")
+	}
+
+	return nil
+}
+
+// Edges returns the edges of the graph ordered by their source and destination nodes
+func (g *Graph) Edges() [][2]int {
+	return g.edges
+}
+
+// canAdd checks if the edge from a to b can be added to the graph
+func (g *Graph) canAdd(a, b int, position []int, degrees []int, children []int, existing map[[2]int]struct{}) bool {
+	if a == b {
+		return false
+	}
+	if _, ok := existing[[2]int{a, b}]; ok {
+		return false
+	}
+	if g.acyclic && position[a] > position[b] {
+		return false
+	}
+
+	if g.degree != 0 {
+		if g.tree {
+			return children[a] < g.degree
+		}
+
+		return degrees[a] < g.degree && degrees[b] < g.degree
+	}
+
+	return true
+}
+
+func (g *Graph) permutation(i uint) {
+	g.value = i
+
+	r := rand.New(rand.NewSource(int64(i)))
+
+	// a random order of the nodes which is the topological order of acyclic graphs
+	order := r.Perm(g.nodes)
+	for j := range order {
+		order[j]++
+
+		if order[j] == 1 && (g.connected || g.tree) {
+			order[0], order[j] = order[j], order[0]
+		}
+	}
+
+	position := make([]int, g.nodes+1)
+	for j, n := range order {
+		position[n] = j
+	}
+
+	degrees := make([]int, g.nodes+1)
+	children := make([]int, g.nodes+1)
+	existing := make(map[[2]int]struct{})
+
+	g.edges = nil
+
+	add := func(a, b int) {
+		existing[[2]int{a, b}] = struct{}{}
+		degrees[a]++
+		degrees[b]++
+		children[a]++
+
+		g.edges = append(g.edges, [2]int{a, b})
+	}
+
+	if g.connected || g.tree {
+		// connect every node with one of the nodes before it so it can be reached from node 1
+		for j := 1; j < len(order); j++ {
+			b := order[j]
+
+			var candidates []int
+			for _, a := range order[:j] {
+				if g.canAdd(a, b, position, degrees, children, existing) {
+					candidates = append(candidates, a)
+				}
+			}
+
+			add(candidates[r.Intn(len(candidates))], b)
+		}
+	}
+
+	if !g.tree {
+		for a := 1; a <= g.nodes; a++ {
+			for b := 1; b <= g.nodes; b++ {
+				if g.canAdd(a, b, position, degrees, children, existing) && r.Float64() < g.density {
+					add(a, b)
+				}
+			}
+		}
+	}
+
+	sortEdges(g.edges)
+}
+
+func sortEdges(edges [][2]int) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+
+		return edges[i][1] < edges[j][1]
+	})
+}
+
+// check returns an error if the given edges are not a valid graph of this token
+func (g *Graph) check(edges [][2]int) error {
+	existing := make(map[[2]int]struct{})
+	degrees := make([]int, g.nodes+1)
+	children := make([]int, g.nodes+1)
+	parents := make([]int, g.nodes+1)
+	connects := make([][]int, g.nodes+1)
+
+	for _, e := range edges {
+		a, b := e[0], e[1]
+
+		if a < 1 || a > g.nodes || b < 1 || b > g.nodes {
+			return fmt.Errorf("edge %d%s%d has an unknown node", a, g.separator, b)
+		}
+		if a == b {
+			return fmt.Errorf("edge %d%s%d is a loop", a, g.separator, b)
+		}
+		if _, ok := existing[e]; ok {
+			return fmt.Errorf("edge %d%s%d is defined more than once", a, g.separator, b)
+		}
+
+		existing[e] = struct{}{}
+		degrees[a]++
+		degrees[b]++
+		children[a]++
+		parents[b]++
+		connects[a] = append(connects[a], b)
+	}
+
+	for n := 1; n <= g.nodes; n++ {
+		if g.degree != 0 {
+			if g.tree && children[n] > g.degree {
+				return fmt.Errorf("node %d has more than %d children", n, g.degree)
+			} else if !g.tree && degrees[n] > g.degree {
+				return fmt.Errorf("node %d has a degree greater than %d", n, g.degree)
+			}
+		}
+		if g.tree && ((n == 1 && parents[n] != 0) || (n != 1 && parents[n] != 1)) {
+			return fmt.Errorf("node %d has not exactly one parent", n)
+		}
+	}
+
+	if g.acyclic {
+		// depth-first search which marks nodes as visiting (1) and visited (2)
+		state := make([]int, g.nodes+1)
+
+		var visit func(n int) bool
+		visit = func(n int) bool {
+			state[n] = 1
+
+			for _, c := range connects[n] {
+				if state[c] == 1 || (state[c] == 0 && !visit(c)) {
+					return false
+				}
+			}
+
+			state[n] = 2
+
+			return true
+		}
+
+		for n := 1; n <= g.nodes; n++ {
+			if state[n] == 0 && !visit(n) {
+				return fmt.Errorf("graph has a cycle")
+			}
+		}
+	}
+
+	if g.connected {
+		reached := make([]bool, g.nodes+1)
+		reached[1] = true
+		queue := []int{1}
+
+		for len(queue) != 0 {
+			n := queue[0]
+			queue = queue[1:]
+
+			for _, c := range connects[n] {
+				if !reached[c] {
+					reached[c] = true
+					queue = append(queue, c)
+				}
+			}
+		}
+
+		for n := 1; n <= g.nodes; n++ {
+			if !reached[n] {
+				return fmt.Errorf("node %d cannot be reached from node 1", n)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseNode parses a node beginning from the current position and returns it with the next position or -1 if there is no node
+func parseNode(pars *token.InternalParser, cur int) (int, int) {
+	nex := cur
+	for nex < pars.DataLen && pars.Data[nex] >= '0' && pars.Data[nex] <= '9' {
+		nex++
+	}
+
+	n, err := strconv.Atoi(pars.Data[cur:nex])
+	if err != nil {
+		return -1, cur
+	}
+
+	return n, nex
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (g *Graph) Clone() token.Token {
+	c := *g
+	c.edges = make([][2]int, len(g.edges))
+	copy(c.edges, g.edges)
+
+	return &c
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (g *Graph) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	var edges [][2]int
+
+	nex := cur
+
+	for {
+		c := nex
+
+		if len(edges) != 0 {
+			if !strings.HasPrefix(pars.Data[c:], g.delimiter) {
+				break
+			}
+
+			c += len(g.delimiter)
+		}
+
+		a, c := parseNode(pars, c)
+		if a == -1 || !strings.HasPrefix(pars.Data[c:], g.separator) {
+			break
+		}
+
+		b, c := parseNode(pars, c+len(g.separator))
+		if b == -1 {
+			break
+		}
+
+		edges = append(edges, [2]int{a, b})
+		nex = c
+	}
+
+	if err := g.check(edges); err != nil {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected a valid graph but %s", err),
+			Type:    token.ParseErrorUnexpectedData,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	sortEdges(edges)
+	g.edges = edges
+
+	return nex, nil
+}
+
+// Permutation sets a specific permutation for this token
+func (g *Graph) Permutation(i uint) error {
+	permutations := g.Permutations()
+
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	g.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (g *Graph) Permutations() uint {
+	return maxGraphPermutations
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (g *Graph) PermutationsAll() uint {
+	return g.Permutations()
+}
+
+func (g *Graph) String() string {
+	var buffer bytes.Buffer
+
+	for i, e := range g.edges {
+		if i != 0 {
+			buffer.WriteString(g.delimiter)
+		}
+
+		buffer.WriteString(strconv.Itoa(e[0]))
+		buffer.WriteString(g.separator)
+		buffer.WriteString(strconv.Itoa(e[1]))
+	}
+
+	return buffer.String()
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+// The token is a list of the source and the destination node of the edge.
+func (g *Graph) Get(i int) (token.Token, error) {
+	if i < 0 || i >= len(g.edges) {
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+
+	return lists.NewAll(
+		primitives.NewConstantInt(g.edges[i][0]),
+		primitives.NewConstantInt(g.edges[i][1]),
+	), nil
+}
+
+// Len returns the number of the current referenced tokens
+func (g *Graph) Len() int {
+	return len(g.edges)
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (g *Graph) InternalGet(i int) (token.Token, error) {
+	return nil, &lists.ListError{
+		Type: lists.ListErrorOutOfBound,
+	}
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (g *Graph) InternalLen() int {
+	return 0
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (g *Graph) InternalLogicalRemove(tok token.Token) token.Token {
+	return g
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (g *Graph) InternalReplace(oldToken, newToken token.Token) error {
+	return nil
+}
+
+// Typed interface methods
+
+// TypedDefinition returns the type and the arguments of a typed token definition which creates an equal token
+func (g *Graph) TypedDefinition() (string, []token.TypedArgument) {
+	if g.tree {
+		return "Tree", []token.TypedArgument{
+			{Name: "nodes", Value: strconv.Itoa(g.nodes)},
+			{Name: "degree", Value: strconv.Itoa(g.degree)},
+			{Name: "separator", Value: strconv.Quote(g.separator)},
+			{Name: "delimiter", Value: strconv.Quote(g.delimiter)},
+		}
+	}
+
+	return "Graph", []token.TypedArgument{
+		{Name: "nodes", Value: strconv.Itoa(g.nodes)},
+		{Name: "density", Value: strconv.FormatFloat(g.density, 'g', -1, 64)},
+		{Name: "acyclic", Value: strconv.FormatBool(g.acyclic)},
+		{Name: "connected", Value: strconv.FormatBool(g.connected)},
+		{Name: "degree", Value: strconv.Itoa(g.degree)},
+		{Name: "separator", Value: strconv.Quote(g.separator)},
+		{Name: "delimiter", Value: strconv.Quote(g.delimiter)},
+	}
+}
@@ -0,0 +1,154 @@
+package graphs
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+)
+
+func TestGraphTokensToBeTokens(t *testing.T) {
+	var tok *token.ListToken
+
+	Implements(t, tok, &Graph{})
+}
+
+func TestGraph(t *testing.T) {
+	g := NewGraph(3, 1, false, false, 0, " ", "\n")
+	Equal(t, "1 2\n1 3\n2 1\n2 3\n3 1\n3 2", g.String())
+	Equal(t, maxGraphPermutations, g.Permutations())
+	Equal(t, 6, g.Len())
+
+	e, err := g.Get(1)
+	Nil(t, err)
+	Equal(t, "13", e.String())
+
+	_, err = g.Get(6)
+	NotNil(t, err)
+
+	g = NewGraph(3, 0, false, false, 0, " ", "\n")
+	Equal(t, "", g.String())
+	Equal(t, 0, g.Len())
+
+	// permutations are deterministic
+	g = NewGraph(10, 0.3, false, false, 0, "-", ",")
+	Nil(t, g.Permutation(7))
+	s := g.String()
+	Nil(t, g.Permutation(8))
+	Nil(t, g.Permutation(7))
+	Equal(t, s, g.String())
+
+	g2 := g.Clone()
+	Equal(t, g.String(), g2.String())
+
+	Equal(t, g.Permutation(0).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+}
+
+func TestGraphProperties(t *testing.T) {
+	for _, g := range []*Graph{
+		NewGraph(8, 0.5, false, false, 0, " ", "\n"),
+		NewGraph(8, 0.5, true, false, 0, " ", "\n"),
+		NewGraph(8, 0.1, false, true, 0, " ", "\n"),
+		NewGraph(8, 0.1, true, true, 0, " ", "\n"),
+		NewGraph(8, 0.8, true, true, 3, " ", "\n"),
+		NewGraph(8, 1, false, true, 2, " ", "\n"),
+		NewTree(8, 0, " ", "\n"),
+		NewTree(8, 1, " ", "\n"),
+		NewTree(8, 2, " ", "\n"),
+	} {
+		for i := uint(1); i <= 50; i++ {
+			Nil(t, g.Permutation(i))
+			Nil(t, g.check(g.Edges()), g.String())
+
+			if g.tree {
+				Equal(t, 7, g.Len())
+			}
+		}
+	}
+
+	// acyclic graphs with a density of 1 have every possible edge
+	g := NewGraph(5, 1, true, false, 0, " ", "\n")
+	Equal(t, 10, g.Len())
+}
+
+func TestGraphParse(t *testing.T) {
+	g := NewGraph(4, 0.5, true, true, 0, " -> ", ", ")
+
+	for _, tc := range []struct {
+		data string
+		nex  int
+	}{
+		{"1 -> 2, 2 -> 3, 2 -> 4", 22},
+		{"1 -> 4, 4 -> 3, 1 -> 2, 1 -> 3, and more", 30},
+		{"1 -> 2, 2 -> 3, 3 -> 4, ", 22},
+	} {
+		pars := &token.InternalParser{
+			Data:    tc.data,
+			DataLen: len(tc.data),
+		}
+
+		nex, errs := g.Parse(pars, 0)
+		Nil(t, errs, tc.data)
+		Equal(t, tc.nex, nex, tc.data)
+	}
+
+	Equal(t, "1 -> 2, 2 -> 3, 3 -> 4", g.String())
+
+	for _, data := range []string{
+		"",
+		"1 -> 2",
+		"1 -> 2, 2 -> 3, 3 -> 4, 4 -> 2",
+		"1 -> 2, 2 -> 3, 3 -> 4, 4 -> 4",
+		"1 -> 2, 2 -> 3, 3 -> 4, 1 -> 2",
+		"1 -> 2, 2 -> 3, 3 -> 5",
+		"2 -> 1, 2 -> 3, 3 -> 4",
+	} {
+		pars := &token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}
+
+		nex, errs := g.Parse(pars, 0)
+		NotNil(t, errs, data)
+		Equal(t, 0, nex, data)
+	}
+
+	// trees
+	g = NewTree(4, 2, " ", "\n")
+
+	for data, valid := range map[string]bool{
+		"1 2\n1 3\n3 4": true,
+		"1 2\n1 3\n1 4": false,
+		"1 2\n2 3\n4 3": false,
+		"1 2\n3 4":      false,
+		"2 1\n1 3\n3 4": false,
+	} {
+		pars := &token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}
+
+		_, errs := g.Parse(pars, 0)
+		Equal(t, valid, errs == nil, data)
+	}
+
+	// graphs which need not to be connected can be empty
+	g = NewGraph(4, 0.5, false, false, 1, " ", "\n")
+
+	for data, valid := range map[string]bool{
+		"":            true,
+		"4 1\n2 3":    true,
+		"1 2\n2 1":    false,
+		"1 2\n1 3":    false,
+		"1 2\n3 4\n4": true,
+	} {
+		pars := &token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}
+
+		_, errs := g.Parse(pars, 0)
+		Equal(t, valid, errs == nil, data)
+	}
+}
@@ -0,0 +1,108 @@
+package lists
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Distribution defines a probability distribution over the repetitions of a Repeat token
+type Distribution interface {
+	fmt.Stringer
+
+	// Weight returns the weight of the given repetition count within the repeat range
+	Weight(count, from, to int64) float64
+}
+
+// GeometricDistribution implements a geometric distribution over repetitions
+// The first repetition count of the range has the probability P and every further count is weighted with 1-P of the previous one. Small probabilities therefore favour long repetitions.
+type GeometricDistribution struct {
+	P float64
+}
+
+// NewGeometricDistribution returns a new instance of a geometric distribution with the given probability
+func NewGeometricDistribution(p float64) *GeometricDistribution {
+	if p <= 0 || p > 1 {
+		panic("probability of geometric distribution must be in the range (0, 1]")
+	}
+
+	return &GeometricDistribution{
+		P: p,
+	}
+}
+
+// Weight returns the weight of the given repetition count within the repeat range
+func (d *GeometricDistribution) Weight(count, from, to int64) float64 {
+	if count < from || count > to {
+		return 0
+	}
+
+	return math.Pow(1-d.P, float64(count-from)) * d.P
+}
+
+func (d *GeometricDistribution) String() string {
+	return fmt.Sprintf("geometric(%v)", d.P)
+}
+
+// HistogramBucket holds the weight of a range of repetition counts
+type HistogramBucket struct {
+	From   int64
+	To     int64
+	Weight float64
+}
+
+// HistogramDistribution implements a distribution over repetitions defined by weighted ranges
+// Every repetition count of a bucket has the weight of the bucket. Counts which are not in a bucket are never chosen.
+type HistogramDistribution struct {
+	Buckets []HistogramBucket
+}
+
+// NewHistogramDistribution returns a new instance of a histogram distribution with the given buckets
+func NewHistogramDistribution(buckets ...HistogramBucket) *HistogramDistribution {
+	if len(buckets) == 0 {
+		panic("at least one bucket needed")
+	}
+	for _, b := range buckets {
+		if b.From > b.To {
+			panic("from of a bucket must not be bigger than its to")
+		}
+		if b.Weight <= 0 {
+			panic("weights must be positive")
+		}
+	}
+
+	return &HistogramDistribution{
+		Buckets: buckets,
+	}
+}
+
+// Weight returns the weight of the given repetition count within the repeat range
+func (d *HistogramDistribution) Weight(count, from, to int64) float64 {
+	if count < from || count > to {
+		return 0
+	}
+
+	var weight float64
+
+	for _, b := range d.Buckets {
+		if count >= b.From && count <= b.To {
+			weight += b.Weight
+		}
+	}
+
+	return weight
+}
+
+func (d *HistogramDistribution) String() string {
+	buckets := make([]string, len(d.Buckets))
+
+	for i, b := range d.Buckets {
+		if b.From == b.To {
+			buckets[i] = fmt.Sprintf("%d: %v", b.From, b.Weight)
+		} else {
+			buckets[i] = fmt.Sprintf("%d-%d: %v", b.From, b.To, b.Weight)
+		}
+	}
+
+	return fmt.Sprintf("histogram(%s)", strings.Join(buckets, ", "))
+}
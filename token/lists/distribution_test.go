@@ -0,0 +1,54 @@
+package lists
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestGeometricDistribution(t *testing.T) {
+	d := NewGeometricDistribution(0.5)
+	Equal(t, "geometric(0.5)", d.String())
+
+	Equal(t, 0.5, d.Weight(2, 2, 5))
+	Equal(t, 0.25, d.Weight(3, 2, 5))
+	Equal(t, 0.0625, d.Weight(5, 2, 5))
+	Equal(t, 0.0, d.Weight(1, 2, 5))
+	Equal(t, 0.0, d.Weight(6, 2, 5))
+
+	d = NewGeometricDistribution(1)
+	Equal(t, 1.0, d.Weight(0, 0, 2))
+	Equal(t, 0.0, d.Weight(1, 0, 2))
+
+	Panics(t, func() {
+		NewGeometricDistribution(0)
+	})
+	Panics(t, func() {
+		NewGeometricDistribution(1.5)
+	})
+}
+
+func TestHistogramDistribution(t *testing.T) {
+	d := NewHistogramDistribution(
+		HistogramBucket{From: 0, To: 2, Weight: 10},
+		HistogramBucket{From: 2, To: 2, Weight: 1},
+		HistogramBucket{From: 5, To: 5, Weight: 0.5},
+	)
+	Equal(t, "histogram(0-2: 10, 2: 1, 5: 0.5)", d.String())
+
+	Equal(t, 10.0, d.Weight(0, 0, 10))
+	Equal(t, 11.0, d.Weight(2, 0, 10))
+	Equal(t, 0.0, d.Weight(3, 0, 10))
+	Equal(t, 0.5, d.Weight(5, 0, 10))
+	Equal(t, 0.0, d.Weight(5, 0, 4))
+
+	Panics(t, func() {
+		NewHistogramDistribution()
+	})
+	Panics(t, func() {
+		NewHistogramDistribution(HistogramBucket{From: 2, To: 1, Weight: 1})
+	})
+	Panics(t, func() {
+		NewHistogramDistribution(HistogramBucket{From: 1, To: 1, Weight: 0})
+	})
+}
@@ -0,0 +1,33 @@
+package lists
+
+import (
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+// ExceptValues returns the values of the given tokens which item tokens, e.g. of dictionaries and sets, must not hold
+// Scope tokens are resolved and every item of a list token is a value on its own.
+func ExceptValues(except []token.Token) (map[string]struct{}, error) {
+	exceptLookup := make(map[string]struct{})
+
+	for _, tok := range except {
+		if v, ok := tok.(*primitives.Scope); ok {
+			tok = v.Get()
+		}
+
+		if l, ok := tok.(token.ListToken); ok {
+			for j := 0; j < l.Len(); j++ {
+				tj, err := l.Get(j)
+				if err != nil {
+					return nil, err
+				}
+
+				exceptLookup[tj.String()] = struct{}{}
+			}
+		} else {
+			exceptLookup[tok.String()] = struct{}{}
+		}
+	}
+
+	return exceptLookup, nil
+}
@@ -0,0 +1,29 @@
+package lists
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestExceptValues(t *testing.T) {
+	values, err := ExceptValues(nil)
+	Nil(t, err)
+	Equal(t, map[string]struct{}{}, values)
+
+	values, err = ExceptValues([]token.Token{
+		primitives.NewConstantString("a"),
+		primitives.NewScope(primitives.NewConstantString("b")),
+		NewAll(primitives.NewConstantString("c"), primitives.NewConstantString("d")),
+	})
+	Nil(t, err)
+	Equal(t, map[string]struct{}{
+		"a": {},
+		"b": {},
+		"c": {},
+		"d": {},
+	}, values)
+}
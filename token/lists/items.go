@@ -20,6 +20,16 @@ func NewListItem(index token.Token, list token.ListToken) *ListItem {
 	}
 }
 
+// IndexToken returns the token holding the index of the referenced list item
+func (l *ListItem) IndexToken() token.Token {
+	return l.index
+}
+
+// List returns the referenced list
+func (l *ListItem) List() token.ListToken {
+	return l.list
+}
+
 // Token interface methods
 
 // Clone returns a copy of the token and all its children
@@ -33,7 +43,7 @@ func (l *ListItem) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (l *ListItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	return pars.ParseValue(l.String(), cur)
 }
 
 // Permutation sets a specific permutation for this token
@@ -105,6 +115,11 @@ func NewIndexItem(tok token.IndexToken) *IndexItem {
 	}
 }
 
+// Token returns the referenced Index token
+func (l *IndexItem) Token() token.IndexToken {
+	return l.token
+}
+
 // Token interface methods
 
 // Clone returns a copy of the token and all its children
@@ -117,7 +132,7 @@ func (l *IndexItem) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (l *IndexItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	return pars.ParseValue(l.String(), cur)
 }
 
 // Permutation sets a specific permutation for this token
@@ -203,6 +218,11 @@ func (l *UniqueItem) pick(i uint) {
 	}
 }
 
+// List returns the referenced list
+func (l *UniqueItem) List() token.ListToken {
+	return l.original.list
+}
+
 // Token interface methods
 
 // Clone returns a copy of the token and all its children
@@ -221,7 +241,43 @@ func (l *UniqueItem) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (l *UniqueItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	l.Release()
+
+	var errs []error
+
+	for i := 0; i < l.original.list.Len(); i++ {
+		if _, ok := l.original.picked[i]; ok {
+			continue
+		}
+
+		tok, err := l.original.list.Get(i)
+		if err != nil {
+			panic(err) // TODO
+		}
+
+		nex, es := pars.ParseValue(tok.String(), cur)
+		if len(es) != 0 {
+			errs = append(errs, es...)
+
+			continue
+		}
+
+		l.index = i
+		l.original.picked[i] = struct{}{}
+
+		return nex, nil
+	}
+
+	if errs == nil {
+		return cur, []error{&token.ParserError{
+			Message: "expected an unique item but all items are already used",
+			Type:    token.ParseErrorUnexpectedData,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	return cur, errs
 }
 
 // Permutation sets a specific permutation for this token
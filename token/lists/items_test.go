@@ -5,6 +5,7 @@ import (
 
 	. "github.com/zimmski/tavor/test/assert"
 
+	"github.com/zimmski/tavor/token"
 	"github.com/zimmski/tavor/token/primitives"
 )
 
@@ -54,3 +55,50 @@ func TestUniqueItem(t *testing.T) {
 	Equal(t, 2, b.Permutations())
 	Equal(t, 2, b.PermutationsAll())
 }
+
+func TestItemsParse(t *testing.T) {
+	list := NewAll(
+		primitives.NewConstantInt(1),
+		primitives.NewConstantInt(2),
+		primitives.NewConstantInt(3),
+	)
+
+	pars := &token.InternalParser{
+		Data:    "2123",
+		DataLen: 4,
+	}
+
+	l := NewListItem(primitives.NewConstantInt(1), list)
+
+	nex, errs := l.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 1, nex)
+
+	nex, errs = l.Parse(pars, 1)
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+	Equal(t, 1, nex)
+
+	a := NewUniqueItem(list)
+	b := a.Clone().(*UniqueItem)
+	c := a.Clone().(*UniqueItem)
+
+	nex, errs = a.Parse(pars, 1)
+	Nil(t, errs)
+	Equal(t, 2, nex)
+	Equal(t, "1", a.String())
+
+	// unique items cannot parse already used items
+	nex, errs = b.Parse(pars, 1)
+	NotNil(t, errs)
+	Equal(t, 1, nex)
+
+	nex, errs = b.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 1, nex)
+	Equal(t, "2", b.String())
+
+	nex, errs = c.Parse(pars, 3)
+	Nil(t, errs)
+	Equal(t, 4, nex)
+	Equal(t, "3", c.String())
+}
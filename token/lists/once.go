@@ -52,7 +52,42 @@ func (l *Once) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (l *Once) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	var errs []error
+
+	values := make([]int, 0, len(l.tokens))
+	used := make([]bool, len(l.tokens))
+
+	for len(values) != len(l.tokens) {
+		found := false
+
+		for i, tok := range l.tokens {
+			if used[i] {
+				continue
+			}
+
+			nex, es := tok.Parse(pars, cur)
+			if len(es) != 0 {
+				errs = append(errs, es...)
+
+				continue
+			}
+
+			cur = nex
+			values = append(values, i)
+			used[i] = true
+			found = true
+
+			break
+		}
+
+		if !found {
+			return cur, errs
+		}
+	}
+
+	l.values = values
+
+	return cur, nil
 }
 
 func (l *Once) permutation(i uint) {
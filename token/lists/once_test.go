@@ -65,3 +65,29 @@ func TestOnce(t *testing.T) {
 	o2 := o.Clone()
 	Equal(t, o.String(), o2.String())
 }
+
+func TestOnceParse(t *testing.T) {
+	a := primitives.NewConstantInt(10)
+	b := primitives.NewConstantString("abc")
+	c := primitives.NewConstantString("def")
+
+	o := NewOnce(a, b, c)
+
+	pars := &token.InternalParser{
+		Data:    "def10abc",
+		DataLen: 8,
+	}
+
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 8, nex)
+	Equal(t, "def10abc", o.String())
+
+	pars = &token.InternalParser{
+		Data:    "def10def",
+		DataLen: 8,
+	}
+
+	_, errs = o.Parse(pars, 0)
+	NotNil(t, errs)
+}
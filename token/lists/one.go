@@ -5,10 +5,11 @@ import (
 )
 
 // One implements a list token which chooses of a set of referenced token exactly one token
-// Every permutation chooses one token out of the token set.
+// Every permutation chooses one token out of the token set. The tokens can be weighted to prefer some tokens over others.
 type One struct {
-	tokens []token.Token
-	value  int
+	tokens  []token.Token
+	weights []float64
+	value   int
 }
 
 // NewOne returns a new instance of a One token given the set of tokens
@@ -23,6 +24,40 @@ func NewOne(toks ...token.Token) *One {
 	}
 }
 
+// NewOneWithWeights returns a new instance of a One token given the set of tokens and their weights
+// Every token must have a positive weight.
+func NewOneWithWeights(weights []float64, toks ...token.Token) *One {
+	if len(weights) != len(toks) {
+		panic("every token needs a weight")
+	}
+
+	l := NewOne(toks...)
+	l.SetWeights(weights)
+
+	return l
+}
+
+// SetWeights sets the weights of the referenced tokens. Nil weights all tokens equally.
+// Every token must have a positive weight.
+func (l *One) SetWeights(weights []float64) {
+	if weights == nil {
+		l.weights = nil
+
+		return
+	}
+
+	if len(weights) != len(l.tokens) {
+		panic("every token needs a weight")
+	}
+	for _, w := range weights {
+		if w <= 0 {
+			panic("weights must be positive")
+		}
+	}
+
+	l.weights = weights
+}
+
 // Token interface methods
 
 // Clone returns a copy of the token and all its children
@@ -32,6 +67,10 @@ func (l *One) Clone() token.Token {
 		value:  l.value,
 	}
 
+	if l.weights != nil {
+		c.weights = append([]float64(nil), l.weights...)
+	}
+
 	for i, tok := range l.tokens {
 		c.tokens[i] = tok.Clone()
 	}
@@ -41,23 +80,18 @@ func (l *One) Clone() token.Token {
 
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
+// The alternative which matches the most data is chosen since there is no backtracking.
 func (l *One) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	var nex int
-	var es, errs []error
-
-	for i := range l.tokens {
-		nex, es = l.tokens[i].Parse(pars, cur)
-
-		if len(es) == 0 {
-			l.value = i
-
-			return nex, nil
-		}
-
-		errs = append(errs, es...)
+	best, nex, errs := pars.ParseLongest(cur, len(l.tokens), func(i int) (int, []error) {
+		return l.tokens[i].Parse(pars, cur)
+	})
+	if best == -1 {
+		return cur, errs
 	}
 
-	return cur, errs
+	l.value = best
+
+	return nex, nil
 }
 
 func (l *One) permutation(i uint) {
@@ -143,6 +177,10 @@ func (l *One) InternalLogicalRemove(tok token.Token) token.Token {
 				l.tokens = append(l.tokens[:i], l.tokens[i+1:]...)
 			}
 
+			if l.weights != nil {
+				l.weights = append(l.weights[:i], l.weights[i+1:]...)
+			}
+
 			i--
 		}
 	}
@@ -182,3 +220,10 @@ func (l *One) Minimize() token.Token {
 
 	return nil
 }
+
+// Weighted interface methods
+
+// Weights returns the weights of the permutations beginning with the first permutation, or nil if all permutations are equally weighted
+func (l *One) Weights() []float64 {
+	return l.weights
+}
@@ -54,3 +54,70 @@ func TestOne(t *testing.T) {
 
 	Equal(t, o.Permutation(2).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
 }
+
+func TestOneWeights(t *testing.T) {
+	a := primitives.NewConstantString("a")
+	b := primitives.NewConstantString("b")
+	c := primitives.NewConstantString("c")
+
+	var tok *token.WeightedToken
+
+	Implements(t, tok, &One{})
+
+	o := NewOne(a, b)
+	Nil(t, o.Weights())
+
+	o = NewOneWithWeights([]float64{1, 2, 3}, a, b, c)
+	Equal(t, []float64{1, 2, 3}, o.Weights())
+	Equal(t, 3, o.Permutations())
+
+	o2 := o.Clone().(*One)
+	Equal(t, []float64{1, 2, 3}, o2.Weights())
+
+	Equal(t, o, o.InternalLogicalRemove(b))
+	Equal(t, []float64{1, 3}, o.Weights())
+	Equal(t, []float64{1, 2, 3}, o2.Weights())
+
+	o2.SetWeights([]float64{3, 2, 1})
+	Equal(t, []float64{3, 2, 1}, o2.Weights())
+	o2.SetWeights(nil)
+	Nil(t, o2.Weights())
+
+	Panics(t, func() {
+		o2.SetWeights([]float64{1, 2})
+	})
+	Panics(t, func() {
+		NewOneWithWeights([]float64{1}, a, b)
+	})
+	Panics(t, func() {
+		NewOneWithWeights([]float64{1, 0}, a, b)
+	})
+}
+
+func TestOneParse(t *testing.T) {
+	a := primitives.NewConstantString("1")
+	b := primitives.NewConstantString("10")
+	c := primitives.NewConstantString("2")
+
+	o := NewOne(a, b, c)
+
+	pars := &token.InternalParser{
+		Data:    "102",
+		DataLen: 3,
+	}
+
+	// the longest alternative is chosen
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 2, nex)
+	Equal(t, "10", o.String())
+
+	nex, errs = o.Parse(pars, 2)
+	Nil(t, errs)
+	Equal(t, 3, nex)
+	Equal(t, "2", o.String())
+
+	nex, errs = o.Parse(pars, 3)
+	Equal(t, 3, len(errs))
+	Equal(t, 3, nex)
+}
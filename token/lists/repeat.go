@@ -10,11 +10,13 @@ import (
 )
 
 // Repeat implements a list token which repeats a referenced token by a given range
+// The repetitions can be weighted by a distribution to prefer some repetition counts over others.
 type Repeat struct {
-	from  token.Token
-	to    token.Token
-	token token.Token
-	value []token.Token
+	from         token.Token
+	to           token.Token
+	token        token.Token
+	value        []token.Token
+	distribution Distribution
 
 	reducing              bool
 	reducingOriginalValue []token.Token
@@ -67,15 +69,36 @@ func (l *Repeat) To() int64 {
 	return int64(iTo)
 }
 
+// FromToken returns the token holding the from value of the repeat range
+func (l *Repeat) FromToken() token.Token {
+	return l.from
+}
+
+// ToToken returns the token holding the to value of the repeat range
+func (l *Repeat) ToToken() token.Token {
+	return l.to
+}
+
+// Distribution returns the distribution of the repetitions or nil if all repetitions are equally weighted
+func (l *Repeat) Distribution() Distribution {
+	return l.distribution
+}
+
+// SetDistribution sets the distribution of the repetitions. Nil weights all repetitions equally.
+func (l *Repeat) SetDistribution(distribution Distribution) {
+	l.distribution = distribution
+}
+
 // Token interface methods
 
 // Clone returns a copy of the token and all its children
 func (l *Repeat) Clone() token.Token {
 	c := Repeat{
-		from:  l.from,
-		to:    l.to,
-		token: l.token.Clone(),
-		value: make([]token.Token, len(l.value)),
+		from:         l.from,
+		to:           l.to,
+		token:        l.token.Clone(),
+		value:        make([]token.Token, len(l.value)),
+		distribution: l.distribution,
 	}
 
 	for i, tok := range l.value {
@@ -510,3 +533,22 @@ func (l *Repeat) Reset() {
 		l.permutation(l.Permutations() - 1)
 	}
 }
+
+// Weighted interface methods
+
+// Weights returns the weights of the permutations beginning with the first permutation, or nil if all permutations are equally weighted
+func (l *Repeat) Weights() []float64 {
+	if l.distribution == nil {
+		return nil
+	}
+
+	from, to := l.From(), l.To()
+
+	weights := make([]float64, 0, to-from+1)
+
+	for i := from; i <= to; i++ {
+		weights = append(weights, l.distribution.Weight(i, from, to))
+	}
+
+	return weights
+}
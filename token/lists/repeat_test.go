@@ -399,3 +399,27 @@ func TestRepeatReduce(t *testing.T) {
 
 	Equal(t, 0, m.Release(), "check for goroutine leaks")
 }
+
+func TestRepeatDistribution(t *testing.T) {
+	a := primitives.NewConstantString("a")
+
+	var tok *token.WeightedToken
+
+	Implements(t, tok, &Repeat{})
+
+	o := NewRepeat(a, 1, 3)
+	Nil(t, o.Distribution())
+	Nil(t, o.Weights())
+
+	o.SetDistribution(NewGeometricDistribution(0.5))
+	Equal(t, []float64{0.5, 0.25, 0.125}, o.Weights())
+
+	o2 := o.Clone().(*Repeat)
+	Equal(t, o.Distribution(), o2.Distribution())
+
+	o.SetDistribution(NewHistogramDistribution(
+		HistogramBucket{From: 0, To: 1, Weight: 10},
+		HistogramBucket{From: 3, To: 3, Weight: 2},
+	))
+	Equal(t, []float64{10, 0, 2}, o.Weights())
+}
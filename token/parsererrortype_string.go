@@ -4,9 +4,9 @@ package token
 
 import "fmt"
 
-const _ParserErrorType_name = "ParseErrorNoStartParseErrorNewLineNeededParseErrorEarlyNewLineParseErrorEmptyExpressionIsInvalidParseErrorEmptyStringParseErrorEmptyTokenDefinitionParseErrorInvalidArgumentValueParseErrorInvalidTokenNameParseErrorInvalidTokenTypeParseErrorUnusedTokenParseErrorMissingTypedTokenArgumentParseErrorNonTerminatedStringParseErrorNoTokenForVariableParseErrorNotAlwaysUsedAsAVariableParseErrorRepeatWithOptionalTermParseErrorTokenAlreadyDefinedParseErrorTokenNotDefinedParseErrorTypeNotDefinedForTypedTokenParseErrorExpectRuneParseErrorExpectOperatorParseErrorUnknownBooleanOperatorParseErrorUnknownConditionParseErrorUnkownOperatorParseErrorUnknownTypedTokenArgumentParseErrorUnknownTypedTokenTypeParseErrorUnknownTokenAttributeParseErrorUnexpectedTokenDefinitionTerminationParseErrorExpectedExpressionTermParseErrorEndlessLoopDetectedParseErrorExpectedEOFParseErrorRootIsNilParseErrorUnexpectedEOFParseErrorUnexpectedData"
+const _ParserErrorType_name = "ParseErrorNoStartParseErrorNewLineNeededParseErrorEarlyNewLineParseErrorEmptyExpressionIsInvalidParseErrorEmptyStringParseErrorEmptyTokenDefinitionParseErrorInvalidArgumentValueParseErrorInvalidTokenNameParseErrorInvalidTokenTypeParseErrorUnusedTokenParseErrorMissingTypedTokenArgumentParseErrorNonTerminatedStringParseErrorNoTokenForVariableParseErrorNotAlwaysUsedAsAVariableParseErrorRepeatWithOptionalTermParseErrorTokenAlreadyDefinedParseErrorTokenNotDefinedParseErrorTypeNotDefinedForTypedTokenParseErrorExpectRuneParseErrorExpectOperatorParseErrorUnknownBooleanOperatorParseErrorUnknownConditionParseErrorUnkownOperatorParseErrorUnknownTypedTokenArgumentParseErrorUnknownTypedTokenTypeParseErrorUnknownTokenAttributeParseErrorUnexpectedTokenDefinitionTerminationParseErrorExpectedExpressionTermParseErrorEndlessLoopDetectedParseErrorCannotIncludeFileParseErrorIncludeCycleParseErrorWrongArgumentCountParseErrorRecursiveParameterizedTokenParseErrorInvalidByteLiteralParseErrorUnknownEncodingParseErrorEncodingAfterDefinitionParseErrorLoopAfterUsageParseErrorInvalidWeightParseErrorInvalidDistributionParseErrorUnknownArithmeticParseErrorConditionWithoutIfParseErrorEmptyConditionBodyParseErrorMissingEndifParseErrorInvalidArithmeticOperandParseErrorInvalidCharacterClassParseErrorExpectedEOFParseErrorRootIsNilParseErrorUnexpectedEOFParseErrorUnexpectedData"
 
-var _ParserErrorType_index = [...]uint16{0, 17, 40, 62, 96, 117, 147, 177, 203, 229, 250, 285, 314, 342, 376, 408, 437, 462, 499, 519, 543, 575, 601, 625, 660, 691, 722, 768, 800, 829, 850, 869, 892, 916}
+var _ParserErrorType_index = [...]uint16{0, 17, 40, 62, 96, 117, 147, 177, 203, 229, 250, 285, 314, 342, 376, 408, 437, 462, 499, 519, 543, 575, 601, 625, 660, 691, 722, 768, 800, 829, 856, 878, 906, 943, 971, 996, 1029, 1053, 1076, 1105, 1132, 1160, 1188, 1210, 1244, 1275, 1296, 1315, 1338, 1362}
 
 func (i ParserErrorType) String() string {
 	if i < 0 || i+1 >= ParserErrorType(len(_ParserErrorType_index)) {
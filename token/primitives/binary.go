@@ -0,0 +1,269 @@
+package primitives
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/token"
+)
+
+// BinaryInt implements a fixed-width unsigned integer token holding a range of integers
+// Every permutation generates a new value within the defined range which is serialized with the given width and byte order. For example a 2 byte wide BinaryInt in big-endian byte order with the value 258 is serialized as the bytes 0x01 and 0x02.
+type BinaryInt struct {
+	size  int
+	order binary.ByteOrder
+	from  uint64
+	to    uint64
+
+	value uint64
+}
+
+// NewBinaryInt returns a new instance of a BinaryInt token with the given width in bytes, byte order and range
+// The width must be 1, 2, 4 or 8 bytes, from must not be bigger than to and to must fit into the width, otherwise the function panics.
+func NewBinaryInt(size int, order binary.ByteOrder, from, to uint64) *BinaryInt {
+	if size != 1 && size != 2 && size != 4 && size != 8 {
+		panic("width of binary integer must be 1, 2, 4 or 8 bytes")
+	}
+	if from > to {
+		panic("from must not be bigger than to")
+	}
+	if max := binaryIntMax(size); to > max {
+		panic(fmt.Sprintf("to value %d does not fit into %d bytes", to, size))
+	}
+
+	return &BinaryInt{
+		size:  size,
+		order: order,
+		from:  from,
+		to:    to,
+
+		value: from,
+	}
+}
+
+func binaryIntMax(size int) uint64 {
+	if size == 8 {
+		return math.MaxUint64
+	}
+
+	return 1<<(uint(size)*8) - 1
+}
+
+func init() {
+	register := func(name string, size int, order binary.ByteOrder) {
+		token.RegisterTyped(name, func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+			max := binaryIntMax(size)
+			if max > math.MaxInt64 {
+				max = math.MaxInt64
+			}
+
+			from := argParser.GetInt("from", 0)
+			to := argParser.GetInt("to", int(max))
+
+			if err := argParser.Err(); err != nil {
+				return nil, err
+			}
+
+			if from < 0 || uint64(from) > binaryIntMax(size) {
+				return nil, fmt.Errorf("%q is out of range for %s", "from", name)
+			}
+			if to < 0 || uint64(to) > binaryIntMax(size) {
+				return nil, fmt.Errorf("%q is out of range for %s", "to", name)
+			}
+			if from > to {
+				return nil, fmt.Errorf("%q must not be bigger than %q", "from", "to")
+			}
+
+			if size == 8 && to == int(max) {
+				// the maximum of the argument parser is not the maximum of the type
+				return NewBinaryInt(size, order, uint64(from), math.MaxUint64), nil
+			}
+
+			return NewBinaryInt(size, order, uint64(from), uint64(to)), nil
+		})
+	}
+
+	register("UInt8", 1, binary.BigEndian)
+	register("UInt16BE", 2, binary.BigEndian)
+	register("UInt16LE", 2, binary.LittleEndian)
+	register("UInt32BE", 4, binary.BigEndian)
+	register("UInt32LE", 4, binary.LittleEndian)
+	register("UInt64BE", 8, binary.BigEndian)
+	register("UInt64LE", 8, binary.LittleEndian)
+}
+
+// From returns the from value of the range
+func (p *BinaryInt) From() uint64 {
+	return p.from
+}
+
+// To returns the to value of the range
+func (p *BinaryInt) To() uint64 {
+	return p.to
+}
+
+// Size returns the width of the integer in bytes
+func (p *BinaryInt) Size() int {
+	return p.size
+}
+
+// ByteOrder returns the byte order of the integer
+func (p *BinaryInt) ByteOrder() binary.ByteOrder {
+	return p.order
+}
+
+// Value returns the current value of the token
+func (p *BinaryInt) Value() uint64 {
+	return p.value
+}
+
+// SetValue sets the value of the token
+func (p *BinaryInt) SetValue(v uint64) {
+	p.value = v
+}
+
+func (p *BinaryInt) encode(v uint64) []byte {
+	b := make([]byte, 8)
+
+	switch p.size {
+	case 1:
+		b[0] = byte(v)
+	case 2:
+		p.order.PutUint16(b, uint16(v))
+	case 4:
+		p.order.PutUint32(b, uint32(v))
+	case 8:
+		p.order.PutUint64(b, v)
+	}
+
+	return b[:p.size]
+}
+
+func (p *BinaryInt) decode(b []byte) uint64 {
+	switch p.size {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(p.order.Uint16(b))
+	case 4:
+		return uint64(p.order.Uint32(b))
+	default:
+		return p.order.Uint64(b)
+	}
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (p *BinaryInt) Clone() token.Token {
+	return &BinaryInt{
+		size:  p.size,
+		order: p.order,
+		from:  p.from,
+		to:    p.to,
+
+		value: p.value,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (p *BinaryInt) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	nextIndex := cur + p.size
+
+	if nextIndex > pars.DataLen {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected %d byte integer in range %d-%d but got early EOF", p.size, p.from, p.to),
+			Type:    token.ParseErrorUnexpectedEOF,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	v := p.decode([]byte(pars.Data[cur:nextIndex]))
+
+	if v < p.from || v > p.to {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected %d byte integer in range %d-%d but got %d", p.size, p.from, p.to, v),
+			Type:    token.ParseErrorUnexpectedData,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	p.value = v
+
+	log.Debugf("Parsed %d", p.value)
+
+	return nextIndex, nil
+}
+
+// Permutation sets a specific permutation for this token
+func (p *BinaryInt) Permutation(i uint) error {
+	permutations := p.Permutations()
+
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	i--
+
+	if r := p.to - p.from; r < uint64(permutations) {
+		p.value = p.from + uint64(i)
+	} else if i == permutations-1 {
+		p.value = p.to
+	} else {
+		// the range is bigger than the permutations so they are spread over the whole range
+		p.value = p.from + uint64(i)*(r/uint64(permutations-1))
+	}
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (p *BinaryInt) Permutations() uint {
+	if r := p.to - p.from; r < math.MaxInt32 {
+		return uint(r) + 1
+	}
+
+	return math.MaxInt32
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (p *BinaryInt) PermutationsAll() uint {
+	return p.Permutations()
+}
+
+func (p *BinaryInt) String() string {
+	return string(p.encode(p.value))
+}
+
+// Typed interface methods
+
+// TypedDefinition returns the type and the arguments of a typed token definition which creates an equal token
+func (p *BinaryInt) TypedDefinition() (string, []token.TypedArgument) {
+	typ := fmt.Sprintf("UInt%d", p.size*8)
+	if p.size != 1 {
+		if p.order == binary.LittleEndian {
+			typ += "LE"
+		} else {
+			typ += "BE"
+		}
+	}
+
+	args := []token.TypedArgument{
+		{Name: "from", Value: strconv.FormatUint(p.from, 10)},
+	}
+
+	// the maximum of an 8 byte integer cannot be written as argument but it is the default
+	if p.to != math.MaxUint64 {
+		args = append(args, token.TypedArgument{Name: "to", Value: strconv.FormatUint(p.to, 10)})
+	}
+
+	return typ, args
+}
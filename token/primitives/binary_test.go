@@ -0,0 +1,57 @@
+package primitives
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+)
+
+func TestBinaryTokensToBeTokens(t *testing.T) {
+	var tok *token.Token
+
+	Implements(t, tok, &BinaryInt{})
+}
+
+func TestBinaryInt(t *testing.T) {
+	o := NewBinaryInt(2, binary.BigEndian, 0x0102, 0x0104)
+	Equal(t, "\x01\x02", o.String())
+
+	Equal(t, 3, o.Permutations())
+
+	Nil(t, o.Permutation(1))
+	Equal(t, "\x01\x02", o.String())
+	Nil(t, o.Permutation(3))
+	Equal(t, "\x01\x04", o.String())
+
+	Equal(t, o.Permutation(4).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	// invalid ranges
+	Panics(t, func() {
+		NewBinaryInt(2, binary.BigEndian, 2, 1)
+	})
+
+	// little-endian
+	o = NewBinaryInt(4, binary.LittleEndian, 0x01020304, 0x01020304)
+	Equal(t, "\x04\x03\x02\x01", o.String())
+
+	o = NewBinaryInt(1, binary.BigEndian, 0, 255)
+	Equal(t, 256, o.Permutations())
+	Nil(t, o.Permutation(256))
+	Equal(t, "\xff", o.String())
+
+	// huge ranges are spread over their permutations
+	o = NewBinaryInt(8, binary.BigEndian, 0, math.MaxUint64)
+	Equal(t, math.MaxInt32, o.Permutations())
+	Nil(t, o.Permutation(1))
+	Equal(t, uint64(0), o.Value())
+	Nil(t, o.Permutation(math.MaxInt32))
+	Equal(t, uint64(math.MaxUint64), o.Value())
+	Equal(t, "\xff\xff\xff\xff\xff\xff\xff\xff", o.String())
+}
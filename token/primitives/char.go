@@ -46,19 +46,22 @@ type characterRange struct {
 }
 
 // NewCharacterClass returns a new instance of a CharacterClass token
+// The pattern must be valid, otherwise the function panics. Use ParseCharacterClass for patterns which are not known to be valid.
 func NewCharacterClass(pattern string) *CharacterClass {
-	if pattern == "" {
-		panic("pattern is empty")
+	c, err := ParseCharacterClass(pattern)
+	if err != nil {
+		panic(err)
 	}
 
-	/*
-
-		TODO FIXME FIXME FIXME
-
-		This part of the code is full of bad code, especially the error handling is not addressed at all, we simple panic!
-		There is a time and place where this can be redone, but it is not now and here.
+	return c
+}
 
-	*/
+// ParseCharacterClass returns a new instance of a CharacterClass token for the given pattern
+// The error return argument is not nil if the pattern is invalid.
+func ParseCharacterClass(pattern string) (*CharacterClass, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is empty")
+	}
 
 	var chars []rune
 	var charRanges []characterRange
@@ -70,10 +73,10 @@ func NewCharacterClass(pattern string) *CharacterClass {
 
 	c, _, err := runes.ReadRune()
 
-	add := func(c rune) {
+	add := func(c rune) error {
 		if isRange {
 			if lastChar > c {
-				panic("Range to character is lower than range from character")
+				return fmt.Errorf("range to character %q is lower than range from character %q", c, lastChar)
 			}
 
 			charRanges = append(charRanges, characterRange{
@@ -88,6 +91,8 @@ func NewCharacterClass(pattern string) *CharacterClass {
 		} else {
 			chars = append(chars, c)
 		}
+
+		return nil
 	}
 
 	checkHex := func(c rune) bool {
@@ -97,21 +102,23 @@ func NewCharacterClass(pattern string) *CharacterClass {
 PARSING:
 	for err != io.EOF {
 		if unicode.IsDigit(c) || unicode.IsLetter(c) || unicode.IsSpace(c) {
-			add(c)
+			if err := add(c); err != nil {
+				return nil, err
+			}
 			lastChar = c
 			lastCharIsRangeChar = true
 		} else {
 			switch c {
 			case '-':
 				if !lastCharIsRangeChar {
-					panic("Range operator without range from character")
+					return nil, fmt.Errorf("range operator without range from character")
 				}
 
 				isRange = true
 			case '\\':
 				c, _, err = runes.ReadRune()
 				if err == io.EOF {
-					panic("early EOF for escaped character")
+					return nil, fmt.Errorf("early EOF for escaped character")
 				} else if err != nil {
 					break PARSING
 				}
@@ -120,7 +127,7 @@ PARSING:
 				case 'x':
 					x, _, err := runes.ReadRune()
 					if err == io.EOF {
-						panic("early EOF for escaped character")
+						return nil, fmt.Errorf("early EOF for escaped character")
 					} else if err != nil {
 						break PARSING
 					}
@@ -131,35 +138,35 @@ PARSING:
 						for {
 							x, _, err = runes.ReadRune()
 							if err == io.EOF {
-								panic("early EOF for escaped character")
+								return nil, fmt.Errorf("early EOF for escaped character")
 							} else if err != nil {
 								break PARSING
 							} else if x == '}' {
 								break
 							} else if !checkHex(x) {
-								panic("x escaping needs HEX characters")
+								return nil, fmt.Errorf("x escaping needs HEX characters")
 							}
 
 							xses += string(x)
 						}
 
 						if len(xses) < 2 {
-							panic("x escaping needs two HEX characters")
+							return nil, fmt.Errorf("x escaping needs two HEX characters")
 						}
 					} else {
 						if !checkHex(x) {
-							panic("x escaping needs two HEX characters")
+							return nil, fmt.Errorf("x escaping needs two HEX characters")
 						}
 
 						xses += string(x)
 
 						x, _, err = runes.ReadRune()
 						if err == io.EOF {
-							panic("early EOF for escaped character")
+							return nil, fmt.Errorf("early EOF for escaped character")
 						} else if err != nil {
 							break PARSING
 						} else if !checkHex(x) {
-							panic("x escaping needs two HEX characters")
+							return nil, fmt.Errorf("x escaping needs two HEX characters")
 						}
 
 						xses += string(x)
@@ -167,38 +174,44 @@ PARSING:
 
 					s, e := strconv.Unquote(`"\U` + strings.Repeat("0", 8-len(xses)) + xses + `"`)
 					if e != nil {
-						panic(e)
+						return nil, fmt.Errorf("invalid character \\x{%s}", xses)
 					}
 
 					c, _ = utf8.DecodeRuneInString(s)
 
-					add(c)
+					if err := add(c); err != nil {
+						return nil, err
+					}
 					lastChar = c
 					lastCharIsRangeChar = true
 				default:
 					if simp, ok := simpleEscapes[c]; ok {
-						add(simp)
+						if err := add(simp); err != nil {
+							return nil, err
+						}
 						lastChar = simp
 						lastCharIsRangeChar = true
 					} else {
 						if isRange {
-							panic("Range operator without range to character")
+							return nil, fmt.Errorf("range operator without range to character")
 						}
 
 						esc, ok := characterClassEscapes[c]
 						if !ok {
-							panic(fmt.Sprintf("Unknown escape character %q", c))
+							return nil, fmt.Errorf("unknown escape character %q", c)
 						}
 
 						for _, v := range esc {
-							add(v)
+							if err := add(v); err != nil {
+								return nil, err
+							}
 						}
 
 						lastCharIsRangeChar = false
 					}
 				}
 			default:
-				panic(fmt.Sprintf("Unknown character %q", c))
+				return nil, fmt.Errorf("unknown character %q", c)
 			}
 		}
 
@@ -206,11 +219,11 @@ PARSING:
 	}
 
 	if err != nil && err != io.EOF {
-		panic(err)
+		return nil, err
 	}
 
 	if len(chars) == 0 && len(charRanges) == 0 {
-		panic("empty character class is not allowed")
+		return nil, fmt.Errorf("empty character class is not allowed")
 	}
 
 	var first rune
@@ -242,7 +255,12 @@ PARSING:
 		pattern: pattern,
 
 		value: first,
-	}
+	}, nil
+}
+
+// Pattern returns the pattern of the character class
+func (c *CharacterClass) Pattern() string {
+	return c.pattern
 }
 
 // Clone returns a copy of the token and all its children
@@ -287,8 +305,11 @@ func (c *CharacterClass) Parse(pars *token.InternalParser, cur int) (int, []erro
 		}}
 	}
 
-	// TODO FIXME NOW we can see the need to put pars.Data into a reader... since we cannot do a readRune here!
-	v := rune(pars.Data[cur])
+	v, size := utf8.DecodeRuneInString(pars.Data[cur:])
+	if v == utf8.RuneError && size <= 1 {
+		// the data is not valid UTF-8 so we match the byte itself
+		v, size = rune(pars.Data[cur]), 1
+	}
 
 	if _, ok := c.charsLookup[v]; !ok {
 		found := false
@@ -315,7 +336,7 @@ func (c *CharacterClass) Parse(pars *token.InternalParser, cur int) (int, []erro
 
 	log.Debugf("Parsed %q", v)
 
-	return cur + 1, nil
+	return cur + size, nil
 }
 
 func (c *CharacterClass) permutation(i uint) {
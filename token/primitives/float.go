@@ -0,0 +1,437 @@
+package primitives
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/token"
+)
+
+// FloatFormatDecimal formats floating point values without exponent, e.g. 123.45
+const FloatFormatDecimal = 'f'
+
+// FloatFormatScientific formats floating point values with exponent, e.g. 1.2345e+02
+const FloatFormatScientific = 'e'
+
+// maxFloatPermutations is the maximum number of range values of a RangeFloat token
+const maxFloatPermutations = math.MaxUint32
+
+// FloatSpecials holds the special floating point values which can be added to a RangeFloat token
+var FloatSpecials = map[string]float64{
+	"NaN":       math.NaN(),
+	"+Inf":      math.Inf(1),
+	"-Inf":      math.Inf(-1),
+	"-0":        math.Copysign(0, -1),
+	"subnormal": math.SmallestNonzeroFloat64,
+	"max":       math.MaxFloat64,
+	"min":       -math.MaxFloat64,
+}
+
+// floatSpecialsOrder defines the order of the special floating point values for "all"
+var floatSpecialsOrder = []string{"NaN", "+Inf", "-Inf", "-0", "subnormal", "max", "min"}
+
+func formatFloat(value float64, format byte, precision int) string {
+	return strconv.FormatFloat(value, format, precision, 64)
+}
+
+// ConstantFloat implements a floating point token which holds a constant floating point value
+type ConstantFloat struct {
+	value     float64
+	format    byte
+	precision int
+}
+
+// NewConstantFloat returns a new instance of a ConstantFloat token
+// The format is either FloatFormatDecimal or FloatFormatScientific. A precision of -1 uses the smallest number of digits necessary to represent the value.
+func NewConstantFloat(value float64, format byte, precision int) *ConstantFloat {
+	return &ConstantFloat{
+		value:     value,
+		format:    format,
+		precision: precision,
+	}
+}
+
+// SetValue sets the value of the token
+func (p *ConstantFloat) SetValue(v float64) {
+	p.value = v
+}
+
+// Value returns the value of the token
+func (p *ConstantFloat) Value() float64 {
+	return p.value
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (p *ConstantFloat) Clone() token.Token {
+	return &ConstantFloat{
+		value:     p.value,
+		format:    p.format,
+		precision: p.precision,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (p *ConstantFloat) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return pars.ParseValue(p.String(), cur)
+}
+
+// Permutation sets a specific permutation for this token
+func (p *ConstantFloat) Permutation(i uint) error {
+	permutations := p.Permutations()
+
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (p *ConstantFloat) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (p *ConstantFloat) PermutationsAll() uint {
+	return p.Permutations()
+}
+
+func (p *ConstantFloat) String() string {
+	return formatFloat(p.value, p.format, p.precision)
+}
+
+// RangeFloat implements a floating point token holding a range of floating point values
+// Every permutation generates a new value within the defined range. If the decimal format with a precision is used the values are spaced by the smallest step of the precision, e.g. the range 1 to 2 with precision 1 holds the values 1.0, 1.1, ... 2.0. Otherwise or if there would be too many values the range is divided into equally spaced values. Additional special values like NaN or infinity can be added which are generated after the range values.
+type RangeFloat struct {
+	from      float64
+	to        float64
+	format    byte
+	precision int
+	specials  []float64
+
+	step         float64
+	permutations uint
+
+	value float64
+}
+
+// NewRangeFloat returns a new instance of a RangeFloat token with the given range, output format and precision
+// The format is either FloatFormatDecimal or FloatFormatScientific. A precision of -1 uses the smallest number of digits necessary to represent each value.
+func NewRangeFloat(from, to float64, format byte, precision int) *RangeFloat {
+	return NewRangeFloatWithSpecials(from, to, format, precision, nil)
+}
+
+// NewRangeFloatWithSpecials returns a new instance of a RangeFloat token with the given range, output format, precision and special values
+// The range must be finite and from must not be bigger than to, otherwise the function panics.
+func NewRangeFloatWithSpecials(from, to float64, format byte, precision int, specials []float64) *RangeFloat {
+	if from > to {
+		panic("from must not be bigger than to")
+	}
+	if math.IsInf(from, 0) || math.IsInf(to, 0) || math.IsNaN(from) || math.IsNaN(to) || math.IsInf(to-from, 0) {
+		panic("range must be finite")
+	}
+	if format != FloatFormatDecimal && format != FloatFormatScientific {
+		panic(fmt.Sprintf("unknown float format %q", format))
+	}
+
+	p := &RangeFloat{
+		from:      from,
+		to:        to,
+		format:    format,
+		precision: precision,
+		specials:  specials,
+
+		value: from,
+	}
+
+	switch {
+	case from == to:
+		p.permutations = 1
+	case format == FloatFormatDecimal && precision >= 0 && (to-from)/math.Pow10(-precision) < maxFloatPermutations:
+		p.step = math.Pow10(-precision)
+		p.permutations = uint(math.Floor((to-from)/p.step+1e-9)) + 1
+	default:
+		p.permutations = maxFloatPermutations
+		p.step = (to - from) / float64(maxFloatPermutations-1)
+	}
+
+	return p
+}
+
+func init() {
+	token.RegisterTyped("Float", func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+		from := argParser.GetFloat("from", 0)
+		to := argParser.GetFloat("to", math.MaxInt32)
+		precision := argParser.GetInt("precision", -1)
+		format := argParser.GetString("format", "decimal")
+		specialNames := argParser.GetStringList("specials", nil)
+
+		if err := argParser.Err(); err != nil {
+			return nil, err
+		}
+
+		if from > to {
+			return nil, fmt.Errorf("%q must not be bigger than %q", "from", "to")
+		}
+		if math.IsInf(to-from, 0) {
+			return nil, fmt.Errorf("range from %g to %g is too big", from, to)
+		}
+		if precision < -1 {
+			return nil, fmt.Errorf("%q must be -1 or positive", "precision")
+		}
+
+		var f byte
+		switch format {
+		case "decimal":
+			f = FloatFormatDecimal
+		case "scientific":
+			f = FloatFormatScientific
+		default:
+			return nil, fmt.Errorf("unknown format %q, expected decimal or scientific", format)
+		}
+
+		var specials []float64
+		for _, name := range specialNames {
+			if name == "all" {
+				for _, name := range floatSpecialsOrder {
+					specials = append(specials, FloatSpecials[name])
+				}
+
+				continue
+			}
+
+			special, ok := FloatSpecials[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown special value %q, expected one of all, %s", name, strings.Join(floatSpecialsOrder, ", "))
+			}
+
+			specials = append(specials, special)
+		}
+
+		return NewRangeFloatWithSpecials(from, to, f, precision, specials), nil
+	})
+}
+
+// From returns the from value of the range
+func (p *RangeFloat) From() float64 {
+	return p.from
+}
+
+// To returns the to value of the range
+func (p *RangeFloat) To() float64 {
+	return p.to
+}
+
+// Format returns the output format of the token
+func (p *RangeFloat) Format() byte {
+	return p.format
+}
+
+// Precision returns the precision of the token
+func (p *RangeFloat) Precision() int {
+	return p.precision
+}
+
+// Specials returns the special values of the token
+func (p *RangeFloat) Specials() []float64 {
+	return p.specials
+}
+
+// RangePermutations returns the number of permutations for the range values of this token without the special values
+func (p *RangeFloat) RangePermutations() uint {
+	return p.permutations
+}
+
+// Value returns the current value of the token
+func (p *RangeFloat) Value() float64 {
+	return p.value
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (p *RangeFloat) Clone() token.Token {
+	return &RangeFloat{
+		from:      p.from,
+		to:        p.to,
+		format:    p.format,
+		precision: p.precision,
+		specials:  p.specials,
+
+		step:         p.step,
+		permutations: p.permutations,
+
+		value: p.value,
+	}
+}
+
+func isFloatCharacter(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '.' || c == '-' || c == '+' || c == 'e' || c == 'E'
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (p *RangeFloat) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	if cur == pars.DataLen {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected float in range %g-%g but got early EOF", p.from, p.to),
+			Type:    token.ParseErrorUnexpectedEOF,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	// the longest special value wins
+	matched := -1
+	for i, special := range p.specials {
+		s := formatFloat(special, p.format, -1)
+
+		if strings.HasPrefix(pars.Data[cur:], s) && (matched == -1 || len(s) > len(formatFloat(p.specials[matched], p.format, -1))) {
+			matched = i
+		}
+	}
+
+	end := cur
+	for end < pars.DataLen && isFloatCharacter(pars.Data[end]) {
+		end++
+	}
+
+	// try the longest valid float first
+	for i := end; i > cur; i-- {
+		v := pars.Data[cur:i]
+
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < p.from || f > p.to || formatFloat(f, p.format, p.precision) != v {
+			continue
+		}
+
+		if matched != -1 && len(formatFloat(p.specials[matched], p.format, -1)) > len(v) {
+			break
+		}
+
+		p.value = f
+
+		log.Debugf("Parsed %q", v)
+
+		return i, nil
+	}
+
+	if matched != -1 {
+		p.value = p.specials[matched]
+
+		log.Debugf("Parsed %q", p.String())
+
+		return cur + len(p.String()), nil
+	}
+
+	return cur, []error{&token.ParserError{
+		Message: fmt.Sprintf("expected float in range %g-%g but got %q", p.from, p.to, pars.Data[cur:end]),
+		Type:    token.ParseErrorUnexpectedData,
+
+		Position: pars.GetPosition(cur),
+	}}
+}
+
+func (p *RangeFloat) permutation(i uint) {
+	if i >= p.permutations {
+		p.value = p.specials[i-p.permutations]
+
+		return
+	}
+
+	if i == p.permutations-1 && p.step != 0 && p.precision < 0 {
+		p.value = p.to
+
+		return
+	}
+
+	p.value = p.from + float64(i)*p.step
+	if p.value > p.to {
+		p.value = p.to
+	}
+}
+
+// Permutation sets a specific permutation for this token
+func (p *RangeFloat) Permutation(i uint) error {
+	permutations := p.Permutations()
+
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	p.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (p *RangeFloat) Permutations() uint {
+	return p.permutations + uint(len(p.specials))
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (p *RangeFloat) PermutationsAll() uint {
+	return p.Permutations()
+}
+
+func (p *RangeFloat) String() string {
+	for _, special := range p.specials {
+		if math.Float64bits(special) == math.Float64bits(p.value) || (math.IsNaN(special) && math.IsNaN(p.value)) {
+			return formatFloat(p.value, p.format, -1)
+		}
+	}
+
+	return formatFloat(p.value, p.format, p.precision)
+}
+
+// Typed interface methods
+
+// TypedDefinition returns the type and the arguments of a typed token definition which creates an equal token
+func (p *RangeFloat) TypedDefinition() (string, []token.TypedArgument) {
+	format := "decimal"
+	if p.format == FloatFormatScientific {
+		format = "scientific"
+	}
+
+	args := []token.TypedArgument{
+		{Name: "from", Value: strconv.FormatFloat(p.from, 'g', -1, 64)},
+		{Name: "to", Value: strconv.FormatFloat(p.to, 'g', -1, 64)},
+		{Name: "precision", Value: strconv.Itoa(p.precision)},
+		{Name: "format", Value: format},
+	}
+
+	if len(p.specials) != 0 {
+		names := make([]string, len(p.specials))
+
+	SPECIALS:
+		for i, special := range p.specials {
+			for _, name := range floatSpecialsOrder {
+				v := FloatSpecials[name]
+
+				if (math.IsNaN(v) && math.IsNaN(special)) || (v == special && math.Signbit(v) == math.Signbit(special)) {
+					names[i] = strconv.Quote(name)
+
+					continue SPECIALS
+				}
+			}
+
+			panic(fmt.Sprintf("unknown special value %g", special))
+		}
+
+		args = append(args, token.TypedArgument{Name: "specials", Value: "[" + strings.Join(names, ", ") + "]"})
+	}
+
+	return "Float", args
+}
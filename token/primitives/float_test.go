@@ -0,0 +1,141 @@
+package primitives
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+)
+
+func TestFloatTokensToBeTokens(t *testing.T) {
+	var tok *token.Token
+
+	Implements(t, tok, &ConstantFloat{})
+	Implements(t, tok, &RangeFloat{})
+}
+
+func TestConstantFloat(t *testing.T) {
+	o := NewConstantFloat(1.5, FloatFormatDecimal, 2)
+	Equal(t, "1.50", o.String())
+
+	Equal(t, 1, o.Permutations())
+
+	Nil(t, o.Permutation(1))
+	Equal(t, "1.50", o.String())
+
+	Equal(t, o.Permutation(2).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	o = NewConstantFloat(1500, FloatFormatScientific, -1)
+	Equal(t, "1.5e+03", o.String())
+}
+
+func TestRangeFloat(t *testing.T) {
+	Panics(t, func() {
+		NewRangeFloat(2, 1, FloatFormatDecimal, 1)
+	})
+
+	o := NewRangeFloat(1, 2, FloatFormatDecimal, 1)
+	Equal(t, "1.0", o.String())
+
+	Equal(t, 11, o.Permutations())
+
+	Nil(t, o.Permutation(1))
+	Equal(t, "1.0", o.String())
+	Nil(t, o.Permutation(4))
+	Equal(t, "1.3", o.String())
+	Nil(t, o.Permutation(11))
+	Equal(t, "2.0", o.String())
+
+	Equal(t, o.Permutation(12).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	// scientific format
+	o = NewRangeFloat(0, 1000, FloatFormatScientific, 2)
+	Equal(t, maxFloatPermutations, o.Permutations())
+	Nil(t, o.Permutation(o.Permutations()))
+	Equal(t, "1.00e+03", o.String())
+
+	// without precision
+	o = NewRangeFloat(-1, 1, FloatFormatDecimal, -1)
+	Equal(t, maxFloatPermutations, o.Permutations())
+	Nil(t, o.Permutation(1))
+	Equal(t, "-1", o.String())
+	Nil(t, o.Permutation(o.Permutations()))
+	Equal(t, "1", o.String())
+
+	// special values
+	o = NewRangeFloatWithSpecials(0, 1, FloatFormatDecimal, 0, []float64{
+		FloatSpecials["NaN"],
+		FloatSpecials["+Inf"],
+		FloatSpecials["-Inf"],
+		FloatSpecials["-0"],
+		FloatSpecials["subnormal"],
+		FloatSpecials["max"],
+	})
+	Equal(t, 8, o.Permutations())
+
+	var got []string
+	for i := uint(1); i <= o.Permutations(); i++ {
+		Nil(t, o.Permutation(i))
+
+		got = append(got, o.String())
+	}
+	Equal(t, []string{"0", "1", "NaN", "+Inf", "-Inf", "-0", "0." + strings.Repeat("0", 323) + "5", "179769313486231570" + strings.Repeat("0", 291)}, got)
+
+	Nil(t, o.Permutation(3))
+	True(t, math.IsNaN(o.Value()))
+}
+
+func TestRangeFloatParse(t *testing.T) {
+	o := NewRangeFloatWithSpecials(-1, 1, FloatFormatDecimal, 2, []float64{
+		FloatSpecials["NaN"],
+		FloatSpecials["-Inf"],
+	})
+
+	for _, tc := range []struct {
+		data string
+		nex  int
+	}{
+		{"0.50", 4},
+		{"-1.00", 5},
+		{"1.00,", 4},
+		{"0.501", 4},
+		{"NaN", 3},
+		{"-Inf", 4},
+	} {
+		pars := &token.InternalParser{
+			Data:    tc.data,
+			DataLen: len(tc.data),
+		}
+
+		nex, errs := o.Parse(pars, 0)
+		Nil(t, errs, tc.data)
+		Equal(t, tc.nex, nex, tc.data)
+		Equal(t, tc.data[:tc.nex], o.String(), tc.data)
+	}
+
+	for _, data := range []string{
+		"",
+		"0.5",
+		"1.01",
+		"+Inf",
+		"abc",
+	} {
+		pars := &token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}
+
+		nex, errs := o.Parse(pars, 0)
+		NotNil(t, errs, data)
+		Equal(t, 0, nex, data)
+	}
+}
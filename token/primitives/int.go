@@ -111,9 +111,10 @@ type RangeInt struct {
 }
 
 // NewRangeInt returns a new instance of a RangeInt token with the given range and step value of 1
+// From must not be bigger than to, otherwise the function panics.
 func NewRangeInt(from, to int) *RangeInt {
 	if from > to {
-		panic("TODO implement that From can be bigger than To")
+		panic("from must not be bigger than to")
 	}
 
 	return &RangeInt{
@@ -126,12 +127,13 @@ func NewRangeInt(from, to int) *RangeInt {
 }
 
 // NewRangeIntWithStep returns a new instance of a RangeInt token with the given range and step value
+// From must not be bigger than to and the step must be positive, otherwise the function panics.
 func NewRangeIntWithStep(from, to, step int) *RangeInt {
 	if from > to {
-		panic("TODO implement that From can be bigger than To")
+		panic("from must not be bigger than to")
 	}
 	if step < 1 {
-		panic("TODO implement 0 and negative step")
+		panic("step must be positive")
 	}
 
 	return &RangeInt{
@@ -153,6 +155,13 @@ func init() {
 			return nil, err
 		}
 
+		if from > to {
+			return nil, fmt.Errorf("%q must not be bigger than %q", "from", "to")
+		}
+		if step < 1 {
+			return nil, fmt.Errorf("%q must be positive", "step")
+		}
+
 		return NewRangeIntWithStep(from, to, step), nil
 	})
 }
@@ -286,3 +295,14 @@ func (p *RangeInt) PermutationsAll() uint {
 func (p *RangeInt) String() string {
 	return strconv.Itoa(p.value)
 }
+
+// Typed interface methods
+
+// TypedDefinition returns the type and the arguments of a typed token definition which creates an equal token
+func (p *RangeInt) TypedDefinition() (string, []token.TypedArgument) {
+	return "Int", []token.TypedArgument{
+		{Name: "from", Value: strconv.Itoa(p.from)},
+		{Name: "to", Value: strconv.Itoa(p.to)},
+		{Name: "step", Value: strconv.Itoa(p.step)},
+	}
+}
@@ -0,0 +1,246 @@
+package primitives
+
+import (
+	"github.com/zimmski/tavor"
+	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/token"
+)
+
+// loopTarget holds the referenced token definition and the depth bound which are shared by all clones of a Loop token
+type loopTarget struct {
+	token    token.Token
+	maxDepth int
+}
+
+// Loop implements a token which references a token definition recursively without unrolling the definition in advance
+// The referenced definition is cloned lazily whenever a permutation is set, the token is parsed or the token is serialized for the first time. Every Loop token of the clone has a depth which is one higher than the depth of the expanded Loop token. If the depth bound is reached, all Loop tokens of the clone are removed so that the recursion ends.
+type Loop struct {
+	target *loopTarget
+	depth  int
+
+	expanded  bool
+	expansion token.Token
+}
+
+// NewLoop returns a new instance of a Loop token with the given depth bound but without a referenced token definition
+// If the depth bound is 0, tavor.MaxRepeat is used as depth bound.
+func NewLoop(maxDepth int) *Loop {
+	return &Loop{
+		target: &loopTarget{
+			maxDepth: maxDepth,
+		},
+	}
+}
+
+// Depth returns the depth of the token
+func (l *Loop) Depth() int {
+	return l.depth
+}
+
+// MaxDepth returns the depth bound of the token
+func (l *Loop) MaxDepth() int {
+	if l.target.maxDepth == 0 {
+		return tavor.MaxRepeat
+	}
+
+	return l.target.maxDepth
+}
+
+// Target returns the referenced token definition
+func (l *Loop) Target() token.Token {
+	return l.target.token
+}
+
+// SetTarget sets the referenced token definition. The definition is shared by all clones of the token.
+func (l *Loop) SetTarget(tok token.Token) {
+	l.target.token = tok
+}
+
+func (l *Loop) expand() {
+	l.expanded = true
+
+	if l.target.token == nil {
+		panic("Loop token does not have a referencing token")
+	}
+
+	l.expansion = l.target.token.Clone()
+
+	type parent struct {
+		tok    token.Token
+		parent *parent
+	}
+
+	var loops []*Loop
+	var loopParents []*parent
+
+	var find func(tok token.Token, p *parent)
+	find = func(tok token.Token, p *parent) {
+		switch t := tok.(type) {
+		case *Loop:
+			loops = append(loops, t)
+			loopParents = append(loopParents, p)
+		case token.ForwardToken:
+			if v := t.InternalGet(); v != nil {
+				find(v, &parent{tok: t, parent: p})
+			}
+		case token.ListToken:
+			for i := 0; i < t.InternalLen(); i++ {
+				c, _ := t.InternalGet(i)
+
+				find(c, &parent{tok: t, parent: p})
+			}
+		}
+	}
+	find(l.expansion, nil)
+
+	depth := l.depth + 1
+
+	if depth < l.MaxDepth() {
+		for _, c := range loops {
+			c.depth = depth
+			c.expanded = false
+			c.expansion = nil
+		}
+
+		return
+	}
+
+	log.Debugf("reached max depth of %d for (%p)%#v", l.MaxDepth(), l, l)
+
+	// the depth bound is reached so the recursion must end here
+	for i, c := range loops {
+		var ta token.Token = c
+
+		p := loopParents[i]
+
+	REMOVE:
+		for p != nil {
+			var r token.Token
+
+			switch t := p.tok.(type) {
+			case token.ForwardToken:
+				r = t.InternalLogicalRemove(ta)
+			case token.ListToken:
+				r = t.InternalLogicalRemove(ta)
+			}
+
+			if r != nil {
+				break REMOVE
+			}
+
+			ta = p.tok
+			p = p.parent
+		}
+
+		if p == nil {
+			// the whole expansion consists only of recursions
+			l.expansion = nil
+
+			return
+		}
+	}
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (l *Loop) Clone() token.Token {
+	c := &Loop{
+		target: l.target, // do not clone further since the definition is shared
+		depth:  l.depth,
+
+		expanded: l.expanded,
+	}
+
+	if l.expansion != nil {
+		c.expansion = l.expansion.Clone()
+	}
+
+	return c
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (l *Loop) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	l.expand()
+
+	if l.expansion == nil {
+		return cur, nil
+	}
+
+	return l.expansion.Parse(pars, cur)
+}
+
+// Permutation sets a specific permutation for this token
+func (l *Loop) Permutation(i uint) error {
+	permutations := l.Permutations()
+
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	// every permutation is a fresh expansion of the referenced definition
+	l.expand()
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (l *Loop) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+// Since the token is expanded lazily, only the permutations of an already expanded token are counted.
+func (l *Loop) PermutationsAll() uint {
+	if l.expansion == nil {
+		return 1
+	}
+
+	return l.expansion.PermutationsAll()
+}
+
+func (l *Loop) String() string {
+	if !l.expanded {
+		l.expand()
+	}
+
+	if l.expansion == nil {
+		return ""
+	}
+
+	return l.expansion.String()
+}
+
+// ForwardToken interface methods
+
+// Get returns the current referenced token
+// The token is nil if the Loop token was not expanded yet.
+func (l *Loop) Get() token.Token {
+	return l.expansion
+}
+
+// InternalGet returns the current referenced internal token
+func (l *Loop) InternalGet() token.Token {
+	return l.expansion
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (l *Loop) InternalLogicalRemove(tok token.Token) token.Token {
+	if l.expansion == tok {
+		return nil
+	}
+
+	return l
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (l *Loop) InternalReplace(oldToken, newToken token.Token) error {
+	if l.expansion == oldToken {
+		l.expansion = newToken
+	}
+
+	return nil
+}
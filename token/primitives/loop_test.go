@@ -0,0 +1,56 @@
+package primitives_test
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestLoopTokensToBeTokens(t *testing.T) {
+	var tok *token.Token
+
+	Implements(t, tok, &primitives.Loop{})
+
+	var forward *token.ForwardToken
+
+	Implements(t, forward, &primitives.Loop{})
+}
+
+func TestLoop(t *testing.T) {
+	o := primitives.NewLoop(3)
+	Equal(t, 3, o.MaxDepth())
+	Equal(t, 0, o.Depth())
+	Nil(t, o.Get())
+
+	// A = "a" A | "b" with o as usage of A
+	def := primitives.NewScope(lists.NewOne(
+		lists.NewAll(primitives.NewConstantString("a"), o.Clone()),
+		primitives.NewConstantString("b"),
+	))
+	o.SetTarget(def)
+	Equal(t, def, o.Target())
+
+	Equal(t, 1, o.Permutations())
+	Equal(t, o.Permutation(2).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	Nil(t, o.Permutation(1))
+	NotNil(t, o.Get())
+	True(t, o.Get() != def)
+	Equal(t, "aab", o.String())
+	Equal(t, 3, o.PermutationsAll())
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+	Equal(t, 3, o2.PermutationsAll())
+
+	// the recursion ends with an empty token if there is no alternative
+	o = primitives.NewLoop(1)
+	o.SetTarget(lists.NewAll(primitives.NewConstantString("a"), o.Clone()))
+
+	Equal(t, "", o.String())
+	Nil(t, o.Get())
+}
@@ -0,0 +1,743 @@
+package primitives
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/token"
+)
+
+// maxTimeLayoutOverhead is the maximum length difference between a time layout and a formatted time which is considered while parsing
+const maxTimeLayoutOverhead = 32
+
+// RangeTime implements a time token holding a range of points in time
+// Every permutation generates a new point in time within the defined range and step which is formatted with the given Go time layout. Additional edge cases which lie within the range are generated after the range values. These are the Unix epoch, the last second representable by a signed 32-bit Unix timestamp, leap days and the transitions between standard and daylight saving time of the location. Edge cases which are formatted like a range value or another edge case are left out.
+type RangeTime struct {
+	from      time.Time
+	to        time.Time
+	step      time.Duration
+	layout    string
+	location  *time.Location
+	withEdges bool
+	edges     []time.Time
+
+	value time.Time
+}
+
+// NewRangeTime returns a new instance of a RangeTime token with the given range, step and layout
+// If edges is true edge cases within the range are generated in addition to the range values. From must not be after to and the step must be positive, otherwise the function panics.
+func NewRangeTime(from, to time.Time, step time.Duration, layout string, edges bool) *RangeTime {
+	if from.After(to) {
+		panic("from must not be after to")
+	}
+	if step < 1 {
+		panic("step must be positive")
+	}
+	if d := to.Sub(from); d == math.MaxInt64 {
+		panic("range is too big")
+	}
+
+	p := &RangeTime{
+		from:      from,
+		to:        to,
+		step:      step,
+		layout:    layout,
+		location:  from.Location(),
+		withEdges: edges,
+
+		value: from,
+	}
+
+	if edges {
+		formatted := make(map[string]struct{})
+
+		for _, e := range timeEdges(from, to) {
+			v := e.Format(layout)
+
+			// edge cases which are formatted like range values or previous edge cases are already generated
+			if _, ok := formatted[v]; ok || p.rangeValue(e, v) {
+				continue
+			}
+
+			formatted[v] = struct{}{}
+			p.edges = append(p.edges, e)
+		}
+	}
+
+	return p
+}
+
+// timeEdges returns the edge cases between from and to including both sorted in ascending order
+func timeEdges(from, to time.Time) []time.Time {
+	var edges []time.Time
+
+	loc := from.Location()
+
+	add := func(t time.Time) {
+		if !t.Before(from) && !t.After(to) {
+			edges = append(edges, t.In(loc))
+		}
+	}
+
+	// Unix epoch and the limits of signed 32-bit Unix timestamps
+	add(time.Unix(-1, 0))
+	add(time.Unix(0, 0))
+	add(time.Unix(math.MaxInt32, 0))
+	add(time.Unix(math.MaxInt32+1, 0))
+
+	// leap days
+	for year := from.Year(); year <= to.Year(); year++ {
+		if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+			add(time.Date(year, time.February, 29, 0, 0, 0, 0, loc))
+			add(time.Date(year, time.February, 29, 23, 59, 59, 0, loc))
+		}
+	}
+
+	// transitions between standard and daylight saving time
+	if loc != time.UTC {
+		_, offset := from.Zone()
+
+		for t := from; !t.After(to); {
+			next := t.Add(24 * time.Hour)
+			if next.After(to) {
+				next = to
+			}
+
+			if _, o := next.Zone(); o != offset {
+				// bisect the exact second of the transition
+				l, h := t, next
+				for h.Sub(l) > time.Second {
+					m := l.Add(h.Sub(l) / 2).Truncate(time.Second)
+					if m.Equal(l) {
+						m = l.Add(time.Second)
+					}
+
+					if _, o := m.Zone(); o == offset {
+						l = m
+					} else {
+						h = m
+					}
+				}
+
+				add(h.Add(-time.Second))
+				add(h)
+
+				offset = o
+			}
+
+			if next.Equal(to) {
+				break
+			}
+
+			t = next
+		}
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].Before(edges[j])
+	})
+
+	return edges
+}
+
+func parseTimeArguments(argParser token.ArgumentsTypedParser, defaultLayout string, defaultFrom string, defaultTo string, defaultStep string) (*RangeTime, error) {
+	layout := argParser.GetString("layout", defaultLayout)
+	from := argParser.GetString("from", defaultFrom)
+	to := argParser.GetString("to", defaultTo)
+	step := argParser.GetString("step", defaultStep)
+	location := argParser.GetString("location", "UTC")
+	edges := argParser.GetBool("edges", true)
+
+	if err := argParser.Err(); err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(location)
+	if err != nil {
+		return nil, fmt.Errorf("unknown location %q: %s", location, err)
+	}
+
+	// the bounds are given in the layout of the token if possible
+	parse := func(name string, value string, defaultValue string) (time.Time, error) {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+		if value == defaultValue {
+			if t, err := time.ParseInLocation(defaultLayout, value, loc); err == nil {
+				return t, nil
+			}
+		}
+
+		return time.Time{}, fmt.Errorf("%q value %q does not match the layout %q", name, value, layout)
+	}
+
+	f, err := parse("from", from, defaultFrom)
+	if err != nil {
+		return nil, err
+	}
+	t, err := parse("to", to, defaultTo)
+	if err != nil {
+		return nil, err
+	}
+
+	// bounds with explicit offsets are moved into the location
+	f, t = f.In(loc), t.In(loc)
+
+	s, err := time.ParseDuration(step)
+	if err != nil || s < 1 {
+		return nil, fmt.Errorf("%q needs a positive duration like 1s or 24h", "step")
+	}
+
+	if f.After(t) {
+		return nil, fmt.Errorf("%q must not be after %q", "from", "to")
+	}
+	if t.Sub(f) == math.MaxInt64 {
+		return nil, fmt.Errorf("range from %s to %s is too big", from, to)
+	}
+
+	return NewRangeTime(f, t, s, layout, edges), nil
+}
+
+// timeTypes holds the typed token names of RangeTime tokens with their default layout, bounds and step
+var timeTypes = []struct {
+	name   string
+	layout string
+	from   string
+	to     string
+	step   string
+}{
+	{"DateTime", time.RFC3339, "1970-01-01T00:00:00Z", "2038-01-19T03:14:07Z", "1s"},
+	{"Date", "2006-01-02", "1970-01-01", "2038-01-19", "24h"},
+	{"Time", "15:04:05", "00:00:00", "23:59:59", "1s"},
+}
+
+func init() {
+	for _, typ := range timeTypes {
+		typ := typ
+
+		token.RegisterTyped(typ.name, func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+			return parseTimeArguments(argParser, typ.layout, typ.from, typ.to, typ.step)
+		})
+	}
+}
+
+// From returns the from value of the range
+func (p *RangeTime) From() time.Time {
+	return p.from
+}
+
+// To returns the to value of the range
+func (p *RangeTime) To() time.Time {
+	return p.to
+}
+
+// Step returns the step value
+func (p *RangeTime) Step() time.Duration {
+	return p.step
+}
+
+// Layout returns the Go time layout of the token
+func (p *RangeTime) Layout() string {
+	return p.layout
+}
+
+// Edges returns the edge cases of the token
+func (p *RangeTime) Edges() []time.Time {
+	return p.edges
+}
+
+// Value returns the current value of the token
+func (p *RangeTime) Value() time.Time {
+	return p.value
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (p *RangeTime) Clone() token.Token {
+	return &RangeTime{
+		from:      p.from,
+		to:        p.to,
+		step:      p.step,
+		layout:    p.layout,
+		location:  p.location,
+		withEdges: p.withEdges,
+		edges:     p.edges,
+
+		value: p.value,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (p *RangeTime) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	if cur == pars.DataLen {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected time in range %s-%s but got early EOF", p.from.Format(p.layout), p.to.Format(p.layout)),
+			Type:    token.ParseErrorUnexpectedEOF,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	end := cur + len(p.layout) + maxTimeLayoutOverhead
+	if end > pars.DataLen {
+		end = pars.DataLen
+	}
+
+	// try the longest valid time first
+	for i := end; i > cur; i-- {
+		v := pars.Data[cur:i]
+
+		t, err := time.ParseInLocation(p.layout, v, p.location)
+		if err != nil || t.Format(p.layout) != v || !p.valid(t) {
+			continue
+		}
+
+		p.value = t
+
+		log.Debugf("Parsed %q", v)
+
+		return i, nil
+	}
+
+	return cur, []error{&token.ParserError{
+		Message: fmt.Sprintf("expected time in range %s-%s with layout %q but got %q", p.from.Format(p.layout), p.to.Format(p.layout), p.layout, pars.Data[cur:end]),
+		Type:    token.ParseErrorUnexpectedData,
+
+		Position: pars.GetPosition(cur),
+	}}
+}
+
+// valid checks that the parsed time is either a range value or an edge case
+// The time is compared on the resolution of the layout, e.g. a layout which omits the time of day matches every time of the day.
+func (p *RangeTime) valid(t time.Time) bool {
+	v := t.Format(p.layout)
+
+	if (t.Before(p.from) && v != p.from.Format(p.layout)) || (t.After(p.to) && v != p.to.Format(p.layout)) {
+		return false
+	}
+
+	k := t.Sub(p.from) / p.step
+	if p.from.Add(k*p.step).Format(p.layout) == v || p.from.Add((k+1)*p.step).Format(p.layout) == v {
+		return true
+	}
+
+	for _, e := range p.edges {
+		if e.Format(p.layout) == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rangeValue checks that one of the range values next to the given time within the range is formatted like the given formatted time
+func (p *RangeTime) rangeValue(t time.Time, v string) bool {
+	k := t.Sub(p.from) / p.step
+
+	if p.from.Add(k*p.step).Format(p.layout) == v {
+		return true
+	}
+	if r := p.from.Add((k + 1) * p.step); !r.After(p.to) && r.Format(p.layout) == v {
+		return true
+	}
+
+	return false
+}
+
+func (p *RangeTime) rangePermutations() uint {
+	return uint(p.to.Sub(p.from)/p.step) + 1
+}
+
+func (p *RangeTime) permutation(i uint) {
+	if r := p.rangePermutations(); i >= r {
+		p.value = p.edges[i-r]
+
+		return
+	}
+
+	p.value = p.from.Add(time.Duration(i) * p.step)
+}
+
+// Permutation sets a specific permutation for this token
+func (p *RangeTime) Permutation(i uint) error {
+	permutations := p.Permutations()
+
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	p.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (p *RangeTime) Permutations() uint {
+	return p.rangePermutations() + uint(len(p.edges))
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (p *RangeTime) PermutationsAll() uint {
+	return p.Permutations()
+}
+
+func (p *RangeTime) String() string {
+	return p.value.Format(p.layout)
+}
+
+// Typed interface methods
+
+// TypedDefinition returns the type and the arguments of a typed token definition which creates an equal token
+// Bounds which cannot be written in the layout of the token are left to the defaults of the type if possible.
+func (p *RangeTime) TypedDefinition() (string, []token.TypedArgument) {
+	// bound returns the argument value of a bound, an empty value if the default of the type is equal, or false if the bound cannot be written
+	bound := func(t time.Time, defaultLayout string, defaultValue string) (string, bool) {
+		s := t.Format(p.layout)
+		if v, err := time.ParseInLocation(p.layout, s, p.location); err == nil && v.Equal(t) {
+			return strconv.Quote(s), true
+		}
+
+		v, err := time.ParseInLocation(p.layout, defaultValue, p.location)
+		if err != nil {
+			v, err = time.ParseInLocation(defaultLayout, defaultValue, p.location)
+		}
+
+		return "", err == nil && v.Equal(t)
+	}
+
+	typ := timeTypes[0]
+	from, to := strconv.Quote(p.from.Format(p.layout)), strconv.Quote(p.to.Format(p.layout))
+
+	for _, t := range timeTypes {
+		f, okFrom := bound(p.from, t.layout, t.from)
+		l, okTo := bound(p.to, t.layout, t.to)
+
+		if okFrom && okTo {
+			typ, from, to = t, f, l
+
+			break
+		}
+	}
+
+	args := []token.TypedArgument{
+		{Name: "layout", Value: strconv.Quote(p.layout)},
+	}
+	if from != "" {
+		args = append(args, token.TypedArgument{Name: "from", Value: from})
+	}
+	if to != "" {
+		args = append(args, token.TypedArgument{Name: "to", Value: to})
+	}
+	args = append(args, token.TypedArgument{Name: "step", Value: strconv.Quote(p.step.String())})
+	if p.location != time.UTC {
+		args = append(args, token.TypedArgument{Name: "location", Value: strconv.Quote(p.location.String())})
+	}
+	if !p.withEdges {
+		args = append(args, token.TypedArgument{Name: "edges", Value: "false"})
+	}
+
+	return typ.name, args
+}
+
+// maxDurationPermutations is the maximum number of range values of a RangeDuration token
+const maxDurationPermutations = 1 << 62
+
+// durationUnits holds the units which can be used to format a RangeDuration token as an integer
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// RangeDuration implements a duration token holding a range of durations
+// Every permutation generates a new duration within the defined range and step. Durations are formatted like 1h2m3s or as integer of a unit. Additional edge cases which lie within the range are generated after the range values. These are zero, one nanosecond, one nanosecond below zero, one day and the minimum and maximum durations.
+type RangeDuration struct {
+	from  time.Duration
+	to    time.Duration
+	step  time.Duration
+	unit  time.Duration
+	edges []time.Duration
+
+	value time.Duration
+}
+
+// NewRangeDuration returns a new instance of a RangeDuration token with the given range and step
+// If unit is not 0 the durations are formatted as integer of the unit, e.g. 90 for a duration of 90 seconds and the unit time.Second. If edges is true edge cases within the range are generated in addition to the range values. From must not be bigger than to, the step must be positive and the unit must not be negative, otherwise the function panics.
+func NewRangeDuration(from, to, step, unit time.Duration, edges bool) *RangeDuration {
+	if from > to {
+		panic("from must not be bigger than to")
+	}
+	if step < 1 {
+		panic("step must be positive")
+	}
+	if unit < 0 {
+		panic("unit must not be negative")
+	}
+
+	p := &RangeDuration{
+		from: from,
+		to:   to,
+		step: step,
+		unit: unit,
+
+		value: from,
+	}
+
+	if edges {
+		for _, e := range []time.Duration{math.MinInt64, -1, 0, 1, 24 * time.Hour, math.MaxInt64} {
+			// edge cases which are range values are already generated
+			if e >= from && e <= to && (e-from)%step != 0 && (unit == 0 || e%unit == 0) {
+				p.edges = append(p.edges, e)
+			}
+		}
+	}
+
+	return p
+}
+
+func init() {
+	token.RegisterTyped("Duration", func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+		from := argParser.GetString("from", "0s")
+		to := argParser.GetString("to", "24h")
+		step := argParser.GetString("step", "1s")
+		unit := argParser.GetString("unit", "")
+		edges := argParser.GetBool("edges", true)
+
+		if err := argParser.Err(); err != nil {
+			return nil, err
+		}
+
+		f, err := time.ParseDuration(from)
+		if err != nil {
+			return nil, fmt.Errorf("%q needs a duration like 1h2m3s", "from")
+		}
+		t, err := time.ParseDuration(to)
+		if err != nil {
+			return nil, fmt.Errorf("%q needs a duration like 1h2m3s", "to")
+		}
+		s, err := time.ParseDuration(step)
+		if err != nil || s < 1 {
+			return nil, fmt.Errorf("%q needs a positive duration like 1s", "step")
+		}
+
+		var u time.Duration
+		if unit != "" {
+			var ok bool
+
+			u, ok = durationUnits[unit]
+			if !ok {
+				return nil, fmt.Errorf("unknown unit %q, expected one of ns, us, ms, s, m or h", unit)
+			}
+		}
+
+		if f > t {
+			return nil, fmt.Errorf("%q must not be bigger than %q", "from", "to")
+		}
+
+		return NewRangeDuration(f, t, s, u, edges), nil
+	})
+}
+
+// From returns the from value of the range
+func (p *RangeDuration) From() time.Duration {
+	return p.from
+}
+
+// To returns the to value of the range
+func (p *RangeDuration) To() time.Duration {
+	return p.to
+}
+
+// Step returns the step value
+func (p *RangeDuration) Step() time.Duration {
+	return p.step
+}
+
+// Edges returns the edge cases of the token
+func (p *RangeDuration) Edges() []time.Duration {
+	return p.edges
+}
+
+// Value returns the current value of the token
+func (p *RangeDuration) Value() time.Duration {
+	return p.value
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (p *RangeDuration) Clone() token.Token {
+	return &RangeDuration{
+		from:  p.from,
+		to:    p.to,
+		step:  p.step,
+		unit:  p.unit,
+		edges: p.edges,
+
+		value: p.value,
+	}
+}
+
+func (p *RangeDuration) format(d time.Duration) string {
+	if p.unit == 0 {
+		return d.String()
+	}
+
+	return strconv.FormatInt(int64(d/p.unit), 10)
+}
+
+func (p *RangeDuration) parse(v string) (time.Duration, bool) {
+	if p.unit == 0 {
+		d, err := time.ParseDuration(v)
+
+		return d, err == nil
+	}
+
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || i > math.MaxInt64/int64(p.unit) || i < math.MinInt64/int64(p.unit) {
+		return 0, false
+	}
+
+	return time.Duration(i) * p.unit, true
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (p *RangeDuration) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	if cur == pars.DataLen {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected duration in range %s-%s but got early EOF", p.format(p.from), p.format(p.to)),
+			Type:    token.ParseErrorUnexpectedEOF,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	end := cur
+	for end < pars.DataLen && strings.IndexByte("-+.0123456789hmnsuµ", pars.Data[end]) != -1 {
+		end++
+	}
+
+	// try the longest valid duration first
+	for i := end; i > cur; i-- {
+		v := pars.Data[cur:i]
+
+		d, ok := p.parse(v)
+		if !ok || p.format(d) != v || d < p.from || d > p.to {
+			continue
+		}
+
+		if (d-p.from)%p.step != 0 {
+			edge := false
+			for _, e := range p.edges {
+				if d == e {
+					edge = true
+
+					break
+				}
+			}
+
+			if !edge {
+				continue
+			}
+		}
+
+		p.value = d
+
+		log.Debugf("Parsed %q", v)
+
+		return i, nil
+	}
+
+	return cur, []error{&token.ParserError{
+		Message: fmt.Sprintf("expected duration in range %s-%s with step %s but got %q", p.format(p.from), p.format(p.to), p.step, pars.Data[cur:end]),
+		Type:    token.ParseErrorUnexpectedData,
+
+		Position: pars.GetPosition(cur),
+	}}
+}
+
+func (p *RangeDuration) rangePermutations() uint {
+	n := uint64(p.to-p.from) / uint64(p.step)
+	if n >= maxDurationPermutations {
+		return maxDurationPermutations
+	}
+
+	return uint(n) + 1
+}
+
+func (p *RangeDuration) permutation(i uint) {
+	if r := p.rangePermutations(); i >= r {
+		p.value = p.edges[i-r]
+
+		return
+	}
+
+	p.value = p.from + time.Duration(i)*p.step
+}
+
+// Permutation sets a specific permutation for this token
+func (p *RangeDuration) Permutation(i uint) error {
+	permutations := p.Permutations()
+
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	p.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (p *RangeDuration) Permutations() uint {
+	return p.rangePermutations() + uint(len(p.edges))
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (p *RangeDuration) PermutationsAll() uint {
+	return p.Permutations()
+}
+
+func (p *RangeDuration) String() string {
+	return p.format(p.value)
+}
+
+// Typed interface methods
+
+// TypedDefinition returns the type and the arguments of a typed token definition which creates an equal token
+func (p *RangeDuration) TypedDefinition() (string, []token.TypedArgument) {
+	args := []token.TypedArgument{
+		{Name: "from", Value: strconv.Quote(p.from.String())},
+		{Name: "to", Value: strconv.Quote(p.to.String())},
+		{Name: "step", Value: strconv.Quote(p.step.String())},
+	}
+
+	for name, unit := range durationUnits {
+		if unit == p.unit {
+			args = append(args, token.TypedArgument{Name: "unit", Value: strconv.Quote(name)})
+
+			break
+		}
+	}
+
+	if len(p.edges) == 0 {
+		args = append(args, token.TypedArgument{Name: "edges", Value: "false"})
+	}
+
+	return "Duration", args
+}
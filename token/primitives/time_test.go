@@ -0,0 +1,272 @@
+package primitives
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+)
+
+func TestTimeTokensToBeTokens(t *testing.T) {
+	var tok *token.Token
+
+	Implements(t, tok, &RangeTime{})
+	Implements(t, tok, &RangeDuration{})
+}
+
+func rangeTimeStrings(o token.Token) []string {
+	var strs []string
+
+	for i := uint(1); i <= o.Permutations(); i++ {
+		if err := o.Permutation(i); err != nil {
+			panic(err)
+		}
+
+		strs = append(strs, o.String())
+	}
+
+	return strs
+}
+
+func TestRangeTime(t *testing.T) {
+	from := time.Date(2000, time.February, 27, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2000, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	Panics(t, func() {
+		NewRangeTime(to, from, 24*time.Hour, "2006-01-02", false)
+	})
+	Panics(t, func() {
+		NewRangeTime(from, to, 0, "2006-01-02", false)
+	})
+
+	o := NewRangeTime(from, to, 24*time.Hour, "2006-01-02", false)
+	Equal(t, "2000-02-27", o.String())
+
+	Equal(t, 4, o.Permutations())
+	Equal(t, []string{"2000-02-27", "2000-02-28", "2000-02-29", "2000-03-01"}, rangeTimeStrings(o))
+
+	Equal(t, o.Permutation(5).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	// leap days
+	o = NewRangeTime(from, to, 48*time.Hour, time.RFC3339, true)
+	Equal(t, []string{"2000-02-27T00:00:00Z", "2000-02-29T00:00:00Z", "2000-02-29T23:59:59Z"}, rangeTimeStrings(o))
+
+	// epoch and 2038
+	from = time.Date(1969, time.December, 31, 23, 59, 59, 0, time.UTC)
+	to = time.Date(2038, time.January, 19, 3, 14, 8, 0, time.UTC)
+
+	o = NewRangeTime(from, to, to.Sub(from), time.RFC3339, true)
+	Equal(t, []string{
+		"1969-12-31T23:59:59Z",
+		"2038-01-19T03:14:08Z",
+		"1970-01-01T00:00:00Z",
+		"1972-02-29T00:00:00Z",
+		"1972-02-29T23:59:59Z",
+	}, rangeTimeStrings(o)[:5])
+	Equal(t, []string{
+		"2036-02-29T00:00:00Z",
+		"2036-02-29T23:59:59Z",
+		"2038-01-19T03:14:07Z",
+	}, rangeTimeStrings(o)[o.Permutations()-3:])
+}
+
+func TestRangeTimeDistinctPermutations(t *testing.T) {
+	leapDay := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		from   time.Time
+		to     time.Time
+		step   time.Duration
+		layout string
+		values []string
+	}{
+		// the leap day is a range value
+		{leapDay, leapDay.AddDate(0, 1, 0), 24 * time.Hour, "2006-01-02", nil},
+		// both edge cases of the leap day are the same date
+		{leapDay, leapDay.AddDate(0, 1, 0), 27 * 24 * time.Hour, "2006-01-02", []string{"2024-02-01", "2024-02-28", "2024-02-29"}},
+		// the leap day is formatted like the range values
+		{leapDay, leapDay.AddDate(0, 2, 0), 31 * 24 * time.Hour, "2006-01", []string{"2024-02", "2024-03"}},
+		{leapDay.Add(12 * time.Hour), leapDay.AddDate(0, 1, 0), 24 * time.Hour, "2006-01-02", nil},
+		{leapDay.Add(12 * time.Hour), leapDay.AddDate(0, 0, 28).Add(11 * time.Hour), 24 * time.Hour, "2006-01-02", nil},
+	} {
+		o := NewRangeTime(tc.from, tc.to, tc.step, tc.layout, true)
+
+		values := rangeTimeStrings(o)
+		Equal(t, int(o.Permutations()), len(values))
+
+		seen := make(map[string]struct{})
+		for _, v := range values {
+			_, ok := seen[v]
+			False(t, ok, v)
+
+			seen[v] = struct{}{}
+		}
+
+		if tc.values != nil {
+			Equal(t, tc.values, values)
+		}
+	}
+}
+
+func TestRangeTimeDaylightSavingTime(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Vienna")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+
+	from := time.Date(2020, time.March, 20, 0, 0, 0, 0, loc)
+	to := time.Date(2020, time.April, 1, 0, 0, 0, 0, loc)
+
+	o := NewRangeTime(from, to, to.Sub(from), time.RFC3339, true)
+	Equal(t, []string{
+		"2020-03-20T00:00:00+01:00",
+		"2020-04-01T00:00:00+02:00",
+		"2020-03-29T01:59:59+01:00",
+		"2020-03-29T03:00:00+02:00",
+	}, rangeTimeStrings(o))
+}
+
+func TestRangeTimeParse(t *testing.T) {
+	from := time.Date(2000, time.February, 27, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2000, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	o := NewRangeTime(from, to, 48*time.Hour, "Jan _2 2006 15:04", true)
+
+	for _, tc := range []struct {
+		data string
+		nex  int
+	}{
+		{"Feb 27 2000 00:00", 17},
+		{"Feb 29 2000 00:00 and more", 17},
+		{"Feb 29 2000 23:59", 17},
+	} {
+		pars := &token.InternalParser{
+			Data:    tc.data,
+			DataLen: len(tc.data),
+		}
+
+		nex, errs := o.Parse(pars, 0)
+		Nil(t, errs, tc.data)
+		Equal(t, tc.nex, nex, tc.data)
+		Equal(t, tc.data[:tc.nex], o.String(), tc.data)
+	}
+
+	for _, data := range []string{
+		"",
+		"Feb 28 2000 00:00",
+		"Feb 26 2000 00:00",
+		"Mar  1 2000 00:00",
+		"Mar 1 2000 00:00",
+		"2000-02-27",
+	} {
+		pars := &token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}
+
+		nex, errs := o.Parse(pars, 0)
+		NotNil(t, errs, data)
+		Equal(t, 0, nex, data)
+	}
+}
+
+func TestRangeDuration(t *testing.T) {
+	Panics(t, func() {
+		NewRangeDuration(time.Second, -time.Second, time.Second, 0, false)
+	})
+	Panics(t, func() {
+		NewRangeDuration(-time.Second, time.Second, 0, 0, false)
+	})
+
+	o := NewRangeDuration(-time.Second, time.Second, time.Second, 0, false)
+	Equal(t, "-1s", o.String())
+
+	Equal(t, 3, o.Permutations())
+	Equal(t, []string{"-1s", "0s", "1s"}, rangeTimeStrings(o))
+
+	Equal(t, o.Permutation(4).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	// edges
+	o = NewRangeDuration(-time.Second, time.Second, time.Second, 0, true)
+	Equal(t, []string{"-1s", "0s", "1s", "-1ns", "1ns"}, rangeTimeStrings(o))
+
+	o = NewRangeDuration(math.MinInt64, math.MaxInt64, time.Nanosecond, 0, true)
+	Equal(t, maxDurationPermutations, o.Permutations())
+	Nil(t, o.Permutation(1))
+	Equal(t, "-2562047h47m16.854775808s", o.String())
+
+	// unit
+	o = NewRangeDuration(0, 2*time.Minute, time.Minute, time.Second, true)
+	Equal(t, []string{"0", "60", "120"}, rangeTimeStrings(o))
+}
+
+func TestRangeDurationParse(t *testing.T) {
+	o := NewRangeDuration(0, 2*time.Hour, 30*time.Minute, 0, true)
+
+	for _, tc := range []struct {
+		data string
+		nex  int
+	}{
+		{"0s", 2},
+		{"1h30m0s", 7},
+		{"2h0m0s,", 6},
+		{"1ns", 3},
+	} {
+		pars := &token.InternalParser{
+			Data:    tc.data,
+			DataLen: len(tc.data),
+		}
+
+		nex, errs := o.Parse(pars, 0)
+		Nil(t, errs, tc.data)
+		Equal(t, tc.nex, nex, tc.data)
+		Equal(t, tc.data[:tc.nex], o.String(), tc.data)
+	}
+
+	for _, data := range []string{
+		"",
+		"1h",
+		"1h15m0s",
+		"3h0m0s",
+		"abc",
+	} {
+		pars := &token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}
+
+		nex, errs := o.Parse(pars, 0)
+		NotNil(t, errs, data)
+		Equal(t, 0, nex, data)
+	}
+
+	o = NewRangeDuration(0, time.Minute, time.Second, time.Millisecond, false)
+
+	pars := &token.InternalParser{
+		Data:    "1500",
+		DataLen: 4,
+	}
+
+	nex, errs := o.Parse(pars, 0)
+	NotNil(t, errs)
+	Equal(t, 0, nex)
+
+	pars = &token.InternalParser{
+		Data:    "2000",
+		DataLen: 4,
+	}
+
+	nex, errs = o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 4, nex)
+	Equal(t, 2*time.Second, o.Value())
+}
@@ -0,0 +1,224 @@
+package regexes
+
+import (
+	"bytes"
+	"fmt"
+	"regexp/syntax"
+	"unicode"
+
+	"github.com/zimmski/tavor"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/constraints"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func init() {
+	token.RegisterTyped("Regex", func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+		argParser.Required("pattern")
+		pattern := argParser.GetString("pattern", "")
+		max := argParser.GetInt("max", tavor.MaxRepeat)
+
+		if err := argParser.Err(); err != nil {
+			return nil, err
+		}
+
+		if max < 1 {
+			return nil, fmt.Errorf("max must be at least 1 but is %d", max)
+		}
+
+		return New(pattern, max)
+	})
+}
+
+// New compiles a Go regular expression into a token graph which generates strings matching the regular expression
+// Unbounded quantifiers like * and + repeat at most maxRepeat times but at least as many times as their minimum. Zero-width assertions like ^, $ and \b are ignored.
+// The error return argument is not nil if the regular expression is invalid or cannot be represented as a token graph.
+func New(pattern string, maxRepeat int) (token.Token, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+
+	return compile(re, maxRepeat)
+}
+
+func compile(re *syntax.Regexp, maxRepeat int) (token.Token, error) {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return nil, fmt.Errorf("regular expression %q matches nothing", re.String())
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return primitives.NewConstantString(""), nil
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase == 0 {
+			return primitives.NewConstantString(string(re.Rune)), nil
+		}
+
+		toks := make([]token.Token, len(re.Rune))
+		for i, r := range re.Rune {
+			toks[i] = foldCase(r)
+		}
+
+		return all(toks), nil
+	case syntax.OpCharClass:
+		return characterClass(re.Rune)
+	case syntax.OpAnyCharNotNL:
+		return characterClass([]rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune})
+	case syntax.OpAnyChar:
+		return characterClass([]rune{0, unicode.MaxRune})
+	case syntax.OpCapture:
+		return compile(re.Sub[0], maxRepeat)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		sub, err := compile(re.Sub[0], maxRepeat)
+		if err != nil {
+			return nil, err
+		}
+
+		min, max := re.Min, re.Max
+
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			return constraints.NewOptional(sub), nil
+		}
+
+		if max == -1 {
+			max = maxRepeat
+			if max < min {
+				max = min
+			}
+		}
+
+		return lists.NewRepeat(sub, int64(min), int64(max)), nil
+	case syntax.OpConcat:
+		toks := make([]token.Token, 0, len(re.Sub))
+
+		for _, s := range re.Sub {
+			tok, err := compile(s, maxRepeat)
+			if err != nil {
+				return nil, err
+			}
+
+			toks = append(toks, tok)
+		}
+
+		return all(toks), nil
+	case syntax.OpAlternate:
+		toks := make([]token.Token, 0, len(re.Sub))
+		optional := false
+
+		for _, s := range re.Sub {
+			if s.Op == syntax.OpEmptyMatch {
+				optional = true
+
+				continue
+			}
+
+			tok, err := compile(s, maxRepeat)
+			if err != nil {
+				return nil, err
+			}
+
+			toks = append(toks, tok)
+		}
+
+		var tok token.Token
+		if len(toks) == 1 {
+			tok = toks[0]
+		} else {
+			tok = lists.NewOne(toks...)
+		}
+
+		if optional {
+			return constraints.NewOptional(tok), nil
+		}
+
+		return tok, nil
+	}
+
+	return nil, fmt.Errorf("unsupported regular expression operator %s", re.Op)
+}
+
+func all(toks []token.Token) token.Token {
+	if len(toks) == 1 {
+		return toks[0]
+	}
+
+	return lists.NewAll(toks...)
+}
+
+// foldCase returns a token for all characters which are equal to the given character under simple case folding
+func foldCase(r rune) token.Token {
+	runes := []rune{r}
+
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		runes = append(runes, f)
+	}
+
+	if len(runes) == 1 {
+		return primitives.NewConstantString(string(r))
+	}
+
+	var pattern bytes.Buffer
+
+	for _, r := range runes {
+		writeRune(&pattern, r)
+	}
+
+	return primitives.NewCharacterClass(pattern.String())
+}
+
+// characterClass returns a character class token for the given pairs of character ranges
+// Surrogate halves are removed from the ranges since they are no valid characters.
+func characterClass(ranges []rune) (token.Token, error) {
+	var pattern bytes.Buffer
+
+	for i := 0; i < len(ranges); i += 2 {
+		from, to := ranges[i], ranges[i+1]
+
+		if from < surrogateMin && to > surrogateMax {
+			writeRange(&pattern, from, surrogateMin-1)
+			writeRange(&pattern, surrogateMax+1, to)
+
+			continue
+		}
+
+		if from >= surrogateMin && from <= surrogateMax {
+			from = surrogateMax + 1
+		}
+		if to >= surrogateMin && to <= surrogateMax {
+			to = surrogateMin - 1
+		}
+
+		if from <= to {
+			writeRange(&pattern, from, to)
+		}
+	}
+
+	if pattern.Len() == 0 {
+		return nil, fmt.Errorf("character class has no valid characters")
+	}
+
+	return primitives.NewCharacterClass(pattern.String()), nil
+}
+
+const (
+	surrogateMin = 0xD800
+	surrogateMax = 0xDFFF
+)
+
+func writeRange(pattern *bytes.Buffer, from, to rune) {
+	writeRune(pattern, from)
+
+	if from != to {
+		_, _ = pattern.WriteRune('-')
+		writeRune(pattern, to)
+	}
+}
+
+func writeRune(pattern *bytes.Buffer, r rune) {
+	_, _ = fmt.Fprintf(pattern, `\x{%02x}`, r)
+}
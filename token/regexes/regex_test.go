@@ -0,0 +1,95 @@
+package regexes
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/fuzz/strategy"
+	"github.com/zimmski/tavor/token"
+)
+
+func TestRegex(t *testing.T) {
+	for _, pattern := range []string{
+		`abc`,
+		`a|bc|`,
+		`[a-c]{2,3}x?`,
+		`^(?i)ab$`,
+		`\d+-\w*`,
+		`[^\x00-\x{10fff0}]`,
+		`x.y`,
+		`(foo|bar)\.(com|org){1,}`,
+		`[\x{d7ff}-\x{e000}]`,
+	} {
+		tok, err := New(pattern, 3)
+		Nil(t, err, pattern)
+
+		re := regexp.MustCompile("^(?:" + pattern + ")$")
+
+		for seed := int64(0); seed < 10; seed++ {
+			s := strategy.NewRandomStrategy(tok)
+
+			ch, err := s.Fuzz(rand.New(rand.NewSource(seed)))
+			Nil(t, err)
+
+			for range ch {
+				out := tok.String()
+				True(t, re.MatchString(out), "%q does not match %q", out, pattern)
+
+				ch <- struct{}{}
+			}
+		}
+	}
+}
+
+func TestRegexParse(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		data    string
+	}{
+		{`abc`, "abc"},
+		{`a|bc|`, "bc"},
+		{`a|bc|`, ""},
+		{`[a-c]{2,3}x?`, "cbax"},
+		{`(?i)ab`, "aB"},
+		{`\d+-`, "123-"},
+	} {
+		tok, err := New(tc.pattern, 3)
+		Nil(t, err)
+
+		pars := &token.InternalParser{
+			Data:    tc.data,
+			DataLen: len(tc.data),
+		}
+
+		nex, errs := tok.Parse(pars, 0)
+		Nil(t, errs, tc.pattern)
+		Equal(t, len(tc.data), nex, tc.pattern)
+		Equal(t, tc.data, tok.String(), tc.pattern)
+	}
+}
+
+func TestRegexMaxRepeat(t *testing.T) {
+	tok, err := New(`a*`, 4)
+	Nil(t, err)
+	Equal(t, uint(5), tok.Permutations())
+
+	// the minimum of a repeat is always reachable
+	tok, err = New(`a{6,}`, 4)
+	Nil(t, err)
+	Equal(t, uint(1), tok.Permutations())
+}
+
+func TestRegexErrors(t *testing.T) {
+	for _, pattern := range []string{
+		`(`,
+		`a**`,
+		`[^\x00-\x{10FFFF}]`,
+	} {
+		tok, err := New(pattern, 2)
+		Nil(t, tok, pattern)
+		NotNil(t, err, pattern)
+	}
+}
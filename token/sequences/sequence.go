@@ -42,20 +42,8 @@ func init() {
 // TODO this must be handled without panics
 var errNoSequenceValue = fmt.Sprintf("There is no sequence value to choose from")
 
-func (s *Sequence) existing(r uint, except []token.Token) int {
-	n := s.value - s.start
-
-	if n == 0 {
-		panic(errNoSequenceValue)
-	}
-
-	n /= s.step
-
-	if len(except) == 0 {
-		return int(r)*s.step + s.start
-	}
-
-	checked := make(map[int]struct{})
+// exceptValues returns the sequence values of the given tokens
+func exceptValues(except []token.Token) map[int]struct{} {
 	exceptLookup := make(map[int]struct{})
 
 	for i := 0; i < len(except); i++ {
@@ -92,6 +80,25 @@ func (s *Sequence) existing(r uint, except []token.Token) int {
 		}
 	}
 
+	return exceptLookup
+}
+
+func (s *Sequence) existing(r uint, except []token.Token) int {
+	n := s.value - s.start
+
+	if n == 0 {
+		panic(errNoSequenceValue)
+	}
+
+	n /= s.step
+
+	if len(except) == 0 {
+		return int(r)*s.step + s.start
+	}
+
+	checked := make(map[int]struct{})
+	exceptLookup := exceptValues(except)
+
 	for n != len(checked) {
 		i := (int(r)%n)*s.step + s.start
 
@@ -108,7 +115,8 @@ func (s *Sequence) existing(r uint, except []token.Token) int {
 		}
 	}
 
-	panic(errNoSequenceValue)
+	// every value is excepted which happens if the excepted tokens have not been permutated yet
+	return -1 // TODO there should be some kind of real nil value
 }
 
 // ExistingItem returns a new instance of a SequenceExistingItem token referencing the sequence and holding the starting value of the sequence as its current value
@@ -179,6 +187,16 @@ func (s *Sequence) PermutationsAll() uint { panic("unusable token") }
 
 func (s *Sequence) String() string { panic("unusable token") }
 
+// Typed interface methods
+
+// TypedDefinition returns the type and the arguments of a typed token definition which creates an equal token
+func (s *Sequence) TypedDefinition() (string, []token.TypedArgument) {
+	return "Sequence", []token.TypedArgument{
+		{Name: "start", Value: strconv.Itoa(s.start)},
+		{Name: "step", Value: strconv.Itoa(s.step)},
+	}
+}
+
 // SequenceItem implements a sequence item token which holds one distinct value of the sequence
 // A new sequence value is generated on every token permutation.
 type SequenceItem struct {
@@ -186,6 +204,11 @@ type SequenceItem struct {
 	value    int
 }
 
+// Sequence returns the referenced sequence
+func (s *SequenceItem) Sequence() *Sequence {
+	return s.sequence
+}
+
 // Clone returns a copy of the token and all its children
 func (s *SequenceItem) Clone() token.Token {
 	return &SequenceItem{
@@ -197,7 +220,9 @@ func (s *SequenceItem) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (s *SequenceItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	s.permutation(0)
+
+	return pars.ParseValue(s.String(), cur)
 }
 
 func (s *SequenceItem) permutation(i uint) {
@@ -248,6 +273,11 @@ type SequenceExistingItem struct {
 	except   []token.Token
 }
 
+// Sequence returns the referenced sequence
+func (s *SequenceExistingItem) Sequence() *Sequence {
+	return s.sequence
+}
+
 // Clone returns a copy of the token and all its children
 func (s *SequenceExistingItem) Clone() token.Token {
 	c := SequenceExistingItem{
@@ -266,7 +296,48 @@ func (s *SequenceExistingItem) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (s *SequenceExistingItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	exceptLookup := exceptValues(s.except)
+
+	var errs []error
+
+	best := -1
+	bestNex := cur
+
+	for v := s.sequence.start; v != s.sequence.value; v += s.sequence.step {
+		if _, ok := exceptLookup[v]; ok {
+			continue
+		}
+
+		nex, es := pars.ParseValue(strconv.Itoa(v), cur)
+		if len(es) != 0 {
+			errs = append(errs, es...)
+
+			continue
+		}
+
+		// take the longest match e.g. 10 instead of 1
+		if best == -1 || nex > bestNex {
+			best = v
+			bestNex = nex
+		}
+	}
+
+	if best == -1 {
+		if errs == nil {
+			errs = []error{&token.ParserError{
+				Message: errNoSequenceValue,
+				Type:    token.ParseErrorUnexpectedData,
+
+				Position: pars.GetPosition(cur),
+			}}
+		}
+
+		return cur, errs
+	}
+
+	s.value = best
+
+	return bestNex, nil
 }
 
 func (s *SequenceExistingItem) permutation(i uint) {
@@ -392,6 +463,11 @@ type SequenceResetItem struct {
 	sequence *Sequence
 }
 
+// Sequence returns the referenced sequence
+func (s *SequenceResetItem) Sequence() *Sequence {
+	return s.sequence
+}
+
 // Clone returns a copy of the token and all its children
 func (s *SequenceResetItem) Clone() token.Token {
 	return &SequenceResetItem{
@@ -402,7 +478,9 @@ func (s *SequenceResetItem) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (s *SequenceResetItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	s.permutation(0)
+
+	return cur, nil
 }
 
 func (s *SequenceResetItem) permutation(i uint) {
@@ -112,3 +112,49 @@ func TestResetSequenceItem(t *testing.T) {
 
 	Equal(t, 1, o.Permutations())
 }
+
+func TestSequenceParse(t *testing.T) {
+	s := NewSequence(1, 9)
+
+	a := s.Item()
+	b := s.Item()
+	r := s.ResetItem()
+	e := s.ExistingItem([]token.Token{primitives.NewConstantInt(1)})
+
+	s.Reset()
+
+	o := lists.NewAll(a, b, e)
+
+	pars := &token.InternalParser{
+		Data:    "11010",
+		DataLen: 5,
+	}
+
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 5, nex)
+	Equal(t, "11010", o.String())
+
+	// the reset item resets the sequence for the following items
+	s.Reset()
+
+	o = lists.NewAll(a, r, b)
+
+	pars = &token.InternalParser{
+		Data:    "11",
+		DataLen: 2,
+	}
+
+	nex, errs = o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 2, nex)
+
+	// there are no existing items after a reset
+	s.Reset()
+
+	o = lists.NewAll(a, r, e)
+
+	nex, errs = o.Parse(pars, 0)
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+	Equal(t, 1, nex)
+}
@@ -0,0 +1,1213 @@
+package sets
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+)
+
+// Set implements an ordered set token which holds live entries that can be added and removed by its item tokens
+// New entries are numerated like a sequence beginning at the given start value and increasing with every new entry by the given step value. Removed entries are remembered until the set is reset so they can be referenced as missing entries. A set which is created as a map holds additionally a value for every live entry which is its key. The values are tokens which are given when an entry is added and which can be replaced.
+type Set struct {
+	start int
+	step  int
+	value int
+
+	entries []int
+	removed []int
+
+	values map[int]token.Token
+}
+
+// NewSet returns a new instance of a Set token with a start value and a step value for new entries
+func NewSet(start, step int) *Set {
+	return &Set{
+		start: start,
+		step:  step,
+		value: start,
+	}
+}
+
+// NewMap returns a new instance of a Set token which holds a value for every entry with a start value and a step value for new entries
+func NewMap(start, step int) *Set {
+	s := NewSet(start, step)
+	s.values = make(map[int]token.Token)
+
+	return s
+}
+
+func init() {
+	register := func(name string, new func(start, step int) *Set) {
+		token.RegisterTyped(name, func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+			start := argParser.GetInt("start", 1)
+			step := argParser.GetInt("step", 1)
+
+			if err := argParser.Err(); err != nil {
+				return nil, err
+			}
+
+			if step == 0 {
+				return nil, fmt.Errorf("step must not be 0")
+			}
+
+			return new(start, step), nil
+		})
+	}
+
+	register("Set", NewSet)
+	register("Map", NewMap)
+}
+
+// IsMap returns true if the set holds a value for every entry
+func (s *Set) IsMap() bool {
+	return s.values != nil
+}
+
+// noValue is the entry of an item which holds no entry
+const noValue = -1
+
+// index returns the index of the given entry or -1 if it is not a live entry
+func (s *Set) index(v int) int {
+	for i, e := range s.entries {
+		if e == v {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// add adds a new entry with the given value and returns it
+func (s *Set) add(value token.Token) int {
+	v := s.value
+
+	s.value += s.step
+	s.entries = append(s.entries, v)
+
+	if s.values != nil {
+		s.values[v] = value
+	}
+
+	return v
+}
+
+// replace replaces the value of the given live entry
+func (s *Set) replace(v int, value token.Token) {
+	if s.values != nil && s.index(v) != -1 {
+		s.values[v] = value
+	}
+}
+
+
+// remove removes the given live entry
+func (s *Set) remove(v int) {
+	i := s.index(v)
+	if i == -1 {
+		return
+	}
+
+	s.entries = append(s.entries[:i], s.entries[i+1:]...)
+	s.removed = append(s.removed, v)
+
+	if s.values != nil {
+		delete(s.values, v)
+	}
+}
+
+// existing returns the r-th live entry which is not excepted or noValue if there is none
+func (s *Set) existing(r uint, except []token.Token) (int, error) {
+	exceptLookup, err := lists.ExceptValues(except)
+	if err != nil {
+		return noValue, err
+	}
+
+	for j := range s.entries {
+		v := s.entries[(int(r)+j)%len(s.entries)]
+
+		if _, ok := exceptLookup[strconv.Itoa(v)]; !ok {
+			return v, nil
+		}
+	}
+
+	// every entry is excepted which happens if the excepted tokens have not been permutated yet
+	return noValue, nil
+}
+
+// missing returns the r-th entry which is not live
+// Missing entries are the removed entries followed by the next entry which has not been added yet.
+func (s *Set) missing(r uint) int {
+	if int(r) < len(s.removed) {
+		return s.removed[r]
+	}
+
+	return s.value
+}
+
+// reset removes all entries and forgets the removed entries
+// This is not the Reset method of the ResetToken interface since the set is an unusable token.
+func (s *Set) reset() {
+	s.value = s.start
+	s.entries = nil
+	s.removed = nil
+
+	if s.values != nil {
+		s.values = make(map[int]token.Token)
+	}
+}
+
+// parseEntry parses the longest accepted entry out of the given entries
+func parseEntry(pars *token.InternalParser, cur int, entries []int, accept func(v int) bool) (int, int, []error) {
+	var candidates []int
+	var values []string
+
+	for _, v := range entries {
+		if accept(v) {
+			candidates = append(candidates, v)
+			values = append(values, strconv.Itoa(v))
+		}
+	}
+
+	// take the longest match e.g. 10 instead of 1
+	best, nex, errs := pars.ParseLongestValue(values, cur)
+	if best == -1 {
+		return noValue, cur, errs
+	}
+
+	return candidates[best], nex, nil
+}
+
+// AddItem returns a new instance of a SetAddItem token referencing the set and adding a new entry
+func (s *Set) AddItem() *SetAddItem {
+	return s.AddValueItem(nil)
+}
+
+// AddValueItem returns a new instance of a SetAddItem token referencing the set and adding a new entry with the given value
+func (s *Set) AddValueItem(value token.Token) *SetAddItem {
+	return &SetAddItem{
+		set:        s,
+		value:      s.add(value),
+		valueToken: value,
+	}
+}
+
+// CountItem returns a new instance of a SetCountItem token referencing the set and holding its current number of live entries
+func (s *Set) CountItem() *SetCountItem {
+	return &SetCountItem{
+		set:   s,
+		value: len(s.entries),
+	}
+}
+
+// ExistingItem returns a new instance of a SetExistingItem token referencing the set and holding the first live entry which is not excepted
+func (s *Set) ExistingItem(except []token.Token) *SetExistingItem {
+	return &SetExistingItem{
+		set:    s,
+		value:  noValue,
+		except: except,
+	}
+}
+
+// MissingItem returns a new instance of a SetMissingItem token referencing the set and holding an entry which is not live
+func (s *Set) MissingItem() *SetMissingItem {
+	return &SetMissingItem{
+		set:   s,
+		value: s.missing(0),
+	}
+}
+
+// RemoveItem returns a new instance of a SetRemoveItem token referencing the set and removing one of its live entries
+func (s *Set) RemoveItem() *SetRemoveItem {
+	r := &SetRemoveItem{
+		set: s,
+	}
+	r.permutation(0)
+
+	return r
+}
+
+// ReplaceItem returns a new instance of a SetReplaceItem token referencing the set and replacing the value of one of its live entries with the given value
+func (s *Set) ReplaceItem(value token.Token) *SetReplaceItem {
+	r := &SetReplaceItem{
+		set:        s,
+		valueToken: value,
+	}
+	r.permutation(0)
+
+	return r
+}
+
+// ValueItem returns a new instance of a SetValueItem token referencing the set and holding the value of the entry which is given by the key token
+func (s *Set) ValueItem(key token.Token) *SetValueItem {
+	return &SetValueItem{
+		set: s,
+		key: key,
+	}
+}
+
+// ResetItem returns a new instance of a SetResetItem token referencing the set
+func (s *Set) ResetItem() *SetResetItem {
+	return &SetResetItem{
+		set: s,
+	}
+}
+
+// Set is an unusable token
+
+// Clone returns a copy of the token and all its children
+func (s *Set) Clone() token.Token { panic("unusable token") }
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (s *Set) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	panic("unusable token")
+}
+
+// Permutation sets a specific permutation for this token
+func (s *Set) Permutation(i uint) error { panic("unusable token") }
+
+// Permutations returns the number of permutations for this token
+func (s *Set) Permutations() uint { panic("unusable token") }
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (s *Set) PermutationsAll() uint { panic("unusable token") }
+
+func (s *Set) String() string { panic("unusable token") }
+
+// Typed interface methods
+
+// TypedDefinition returns the type and the arguments of a typed token definition which creates an equal token
+func (s *Set) TypedDefinition() (string, []token.TypedArgument) {
+	typ := "Set"
+	if s.IsMap() {
+		typ = "Map"
+	}
+
+	return typ, []token.TypedArgument{
+		{Name: "start", Value: strconv.Itoa(s.start)},
+		{Name: "step", Value: strconv.Itoa(s.step)},
+	}
+}
+
+func entryString(v int) string {
+	if v == noValue {
+		return ""
+	}
+
+	return strconv.Itoa(v)
+}
+
+func checkPermutation(i uint, permutations uint) error {
+	if i < 1 || i > permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	return nil
+}
+
+// SetAddItem implements a set item token which adds a new entry to the set
+// A new entry is added on every token permutation. If the set is a map, the entry gets the value token of the item as its value.
+type SetAddItem struct {
+	set        *Set
+	value      int
+	valueToken token.Token
+}
+
+// Set returns the referenced set
+func (s *SetAddItem) Set() *Set {
+	return s.set
+}
+
+// ValueToken returns the token holding the value of the added entry or nil if the item adds no value
+func (s *SetAddItem) ValueToken() token.Token {
+	return s.valueToken
+}
+
+// Clone returns a copy of the token and all its children
+func (s *SetAddItem) Clone() token.Token {
+	c := SetAddItem{
+		set:   s.set,
+		value: s.value,
+	}
+
+	if s.valueToken != nil {
+		c.valueToken = s.valueToken.Clone()
+	}
+
+	return &c
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (s *SetAddItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	s.permutation(0)
+
+	return pars.ParseValue(s.String(), cur)
+}
+
+func (s *SetAddItem) permutation(i uint) {
+	s.value = s.set.add(s.valueToken)
+}
+
+// Permutation sets a specific permutation for this token
+func (s *SetAddItem) Permutation(i uint) error {
+	if err := checkPermutation(i, s.Permutations()); err != nil {
+		return err
+	}
+
+	s.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (s *SetAddItem) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (s *SetAddItem) PermutationsAll() uint {
+	return s.Permutations()
+}
+
+func (s *SetAddItem) String() string {
+	return strconv.Itoa(s.value)
+}
+
+// ListToken interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+// The value token is referenced so it is permutated too, but it is not part of the string representation of the item.
+func (s *SetAddItem) Get(i int) (token.Token, error) {
+	return s.InternalGet(i)
+}
+
+// Len returns the number of the current referenced tokens
+func (s *SetAddItem) Len() int {
+	return s.InternalLen()
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (s *SetAddItem) InternalGet(i int) (token.Token, error) {
+	if i != 0 || s.valueToken == nil {
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+
+	return s.valueToken, nil
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (s *SetAddItem) InternalLen() int {
+	if s.valueToken == nil {
+		return 0
+	}
+
+	return 1
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (s *SetAddItem) InternalLogicalRemove(tok token.Token) token.Token {
+	if s.valueToken == tok {
+		return nil
+	}
+
+	return s
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (s *SetAddItem) InternalReplace(oldToken, newToken token.Token) error {
+	if s.valueToken == oldToken {
+		s.valueToken = newToken
+	}
+
+	return nil
+}
+
+// ResetToken interface methods
+
+// Reset resets the (internal) state of this token and its dependences
+func (s *SetAddItem) Reset() {
+	s.permutation(0)
+}
+
+// ScopeToken interface methods
+
+// SetScope sets the scope of the token
+func (s *SetAddItem) SetScope(variableScope *token.VariableScope) {
+	if tok, ok := s.valueToken.(token.ScopeToken); ok {
+		tok.SetScope(variableScope)
+	}
+}
+
+// SetReplaceItem implements a set item token which replaces the value of one live entry of a map
+// The value of a live entry is replaced with the value token of the item on every token permutation and the item holds the entry. If there is no live entry the item holds no entry.
+type SetReplaceItem struct {
+	set        *Set
+	value      int
+	valueToken token.Token
+}
+
+// Set returns the referenced set
+func (s *SetReplaceItem) Set() *Set {
+	return s.set
+}
+
+// ValueToken returns the token holding the new value of the entry
+func (s *SetReplaceItem) ValueToken() token.Token {
+	return s.valueToken
+}
+
+// Clone returns a copy of the token and all its children
+func (s *SetReplaceItem) Clone() token.Token {
+	return &SetReplaceItem{
+		set:        s.set,
+		value:      s.value,
+		valueToken: s.valueToken.Clone(),
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (s *SetReplaceItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	v, nex, errs := parseEntry(pars, cur, s.set.entries, func(v int) bool {
+		return true
+	})
+	if errs != nil {
+		return cur, errs
+	}
+
+	s.value = v
+	s.set.replace(v, s.valueToken)
+
+	return nex, nil
+}
+
+func (s *SetReplaceItem) permutation(i uint) {
+	// there are no excepted entries which could not be determined
+	s.value, _ = s.set.existing(i, nil)
+	s.set.replace(s.value, s.valueToken)
+}
+
+// Permutation sets a specific permutation for this token
+func (s *SetReplaceItem) Permutation(i uint) error {
+	if err := checkPermutation(i, s.Permutations()); err != nil {
+		return err
+	}
+
+	s.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+// There is always at least one permutation which holds no entry if the set has no live entry.
+func (s *SetReplaceItem) Permutations() uint {
+	if len(s.set.entries) == 0 {
+		return 1
+	}
+
+	return uint(len(s.set.entries))
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (s *SetReplaceItem) PermutationsAll() uint {
+	return s.Permutations()
+}
+
+func (s *SetReplaceItem) String() string {
+	return entryString(s.value)
+}
+
+// ListToken interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+// The value token is referenced so it is permutated too, but it is not part of the string representation of the item.
+func (s *SetReplaceItem) Get(i int) (token.Token, error) {
+	return s.InternalGet(i)
+}
+
+// Len returns the number of the current referenced tokens
+func (s *SetReplaceItem) Len() int {
+	return s.InternalLen()
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (s *SetReplaceItem) InternalGet(i int) (token.Token, error) {
+	if i != 0 {
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+
+	return s.valueToken, nil
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (s *SetReplaceItem) InternalLen() int {
+	return 1
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (s *SetReplaceItem) InternalLogicalRemove(tok token.Token) token.Token {
+	if s.valueToken == tok {
+		return nil
+	}
+
+	return s
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (s *SetReplaceItem) InternalReplace(oldToken, newToken token.Token) error {
+	if s.valueToken == oldToken {
+		s.valueToken = newToken
+	}
+
+	return nil
+}
+
+// ResetToken interface methods
+
+// Reset resets the (internal) state of this token and its dependences
+// The item replaces the value of its entry again if it is still live.
+func (s *SetReplaceItem) Reset() {
+	if s.value == noValue || s.set.index(s.value) == -1 {
+		s.value, _ = s.set.existing(0, nil)
+	}
+
+	s.set.replace(s.value, s.valueToken)
+}
+
+// ScopeToken interface methods
+
+// SetScope sets the scope of the token
+func (s *SetReplaceItem) SetScope(variableScope *token.VariableScope) {
+	if tok, ok := s.valueToken.(token.ScopeToken); ok {
+		tok.SetScope(variableScope)
+	}
+}
+
+// SetValueItem implements a set item token which holds the value of an entry of a map
+// The entry is given by a key token. The value is updated on every token permutation. The item holds no value if the entry is not live.
+type SetValueItem struct {
+	set   *Set
+	key   token.Token
+	value string
+}
+
+// Set returns the referenced set
+func (s *SetValueItem) Set() *Set {
+	return s.set
+}
+
+// KeyToken returns the token holding the entry of the value
+func (s *SetValueItem) KeyToken() token.Token {
+	return s.key
+}
+
+// Clone returns a copy of the token and all its children
+func (s *SetValueItem) Clone() token.Token {
+	return &SetValueItem{
+		set:   s.set,
+		key:   s.key.Clone(),
+		value: s.value,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+// The value token of the entry parses the data, since values are usually not part of the data before they are referenced.
+func (s *SetValueItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	if value := s.entryToken(); value != nil {
+		nex, errs := value.Parse(pars, cur)
+		if errs != nil {
+			return cur, errs
+		}
+
+		s.value = pars.Data[cur:nex]
+
+		return nex, nil
+	}
+
+	s.permutation(0)
+
+	return pars.ParseValue(s.String(), cur)
+}
+
+// entryToken returns the value token of the entry or nil if the entry is not live or has no value
+func (s *SetValueItem) entryToken() token.Token {
+	v, err := strconv.Atoi(s.key.String())
+	if err != nil || s.set.index(v) == -1 {
+		return nil
+	}
+
+	return s.set.values[v]
+}
+
+func (s *SetValueItem) permutation(i uint) {
+	if value := s.entryToken(); value != nil {
+		s.value = value.String()
+	} else {
+		s.value = ""
+	}
+}
+
+// Permutation sets a specific permutation for this token
+func (s *SetValueItem) Permutation(i uint) error {
+	if err := checkPermutation(i, s.Permutations()); err != nil {
+		return err
+	}
+
+	s.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (s *SetValueItem) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (s *SetValueItem) PermutationsAll() uint {
+	return s.Permutations()
+}
+
+func (s *SetValueItem) String() string {
+	return s.value
+}
+
+// ListToken interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (s *SetValueItem) Get(i int) (token.Token, error) {
+	return nil, &lists.ListError{
+		Type: lists.ListErrorOutOfBound,
+	}
+}
+
+// Len returns the number of the current referenced tokens
+func (s *SetValueItem) Len() int {
+	return 0
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (s *SetValueItem) InternalGet(i int) (token.Token, error) {
+	if i != 0 {
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+
+	return s.key, nil
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (s *SetValueItem) InternalLen() int {
+	return 1
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (s *SetValueItem) InternalLogicalRemove(tok token.Token) token.Token {
+	if s.key == tok {
+		return nil
+	}
+
+	return s
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (s *SetValueItem) InternalReplace(oldToken, newToken token.Token) error {
+	if s.key == oldToken {
+		s.key = newToken
+	}
+
+	return nil
+}
+
+// ResetToken interface methods
+
+// Reset resets the (internal) state of this token and its dependences
+func (s *SetValueItem) Reset() {
+	s.permutation(0)
+}
+
+// ScopeToken interface methods
+
+// SetScope sets the scope of the token
+func (s *SetValueItem) SetScope(variableScope *token.VariableScope) {
+	if tok, ok := s.key.(token.ScopeToken); ok {
+		tok.SetScope(variableScope)
+	}
+}
+
+// SetRemoveItem implements a set item token which removes one live entry of the set
+// A live entry is removed on every token permutation. If there is no live entry the item holds no entry.
+type SetRemoveItem struct {
+	set   *Set
+	value int
+}
+
+// Set returns the referenced set
+func (s *SetRemoveItem) Set() *Set {
+	return s.set
+}
+
+// Clone returns a copy of the token and all its children
+func (s *SetRemoveItem) Clone() token.Token {
+	return &SetRemoveItem{
+		set:   s.set,
+		value: s.value,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (s *SetRemoveItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	v, nex, errs := parseEntry(pars, cur, s.set.entries, func(v int) bool {
+		return true
+	})
+	if errs != nil {
+		return cur, errs
+	}
+
+	s.value = v
+	s.set.remove(v)
+
+	return nex, nil
+}
+
+func (s *SetRemoveItem) permutation(i uint) {
+	// there are no excepted entries which could not be determined
+	s.value, _ = s.set.existing(i, nil)
+	s.set.remove(s.value)
+}
+
+// Permutation sets a specific permutation for this token
+func (s *SetRemoveItem) Permutation(i uint) error {
+	if err := checkPermutation(i, s.Permutations()); err != nil {
+		return err
+	}
+
+	s.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+// There is always at least one permutation which holds no entry if the set has no live entry.
+func (s *SetRemoveItem) Permutations() uint {
+	if len(s.set.entries) == 0 {
+		return 1
+	}
+
+	return uint(len(s.set.entries))
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (s *SetRemoveItem) PermutationsAll() uint {
+	return s.Permutations()
+}
+
+func (s *SetRemoveItem) String() string {
+	return entryString(s.value)
+}
+
+// ResetToken interface methods
+
+// Reset resets the (internal) state of this token and its dependences
+// The item removes its entry again if it is still live.
+func (s *SetRemoveItem) Reset() {
+	if s.value == noValue || s.set.index(s.value) == -1 {
+		s.value, _ = s.set.existing(0, nil)
+	}
+
+	s.set.remove(s.value)
+}
+
+// SetExistingItem implements a set item token which holds one live entry of the set
+// A new live entry is chosen on every token permutation. If there is no live entry the item holds no entry.
+type SetExistingItem struct {
+	set    *Set
+	value  int
+	except []token.Token
+}
+
+// Set returns the referenced set
+func (s *SetExistingItem) Set() *Set {
+	return s.set
+}
+
+// Clone returns a copy of the token and all its children
+func (s *SetExistingItem) Clone() token.Token {
+	c := SetExistingItem{
+		set:    s.set,
+		value:  s.value,
+		except: make([]token.Token, len(s.except)),
+	}
+
+	for i, tok := range s.except {
+		c.except[i] = tok.Clone()
+	}
+
+	return &c
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (s *SetExistingItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	exceptLookup, err := lists.ExceptValues(s.except)
+	if err != nil {
+		return cur, []error{err}
+	}
+
+	v, nex, errs := parseEntry(pars, cur, s.set.entries, func(v int) bool {
+		_, ok := exceptLookup[strconv.Itoa(v)]
+
+		return !ok
+	})
+	if errs != nil {
+		return cur, errs
+	}
+
+	s.value = v
+
+	return nex, nil
+}
+
+func (s *SetExistingItem) permutation(i uint) error {
+	v, err := s.set.existing(i, s.except)
+
+	s.value = v
+
+	return err
+}
+
+// Permutation sets a specific permutation for this token
+func (s *SetExistingItem) Permutation(i uint) error {
+	if err := checkPermutation(i, s.Permutations()); err != nil {
+		return err
+	}
+
+	return s.permutation(i - 1)
+}
+
+// Permutations returns the number of permutations for this token
+// There is always at least one permutation which holds no entry if the set has no live entry.
+// Excepted entries are counted too since they change with the excepted tokens. A permutation which would choose an excepted entry chooses the next entry which is not excepted.
+func (s *SetExistingItem) Permutations() uint {
+	if len(s.set.entries) == 0 {
+		return 1
+	}
+
+	return uint(len(s.set.entries))
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (s *SetExistingItem) PermutationsAll() uint {
+	return s.Permutations()
+}
+
+func (s *SetExistingItem) String() string {
+	return entryString(s.value)
+}
+
+// ForwardToken interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (s *SetExistingItem) Get(i int) (token.Token, error) {
+	return nil, &lists.ListError{
+		Type: lists.ListErrorOutOfBound,
+	}
+}
+
+// Len returns the number of the current referenced tokens
+func (s *SetExistingItem) Len() int {
+	return 0
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (s *SetExistingItem) InternalGet(i int) (token.Token, error) {
+	if i < 0 || i >= len(s.except) {
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+
+	return s.except[i], nil
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (s *SetExistingItem) InternalLen() int {
+	return len(s.except)
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (s *SetExistingItem) InternalLogicalRemove(tok token.Token) token.Token {
+	for i := 0; i < len(s.except); i++ {
+		if s.except[i] == tok {
+			s.except = append(s.except[:i], s.except[i+1:]...)
+
+			i--
+		}
+	}
+
+	return s
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (s *SetExistingItem) InternalReplace(oldToken, newToken token.Token) error {
+	for i := 0; i < len(s.except); i++ {
+		if s.except[i] == oldToken {
+			s.except[i] = newToken
+		}
+	}
+
+	return nil
+}
+
+// ResetToken interface methods
+
+// Reset resets the (internal) state of this token and its dependences
+// The item keeps its entry if it is still live and not excepted. It holds no entry if its excepted entries cannot be determined.
+func (s *SetExistingItem) Reset() {
+	if s.value != noValue && s.set.index(s.value) != -1 {
+		exceptLookup, err := lists.ExceptValues(s.except)
+		if err != nil {
+			s.value = noValue
+
+			return
+		}
+
+		if _, ok := exceptLookup[strconv.Itoa(s.value)]; !ok {
+			return
+		}
+	}
+
+	_ = s.permutation(0)
+}
+
+// ScopeToken interface methods
+
+// SetScope sets the scope of the token
+func (s *SetExistingItem) SetScope(variableScope *token.VariableScope) {
+	for i := 0; i < len(s.except); i++ {
+		if tok, ok := s.except[i].(token.ScopeToken); ok {
+			tok.SetScope(variableScope)
+		}
+	}
+}
+
+// SetMissingItem implements a set item token which holds one entry that is not live
+// A new missing entry is chosen on every token permutation. Missing entries are the removed entries of the set and the next entry that has not been added yet.
+type SetMissingItem struct {
+	set   *Set
+	value int
+}
+
+// Set returns the referenced set
+func (s *SetMissingItem) Set() *Set {
+	return s.set
+}
+
+// Clone returns a copy of the token and all its children
+func (s *SetMissingItem) Clone() token.Token {
+	return &SetMissingItem{
+		set:   s.set,
+		value: s.value,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (s *SetMissingItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	nex := cur
+	if nex < pars.DataLen && pars.Data[nex] == '-' {
+		nex++
+	}
+	for nex < pars.DataLen && pars.Data[nex] >= '0' && pars.Data[nex] <= '9' {
+		nex++
+	}
+
+	v, err := strconv.Atoi(pars.Data[cur:nex])
+	if err != nil || s.set.index(v) != -1 {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected a missing set entry but got %q", pars.Data[cur:nex]),
+			Type:    token.ParseErrorUnexpectedData,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	s.value = v
+
+	return nex, nil
+}
+
+func (s *SetMissingItem) permutation(i uint) {
+	s.value = s.set.missing(i)
+}
+
+// Permutation sets a specific permutation for this token
+func (s *SetMissingItem) Permutation(i uint) error {
+	if err := checkPermutation(i, s.Permutations()); err != nil {
+		return err
+	}
+
+	s.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (s *SetMissingItem) Permutations() uint {
+	return uint(len(s.set.removed) + 1)
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (s *SetMissingItem) PermutationsAll() uint {
+	return s.Permutations()
+}
+
+func (s *SetMissingItem) String() string {
+	return strconv.Itoa(s.value)
+}
+
+// ResetToken interface methods
+
+// Reset resets the (internal) state of this token and its dependences
+// The item keeps its entry if it is still not live.
+func (s *SetMissingItem) Reset() {
+	if s.set.index(s.value) != -1 {
+		s.permutation(0)
+	}
+}
+
+// SetCountItem implements a set item token which holds the number of live entries of the set
+// The number is updated on every token permutation.
+type SetCountItem struct {
+	set   *Set
+	value int
+}
+
+// Set returns the referenced set
+func (s *SetCountItem) Set() *Set {
+	return s.set
+}
+
+// Clone returns a copy of the token and all its children
+func (s *SetCountItem) Clone() token.Token {
+	return &SetCountItem{
+		set:   s.set,
+		value: s.value,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (s *SetCountItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	s.permutation(0)
+
+	return pars.ParseValue(s.String(), cur)
+}
+
+func (s *SetCountItem) permutation(i uint) {
+	s.value = len(s.set.entries)
+}
+
+// Permutation sets a specific permutation for this token
+func (s *SetCountItem) Permutation(i uint) error {
+	if err := checkPermutation(i, s.Permutations()); err != nil {
+		return err
+	}
+
+	s.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (s *SetCountItem) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (s *SetCountItem) PermutationsAll() uint {
+	return s.Permutations()
+}
+
+func (s *SetCountItem) String() string {
+	return strconv.Itoa(s.value)
+}
+
+// ResetToken interface methods
+
+// Reset resets the (internal) state of this token and its dependences
+func (s *SetCountItem) Reset() {
+	s.permutation(0)
+}
+
+// SetResetItem implements a set item token which removes all entries of its referencing set on every permutation
+type SetResetItem struct {
+	set *Set
+}
+
+// Set returns the referenced set
+func (s *SetResetItem) Set() *Set {
+	return s.set
+}
+
+// Clone returns a copy of the token and all its children
+func (s *SetResetItem) Clone() token.Token {
+	return &SetResetItem{
+		set: s.set,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (s *SetResetItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	s.permutation(0)
+
+	return cur, nil
+}
+
+func (s *SetResetItem) permutation(i uint) {
+	s.set.reset()
+}
+
+// Permutation sets a specific permutation for this token
+func (s *SetResetItem) Permutation(i uint) error {
+	if err := checkPermutation(i, s.Permutations()); err != nil {
+		return err
+	}
+
+	s.permutation(i - 1)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (s *SetResetItem) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (s *SetResetItem) PermutationsAll() uint {
+	return s.Permutations()
+}
+
+func (s *SetResetItem) String() string {
+	return ""
+}
+
+// ResetToken interface methods
+
+// Reset resets the (internal) state of this token and its dependences
+func (s *SetResetItem) Reset() {
+	s.permutation(0)
+}
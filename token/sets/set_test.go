@@ -0,0 +1,294 @@
+package sets
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestSetTokensToBeTokens(t *testing.T) {
+	var tok *token.Token
+
+	Implements(t, tok, &Set{})
+	Implements(t, tok, &SetAddItem{})
+	Implements(t, tok, &SetCountItem{})
+	Implements(t, tok, &SetExistingItem{})
+	Implements(t, tok, &SetMissingItem{})
+	Implements(t, tok, &SetRemoveItem{})
+	Implements(t, tok, &SetResetItem{})
+	Implements(t, tok, &SetReplaceItem{})
+	Implements(t, tok, &SetValueItem{})
+}
+
+func TestSet(t *testing.T) {
+	s := NewSet(10, 5)
+
+	count := s.CountItem()
+	Equal(t, "0", count.String())
+
+	// nothing to remove or reference
+	remove := s.RemoveItem()
+	Equal(t, "", remove.String())
+	Equal(t, 1, remove.Permutations())
+
+	existing := s.ExistingItem(nil)
+	Nil(t, existing.Permutation(1))
+	Equal(t, "", existing.String())
+
+	missing := s.MissingItem()
+	Equal(t, "10", missing.String())
+	Equal(t, 1, missing.Permutations())
+
+	// add entries
+	a1 := s.AddItem()
+	a2 := s.AddItem()
+	a3 := s.AddItem()
+	Equal(t, "10", a1.String())
+	Equal(t, "15", a2.String())
+	Equal(t, "20", a3.String())
+
+	Nil(t, count.Permutation(1))
+	Equal(t, "3", count.String())
+
+	Equal(t, 3, existing.Permutations())
+	Nil(t, existing.Permutation(2))
+	Equal(t, "15", existing.String())
+	Equal(t, existing.Permutation(4).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	// remove entries
+	Equal(t, 3, remove.Permutations())
+	Nil(t, remove.Permutation(2))
+	Equal(t, "15", remove.String())
+
+	Nil(t, count.Permutation(1))
+	Equal(t, "2", count.String())
+
+	Equal(t, 2, existing.Permutations())
+	Nil(t, existing.Permutation(2))
+	Equal(t, "20", existing.String())
+
+	// removed entries and the next entry are missing
+	Equal(t, 2, missing.Permutations())
+	Nil(t, missing.Permutation(1))
+	Equal(t, "15", missing.String())
+	Nil(t, missing.Permutation(2))
+	Equal(t, "25", missing.String())
+
+	// except entries
+	existing = s.ExistingItem([]token.Token{primitives.NewConstantInt(10)})
+	Nil(t, existing.Permutation(1))
+	Equal(t, "20", existing.String())
+
+	existing2 := existing.Clone()
+	Equal(t, existing.String(), existing2.String())
+
+	// reset
+	reset := s.ResetItem()
+	Nil(t, reset.Permutation(1))
+	Equal(t, 1, s.MissingItem().Permutations())
+	Equal(t, "10", s.AddItem().String())
+}
+
+func TestSetItemsReset(t *testing.T) {
+	s := NewSet(1, 1)
+
+	reset := s.ResetItem()
+	a1 := s.AddItem()
+	a2 := s.AddItem()
+	remove := s.RemoveItem()
+	existing := s.ExistingItem(nil)
+	Nil(t, existing.Permutation(1))
+	missing := s.MissingItem()
+	count := s.CountItem()
+
+	Equal(t, "1", remove.String())
+	Equal(t, "2", existing.String())
+	Equal(t, "1", missing.String())
+	Equal(t, "1", count.String())
+
+	// resetting the items in order replays the state of the set
+	for _, tok := range []token.ResetToken{reset, a1, a2, remove, existing, missing, count} {
+		tok.Reset()
+	}
+
+	Equal(t, "1", a1.String())
+	Equal(t, "2", a2.String())
+	Equal(t, "1", remove.String())
+	Equal(t, "2", existing.String())
+	Equal(t, "1", missing.String())
+	Equal(t, "1", count.String())
+}
+
+func TestSetItemsParse(t *testing.T) {
+	s := NewSet(1, 1)
+
+	reset := s.ResetItem()
+	add := s.AddItem()
+	remove := s.RemoveItem()
+	existing := s.ExistingItem(nil)
+	missing := s.MissingItem()
+	count := s.CountItem()
+
+	parse := func(tok token.Token, data string) (int, []error) {
+		return tok.Parse(&token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}, 0)
+	}
+
+	nex, errs := reset.Parse(&token.InternalParser{}, 0)
+	Nil(t, errs)
+	Equal(t, 0, nex)
+
+	for _, data := range []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"} {
+		nex, errs = parse(add, data)
+		Nil(t, errs)
+		Equal(t, len(data), nex)
+	}
+
+	// the longest entry is parsed
+	nex, errs = parse(existing, "10")
+	Nil(t, errs)
+	Equal(t, 2, nex)
+	Equal(t, "10", existing.String())
+
+	nex, errs = parse(remove, "10")
+	Nil(t, errs)
+	Equal(t, 2, nex)
+
+	nex, errs = parse(existing, "10")
+	Nil(t, errs)
+	Equal(t, 1, nex)
+	Equal(t, "1", existing.String())
+
+	nex, errs = parse(count, "9")
+	Nil(t, errs)
+	Equal(t, 1, nex)
+
+	nex, errs = parse(missing, "10")
+	Nil(t, errs)
+	Equal(t, 2, nex)
+
+	nex, errs = parse(missing, "-3x")
+	Nil(t, errs)
+	Equal(t, 2, nex)
+
+	for _, data := range []string{"", "a", "-", "9"} {
+		nex, errs = parse(missing, data)
+		NotNil(t, errs, data)
+		Equal(t, 0, nex, data)
+	}
+
+	nex, errs = parse(remove, "a")
+	NotNil(t, errs)
+	Equal(t, 0, nex)
+
+	nex, errs = parse(count, "10")
+	NotNil(t, errs)
+	Equal(t, 0, nex)
+}
+
+func TestMap(t *testing.T) {
+	m := NewMap(1, 1)
+	True(t, m.IsMap())
+	False(t, NewSet(1, 1).IsMap())
+
+	typ, _ := m.TypedDefinition()
+	Equal(t, "Map", typ)
+
+	key := primitives.NewConstantInt(1)
+	value := m.ValueItem(key)
+	Nil(t, value.Permutation(1))
+	Equal(t, "", value.String())
+
+	// nothing to replace
+	replace := m.ReplaceItem(primitives.NewConstantString("x"))
+	Equal(t, "", replace.String())
+	Equal(t, 1, replace.Permutations())
+
+	// add entries with values
+	a1 := m.AddValueItem(primitives.NewConstantString("alice"))
+	a2 := m.AddValueItem(primitives.NewConstantString("bob"))
+	Equal(t, "1", a1.String())
+	Equal(t, "2", a2.String())
+	Equal(t, 1, a1.Len())
+
+	Nil(t, value.Permutation(1))
+	Equal(t, "alice", value.String())
+
+	// replace the value of an entry
+	replace = m.ReplaceItem(primitives.NewConstantString("carol"))
+	Equal(t, 2, replace.Permutations())
+	Nil(t, replace.Permutation(2))
+	Equal(t, "2", replace.String())
+
+	value2 := m.ValueItem(primitives.NewConstantInt(2))
+	Nil(t, value2.Permutation(1))
+	Equal(t, "carol", value2.String())
+
+	// values are taken when the item is permutated
+	Equal(t, "alice", value.String())
+	Nil(t, replace.Permutation(1))
+	Equal(t, "alice", value.String())
+	Nil(t, value.Permutation(1))
+	Equal(t, "carol", value.String())
+
+	// removed entries have no value
+	remove := m.RemoveItem()
+	Equal(t, "1", remove.String())
+	Nil(t, value.Permutation(1))
+	Equal(t, "", value.String())
+
+	// the value of an entry which was added again after a reset is the new value
+	Nil(t, m.ResetItem().Permutation(1))
+	Equal(t, "1", m.AddValueItem(primitives.NewConstantString("dave")).String())
+	Nil(t, value.Permutation(1))
+	Equal(t, "dave", value.String())
+	Nil(t, value2.Permutation(1))
+	Equal(t, "", value2.String())
+
+	// clones have their own value tokens
+	c := a1.Clone().(*SetAddItem)
+	True(t, a1.ValueToken() != c.ValueToken())
+	Equal(t, "alice", c.ValueToken().String())
+}
+
+func TestMapItemsParse(t *testing.T) {
+	m := NewMap(1, 1)
+
+	parse := func(tok token.Token, data string) (int, []error) {
+		return tok.Parse(&token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}, 0)
+	}
+
+	add := m.AddValueItem(primitives.NewConstantString("alice"))
+	nex, errs := parse(add, "2")
+	Nil(t, errs)
+	Equal(t, 1, nex)
+
+	replace := m.ReplaceItem(primitives.NewConstantString("bob"))
+	nex, errs = parse(replace, "2")
+	Nil(t, errs)
+	Equal(t, 1, nex)
+
+	nex, errs = parse(replace, "3")
+	NotNil(t, errs)
+	Equal(t, 0, nex)
+
+	value := m.ValueItem(primitives.NewConstantInt(2))
+	nex, errs = parse(value, "bob")
+	Nil(t, errs)
+	Equal(t, 3, nex)
+
+	// entries which are not live have no value
+	value = m.ValueItem(primitives.NewConstantInt(3))
+	nex, errs = parse(value, "bob")
+	Nil(t, errs)
+	Equal(t, 0, nex)
+	Equal(t, "", value.String())
+}
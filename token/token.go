@@ -2,6 +2,7 @@ package token
 
 import (
 	"fmt"
+	"strings"
 	"text/scanner"
 )
 
@@ -223,6 +224,18 @@ type VariableToken interface {
 	Variable
 }
 
+// Weighted defines a weighted token which provides weights for its permutations
+type Weighted interface {
+	// Weights returns the weights of the permutations beginning with the first permutation, or nil if all permutations are equally weighted
+	Weights() []float64
+}
+
+// WeightedToken combines the Token and Weighted interface
+type WeightedToken interface {
+	Token
+	Weighted
+}
+
 ////////////////////////
 
 // TODO put this somewhere else?
@@ -361,6 +374,30 @@ func (s *VariableScope) Push() *VariableScope {
 type InternalParser struct { // TODO move this some place else
 	Data    string
 	DataLen int
+
+	variables map[string]VariableToken
+}
+
+// SetVariable remembers the given variable as the most recently parsed variable with its name
+// The variables are copied on every change so that ParseLongest can restore them cheaply.
+func (p *InternalParser) SetVariable(variable VariableToken) {
+	variables := make(map[string]VariableToken, len(p.variables)+1)
+	for name, v := range p.variables {
+		variables[name] = v
+	}
+	variables[variable.Name()] = variable
+
+	p.variables = variables
+}
+
+// Variable returns the most recently parsed variable with the name of the given variable or the given variable itself if no such variable was parsed.
+// Tokens referencing variables need this since repeated tokens are copies which are parsed instead of the referenced variables.
+func (p *InternalParser) Variable(variable VariableToken) VariableToken {
+	if v, ok := p.variables[variable.Name()]; ok {
+		return v
+	}
+
+	return variable
 }
 
 // GetPosition returns a text position in the data given an index of the data
@@ -384,6 +421,88 @@ func (p InternalParser) GetPosition(i int) scanner.Position {
 	}
 }
 
+// ParseValue tries to parse the given value beginning from the current position in the data.
+// This can be used by tokens which compute their value e.g. out of other tokens.
+// If the parsing is successful the error argument is nil and the next current position after the value is returned.
+func (p InternalParser) ParseValue(value string, cur int) (int, []error) {
+	nextIndex := len(value) + cur
+
+	if nextIndex > p.DataLen {
+		return cur, []error{&ParserError{
+			Message: fmt.Sprintf("expected %q but got early EOF", value),
+			Type:    ParseErrorUnexpectedEOF,
+
+			Position: p.GetPosition(cur),
+		}}
+	}
+
+	if got := p.Data[cur:nextIndex]; value != got {
+		return cur, []error{&ParserError{
+			Message: fmt.Sprintf("expected %q but got %q", value, got),
+			Type:    ParseErrorUnexpectedData,
+
+			Position: p.GetPosition(cur),
+		}}
+	}
+
+	return nextIndex, nil
+}
+
+// ParseLongest parses n alternatives beginning from the current position and chooses the alternative which matches the most data since there is no backtracking.
+// The given parse function parses the i-th alternative. Every alternative is parsed exactly once and the variables parsed by the chosen alternative are kept.
+// If the parsing is successful the index of the chosen alternative and the next current position after it are returned. Otherwise the index is -1 and the errors of all alternatives are returned.
+func (p *InternalParser) ParseLongest(cur int, n int, parse func(i int) (int, []error)) (int, int, []error) {
+	var errs []error
+
+	variables := p.variables
+
+	best := -1
+	bestNex := cur
+	bestVariables := variables
+
+	for i := 0; i < n; i++ {
+		p.variables = variables
+
+		nex, es := parse(i)
+		if len(es) != 0 {
+			errs = append(errs, es...)
+
+			continue
+		}
+
+		if best == -1 || nex > bestNex {
+			best = i
+			bestNex = nex
+			bestVariables = p.variables
+		}
+	}
+
+	p.variables = bestVariables
+
+	if best == -1 {
+		return -1, cur, errs
+	}
+
+	return best, bestNex, nil
+}
+
+// ParseLongestValue parses the longest of the given values beginning from the current position.
+// If the parsing is successful the index of the parsed value and the next current position after it are returned. Otherwise the index is -1 and the errors of all values are returned.
+func (p *InternalParser) ParseLongestValue(values []string, cur int) (int, int, []error) {
+	if len(values) == 0 {
+		return -1, cur, []error{&ParserError{
+			Message: "there is no value to choose from",
+			Type:    ParseErrorUnexpectedData,
+
+			Position: p.GetPosition(cur),
+		}}
+	}
+
+	return p.ParseLongest(cur, len(values), func(i int) (int, []error) {
+		return p.ParseValue(values[i], cur)
+	})
+}
+
 ////////////////////////
 // TODO was in parser.go but "import cycle not allowed" forced me to do this
 
@@ -450,6 +569,38 @@ const (
 	ParseErrorExpectedExpressionTerm
 	// ParseErrorEndlessLoopDetected an invalid loop was detected
 	ParseErrorEndlessLoopDetected
+	// ParseErrorCannotIncludeFile the file cannot be included
+	ParseErrorCannotIncludeFile
+	// ParseErrorIncludeCycle a file includes itself directly or indirectly
+	ParseErrorIncludeCycle
+	// ParseErrorWrongArgumentCount a parameterized token is called with the wrong amount of arguments
+	ParseErrorWrongArgumentCount
+	// ParseErrorRecursiveParameterizedToken a parameterized token calls itself directly or indirectly
+	ParseErrorRecursiveParameterizedToken
+	// ParseErrorInvalidByteLiteral the byte literal is invalid
+	ParseErrorInvalidByteLiteral
+	// ParseErrorUnknownEncoding the encoding is unknown
+	ParseErrorUnknownEncoding
+	// ParseErrorEncodingAfterDefinition the encoding is set after the tokens it applies to are already defined
+	ParseErrorEncodingAfterDefinition
+	// ParseErrorLoopAfterUsage the loop is declared after the token is already used or defined
+	ParseErrorLoopAfterUsage
+	// ParseErrorInvalidWeight the weight of an alternation term is invalid
+	ParseErrorInvalidWeight
+	// ParseErrorInvalidDistribution the distribution of a repeat group is invalid
+	ParseErrorInvalidDistribution
+	// ParseErrorUnknownArithmetic the arithmetic semantics are unknown
+	ParseErrorUnknownArithmetic
+	// ParseErrorConditionWithoutIf an else, else if or endif statement has no opening if statement
+	ParseErrorConditionWithoutIf
+	// ParseErrorEmptyConditionBody the body of an if statement is empty
+	ParseErrorEmptyConditionBody
+	// ParseErrorMissingEndif an if statement is not closed with an endif statement
+	ParseErrorMissingEndif
+	// ParseErrorInvalidArithmeticOperand the constant operand of an arithmetic operator is invalid
+	ParseErrorInvalidArithmeticOperand
+	// ParseErrorInvalidCharacterClass the pattern of the character class is invalid
+	ParseErrorInvalidCharacterClass
 
 	// ParseErrorExpectedEOF expected EOF
 	ParseErrorExpectedEOF
@@ -470,7 +621,23 @@ type ParserError struct {
 }
 
 func (err *ParserError) Error() string {
+	if err.Position.Filename != "" {
+		return fmt.Sprintf("%s, L:%d, C:%d - %s", err.Position.Filename, err.Position.Line, err.Position.Column, err.Message)
+	}
+
 	return fmt.Sprintf("L:%d, C:%d - %s", err.Position.Line, err.Position.Column, err.Message)
 }
 
+// ParserErrors holds multiple parser errors
+type ParserErrors []error
+
+func (errs ParserErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
 ////////////////////////
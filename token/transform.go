@@ -310,6 +310,14 @@ func UnrollPointers(root Token) (Token, error) {
 						tt = tt.parent
 					}
 				}
+
+				if tt == nil {
+					// every token up to the root had to be removed, so the graph can only be generated endlessly
+					return nil, &ParserError{
+						Message: "Found an endless loop without an exit while unrolling. This is not allowed.",
+						Type:    ParseErrorEndlessLoopDetected,
+					}
+				}
 			}
 		case ForwardToken:
 			if v := t.InternalGet(); v != nil {
@@ -7,11 +7,34 @@ import (
 )
 
 // ArgumentsTypedParser defines a parser for the arguments of a typed token.
-// Parsing stops unrecoverably at the first error. The return value of Err must be checked before using the values returned by precedings calls to GetInt.
+// Parsing stops unrecoverably at the first error. The return value of Err must be checked before using the values returned by precedings calls to the Get methods.
 type ArgumentsTypedParser interface {
 	// GetInt tries to parse the argument name and returns its integer value or defaultValue if the argument is not found.
 	// The return value is valid only if Err returns nil.
 	GetInt(name string, defaultValue int) int
+	// GetString tries to parse the argument name and returns its string value or defaultValue if the argument is not found.
+	// The return value is valid only if Err returns nil.
+	GetString(name string, defaultValue string) string
+	// GetFloat tries to parse the argument name and returns its floating point value or defaultValue if the argument is not found.
+	// The return value is valid only if Err returns nil.
+	GetFloat(name string, defaultValue float64) float64
+	// GetBool tries to parse the argument name and returns its boolean value or defaultValue if the argument is not found.
+	// The return value is valid only if Err returns nil.
+	GetBool(name string, defaultValue bool) bool
+	// GetIntList tries to parse the argument name and returns its integer values or defaultValue if the argument is not found.
+	// The return value is valid only if Err returns nil.
+	GetIntList(name string, defaultValue []int) []int
+	// GetStringList tries to parse the argument name and returns its string values or defaultValue if the argument is not found.
+	// The return value is valid only if Err returns nil.
+	GetStringList(name string, defaultValue []string) []string
+	// GetFile tries to parse the argument name and returns the file name it references or defaultValue if the argument is not found. Relative file names are resolved relative to the directory of the format file.
+	// The return value is valid only if Err returns nil.
+	GetFile(name string, defaultValue string) string
+	// GetToken tries to parse the argument name and returns the token it references or defaultValue if the argument is not found.
+	// The return value is valid only if Err returns nil.
+	GetToken(name string, defaultValue Token) Token
+	// Required checks that the arguments are defined. Err returns an error of the type ParseErrorMissingTypedTokenArgument if one argument is not defined.
+	Required(names ...string)
 	// Err returns the first error encountered by the ArgumentsTypedParser.
 	Err() error
 }
@@ -36,6 +59,12 @@ func NewTyped(name string, argParser ArgumentsTypedParser, pos scanner.Position)
 
 	tok, err := createTok(argParser)
 	if err != nil {
+		if perr, ok := err.(*ParserError); ok {
+			perr.Position = pos
+
+			return nil, perr
+		}
+
 		return nil, &ParserError{
 			Message:  err.Error(),
 			Type:     ParseErrorInvalidArgumentValue,
@@ -71,3 +100,23 @@ func RegisterTyped(name string, ct CreateTypedFunc) {
 
 	typedLookup[name] = ct
 }
+
+// TypedArgument holds one argument of a typed token definition
+type TypedArgument struct {
+	// Name is the name of the argument
+	Name string
+	// Value is the value of the argument written in the Tavor format, e.g. strings are quoted
+	Value string
+}
+
+// Typed defines a token which can be written as typed token definition
+type Typed interface {
+	// TypedDefinition returns the type and the arguments of a typed token definition which creates an equal token
+	TypedDefinition() (string, []TypedArgument)
+}
+
+// TypedToken defines a typed token
+type TypedToken interface {
+	Token
+	Typed
+}
@@ -27,9 +27,20 @@ func (v *Variable) Name() string {
 	return v.name
 }
 
+// listToken returns the list token of the given token which can be wrapped in a scope
+func listToken(tok token.Token) (token.ListToken, bool) {
+	if s, ok := tok.(*primitives.Scope); ok {
+		tok = s.Get()
+	}
+
+	l, ok := tok.(token.ListToken)
+
+	return l, ok
+}
+
 // Len returns the number of the current referenced tokens
 func (v *Variable) Len() int {
-	if l, ok := v.token.(token.ListToken); ok {
+	if l, ok := listToken(v.token); ok {
 		return l.Len()
 	}
 
@@ -49,7 +60,9 @@ func (v *Variable) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (v *Variable) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	pars.SetVariable(v)
+
+	return v.token.Parse(pars, cur)
 }
 
 // Permutation sets a specific permutation for this token
@@ -142,6 +155,11 @@ func NewVariableItem(index token.Token, variable token.VariableToken) *VariableI
 	}
 }
 
+// IndexToken returns the token holding the index of the referenced variable item
+func (v *VariableItem) IndexToken() token.Token {
+	return v.index
+}
+
 // Token interface methods
 
 // Clone returns a copy of the token and all its children
@@ -155,7 +173,9 @@ func (v *VariableItem) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (v *VariableItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	v.variable = pars.Variable(v.variable)
+
+	return pars.ParseValue(v.String(), cur)
 }
 
 // Permutation sets a specific permutation for this token
@@ -178,7 +198,7 @@ func (v *VariableItem) PermutationsAll() uint {
 func (v *VariableItem) String() string {
 	i := v.Index()
 
-	l, ok := v.variable.Get().(token.ListToken)
+	l, ok := listToken(v.variable.Get())
 	if !ok {
 		// TODO
 
@@ -270,6 +290,8 @@ func (v *VariableItem) SetScope(variableScope *token.VariableScope) {
 // VariableSave is based on the general Variable token but does prevent the output of the referenced token
 type VariableSave struct {
 	Variable
+
+	parsed bool
 }
 
 // Overwrite Variable methods
@@ -284,10 +306,26 @@ func (v *VariableSave) Clone() token.Token {
 	}
 }
 
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+// The referenced token is not parsed since it is not relayed. Its value is instead parsed by the first usage of the variable's value.
+func (v *VariableSave) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	pars.SetVariable(v)
+
+	v.parsed = false
+
+	return cur, nil
+}
+
 func (v *VariableSave) String() string {
 	return ""
 }
 
+// SetScope sets the scope of the token
+func (v *VariableSave) SetScope(variableScope *token.VariableScope) {
+	variableScope.Set(v.name, v)
+}
+
 // NewVariableSave returns a new instance of a VariableSave token
 func NewVariableSave(name string, token token.Token) *VariableSave {
 	return &VariableSave{
@@ -315,6 +353,11 @@ func (v *VariableReference) Reference() token.Token {
 	return v.variable.Get()
 }
 
+// Variable returns the referenced variable
+func (v *VariableReference) Variable() token.VariableToken {
+	return v.variable
+}
+
 // Token interface methods
 
 // Clone returns a copy of the token and all its children
@@ -327,7 +370,7 @@ func (v *VariableReference) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (v *VariableReference) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	return pars.ParseValue(v.String(), cur)
 }
 
 // Permutation sets a specific permutation for this token
@@ -409,8 +452,20 @@ func (v *VariableValue) Clone() token.Token {
 
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
+// The value of the variable must be already known, the token does therefore only parse the current value of the variable.
 func (v *VariableValue) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	v.variable = pars.Variable(v.variable)
+
+	if s, ok := v.variable.(*VariableSave); ok && !s.parsed {
+		nex, errs := s.token.Parse(pars, cur)
+		if len(errs) == 0 {
+			s.parsed = true
+		}
+
+		return nex, errs
+	}
+
+	return pars.ParseValue(v.String(), cur)
 }
 
 // Permutation sets a specific permutation for this token
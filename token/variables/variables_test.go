@@ -29,3 +29,59 @@ func TestVariable(t *testing.T) {
 	o2 := o.Clone()
 	Equal(t, o.String(), o2.String())
 }
+
+func TestVariableParse(t *testing.T) {
+	a := primitives.NewRangeInt(1, 9)
+	o := NewVariable("var", a)
+	v := NewVariableValue(o)
+
+	pars := &token.InternalParser{
+		Data:    "333",
+		DataLen: 3,
+	}
+
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 1, nex)
+	Equal(t, "3", o.String())
+
+	nex, errs = v.Parse(pars, 1)
+	Nil(t, errs)
+	Equal(t, 2, nex)
+
+	Nil(t, a.Permutation(4))
+
+	nex, errs = v.Parse(pars, 2)
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+	Equal(t, 2, nex)
+
+	nex, errs = v.Parse(pars, 3)
+	Equal(t, token.ParseErrorUnexpectedEOF, errs[0].(*token.ParserError).Type)
+	Equal(t, 3, nex)
+}
+
+func TestVariableSaveParse(t *testing.T) {
+	a := primitives.NewRangeInt(1, 9)
+	o := NewVariableSave("var", a)
+	v := NewVariableValue(o)
+
+	pars := &token.InternalParser{
+		Data:    "33",
+		DataLen: 2,
+	}
+
+	// the saved token is not part of the data
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 0, nex)
+
+	// the first usage of the value parses the saved token
+	nex, errs = v.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 1, nex)
+	Equal(t, "3", a.String())
+
+	nex, errs = v.Parse(pars, 1)
+	Nil(t, errs)
+	Equal(t, 2, nex)
+}